@@ -12,10 +12,12 @@ import (
 	"time"
 
 	accountshttp "github.com/amirhf/credit-ledger/services/accounts/internal/http"
-	"github.com/amirhf/credit-ledger/services/accounts/internal/outbox"
+	"github.com/amirhf/credit-ledger/services/accounts/internal/ledger"
+	"github.com/amirhf/credit-ledger/services/accounts/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/accounts/internal/store"
 	"github.com/amirhf/credit-ledger/services/accounts/internal/telemetry"
 	"github.com/amirhf/credit-ledger/services/common/migrate"
+	"github.com/amirhf/credit-ledger/services/common/outbox"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -73,11 +75,48 @@ func main() {
 	log.Printf("Kafka brokers: %v", brokers)
 
 	// Create Kafka publisher
-	publisher := outbox.NewPublisher(brokers)
+	publisher, err := outbox.NewPublisher(brokers)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka publisher: %v", err)
+	}
 	defer publisher.Close()
 
+	// Sent outbox rows are kept forever unless a retention is configured (e.g. "168h")
+	var outboxRetention time.Duration
+	if v := os.Getenv("OUTBOX_RETENTION"); v != "" {
+		outboxRetention, err = time.ParseDuration(v)
+		if err != nil || outboxRetention <= 0 {
+			log.Fatalf("Invalid OUTBOX_RETENTION: %q", v)
+		}
+	}
+
+	// The relay wakes on NOTIFY from the outbox insert trigger; OUTBOX_RELAY_MODE=poll polls every 100ms instead
+	var outboxListenURL string
+	switch mode := os.Getenv("OUTBOX_RELAY_MODE"); mode {
+	case "", "notify":
+		outboxListenURL = dbURL
+	case "poll":
+	default:
+		log.Fatalf("Invalid OUTBOX_RELAY_MODE: %q (use notify or poll)", mode)
+	}
+
 	// Create outbox relay
-	relay := outbox.NewRelay(db, publisher, log.Default())
+	relay := outbox.NewRelay(db, publisher, log.Default(), outbox.Config{
+		Router: outbox.RouteByEventType("ledger.events.v1", map[string][]string{
+			"ledger.account.v1": {"AccountCreated", "AccountStatusChanged"},
+		}),
+		Metrics: outbox.Metrics{
+			Published:       metrics.OutboxEventsPublished,
+			PublishErrors:   metrics.OutboxEventsPublishErrors,
+			Latency:         metrics.OutboxRelayLatency,
+			QueueSize:       metrics.OutboxQueueSize,
+			OldestUnsentAge: metrics.OutboxOldestUnsentAge,
+			Purged:          metrics.OutboxRowsPurged,
+		},
+		ListenURL: outboxListenURL,
+		Retention: outboxRetention,
+		Archive:   os.Getenv("OUTBOX_ARCHIVE") == "true",
+	})
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -90,8 +129,14 @@ func main() {
 		}
 	}()
 
+	// Get ledger service URL from environment (used to verify zero balance on close)
+	ledgerURL := os.Getenv("LEDGER_URL")
+	if ledgerURL == "" {
+		ledgerURL = "http://localhost:7102" // Default for local development
+	}
+
 	// Create handler
-	handler := accountshttp.NewHandler(db, log.Default())
+	handler := accountshttp.NewHandler(db, ledger.NewClient(ledgerURL), log.Default())
 
 	// Setup router
 	r := chi.NewRouter()
@@ -124,6 +169,9 @@ func main() {
 	r.Post("/v1/accounts", handler.CreateAccount)
 	r.Get("/v1/accounts", handler.ListAccounts)
 	r.Get("/v1/accounts/{id}", handler.GetAccount)
+	r.Post("/v1/accounts/{id}/suspend", handler.SuspendAccount)
+	r.Post("/v1/accounts/{id}/reactivate", handler.ReactivateAccount)
+	r.Post("/v1/accounts/{id}/close", handler.CloseAccount)
 
 	// Setup HTTP server
 	addr := ":7101"
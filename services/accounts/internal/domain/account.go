@@ -13,12 +13,14 @@ type AccountStatus string
 const (
 	StatusActive    AccountStatus = "ACTIVE"
 	StatusSuspended AccountStatus = "SUSPENDED"
+	StatusClosed    AccountStatus = "CLOSED"
 )
 
 // Account represents a financial account
 type Account struct {
 	ID       uuid.UUID
 	Currency string
+	Type     AccountType
 	Status   AccountStatus
 }
 
@@ -32,8 +34,9 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Field, e.Message)
 }
 
-// NewAccount creates a new account with validation
-func NewAccount(currency string) (*Account, error) {
+// NewAccount creates a new account with validation. An empty accountType
+// defaults to DefaultAccountType.
+func NewAccount(currency, accountType string) (*Account, error) {
 	// Validate currency
 	currency = strings.ToUpper(strings.TrimSpace(currency))
 	if currency == "" {
@@ -43,9 +46,15 @@ func NewAccount(currency string) (*Account, error) {
 		return nil, &ValidationError{Field: "currency", Message: "currency must be a 3-letter ISO code (e.g., USD, EUR)"}
 	}
 
+	t, err := ParseAccountType(accountType)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Account{
 		ID:       uuid.New(),
 		Currency: currency,
+		Type:     t,
 		Status:   StatusActive,
 	}, nil
 }
@@ -61,8 +70,11 @@ func (a *Account) Validate() error {
 	if len(a.Currency) != 3 {
 		return &ValidationError{Field: "currency", Message: "currency must be 3 characters"}
 	}
-	if a.Status != StatusActive && a.Status != StatusSuspended {
-		return &ValidationError{Field: "status", Message: "status must be ACTIVE or SUSPENDED"}
+	if !a.Type.Valid() {
+		return &ValidationError{Field: "account_type", Message: "account_type must be ASSET, LIABILITY, EQUITY, REVENUE or EXPENSE"}
+	}
+	if a.Status != StatusActive && a.Status != StatusSuspended && a.Status != StatusClosed {
+		return &ValidationError{Field: "status", Message: "status must be ACTIVE, SUSPENDED or CLOSED"}
 	}
 	return nil
 }
@@ -0,0 +1,60 @@
+package domain
+
+import "strings"
+
+// AccountType places an account in the chart of accounts
+type AccountType string
+
+const (
+	TypeAsset     AccountType = "ASSET"
+	TypeLiability AccountType = "LIABILITY"
+	TypeEquity    AccountType = "EQUITY"
+	TypeRevenue   AccountType = "REVENUE"
+	TypeExpense   AccountType = "EXPENSE"
+)
+
+// DefaultAccountType is used when no type is given. Customer credit accounts
+// are liabilities of the ledger, which matches balances that grow on credit.
+const DefaultAccountType = TypeLiability
+
+// Side is the side of a posting, DEBIT or CREDIT
+type Side string
+
+const (
+	SideDebit  Side = "DEBIT"
+	SideCredit Side = "CREDIT"
+)
+
+// normalSides is the side on which each account type's balance increases
+var normalSides = map[AccountType]Side{
+	TypeAsset:     SideDebit,
+	TypeLiability: SideCredit,
+	TypeEquity:    SideCredit,
+	TypeRevenue:   SideCredit,
+	TypeExpense:   SideDebit,
+}
+
+// ParseAccountType normalizes an account type, defaulting an empty one to DefaultAccountType
+func ParseAccountType(s string) (AccountType, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return DefaultAccountType, nil
+	}
+	t := AccountType(s)
+	if !t.Valid() {
+		return "", &ValidationError{Field: "account_type", Message: "account_type must be ASSET, LIABILITY, EQUITY, REVENUE or EXPENSE"}
+	}
+	return t, nil
+}
+
+// Valid reports whether t is a known account type
+func (t AccountType) Valid() bool {
+	_, ok := normalSides[t]
+	return ok
+}
+
+// NormalSide returns the side on which the account type's balance increases:
+// DEBIT for assets and expenses, CREDIT for liabilities, equity and revenue
+func (t AccountType) NormalSide() Side {
+	return normalSides[t]
+}
@@ -0,0 +1,59 @@
+package domain
+
+import "testing"
+
+func TestParseAccountType(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		want       AccountType
+		wantNormal Side
+		wantErr    bool
+	}{
+		{name: "empty defaults to liability", input: "", want: TypeLiability, wantNormal: SideCredit},
+		{name: "asset", input: "ASSET", want: TypeAsset, wantNormal: SideDebit},
+		{name: "lower case liability", input: "liability", want: TypeLiability, wantNormal: SideCredit},
+		{name: "equity", input: " EQUITY ", want: TypeEquity, wantNormal: SideCredit},
+		{name: "revenue", input: "REVENUE", want: TypeRevenue, wantNormal: SideCredit},
+		{name: "expense", input: "Expense", want: TypeExpense, wantNormal: SideDebit},
+		{name: "unknown", input: "CONTRA", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAccountType(tt.input)
+			if tt.wantErr {
+				if _, ok := err.(*ValidationError); !ok {
+					t.Fatalf("expected ValidationError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected type %s, got %s", tt.want, got)
+			}
+			if side := got.NormalSide(); side != tt.wantNormal {
+				t.Errorf("expected normal side %s, got %s", tt.wantNormal, side)
+			}
+		})
+	}
+}
+
+func TestNewAccount_Type(t *testing.T) {
+	account, err := NewAccount("usd", "asset")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if account.Type != TypeAsset {
+		t.Errorf("expected type ASSET, got %s", account.Type)
+	}
+	if err := account.Validate(); err != nil {
+		t.Errorf("expected valid account, got %v", err)
+	}
+
+	if _, err := NewAccount("USD", "CONTRA"); err == nil {
+		t.Error("expected error for unknown account type")
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// allowedTransitions is the account lifecycle state machine.
+// CLOSED is terminal; accounts are never reopened.
+var allowedTransitions = map[AccountStatus][]AccountStatus{
+	StatusActive:    {StatusSuspended, StatusClosed},
+	StatusSuspended: {StatusActive, StatusClosed},
+	StatusClosed:    {},
+}
+
+// TransitionError is returned when a status change is not allowed by the lifecycle
+type TransitionError struct {
+	From AccountStatus
+	To   AccountStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change account status from %s to %s", e.From, e.To)
+}
+
+// NonZeroBalanceError is returned when closing an account that still holds funds
+type NonZeroBalanceError struct {
+	Currency     string
+	BalanceMinor int64
+}
+
+func (e *NonZeroBalanceError) Error() string {
+	return fmt.Sprintf("account balance must be zero to close, has %d %s", e.BalanceMinor, e.Currency)
+}
+
+// CanTransition reports whether the lifecycle allows moving from one status to another
+func CanTransition(from, to AccountStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Suspend blocks new postings on an active account
+func (a *Account) Suspend() error {
+	return a.transitionTo(StatusSuspended)
+}
+
+// Reactivate returns a suspended account to active
+func (a *Account) Reactivate() error {
+	return a.transitionTo(StatusActive)
+}
+
+// Close permanently closes the account. balancesMinor holds the account's
+// ledger balance per currency; every balance must be zero.
+func (a *Account) Close(balancesMinor map[string]int64) error {
+	if !CanTransition(a.Status, StatusClosed) {
+		return &TransitionError{From: a.Status, To: StatusClosed}
+	}
+
+	// Report currencies in a stable order
+	currencies := make([]string, 0, len(balancesMinor))
+	for currency := range balancesMinor {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		if balancesMinor[currency] != 0 {
+			return &NonZeroBalanceError{Currency: currency, BalanceMinor: balancesMinor[currency]}
+		}
+	}
+
+	return a.transitionTo(StatusClosed)
+}
+
+// transitionTo moves the account to the given status if the lifecycle allows it
+func (a *Account) transitionTo(to AccountStatus) error {
+	if !CanTransition(a.Status, to) {
+		return &TransitionError{From: a.Status, To: to}
+	}
+	a.Status = to
+	return nil
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAccount_Transitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    AccountStatus
+		apply   func(a *Account) error
+		want    AccountStatus
+		wantErr bool
+	}{
+		{name: "suspend active", from: StatusActive, apply: (*Account).Suspend, want: StatusSuspended},
+		{name: "suspend suspended", from: StatusSuspended, apply: (*Account).Suspend, want: StatusSuspended, wantErr: true},
+		{name: "reactivate suspended", from: StatusSuspended, apply: (*Account).Reactivate, want: StatusActive},
+		{name: "reactivate active", from: StatusActive, apply: (*Account).Reactivate, want: StatusActive, wantErr: true},
+		{name: "reactivate closed", from: StatusClosed, apply: (*Account).Reactivate, want: StatusClosed, wantErr: true},
+		{name: "suspend closed", from: StatusClosed, apply: (*Account).Suspend, want: StatusClosed, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := &Account{ID: uuid.New(), Currency: "USD", Status: tt.from}
+			err := tt.apply(account)
+			if tt.wantErr {
+				if _, ok := err.(*TransitionError); !ok {
+					t.Fatalf("expected TransitionError, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if account.Status != tt.want {
+				t.Errorf("expected status %s, got %s", tt.want, account.Status)
+			}
+		})
+	}
+}
+
+func TestAccount_Close(t *testing.T) {
+	t.Run("zero balance", func(t *testing.T) {
+		account := &Account{ID: uuid.New(), Currency: "USD", Status: StatusSuspended}
+		if err := account.Close(map[string]int64{"USD": 0}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if account.Status != StatusClosed {
+			t.Errorf("expected status %s, got %s", StatusClosed, account.Status)
+		}
+	})
+
+	t.Run("non-zero balance", func(t *testing.T) {
+		account := &Account{ID: uuid.New(), Currency: "USD", Status: StatusActive}
+		err := account.Close(map[string]int64{"EUR": 0, "USD": -250})
+		balanceErr, ok := err.(*NonZeroBalanceError)
+		if !ok {
+			t.Fatalf("expected NonZeroBalanceError, got %v", err)
+		}
+		if balanceErr.Currency != "USD" || balanceErr.BalanceMinor != -250 {
+			t.Errorf("unexpected error details: %+v", balanceErr)
+		}
+		if account.Status != StatusActive {
+			t.Errorf("expected status to stay %s, got %s", StatusActive, account.Status)
+		}
+	})
+
+	t.Run("already closed", func(t *testing.T) {
+		account := &Account{ID: uuid.New(), Currency: "USD", Status: StatusClosed}
+		if _, ok := account.Close(nil).(*TransitionError); !ok {
+			t.Fatal("expected TransitionError")
+		}
+	})
+}
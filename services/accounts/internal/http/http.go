@@ -3,28 +3,35 @@ package http
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
 	"github.com/amirhf/credit-ledger/services/accounts/internal/domain"
+	"github.com/amirhf/credit-ledger/services/accounts/internal/ledger"
+	"github.com/amirhf/credit-ledger/services/accounts/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/accounts/internal/store"
+	"github.com/amirhf/credit-ledger/services/common/pagination"
 	"github.com/google/uuid"
 	"google.golang.org/protobuf/proto"
 )
 
 // CreateAccountRequest represents the HTTP request body
 type CreateAccountRequest struct {
-	Currency string `json:"currency"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type,omitempty"` // defaults to LIABILITY
 }
 
 // CreateAccountResponse represents the HTTP response
 type CreateAccountResponse struct {
-	AccountID string `json:"account_id"`
-	Currency  string `json:"currency"`
-	Status    string `json:"status"`
+	AccountID   string `json:"account_id"`
+	Currency    string `json:"currency"`
+	AccountType string `json:"account_type"`
+	NormalSide  string `json:"normal_side"`
+	Status      string `json:"status"`
 }
 
 // ErrorResponse represents an error response
@@ -33,18 +40,25 @@ type ErrorResponse struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ChangeStatusRequest represents the optional body of a lifecycle request
+type ChangeStatusRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
 // Handler handles HTTP requests for the accounts service
 type Handler struct {
 	db      *sql.DB
 	queries *store.Queries
+	ledger  *ledger.Client
 	logger  *log.Logger
 }
 
 // NewHandler creates a new HTTP handler
-func NewHandler(db *sql.DB, logger *log.Logger) *Handler {
+func NewHandler(db *sql.DB, ledgerClient *ledger.Client, logger *log.Logger) *Handler {
 	return &Handler{
 		db:      db,
 		queries: store.New(db),
+		ledger:  ledgerClient,
 		logger:  logger,
 	}
 }
@@ -61,7 +75,7 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create account with validation
-	account, err := domain.NewAccount(req.Currency)
+	account, err := domain.NewAccount(req.Currency, req.AccountType)
 	if err != nil {
 		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
 		return
@@ -81,10 +95,11 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	// Insert account
 	now := time.Now()
 	dbAccount, err := qtx.CreateAccount(ctx, store.CreateAccountParams{
-		ID:        account.ID,
-		Currency:  account.Currency,
-		Status:    string(account.Status),
-		CreatedAt: now,
+		ID:          account.ID,
+		Currency:    account.Currency,
+		Status:      string(account.Status),
+		CreatedAt:   now,
+		AccountType: string(account.Type),
 	})
 	if err != nil {
 		h.logger.Printf("Failed to create account: %v", err)
@@ -94,9 +109,11 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 
 	// Create AccountCreated event
 	event := &ledgerv1.AccountCreated{
-		AccountId: account.ID.String(),
-		Currency:  account.Currency,
-		TsUnixMs:  now.UnixMilli(),
+		AccountId:   account.ID.String(),
+		Currency:    account.Currency,
+		TsUnixMs:    now.UnixMilli(),
+		AccountType: toProtoAccountType(account.Type),
+		NormalSide:  toProtoSide(account.Type.NormalSide()),
 	}
 
 	// Serialize event to protobuf
@@ -137,15 +154,17 @@ func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.logger.Printf("Created account %s with currency %s", account.ID, account.Currency)
+	h.logger.Printf("Created %s account %s with currency %s", account.Type, account.ID, account.Currency)
 
 	// Respond with success
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(CreateAccountResponse{
-		AccountID: dbAccount.ID.String(),
-		Currency:  dbAccount.Currency,
-		Status:    dbAccount.Status,
+		AccountID:   dbAccount.ID.String(),
+		Currency:    dbAccount.Currency,
+		AccountType: dbAccount.AccountType,
+		NormalSide:  string(domain.AccountType(dbAccount.AccountType).NormalSide()),
+		Status:      dbAccount.Status,
 	})
 }
 
@@ -156,29 +175,30 @@ func (h *Handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	currency := r.URL.Query().Get("currency")
 	status := r.URL.Query().Get("status")
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
 
-	// Default values
-	limit := int32(20)
-	offset := int32(0)
-
-	if limitStr != "" {
-		if l, err := strconv.ParseInt(limitStr, 10, 32); err == nil && l > 0 && l <= 100 {
-			limit = int32(l)
+	// Parse pagination: pages are ordered newest first and continue from an opaque cursor
+	limit := pagination.ParseLimit(r, 20, 100)
+	var cursor *pagination.Cursor
+	var cursorID uuid.UUID
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := pagination.DecodeCursor(token)
+		if err == nil {
+			cursorID, err = uuid.Parse(c.ID)
 		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.ParseInt(offsetStr, 10, 32); err == nil && o >= 0 {
-			offset = int32(o)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_cursor", "cursor is not valid")
+			return
 		}
+		cursor = &c
 	}
 
+	currencyFilter := sql.NullString{String: currency, Valid: currency != ""}
+	statusFilter := sql.NullString{String: status, Valid: status != ""}
+
 	// Get total count
 	total, err := h.queries.CountAccounts(ctx, store.CountAccountsParams{
-		Column1: currency,
-		Column2: status,
+		Currency: currencyFilter,
+		Status:   statusFilter,
 	})
 	if err != nil {
 		h.logger.Printf("Failed to count accounts: %v", err)
@@ -186,38 +206,64 @@ func (h *Handler) ListAccounts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get accounts
-	accounts, err := h.queries.ListAccounts(ctx, store.ListAccountsParams{
-		Column1: currency,
-		Column2: status,
-		Limit:   limit,
-		Offset:  offset,
-	})
+	// Get accounts, with one extra row to tell whether another page follows
+	var accounts []store.Account
+	if cursor != nil && cursor.Prev {
+		accounts, err = h.queries.ListAccountsPrev(ctx, store.ListAccountsPrevParams{
+			Currency:        currencyFilter,
+			Status:          statusFilter,
+			BeforeCreatedAt: cursor.Ts,
+			BeforeID:        cursorID,
+			Limit:           limit + 1,
+		})
+	} else {
+		params := store.ListAccountsParams{
+			Currency: currencyFilter,
+			Status:   statusFilter,
+			Limit:    limit + 1,
+		}
+		if cursor != nil {
+			params.AfterCreatedAt = sql.NullTime{Time: cursor.Ts, Valid: true}
+			params.AfterID = uuid.NullUUID{UUID: cursorID, Valid: true}
+		}
+		accounts, err = h.queries.ListAccounts(ctx, params)
+	}
 	if err != nil {
 		h.logger.Printf("Failed to list accounts: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to list accounts")
 		return
 	}
+	accounts, nextCursor, prevCursor := pagination.KeysetPage(accounts, limit, cursor, func(acc store.Account) pagination.Cursor {
+		return pagination.Cursor{Ts: acc.CreatedAt, ID: acc.ID.String()}
+	})
 
 	// Build response
 	accountsList := make([]map[string]interface{}, len(accounts))
 	for i, acc := range accounts {
 		accountsList[i] = map[string]interface{}{
-			"id":         acc.ID.String(),
-			"currency":   acc.Currency,
-			"status":     acc.Status,
-			"created_at": acc.CreatedAt.Format(time.RFC3339),
+			"id":           acc.ID.String(),
+			"currency":     acc.Currency,
+			"account_type": acc.AccountType,
+			"normal_side":  string(domain.AccountType(acc.AccountType).NormalSide()),
+			"status":       acc.Status,
+			"created_at":   acc.CreatedAt.Format(time.RFC3339),
 		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"accounts": accountsList,
 		"total":    total,
 		"limit":    limit,
-		"offset":   offset,
-	})
+	}
+	if nextCursor != "" {
+		resp["next_cursor"] = nextCursor
+	}
+	if prevCursor != "" {
+		resp["prev_cursor"] = prevCursor
+	}
+	json.NewEncoder(w).Encode(resp)
 }
 
 // GetAccount handles GET /v1/accounts/:id
@@ -253,13 +299,228 @@ func (h *Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"id":         account.ID.String(),
-		"currency":   account.Currency,
-		"status":     account.Status,
-		"created_at": account.CreatedAt.Format(time.RFC3339),
+		"id":           account.ID.String(),
+		"currency":     account.Currency,
+		"account_type": account.AccountType,
+		"normal_side":  string(domain.AccountType(account.AccountType).NormalSide()),
+		"status":       account.Status,
+		"created_at":   account.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+// SuspendAccount handles POST /v1/accounts/:id/suspend
+func (h *Handler) SuspendAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.StatusSuspended)
+}
+
+// ReactivateAccount handles POST /v1/accounts/:id/reactivate
+func (h *Handler) ReactivateAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.StatusActive)
+}
+
+// CloseAccount handles POST /v1/accounts/:id/close
+// The account must have a zero ledger balance in every currency.
+func (h *Handler) CloseAccount(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, domain.StatusClosed)
+}
+
+// changeStatus applies a lifecycle transition and emits AccountStatusChanged
+func (h *Handler) changeStatus(w http.ResponseWriter, r *http.Request, target domain.AccountStatus) {
+	ctx := r.Context()
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_account_id", "Account ID must be a valid UUID")
+		return
+	}
+
+	// Body is optional
+	var req ChangeStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+
+	// Start transaction
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.logger.Printf("Failed to begin transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	dbAccount, err := qtx.GetAccountForUpdate(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.respondError(w, http.StatusNotFound, "account_not_found", "Account not found")
+			return
+		}
+		h.logger.Printf("Failed to get account: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+
+	account := &domain.Account{
+		ID:       dbAccount.ID,
+		Currency: dbAccount.Currency,
+		Type:     domain.AccountType(dbAccount.AccountType),
+		Status:   domain.AccountStatus(dbAccount.Status),
+	}
+	previous := account.Status
+
+	// Closing checks the ledger balances with the account row locked, so no
+	// concurrent status change can interleave between the check and the close
+	var balances map[string]int64
+	if target == domain.StatusClosed && domain.CanTransition(previous, target) {
+		balances, err = h.ledger.GetBalances(ctx, accountID)
+		if err != nil {
+			h.logger.Printf("Failed to get ledger balances for account %s: %v", accountID, err)
+			h.respondError(w, http.StatusServiceUnavailable, "ledger_unavailable", "Failed to verify account balance")
+			return
+		}
+	}
+
+	// Apply the transition through the lifecycle state machine
+	switch target {
+	case domain.StatusSuspended:
+		err = account.Suspend()
+	case domain.StatusActive:
+		err = account.Reactivate()
+	case domain.StatusClosed:
+		err = account.Close(balances)
+	}
+	if err != nil {
+		var transitionErr *domain.TransitionError
+		var balanceErr *domain.NonZeroBalanceError
+		switch {
+		case errors.As(err, &transitionErr):
+			h.respondError(w, http.StatusConflict, "invalid_transition", transitionErr.Error())
+		case errors.As(err, &balanceErr):
+			h.respondError(w, http.StatusConflict, "balance_not_zero", balanceErr.Error())
+		default:
+			h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+		}
+		return
+	}
+
+	now := time.Now()
+	dbAccount, err = qtx.UpdateAccountStatus(ctx, store.UpdateAccountStatusParams{
+		ID:              account.ID,
+		Status:          string(account.Status),
+		StatusChangedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		h.logger.Printf("Failed to update account status: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+
+	// Create AccountStatusChanged event
+	event := &ledgerv1.AccountStatusChanged{
+		AccountId:      account.ID.String(),
+		PreviousStatus: toProtoStatus(previous),
+		Status:         toProtoStatus(account.Status),
+		Reason:         req.Reason,
+		TsUnixMs:       now.UnixMilli(),
+	}
+
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		h.logger.Printf("Failed to marshal event: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+
+	headers := map[string]interface{}{
+		"event_name": "AccountStatusChanged",
+		"schema":     "ledger.v1.AccountStatusChanged",
+	}
+	headersJSON, _ := json.Marshal(headers)
+
+	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "Account",
+		AggregateID:   account.ID,
+		EventType:     "AccountStatusChanged",
+		Payload:       payload,
+		Headers:       headersJSON,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		h.logger.Printf("Failed to create outbox event: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		h.logger.Printf("Failed to commit transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to update account")
+		return
+	}
+
+	metrics.AccountStatusChanges.WithLabelValues(string(account.Status)).Inc()
+	h.logger.Printf("Account %s status changed from %s to %s", account.ID, previous, account.Status)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":              dbAccount.ID.String(),
+		"currency":        dbAccount.Currency,
+		"account_type":    dbAccount.AccountType,
+		"status":          dbAccount.Status,
+		"previous_status": string(previous),
+		"created_at":      dbAccount.CreatedAt.Format(time.RFC3339),
 	})
 }
 
+// toProtoStatus converts a domain account status to its protobuf enum
+func toProtoStatus(status domain.AccountStatus) ledgerv1.AccountStatus {
+	switch status {
+	case domain.StatusActive:
+		return ledgerv1.AccountStatus_ACCOUNT_STATUS_ACTIVE
+	case domain.StatusSuspended:
+		return ledgerv1.AccountStatus_ACCOUNT_STATUS_SUSPENDED
+	case domain.StatusClosed:
+		return ledgerv1.AccountStatus_ACCOUNT_STATUS_CLOSED
+	default:
+		return ledgerv1.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED
+	}
+}
+
+// toProtoAccountType converts a domain account type to its protobuf enum
+func toProtoAccountType(t domain.AccountType) ledgerv1.AccountType {
+	switch t {
+	case domain.TypeAsset:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_ASSET
+	case domain.TypeLiability:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_LIABILITY
+	case domain.TypeEquity:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_EQUITY
+	case domain.TypeRevenue:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_REVENUE
+	case domain.TypeExpense:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_EXPENSE
+	default:
+		return ledgerv1.AccountType_ACCOUNT_TYPE_UNSPECIFIED
+	}
+}
+
+// toProtoSide converts a domain posting side to its protobuf enum
+func toProtoSide(side domain.Side) ledgerv1.Side {
+	switch side {
+	case domain.SideDebit:
+		return ledgerv1.Side_DEBIT
+	case domain.SideCredit:
+		return ledgerv1.Side_CREDIT
+	default:
+		return ledgerv1.Side_SIDE_UNSPECIFIED
+	}
+}
+
 // respondError sends an error response
 func (h *Handler) respondError(w http.ResponseWriter, status int, error string, message string) {
 	w.Header().Set("Content-Type", "application/json")
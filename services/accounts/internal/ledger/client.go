@@ -0,0 +1,70 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Client queries the ledger service for authoritative account balances
+type Client struct {
+	ledgerURL  string
+	httpClient *http.Client
+}
+
+// balancesResponse represents the response from ledger's GET /v1/accounts/{id}/balances
+type balancesResponse struct {
+	AccountID string `json:"account_id"`
+	Balances  []struct {
+		Currency     string `json:"currency"`
+		BalanceMinor int64  `json:"balance_minor"`
+	} `json:"balances"`
+}
+
+// NewClient creates a new ledger client
+func NewClient(ledgerURL string) *Client {
+	return &Client{
+		ledgerURL: ledgerURL,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+// GetBalances returns the account's ledger balance per currency.
+// Accounts that never had a posting have no balances.
+func (c *Client) GetBalances(ctx context.Context, accountID uuid.UUID) (map[string]int64, error) {
+	url := fmt.Sprintf("%s/v1/accounts/%s/balances", c.ledgerURL, accountID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ledger request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ledger returned status %d", resp.StatusCode)
+	}
+
+	var balancesResp balancesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&balancesResp); err != nil {
+		return nil, fmt.Errorf("failed to parse ledger response: %w", err)
+	}
+
+	balances := make(map[string]int64, len(balancesResp.Balances))
+	for _, balance := range balancesResp.Balances {
+		balances[balance.Currency] = balance.BalanceMinor
+	}
+	return balances, nil
+}
@@ -25,6 +25,15 @@ var (
 		[]string{"status"},
 	)
 
+	// AccountStatusChanges tracks lifecycle transitions by target status
+	AccountStatusChanges = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "accounts_status_changes_total",
+			Help: "Total number of account lifecycle transitions",
+		},
+		[]string{"status"},
+	)
+
 	// OutboxEventsPublished tracks the total number of outbox events published
 	OutboxEventsPublished = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -51,4 +60,29 @@ var (
 			Buckets: []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
 		},
 	)
+
+	// OutboxQueueSize tracks the current number of unsent events in the outbox
+	OutboxQueueSize = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "accounts_outbox_queue_size",
+			Help: "Current number of unsent events in the outbox table",
+		},
+	)
+
+	// OutboxOldestUnsentAge tracks how long the oldest unsent outbox event has waited
+	OutboxOldestUnsentAge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "accounts_outbox_oldest_unsent_age_seconds",
+			Help: "Age of the oldest unsent event in the outbox table",
+		},
+	)
+
+	// OutboxRowsPurged tracks sent outbox rows removed after the retention period
+	OutboxRowsPurged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "accounts_outbox_rows_purged_total",
+			Help: "Total number of sent outbox rows deleted or archived after retention",
+		},
+		[]string{"mode"},
+	)
 )
@@ -0,0 +1,8 @@
+-- Rollback account lifecycle (closed accounts are reverted to SUSPENDED)
+DROP INDEX IF EXISTS idx_accounts_status;
+ALTER TABLE accounts DROP COLUMN IF EXISTS status_changed_at;
+
+UPDATE accounts SET status = 'SUSPENDED' WHERE status = 'CLOSED';
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+ALTER TABLE accounts
+  ADD CONSTRAINT accounts_status_check CHECK (status IN ('ACTIVE', 'SUSPENDED'));
@@ -0,0 +1,12 @@
+-- Account lifecycle: allow CLOSED status and track when the status last changed
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_status_check;
+ALTER TABLE accounts
+  ADD CONSTRAINT accounts_status_check CHECK (status IN ('ACTIVE', 'SUSPENDED', 'CLOSED'));
+
+ALTER TABLE accounts
+  ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts(status);
+
+COMMENT ON COLUMN accounts.status_changed_at IS 'Timestamp of the last lifecycle transition; NULL if never changed';
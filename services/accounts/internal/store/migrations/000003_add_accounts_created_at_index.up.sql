@@ -0,0 +1,3 @@
+-- Keyset pagination for account listings: newest first, id breaks ties between equal timestamps
+
+CREATE INDEX IF NOT EXISTS idx_accounts_created_at ON accounts(created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+-- Remove outbox archive
+DROP INDEX IF EXISTS idx_outbox_sent;
+DROP TABLE IF EXISTS outbox_archive;
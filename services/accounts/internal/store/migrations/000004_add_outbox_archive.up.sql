@@ -0,0 +1,18 @@
+-- Outbox retention: the relay janitor moves sent rows past retention here when archiving is enabled
+
+CREATE TABLE IF NOT EXISTS outbox_archive (
+  id UUID PRIMARY KEY,
+  aggregate_type TEXT NOT NULL,
+  aggregate_id UUID NOT NULL,
+  event_type TEXT NOT NULL,
+  payload BYTEA NOT NULL,
+  headers JSONB NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL,
+  sent_at TIMESTAMPTZ NOT NULL,
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_archive_aggregate ON outbox_archive(aggregate_id, created_at);
+
+-- Finds sent rows past retention without scanning unsent ones
+CREATE INDEX IF NOT EXISTS idx_outbox_sent ON outbox(sent_at) WHERE sent_at IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Wake the outbox relay on insert instead of waiting for its next poll.
+-- One notification per statement; NOTIFY is delivered on commit and
+-- duplicates within a transaction collapse, and the relay drains the table.
+
+CREATE OR REPLACE FUNCTION notify_outbox() RETURNS trigger AS $$
+BEGIN
+  PERFORM pg_notify('outbox_events', '');
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_notify ON outbox;
+CREATE TRIGGER outbox_notify AFTER INSERT ON outbox
+FOR EACH STATEMENT EXECUTE FUNCTION notify_outbox();
@@ -0,0 +1,4 @@
+-- Rollback account types
+DROP INDEX IF EXISTS idx_accounts_account_type;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_account_type_check;
+ALTER TABLE accounts DROP COLUMN IF EXISTS account_type;
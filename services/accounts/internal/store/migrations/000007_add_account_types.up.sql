@@ -0,0 +1,13 @@
+-- Chart of accounts: every account has a type, which fixes its normal balance side.
+-- Existing accounts hold customer credit, a liability of the ledger.
+
+ALTER TABLE accounts
+  ADD COLUMN IF NOT EXISTS account_type TEXT NOT NULL DEFAULT 'LIABILITY';
+
+ALTER TABLE accounts
+  ADD CONSTRAINT accounts_account_type_check
+  CHECK (account_type IN ('ASSET', 'LIABILITY', 'EQUITY', 'REVENUE', 'EXPENSE'));
+
+CREATE INDEX IF NOT EXISTS idx_accounts_account_type ON accounts(account_type);
+
+COMMENT ON COLUMN accounts.account_type IS 'ASSET and EXPENSE accounts are debit-normal; LIABILITY, EQUITY and REVENUE are credit-normal';
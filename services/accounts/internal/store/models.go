@@ -17,6 +17,10 @@ type Account struct {
 	Currency  string
 	Status    string
 	CreatedAt time.Time
+	// Timestamp of the last lifecycle transition; NULL if never changed
+	StatusChangedAt sql.NullTime
+	// ASSET and EXPENSE accounts are debit-normal; LIABILITY, EQUITY and REVENUE are credit-normal
+	AccountType string
 }
 
 type Outbox struct {
@@ -28,4 +32,18 @@ type Outbox struct {
 	Headers       json.RawMessage
 	CreatedAt     time.Time
 	SentAt        sql.NullTime
+	AggregateSeq  int64
+}
+
+type OutboxArchive struct {
+	ID            uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	Payload       []byte
+	Headers       json.RawMessage
+	CreatedAt     time.Time
+	SentAt        time.Time
+	ArchivedAt    time.Time
+	AggregateSeq  sql.NullInt64
 }
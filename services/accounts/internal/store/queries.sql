@@ -1,24 +1,44 @@
 -- Account Operations
 
 -- name: CreateAccount :one
-INSERT INTO accounts (id, currency, status, created_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO accounts (id, currency, status, created_at, account_type)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountForUpdate :one
+SELECT * FROM accounts WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2, status_changed_at = $3
+WHERE id = $1
+RETURNING *;
+
 -- name: ListAccounts :many
+-- Newest first; after_created_at/after_id continue below the last account of the previous page
+SELECT * FROM accounts
+WHERE (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (sqlc.narg('after_created_at')::timestamptz IS NULL OR (created_at, id) < (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListAccountsPrev :many
+-- Oldest first from just above before_created_at/before_id; callers reverse the page
 SELECT * FROM accounts
-WHERE ($1::text IS NULL OR currency = $1)
-  AND ($2::text IS NULL OR status = $2)
-ORDER BY created_at DESC
-LIMIT $3 OFFSET $4;
+WHERE (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'))
+  AND (created_at, id) > (sqlc.arg('before_created_at')::timestamptz, sqlc.arg('before_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: CountAccounts :one
 SELECT COUNT(*) FROM accounts
-WHERE ($1::text IS NULL OR currency = $1)
-  AND ($2::text IS NULL OR status = $2);
+WHERE (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
+  AND (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status'));
 
 -- Outbox Operations
 
@@ -7,6 +7,7 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"time"
 
@@ -20,12 +21,12 @@ WHERE ($1::text IS NULL OR currency = $1)
 `
 
 type CountAccountsParams struct {
-	Column1 string
-	Column2 string
+	Currency sql.NullString
+	Status   sql.NullString
 }
 
 func (q *Queries) CountAccounts(ctx context.Context, arg CountAccountsParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countAccounts, arg.Column1, arg.Column2)
+	row := q.db.QueryRowContext(ctx, countAccounts, arg.Currency, arg.Status)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -33,16 +34,17 @@ func (q *Queries) CountAccounts(ctx context.Context, arg CountAccountsParams) (i
 
 const createAccount = `-- name: CreateAccount :one
 
-INSERT INTO accounts (id, currency, status, created_at)
-VALUES ($1, $2, $3, $4)
-RETURNING id, currency, status, created_at
+INSERT INTO accounts (id, currency, status, created_at, account_type)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, currency, status, created_at, status_changed_at, account_type
 `
 
 type CreateAccountParams struct {
-	ID        uuid.UUID
-	Currency  string
-	Status    string
-	CreatedAt time.Time
+	ID          uuid.UUID
+	Currency    string
+	Status      string
+	CreatedAt   time.Time
+	AccountType string
 }
 
 // Account Operations
@@ -52,6 +54,7 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		arg.Currency,
 		arg.Status,
 		arg.CreatedAt,
+		arg.AccountType,
 	)
 	var i Account
 	err := row.Scan(
@@ -59,6 +62,8 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Currency,
 		&i.Status,
 		&i.CreatedAt,
+		&i.StatusChangedAt,
+		&i.AccountType,
 	)
 	return i, err
 }
@@ -67,7 +72,7 @@ const createOutboxEvent = `-- name: CreateOutboxEvent :one
 
 INSERT INTO outbox (id, aggregate_type, aggregate_id, event_type, payload, headers, created_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq
 `
 
 type CreateOutboxEventParams struct {
@@ -101,12 +106,13 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
 	)
 	return i, err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, currency, status, created_at FROM accounts WHERE id = $1 LIMIT 1
+SELECT id, currency, status, created_at, status_changed_at, account_type FROM accounts WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, id uuid.UUID) (Account, error) {
@@ -117,12 +123,32 @@ func (q *Queries) GetAccount(ctx context.Context, id uuid.UUID) (Account, error)
 		&i.Currency,
 		&i.Status,
 		&i.CreatedAt,
+		&i.StatusChangedAt,
+		&i.AccountType,
+	)
+	return i, err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT id, currency, status, created_at, status_changed_at, account_type FROM accounts WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, id uuid.UUID) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+		&i.AccountType,
 	)
 	return i, err
 }
 
 const getOutboxEvent = `-- name: GetOutboxEvent :one
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
 WHERE id = $1
 `
 
@@ -138,12 +164,13 @@ func (q *Queries) GetOutboxEvent(ctx context.Context, id uuid.UUID) (Outbox, err
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
 	)
 	return i, err
 }
 
 const getUnsentOutboxEvents = `-- name: GetUnsentOutboxEvents :many
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
 WHERE sent_at IS NULL
 ORDER BY created_at
 LIMIT $1
@@ -168,6 +195,7 @@ func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Out
 			&i.Headers,
 			&i.CreatedAt,
 			&i.SentAt,
+			&i.AggregateSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -183,26 +211,30 @@ func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Out
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, currency, status, created_at FROM accounts
+SELECT id, currency, status, created_at, status_changed_at, account_type FROM accounts
 WHERE ($1::text IS NULL OR currency = $1)
   AND ($2::text IS NULL OR status = $2)
-ORDER BY created_at DESC
-LIMIT $3 OFFSET $4
+  AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $5
 `
 
 type ListAccountsParams struct {
-	Column1 string
-	Column2 string
-	Limit   int32
-	Offset  int32
+	Currency       sql.NullString
+	Status         sql.NullString
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	Limit          int32
 }
 
+// Newest first; after_created_at/after_id continue below the last account of the previous page
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
 	rows, err := q.db.QueryContext(ctx, listAccounts,
-		arg.Column1,
-		arg.Column2,
+		arg.Currency,
+		arg.Status,
+		arg.AfterCreatedAt,
+		arg.AfterID,
 		arg.Limit,
-		arg.Offset,
 	)
 	if err != nil {
 		return nil, err
@@ -216,6 +248,62 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Currency,
 			&i.Status,
 			&i.CreatedAt,
+			&i.StatusChangedAt,
+			&i.AccountType,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsPrev = `-- name: ListAccountsPrev :many
+SELECT id, currency, status, created_at, status_changed_at, account_type FROM accounts
+WHERE ($1::text IS NULL OR currency = $1)
+  AND ($2::text IS NULL OR status = $2)
+  AND (created_at, id) > ($3::timestamptz, $4::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $5
+`
+
+type ListAccountsPrevParams struct {
+	Currency        sql.NullString
+	Status          sql.NullString
+	BeforeCreatedAt time.Time
+	BeforeID        uuid.UUID
+	Limit           int32
+}
+
+// Oldest first from just above before_created_at/before_id; callers reverse the page
+func (q *Queries) ListAccountsPrev(ctx context.Context, arg ListAccountsPrevParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsPrev,
+		arg.Currency,
+		arg.Status,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Currency,
+			&i.Status,
+			&i.CreatedAt,
+			&i.StatusChangedAt,
+			&i.AccountType,
 		); err != nil {
 			return nil, err
 		}
@@ -240,3 +328,30 @@ func (q *Queries) MarkOutboxEventSent(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markOutboxEventSent, id)
 	return err
 }
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $2, status_changed_at = $3
+WHERE id = $1
+RETURNING id, currency, status, created_at, status_changed_at, account_type
+`
+
+type UpdateAccountStatusParams struct {
+	ID              uuid.UUID
+	Status          string
+	StatusChangedAt sql.NullTime
+}
+
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountStatus, arg.ID, arg.Status, arg.StatusChangedAt)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Currency,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+		&i.AccountType,
+	)
+	return i, err
+}
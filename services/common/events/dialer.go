@@ -0,0 +1,54 @@
+package events
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// NewDialer returns a Kafka dialer for reading event topics. SASL is configured
+// from KAFKA_SASL_USERNAME, KAFKA_SASL_PASSWORD and KAFKA_SASL_MECHANISM
+// ("PLAIN" or "SCRAM-SHA-256") when a username is set, as for the outbox publisher.
+func NewDialer() (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+
+	username := os.Getenv("KAFKA_SASL_USERNAME")
+	if username == "" {
+		return dialer, nil
+	}
+	password := os.Getenv("KAFKA_SASL_PASSWORD")
+	mechanismType := os.Getenv("KAFKA_SASL_MECHANISM")
+	if mechanismType == "" {
+		mechanismType = "PLAIN"
+	}
+
+	var mechanism sasl.Mechanism
+	switch mechanismType {
+	case "PLAIN":
+		mechanism = plain.Mechanism{
+			Username: username,
+			Password: password,
+		}
+	case "SCRAM-SHA-256":
+		var err error
+		mechanism, err = scram.Mechanism(scram.SHA256, username, password)
+		if err != nil {
+			return nil, fmt.Errorf("create SCRAM mechanism: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s. Use PLAIN or SCRAM-SHA-256", mechanismType)
+	}
+
+	dialer.SASLMechanism = mechanism
+	dialer.TLS = &tls.Config{}
+	return dialer, nil
+}
@@ -0,0 +1,167 @@
+// Package events encodes and decodes the ledger.v1 EventEnvelope that every
+// Kafka event travels in. Messages published before the envelope existed carry
+// the bare event message and their metadata in headers; Decode reads both.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// HeaderEnvelope marks a message whose value is an EventEnvelope; its value is EnvelopeName
+const HeaderEnvelope = "envelope"
+
+// EnvelopeName is the full protobuf name of the envelope message
+var EnvelopeName = string((&ledgerv1.EventEnvelope{}).ProtoReflect().Descriptor().FullName())
+
+// typeURLPrefix is the conventional prefix of an Any type URL
+const typeURLPrefix = "type.googleapis.com/"
+
+var (
+	// ErrUnknownEventType is returned for event types this build does not know
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrUnsupportedVersion is returned for schema versions newer than this build knows
+	ErrUnsupportedVersion = errors.New("unsupported schema version")
+)
+
+// SchemaVersions is the latest schema version of each event type this build
+// can read. Bump an entry, and handle both versions in consumers, when an
+// event's payload changes in a way old readers cannot handle.
+var SchemaVersions = map[string]uint32{
+	"AccountCreated":       1,
+	"AccountStatusChanged": 1,
+	"EntryPosted":          1,
+	"EntryVoided":          1,
+	"TransferInitiated":    1,
+	"TransferCompleted":    1,
+	"TransferFailed":       1,
+	"TransferRefunded":     1,
+	"HoldPlaced":           1,
+	"HoldCaptured":         1,
+	"HoldReleased":         1,
+	"PeriodStatusChanged":  1,
+}
+
+// Metadata describes an event independently of its payload
+type Metadata struct {
+	EventID       string
+	EventType     string
+	SchemaVersion uint32 // zero means the type's current version, or 1 for unknown types
+	Schema        string // full payload message name; default "ledger.v1." + EventType
+	AggregateType string
+	AggregateID   string
+	AggregateSeq  int64
+	OccurredAt    time.Time
+	CorrelationID string
+	CausationID   string
+}
+
+// Encode wraps a marshaled event payload in an EventEnvelope and marshals it
+func Encode(meta Metadata, payload []byte) ([]byte, error) {
+	return proto.Marshal(wrap(meta, payload))
+}
+
+func wrap(meta Metadata, payload []byte) *ledgerv1.EventEnvelope {
+	version := meta.SchemaVersion
+	if version == 0 {
+		version = max(SchemaVersions[meta.EventType], 1)
+	}
+	schema := meta.Schema
+	if schema == "" {
+		schema = "ledger.v1." + meta.EventType
+	}
+
+	envelope := &ledgerv1.EventEnvelope{
+		EventId:       meta.EventID,
+		EventType:     meta.EventType,
+		SchemaVersion: version,
+		AggregateType: meta.AggregateType,
+		AggregateId:   meta.AggregateID,
+		AggregateSeq:  meta.AggregateSeq,
+		CorrelationId: meta.CorrelationID,
+		CausationId:   meta.CausationID,
+		Payload:       &anypb.Any{TypeUrl: typeURLPrefix + schema, Value: payload},
+	}
+	if !meta.OccurredAt.IsZero() {
+		envelope.OccurredAt = timestamppb.New(meta.OccurredAt)
+	}
+	return envelope
+}
+
+// Decode reads the envelope of a Kafka message. A message without the
+// envelope header is a bare event from before the envelope; its envelope is
+// rebuilt from the event_id, event_type (or event_name), aggregate_type,
+// aggregate_id, aggregate_seq and schema headers at schema version 1.
+func Decode(value []byte, headers []kafka.Header) (*ledgerv1.EventEnvelope, error) {
+	if header(headers, HeaderEnvelope) == EnvelopeName {
+		var envelope ledgerv1.EventEnvelope
+		if err := proto.Unmarshal(value, &envelope); err != nil {
+			return nil, fmt.Errorf("unmarshal event envelope: %w", err)
+		}
+		if envelope.Payload == nil {
+			return nil, fmt.Errorf("event envelope %s has no payload", envelope.EventId)
+		}
+		return &envelope, nil
+	}
+
+	eventType := header(headers, "event_type")
+	if eventType == "" {
+		eventType = header(headers, "event_name")
+	}
+	meta := Metadata{
+		EventID:       header(headers, "event_id"),
+		EventType:     eventType,
+		SchemaVersion: 1,
+		Schema:        header(headers, "schema"),
+		AggregateType: header(headers, "aggregate_type"),
+		AggregateID:   header(headers, "aggregate_id"),
+		CorrelationID: header(headers, "correlation_id"),
+		CausationID:   header(headers, "causation_id"),
+	}
+	meta.AggregateSeq, _ = strconv.ParseInt(header(headers, "aggregate_seq"), 10, 64)
+	return wrap(meta, value), nil
+}
+
+// Check reports whether this build can read an envelope's event: it returns
+// ErrUnknownEventType or ErrUnsupportedVersion for events to skip
+func Check(envelope *ledgerv1.EventEnvelope) error {
+	latest, ok := SchemaVersions[envelope.EventType]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownEventType, envelope.EventType)
+	}
+	if envelope.SchemaVersion > latest {
+		return fmt.Errorf("%w: %s v%d, latest known is v%d", ErrUnsupportedVersion, envelope.EventType, envelope.SchemaVersion, latest)
+	}
+	return nil
+}
+
+// Unpack unmarshals an envelope's payload into event after checking that the
+// payload holds that message type
+func Unpack(envelope *ledgerv1.EventEnvelope, event proto.Message) error {
+	want := event.ProtoReflect().Descriptor().FullName()
+	if got := envelope.GetPayload().MessageName(); got != want {
+		return fmt.Errorf("event %s payload is %q, not %s", envelope.EventId, got, want)
+	}
+	if err := proto.Unmarshal(envelope.Payload.Value, event); err != nil {
+		return fmt.Errorf("unmarshal %s: %w", want, err)
+	}
+	return nil
+}
+
+// header returns the value of a Kafka message header, or "" when absent
+func header(headers []kafka.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,268 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+func mustMarshal(t *testing.T, m proto.Message) []byte {
+	t.Helper()
+	data, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	event := &ledgerv1.TransferCompleted{TransferId: "t-1", TsUnixMs: 1700000000000}
+	occurredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	meta := Metadata{
+		EventID:       "evt-1",
+		EventType:     "TransferCompleted",
+		AggregateType: "Transfer",
+		AggregateID:   "agg-1",
+		AggregateSeq:  7,
+		OccurredAt:    occurredAt,
+		CorrelationID: "corr-1",
+		CausationID:   "cause-1",
+	}
+
+	value, err := Encode(meta, mustMarshal(t, event))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	envelope, err := Decode(value, []kafka.Header{{Key: HeaderEnvelope, Value: []byte(EnvelopeName)}})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if envelope.EventId != "evt-1" || envelope.EventType != "TransferCompleted" ||
+		envelope.AggregateType != "Transfer" || envelope.AggregateId != "agg-1" || envelope.AggregateSeq != 7 ||
+		envelope.CorrelationId != "corr-1" || envelope.CausationId != "cause-1" {
+		t.Errorf("metadata did not round-trip: %v", envelope)
+	}
+	if envelope.SchemaVersion != SchemaVersions["TransferCompleted"] {
+		t.Errorf("schema version = %d, want the current %d", envelope.SchemaVersion, SchemaVersions["TransferCompleted"])
+	}
+	if !envelope.OccurredAt.AsTime().Equal(occurredAt) {
+		t.Errorf("occurred_at = %v, want %v", envelope.OccurredAt.AsTime(), occurredAt)
+	}
+	if got := envelope.Payload.TypeUrl; got != "type.googleapis.com/ledger.v1.TransferCompleted" {
+		t.Errorf("type URL = %q", got)
+	}
+	if err := Check(envelope); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+
+	var got ledgerv1.TransferCompleted
+	if err := Unpack(envelope, &got); err != nil {
+		t.Fatalf("Unpack: %v", err)
+	}
+	if !proto.Equal(&got, event) {
+		t.Errorf("payload = %v, want %v", &got, event)
+	}
+}
+
+func TestEncode_Defaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		meta        Metadata
+		wantVersion uint32
+		wantTypeURL string
+	}{
+		{
+			name:        "known type at its current version",
+			meta:        Metadata{EventType: "HoldPlaced"},
+			wantVersion: 1,
+			wantTypeURL: "type.googleapis.com/ledger.v1.HoldPlaced",
+		},
+		{
+			name:        "unknown type at version 1",
+			meta:        Metadata{EventType: "SomethingNew"},
+			wantVersion: 1,
+			wantTypeURL: "type.googleapis.com/ledger.v1.SomethingNew",
+		},
+		{
+			name:        "explicit version and schema",
+			meta:        Metadata{EventType: "EntryPosted", SchemaVersion: 2, Schema: "ledger.v2.EntryPosted"},
+			wantVersion: 2,
+			wantTypeURL: "type.googleapis.com/ledger.v2.EntryPosted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope := wrap(tt.meta, nil)
+			if envelope.SchemaVersion != tt.wantVersion {
+				t.Errorf("schema version = %d, want %d", envelope.SchemaVersion, tt.wantVersion)
+			}
+			if envelope.Payload.TypeUrl != tt.wantTypeURL {
+				t.Errorf("type URL = %q, want %q", envelope.Payload.TypeUrl, tt.wantTypeURL)
+			}
+			if envelope.OccurredAt != nil {
+				t.Errorf("occurred_at = %v, want unset", envelope.OccurredAt)
+			}
+		})
+	}
+}
+
+func TestDecode_LegacyMessage(t *testing.T) {
+	event := &ledgerv1.EntryPosted{EntryId: "e-1", BatchId: "b-1"}
+	value := mustMarshal(t, event)
+
+	tests := []struct {
+		name          string
+		headers       []kafka.Header
+		wantEventType string
+		wantTypeURL   string
+		wantSeq       int64
+	}{
+		{
+			name: "event_type header",
+			headers: []kafka.Header{
+				{Key: "event_id", Value: []byte("evt-1")},
+				{Key: "event_type", Value: []byte("EntryPosted")},
+				{Key: "aggregate_type", Value: []byte("Entry")},
+				{Key: "aggregate_id", Value: []byte("agg-1")},
+				{Key: "aggregate_seq", Value: []byte("3")},
+			},
+			wantEventType: "EntryPosted",
+			wantTypeURL:   "type.googleapis.com/ledger.v1.EntryPosted",
+			wantSeq:       3,
+		},
+		{
+			name: "event_name header fallback",
+			headers: []kafka.Header{
+				{Key: "event_name", Value: []byte("EntryPosted")},
+				{Key: "schema", Value: []byte("ledger.v1.EntryPosted")},
+			},
+			wantEventType: "EntryPosted",
+			wantTypeURL:   "type.googleapis.com/ledger.v1.EntryPosted",
+		},
+		{
+			name: "event_type wins over event_name",
+			headers: []kafka.Header{
+				{Key: "event_name", Value: []byte("Ignored")},
+				{Key: "event_type", Value: []byte("EntryPosted")},
+			},
+			wantEventType: "EntryPosted",
+			wantTypeURL:   "type.googleapis.com/ledger.v1.EntryPosted",
+		},
+		{
+			name:          "unparseable aggregate_seq",
+			headers:       []kafka.Header{{Key: "event_type", Value: []byte("EntryPosted")}, {Key: "aggregate_seq", Value: []byte("x")}},
+			wantEventType: "EntryPosted",
+			wantTypeURL:   "type.googleapis.com/ledger.v1.EntryPosted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envelope, err := Decode(value, tt.headers)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if envelope.EventType != tt.wantEventType {
+				t.Errorf("event type = %q, want %q", envelope.EventType, tt.wantEventType)
+			}
+			if envelope.SchemaVersion != 1 {
+				t.Errorf("schema version = %d, want 1", envelope.SchemaVersion)
+			}
+			if envelope.AggregateSeq != tt.wantSeq {
+				t.Errorf("aggregate seq = %d, want %d", envelope.AggregateSeq, tt.wantSeq)
+			}
+			if envelope.Payload.TypeUrl != tt.wantTypeURL {
+				t.Errorf("type URL = %q, want %q", envelope.Payload.TypeUrl, tt.wantTypeURL)
+			}
+			if err := Check(envelope); err != nil {
+				t.Errorf("Check: %v", err)
+			}
+
+			var got ledgerv1.EntryPosted
+			if err := Unpack(envelope, &got); err != nil {
+				t.Fatalf("Unpack: %v", err)
+			}
+			if !proto.Equal(&got, event) {
+				t.Errorf("payload = %v, want %v", &got, event)
+			}
+		})
+	}
+}
+
+func TestDecode_LegacyMessageWithoutEventType(t *testing.T) {
+	value := mustMarshal(t, &ledgerv1.EntryPosted{EntryId: "e-1"})
+
+	envelope, err := Decode(value, []kafka.Header{{Key: "event_id", Value: []byte("evt-1")}})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	// Without a type the schema defaults to the bare package prefix
+	if got := envelope.Payload.TypeUrl; got != "type.googleapis.com/ledger.v1." {
+		t.Errorf("type URL = %q, want %q", got, "type.googleapis.com/ledger.v1.")
+	}
+	// which Check reports as unknown, so consumers skip the message
+	if err := Check(envelope); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Check = %v, want ErrUnknownEventType", err)
+	}
+	var got ledgerv1.EntryPosted
+	if err := Unpack(envelope, &got); err == nil {
+		t.Error("Unpack succeeded for a payload without a message type")
+	}
+}
+
+func TestDecode_InvalidEnvelope(t *testing.T) {
+	headers := []kafka.Header{{Key: HeaderEnvelope, Value: []byte(EnvelopeName)}}
+
+	if _, err := Decode([]byte{0xff, 0xff}, headers); err == nil {
+		t.Error("Decode succeeded for a malformed envelope")
+	}
+
+	value := mustMarshal(t, &ledgerv1.EventEnvelope{EventId: "evt-1", EventType: "EntryPosted"})
+	if _, err := Decode(value, headers); err == nil {
+		t.Error("Decode succeeded for an envelope without a payload")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		version   uint32
+		wantErr   error
+	}{
+		{name: "current version", eventType: "EntryPosted", version: 1},
+		{name: "older version", eventType: "EntryPosted", version: 0},
+		{name: "newer version", eventType: "EntryPosted", version: 2, wantErr: ErrUnsupportedVersion},
+		{name: "unknown type", eventType: "SomethingNew", version: 1, wantErr: ErrUnknownEventType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(&ledgerv1.EventEnvelope{EventType: tt.eventType, SchemaVersion: tt.version})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnpack_WrongMessageType(t *testing.T) {
+	envelope := wrap(Metadata{EventType: "HoldPlaced"}, mustMarshal(t, &ledgerv1.HoldPlaced{HoldId: "h-1"}))
+
+	var wrong ledgerv1.HoldReleased
+	if err := Unpack(envelope, &wrong); err == nil {
+		t.Error("Unpack into a different message type succeeded")
+	}
+
+	var right ledgerv1.HoldPlaced
+	if err := Unpack(envelope, &right); err != nil || right.HoldId != "h-1" {
+		t.Errorf("Unpack = %v, hold %q", err, right.HoldId)
+	}
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const unsentStats = `
+SELECT count(*), COALESCE(EXTRACT(EPOCH FROM now() - min(created_at)), 0)::float8
+FROM outbox
+WHERE sent_at IS NULL
+`
+
+// Rows past retention, oldest first; SKIP LOCKED lets replicas purge side by side
+const expiredRows = `
+SELECT id FROM outbox
+WHERE sent_at < now() - make_interval(secs => $1)
+ORDER BY sent_at
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+const deleteExpired = `
+DELETE FROM outbox
+WHERE id IN (` + expiredRows + `)
+`
+
+const archiveExpired = `
+WITH purged AS (
+  DELETE FROM outbox
+  WHERE id IN (` + expiredRows + `)
+  RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq
+)
+INSERT INTO outbox_archive (id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq)
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM purged
+ON CONFLICT (id) DO NOTHING
+`
+
+// execer runs the janitor's purge statements; *sql.DB satisfies it
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// runJanitor records the queue depth and purges sent rows past retention
+// every JanitorInterval until ctx is canceled
+func (r *Relay) runJanitor(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.recordQueueDepth(ctx); err != nil && ctx.Err() == nil {
+			r.logger.Printf("Error recording outbox queue depth: %v", err)
+		}
+		if r.cfg.Retention > 0 {
+			if err := r.purgeSent(ctx, r.db); err != nil && ctx.Err() == nil {
+				r.logger.Printf("Error purging sent outbox rows: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recordQueueDepth sets the unsent count and oldest unsent age gauges
+func (r *Relay) recordQueueDepth(ctx context.Context) error {
+	var unsent int64
+	var oldestAge float64
+	if err := r.db.QueryRowContext(ctx, unsentStats).Scan(&unsent, &oldestAge); err != nil {
+		return fmt.Errorf("count unsent events: %w", err)
+	}
+
+	if r.cfg.Metrics.QueueSize != nil {
+		r.cfg.Metrics.QueueSize.Set(float64(unsent))
+	}
+	if r.cfg.Metrics.OldestUnsentAge != nil {
+		r.cfg.Metrics.OldestUnsentAge.Set(oldestAge)
+	}
+	return nil
+}
+
+// purgeSent deletes, or moves to outbox_archive, sent rows older than the
+// retention, PurgeBatchSize rows per statement so no transaction grows large.
+// It stops after a short batch, which leaves nothing past retention, or when
+// ctx is canceled.
+func (r *Relay) purgeSent(ctx context.Context, db execer) error {
+	query, mode := deleteExpired, "deleted"
+	if r.cfg.Archive {
+		query, mode = archiveExpired, "archived"
+	}
+
+	var total int64
+	for {
+		result, err := db.ExecContext(ctx, query, r.cfg.Retention.Seconds(), r.cfg.PurgeBatchSize)
+		if err != nil {
+			return fmt.Errorf("purge batch after %d rows: %w", total, err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		total += n
+		if r.cfg.Metrics.Purged != nil {
+			r.cfg.Metrics.Purged.WithLabelValues(mode).Add(float64(n))
+		}
+		if n < int64(r.cfg.PurgeBatchSize) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if total > 0 {
+		r.logger.Printf("Outbox janitor %s %d sent rows older than %s", mode, total, r.cfg.Retention)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type execCall struct {
+	query string
+	args  []any
+}
+
+// fakeExecer returns the next of its row counts on each call, or err once they run out
+type fakeExecer struct {
+	rows  []int64
+	err   error
+	calls []execCall
+}
+
+func (f *fakeExecer) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	f.calls = append(f.calls, execCall{query: query, args: args})
+	if len(f.rows) == 0 {
+		return nil, f.err
+	}
+	n := f.rows[0]
+	f.rows = f.rows[1:]
+	return driver.RowsAffected(n), nil
+}
+
+func TestPurgeSent(t *testing.T) {
+	failed := errors.New("connection reset")
+
+	tests := []struct {
+		name      string
+		cfg       Config
+		rows      []int64
+		err       error
+		canceled  bool
+		wantQuery string
+		wantArgs  []any
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "deletes by default",
+			cfg:       Config{Retention: time.Hour, PurgeBatchSize: 10},
+			rows:      []int64{4},
+			wantQuery: deleteExpired,
+			wantArgs:  []any{3600.0, 10},
+			wantCalls: 1,
+		},
+		{
+			name:      "archives when configured",
+			cfg:       Config{Retention: time.Hour, Archive: true, PurgeBatchSize: 10},
+			rows:      []int64{4},
+			wantQuery: archiveExpired,
+			wantArgs:  []any{3600.0, 10},
+			wantCalls: 1,
+		},
+		{
+			name:      "cutoff is the retention in seconds",
+			cfg:       Config{Retention: 36*time.Hour + 500*time.Millisecond, PurgeBatchSize: 10},
+			rows:      []int64{0},
+			wantQuery: deleteExpired,
+			wantArgs:  []any{129600.5, 10},
+			wantCalls: 1,
+		},
+		{
+			name:      "default batch size",
+			cfg:       Config{Retention: time.Minute},
+			rows:      []int64{0},
+			wantQuery: deleteExpired,
+			wantArgs:  []any{60.0, 1000},
+			wantCalls: 1,
+		},
+		{
+			name:      "full batches continue until a short one",
+			cfg:       Config{Retention: time.Hour, PurgeBatchSize: 3},
+			rows:      []int64{3, 3, 1},
+			wantQuery: deleteExpired,
+			wantArgs:  []any{3600.0, 3},
+			wantCalls: 3,
+		},
+		{
+			name:      "full batch then nothing left",
+			cfg:       Config{Retention: time.Hour, PurgeBatchSize: 3},
+			rows:      []int64{3, 0},
+			wantQuery: deleteExpired,
+			wantArgs:  []any{3600.0, 3},
+			wantCalls: 2,
+		},
+		{
+			name:      "cancellation stops after the current batch",
+			cfg:       Config{Retention: time.Hour, PurgeBatchSize: 3},
+			rows:      []int64{3, 3, 3},
+			canceled:  true,
+			wantQuery: deleteExpired,
+			wantArgs:  []any{3600.0, 3},
+			wantCalls: 1,
+		},
+		{
+			name:      "failed batch stops the purge",
+			cfg:       Config{Retention: time.Hour, PurgeBatchSize: 3},
+			rows:      []int64{3},
+			err:       failed,
+			wantQuery: deleteExpired,
+			wantArgs:  []any{3600.0, 3},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.canceled {
+				cancel()
+			}
+
+			r := NewRelay(nil, nil, log.New(io.Discard, "", 0), tt.cfg)
+			db := &fakeExecer{rows: tt.rows, err: tt.err}
+			err := r.purgeSent(ctx, db)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("purgeSent error = %v, want error: %v", err, tt.wantErr)
+			}
+			if tt.wantErr && !errors.Is(err, tt.err) {
+				t.Errorf("purgeSent error = %v, want it to wrap %v", err, tt.err)
+			}
+
+			if len(db.calls) != tt.wantCalls {
+				t.Fatalf("purge statements = %d, want %d", len(db.calls), tt.wantCalls)
+			}
+			for i, call := range db.calls {
+				if call.query != tt.wantQuery {
+					t.Errorf("statement %d ran the wrong query:\n%s", i, call.query)
+				}
+				if !reflect.DeepEqual(call.args, tt.wantArgs) {
+					t.Errorf("statement %d args = %v, want %v", i, call.args, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// NotifyChannel is the channel the outbox insert trigger notifies:
+//
+//	CREATE TRIGGER outbox_notify AFTER INSERT ON outbox
+//	FOR EACH STATEMENT EXECUTE FUNCTION notify_outbox();
+const NotifyChannel = "outbox_events"
+
+// listen subscribes to NotifyChannel on a dedicated connection. The returned
+// channel holds at most one pending wake-up, so a burst of inserts costs one
+// drain. A reconnect also wakes the relay, since notifications sent while the
+// connection was down are lost.
+func (r *Relay) listen() (*pq.Listener, <-chan struct{}) {
+	listener := pq.NewListener(r.cfg.ListenURL, 100*time.Millisecond, 10*time.Second,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				r.logger.Printf("Outbox listener error: %v", err)
+			}
+		})
+	if err := listener.Listen(NotifyChannel); err != nil {
+		// The poll timer still drives the relay, just without early wake-ups
+		r.logger.Printf("Error listening on %q, falling back to polling: %v", NotifyChannel, err)
+	}
+
+	wake := make(chan struct{}, 1)
+	go func() {
+		for range listener.Notify {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return listener, wake
+}
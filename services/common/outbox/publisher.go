@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Publisher writes batches of messages to Kafka
+type Publisher struct {
+	writer *kafka.Writer
+}
+
+// NewPublisher creates a Kafka publisher. Messages are partitioned by key, so
+// all events of one aggregate land on the same partition in the order written.
+// SASL is configured from KAFKA_SASL_USERNAME, KAFKA_SASL_PASSWORD and
+// KAFKA_SASL_MECHANISM ("PLAIN" or "SCRAM-SHA-256") when a username is set.
+func NewPublisher(brokers []string) (*Publisher, error) {
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafka.RequireAll,
+		// One relay batch must fit in a single produce request per partition, so
+		// a partition's messages of a batch succeed or fail together
+		BatchSize:    maxBatchSize,
+		BatchTimeout: 10 * time.Millisecond, // Synchronous writes wait for this before flushing a partial batch
+	}
+
+	if username := os.Getenv("KAFKA_SASL_USERNAME"); username != "" {
+		password := os.Getenv("KAFKA_SASL_PASSWORD")
+		mechanismType := os.Getenv("KAFKA_SASL_MECHANISM")
+		if mechanismType == "" {
+			mechanismType = "PLAIN" // Default to PLAIN for Confluent Cloud
+		}
+
+		var mechanism sasl.Mechanism
+		switch mechanismType {
+		case "PLAIN":
+			mechanism = plain.Mechanism{
+				Username: username,
+				Password: password,
+			}
+		case "SCRAM-SHA-256":
+			var err error
+			mechanism, err = scram.Mechanism(scram.SHA256, username, password)
+			if err != nil {
+				return nil, fmt.Errorf("create SCRAM mechanism: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported SASL mechanism: %s. Use PLAIN or SCRAM-SHA-256", mechanismType)
+		}
+
+		writer.Transport = &kafka.Transport{
+			SASL: mechanism,
+			TLS:  &tls.Config{},
+		}
+	}
+
+	return &Publisher{
+		writer: writer,
+	}, nil
+}
+
+// Publish writes msgs synchronously. When only some messages fail the error is
+// a kafka.WriteErrors holding one entry per message, nil for those written.
+func (p *Publisher) Publish(ctx context.Context, msgs ...kafka.Message) error {
+	return p.writer.WriteMessages(ctx, msgs...)
+}
+
+// Close flushes and closes the Kafka writer
+func (p *Publisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,510 @@
+// Package outbox relays events from a service's transactional outbox table to
+// Kafka. Every service with an outbox uses the same table shape:
+//
+//	outbox (id UUID, aggregate_type TEXT, aggregate_id UUID, event_type TEXT,
+//	        payload BYTEA, headers JSONB, created_at TIMESTAMPTZ, sent_at TIMESTAMPTZ,
+//	        aggregate_seq BIGINT)
+//	outbox_sequences (aggregate_id UUID, last_seq BIGINT, last_sent_seq BIGINT)
+//
+// An insert trigger numbers each aggregate's events 1, 2, 3... in
+// aggregate_seq, counting in outbox_sequences, and the relay publishes them in
+// that order.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/common/events"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxBatchSize caps Config.BatchSize; the publisher's Kafka batches are sized to match
+const maxBatchSize = 1000
+
+// The aggregates with the oldest unsent events and all their unsent events in
+// sequence order, with the last sequence number published for each aggregate
+const selectUnsent = `
+WITH pending AS (
+  SELECT aggregate_id, min(created_at) AS first_created_at
+  FROM outbox
+  WHERE sent_at IS NULL
+  GROUP BY aggregate_id
+  ORDER BY first_created_at, aggregate_id
+  LIMIT $1
+)
+SELECT o.id, o.aggregate_type, o.aggregate_id, o.aggregate_seq, o.event_type, o.payload, o.headers, o.created_at,
+       COALESCE(s.last_sent_seq, 0)
+FROM pending p
+JOIN outbox o ON o.aggregate_id = p.aggregate_id AND o.sent_at IS NULL
+LEFT JOIN outbox_sequences s ON s.aggregate_id = p.aggregate_id
+ORDER BY p.first_created_at, p.aggregate_id, o.aggregate_seq
+LIMIT $1
+`
+
+// Marks events sent and advances their aggregates' last published sequence in one statement
+const markSent = `
+WITH sent AS (
+  UPDATE outbox SET sent_at = now()
+  WHERE id = ANY($1::uuid[])
+  RETURNING aggregate_id, aggregate_seq
+)
+UPDATE outbox_sequences s SET last_sent_seq = GREATEST(s.last_sent_seq, m.seq)
+FROM (SELECT aggregate_id, max(aggregate_seq) AS seq FROM sent GROUP BY aggregate_id) m
+WHERE s.aggregate_id = m.aggregate_id
+`
+
+// Event is one outbox row
+type Event struct {
+	ID            uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	AggregateSeq  int64 // position in the aggregate's event stream, from 1
+	EventType     string
+	Payload       []byte
+	Headers       []byte // JSON object of extra Kafka headers
+	CreatedAt     time.Time
+
+	lastSentSeq int64 // the aggregate's last published sequence number when read
+}
+
+// Metrics are the service's relay collectors; nil collectors are skipped
+type Metrics struct {
+	Published       *prometheus.CounterVec // labels: event_type, topic
+	PublishErrors   *prometheus.CounterVec // labels: event_type, error_type
+	Latency         prometheus.Observer    // seconds from event creation to publish
+	QueueSize       prometheus.Gauge       // unsent rows
+	OldestUnsentAge prometheus.Gauge       // seconds since the oldest unsent row was created
+	Purged          *prometheus.CounterVec // labels: mode ("deleted" or "archived")
+}
+
+// Config configures a Relay; zero durations and sizes take the defaults
+type Config struct {
+	Router  Router // required
+	Metrics Metrics
+
+	// ListenURL switches the relay to notify mode: it wakes on NOTIFY from the
+	// outbox insert trigger on this database and polls only as a safety net
+	ListenURL    string
+	PollInterval time.Duration // default 100ms, or 5s in notify mode
+	BatchSize    int           // events per Kafka write; default 100, at most 1000
+	MinBackoff   time.Duration // first wait after a failed batch; default 200ms
+	MaxBackoff   time.Duration // default 30s
+	LockName     string        // advisory lock that elects the publishing replica; default "outbox-relay"
+
+	// The janitor records queue depth every JanitorInterval and, when Retention
+	// is set, purges sent rows older than it
+	JanitorInterval time.Duration // default 1m
+	Retention       time.Duration // zero keeps sent rows forever
+	Archive         bool          // move purged rows to outbox_archive instead of deleting them
+	PurgeBatchSize  int           // rows per purge statement; default 1000
+}
+
+// Relay publishes unsent outbox events to Kafka in batches.
+//
+// One replica at a time publishes, elected by a session advisory lock held on a
+// dedicated connection. The leader reads a batch of the aggregates waiting
+// longest without locking rows, writes their events to Kafka in one call and
+// then marks them sent, so no transaction stays open while Kafka is slow.
+// Messages are keyed by aggregate ID and carry the aggregate_seq header. Each
+// aggregate's events are published strictly in sequence: an event is held back
+// while any earlier one is unpublished, and if part of a batch fails,
+// everything from the first failed message on stays unsent.
+type Relay struct {
+	db        *sql.DB
+	publisher *Publisher
+	logger    *log.Logger
+	cfg       Config
+
+	conn *sql.Conn // holds the leader lock while this replica is publishing
+}
+
+// NewRelay creates an outbox relay
+func NewRelay(db *sql.DB, publisher *Publisher, logger *log.Logger, cfg Config) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 100 * time.Millisecond
+		if cfg.ListenURL != "" {
+			cfg.PollInterval = 5 * time.Second
+		}
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	cfg.BatchSize = min(cfg.BatchSize, maxBatchSize)
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	if cfg.LockName == "" {
+		cfg.LockName = "outbox-relay"
+	}
+	if cfg.JanitorInterval <= 0 {
+		cfg.JanitorInterval = time.Minute
+	}
+	if cfg.PurgeBatchSize <= 0 {
+		cfg.PurgeBatchSize = 1000
+	}
+
+	return &Relay{
+		db:        db,
+		publisher: publisher,
+		logger:    logger,
+		cfg:       cfg,
+	}
+}
+
+// Start runs the relay and its janitor until ctx is canceled. Each wake-up,
+// from a notification or the poll timer, drains the outbox until a batch
+// comes back short; failures back off exponentially.
+func (r *Relay) Start(ctx context.Context) error {
+	r.logger.Println("Outbox relay worker started")
+	defer r.resign()
+
+	go r.runJanitor(ctx)
+
+	var wake <-chan struct{}
+	if r.cfg.ListenURL != "" {
+		listener, notifications := r.listen()
+		defer listener.Close()
+		wake = notifications
+		r.logger.Printf("Outbox relay listening on %q, polling every %s", NotifyChannel, r.cfg.PollInterval)
+	}
+
+	return r.run(ctx, wake, r.drain)
+}
+
+// run calls pass once per wake-up, from wake or the poll timer, until ctx is
+// canceled. While a failed pass backs off, notifications are ignored.
+func (r *Relay) run(ctx context.Context, wake <-chan struct{}, pass func(context.Context) error) error {
+	var backoff time.Duration
+	for {
+		wait, notified := r.cfg.PollInterval, wake
+		if backoff > 0 {
+			// Keep backing off; a notification does not mean the failure cleared
+			wait, notified = backoff, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			r.logger.Println("Outbox relay worker stopping...")
+			return ctx.Err()
+		case <-notified:
+		case <-timer.C:
+		}
+		timer.Stop()
+
+		if err := pass(ctx); err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			backoff = min(max(2*backoff, r.cfg.MinBackoff), r.cfg.MaxBackoff)
+			r.logger.Printf("Error processing outbox, retrying in %s: %v", backoff, err)
+			continue
+		}
+		backoff = 0
+	}
+}
+
+// drain relays batches until one comes back short or publishes nothing
+func (r *Relay) drain(ctx context.Context) error {
+	for ctx.Err() == nil {
+		more, err := r.relayBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+// relayBatch publishes one batch of unsent events and reports whether it was
+// full and made progress, so more events may be waiting
+func (r *Relay) relayBatch(ctx context.Context) (bool, error) {
+	conn, err := r.lead(ctx)
+	if err != nil || conn == nil {
+		return false, err
+	}
+
+	tracer := otel.Tracer("outbox-relay")
+	ctx, span := tracer.Start(ctx, "processOutbox")
+	defer span.End()
+
+	unsent, err := r.listUnsent(ctx, conn)
+	if err != nil {
+		span.RecordError(err)
+		r.resign()
+		return false, err
+	}
+	full := len(unsent) == r.cfg.BatchSize
+
+	events, held := inSequence(unsent)
+	for _, event := range held {
+		r.observeError(event.EventType, "sequence_gap")
+	}
+	if len(held) > 0 {
+		r.logger.Printf("Holding back %d outbox events whose aggregates have unpublished earlier events; first is %s seq %d",
+			len(held), held[0].AggregateID, held[0].AggregateSeq)
+	}
+	if len(events) == 0 {
+		return false, nil
+	}
+	span.SetAttributes(attribute.Int("event_count", len(events)))
+
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		msg, err := r.message(ctx, event)
+		if err != nil {
+			span.RecordError(err)
+			return false, err
+		}
+		msgs[i] = msg
+	}
+
+	publishErr := r.publisher.Publish(ctx, msgs...)
+	if publishErr != nil {
+		span.RecordError(publishErr)
+	}
+	sent := sentInOrder(events, publishErr)
+
+	ids := make([]string, len(sent))
+	for i, event := range sent {
+		ids[i] = event.ID.String()
+	}
+	if len(ids) > 0 {
+		if _, err := conn.ExecContext(ctx, markSent, pq.Array(ids)); err != nil {
+			// The events are published again on the next batch; consumers skip duplicates by event_id
+			span.RecordError(err)
+			for _, event := range sent {
+				r.observeError(event.EventType, "db_error")
+			}
+			r.resign()
+			return false, fmt.Errorf("mark %d events sent: %w", len(ids), err)
+		}
+	}
+
+	now := time.Now()
+	for i, event := range sent {
+		if r.cfg.Metrics.Published != nil {
+			r.cfg.Metrics.Published.WithLabelValues(event.EventType, msgs[i].Topic).Inc()
+		}
+		if r.cfg.Metrics.Latency != nil {
+			r.cfg.Metrics.Latency.Observe(now.Sub(event.CreatedAt).Seconds())
+		}
+	}
+
+	if publishErr != nil {
+		for _, event := range events[len(sent):] {
+			r.observeError(event.EventType, "kafka_error")
+		}
+		return false, fmt.Errorf("published %d of %d events: %w", len(sent), len(events), publishErr)
+	}
+	r.logger.Printf("Published %d outbox events", len(sent))
+	return full, nil
+}
+
+// lead returns the connection holding the leader lock, taking the lock when it
+// is free. It returns nil when another replica is publishing.
+func (r *Relay) lead(ctx context.Context) (*sql.Conn, error) {
+	if r.conn != nil {
+		return r.conn, nil
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", r.cfg.LockName).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("acquire relay lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, nil
+	}
+
+	r.logger.Printf("Acquired outbox relay lock %q; this replica publishes", r.cfg.LockName)
+	r.conn = conn
+	return conn, nil
+}
+
+// resign releases the leader lock so another replica, or this one on its next
+// batch, can take over with a fresh connection
+func (r *Relay) resign() {
+	if r.conn == nil {
+		return
+	}
+	if _, err := r.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", r.cfg.LockName); err != nil {
+		// Never return a session that may still hold the lock to the pool
+		r.conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	r.conn.Close()
+	r.conn = nil
+}
+
+func (r *Relay) listUnsent(ctx context.Context, conn *sql.Conn) ([]Event, error) {
+	rows, err := conn.QueryContext(ctx, selectUnsent, r.cfg.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetch unsent events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.AggregateSeq, &e.EventType, &e.Payload, &e.Headers, &e.CreatedAt, &e.lastSentSeq); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("fetch unsent events: %w", err)
+	}
+	return events, nil
+}
+
+// message builds the Kafka message for an event: its payload wrapped in an
+// EventEnvelope, with the event's JSON headers, its identity and the trace
+// context of a span for the publish as headers. The schema, schema_version,
+// correlation_id and causation_id JSON headers fill in the envelope.
+func (r *Relay) message(ctx context.Context, event Event) (kafka.Message, error) {
+	topic := r.cfg.Router(event.EventType)
+
+	tracer := otel.Tracer("outbox-relay")
+	ctx, span := tracer.Start(ctx, "publishEvent",
+		trace.WithAttributes(
+			attribute.String("event_id", event.ID.String()),
+			attribute.String("event_type", event.EventType),
+			attribute.String("aggregate_id", event.AggregateID.String()),
+			attribute.String("aggregate_type", event.AggregateType),
+			attribute.Int64("aggregate_seq", event.AggregateSeq),
+			attribute.String("kafka.topic", topic),
+		),
+	)
+	defer span.End()
+
+	// Headers that are not a JSON object are dropped rather than blocking the outbox
+	var extra map[string]interface{}
+	if err := json.Unmarshal(event.Headers, &extra); err != nil {
+		span.RecordError(err)
+		r.observeError(event.EventType, "invalid_headers")
+		r.logger.Printf("Ignoring headers of event %s: %v", event.ID, err)
+	}
+
+	headers := make(map[string]string, len(extra)+6)
+	for k, v := range extra {
+		headers[k] = fmt.Sprintf("%v", v)
+	}
+
+	meta := events.Metadata{
+		EventID:       event.ID.String(),
+		EventType:     event.EventType,
+		Schema:        headers["schema"],
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID.String(),
+		AggregateSeq:  event.AggregateSeq,
+		OccurredAt:    event.CreatedAt,
+		CorrelationID: headers["correlation_id"],
+		CausationID:   headers["causation_id"],
+	}
+	if version, err := strconv.ParseUint(headers["schema_version"], 10, 32); err == nil {
+		meta.SchemaVersion = uint32(version)
+	}
+	value, err := events.Encode(meta, event.Payload)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("encode envelope of event %s: %w", event.ID, err)
+	}
+
+	headers[events.HeaderEnvelope] = events.EnvelopeName
+	headers["event_id"] = event.ID.String()
+	headers["event_type"] = event.EventType
+	headers["aggregate_id"] = event.AggregateID.String()
+	headers["aggregate_type"] = event.AggregateType
+	headers["aggregate_seq"] = strconv.FormatInt(event.AggregateSeq, 10)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+
+	kafkaHeaders := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     []byte(event.AggregateID.String()),
+		Value:   value,
+		Headers: kafkaHeaders,
+	}, nil
+}
+
+func (r *Relay) observeError(eventType, errorType string) {
+	if r.cfg.Metrics.PublishErrors != nil {
+		r.cfg.Metrics.PublishErrors.WithLabelValues(eventType, errorType).Inc()
+	}
+}
+
+// inSequence splits events, grouped by aggregate in sequence order, into those
+// that may be published now and those held back behind a missing earlier
+// sequence number. Events at or below the aggregate's last published sequence
+// predate sequencing and go out as they are.
+func inSequence(events []Event) (ready, held []Event) {
+	var aggregate uuid.UUID
+	var next int64
+	blocked := false
+	for i, event := range events {
+		if i == 0 || event.AggregateID != aggregate {
+			aggregate, next, blocked = event.AggregateID, event.lastSentSeq+1, false
+		}
+		switch {
+		case blocked || event.AggregateSeq > next:
+			blocked = true
+			held = append(held, event)
+		case event.AggregateSeq == next:
+			next++
+			ready = append(ready, event)
+		default:
+			ready = append(ready, event)
+		}
+	}
+	return ready, held
+}
+
+// sentInOrder returns the events that may be marked sent after publishing
+// events with the given error: all of them on success, none on a whole-batch
+// failure, and on a partial failure the leading run of the batch before the
+// first failed message, so no aggregate's later event is marked sent ahead of
+// an earlier one that must be published again
+func sentInOrder(events []Event, err error) []Event {
+	if err == nil {
+		return events
+	}
+	var writeErrs kafka.WriteErrors
+	if !errors.As(err, &writeErrs) || len(writeErrs) != len(events) {
+		return nil
+	}
+	for i, writeErr := range writeErrs {
+		if writeErr != nil {
+			return events[:i]
+		}
+	}
+	return events
+}
@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// seqEvent builds an event of the aggregate at seq, labelled by its event type
+func seqEvent(aggregate uuid.UUID, seq, lastSent int64, label string) Event {
+	return Event{ID: uuid.New(), AggregateID: aggregate, AggregateSeq: seq, EventType: label, lastSentSeq: lastSent}
+}
+
+func labels(events []Event) []string {
+	var out []string
+	for _, event := range events {
+		out = append(out, event.EventType)
+	}
+	return out
+}
+
+func TestInSequence(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		events    []Event
+		wantReady []string
+		wantHeld  []string
+	}{
+		{
+			name:      "contiguous from the last sent",
+			events:    []Event{seqEvent(a, 3, 2, "a3"), seqEvent(a, 4, 2, "a4"), seqEvent(a, 5, 2, "a5")},
+			wantReady: []string{"a3", "a4", "a5"},
+		},
+		{
+			name:      "gap holds the rest of the aggregate",
+			events:    []Event{seqEvent(a, 1, 0, "a1"), seqEvent(a, 3, 0, "a3"), seqEvent(a, 4, 0, "a4")},
+			wantReady: []string{"a1"},
+			wantHeld:  []string{"a3", "a4"},
+		},
+		{
+			name:     "gap right after the last sent",
+			events:   []Event{seqEvent(a, 5, 3, "a5")},
+			wantHeld: []string{"a5"},
+		},
+		{
+			name:      "events predating sequencing go out as they are",
+			events:    []Event{seqEvent(a, 0, 4, "a0"), seqEvent(a, 2, 4, "a2"), seqEvent(a, 5, 4, "a5")},
+			wantReady: []string{"a0", "a2", "a5"},
+		},
+		{
+			name: "gap in one aggregate does not hold another",
+			events: []Event{
+				seqEvent(a, 2, 0, "a2"), seqEvent(a, 3, 0, "a3"),
+				seqEvent(b, 1, 0, "b1"), seqEvent(b, 2, 0, "b2"),
+			},
+			wantReady: []string{"b1", "b2"},
+			wantHeld:  []string{"a2", "a3"},
+		},
+		{
+			name:   "empty batch",
+			events: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, held := inSequence(tt.events)
+			if got := labels(ready); !reflect.DeepEqual(got, tt.wantReady) {
+				t.Errorf("ready = %v, want %v", got, tt.wantReady)
+			}
+			if got := labels(held); !reflect.DeepEqual(got, tt.wantHeld) {
+				t.Errorf("held = %v, want %v", got, tt.wantHeld)
+			}
+		})
+	}
+}
+
+func TestSentInOrder(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	events := []Event{seqEvent(a, 1, 0, "a1"), seqEvent(b, 1, 0, "b1"), seqEvent(a, 2, 0, "a2")}
+	failed := errors.New("message too large")
+
+	tests := []struct {
+		name string
+		err  error
+		want []string
+	}{
+		{name: "success", err: nil, want: []string{"a1", "b1", "a2"}},
+		{name: "whole batch failure", err: errors.New("broker unavailable"), want: nil},
+		{name: "first message failed", err: kafka.WriteErrors{failed, nil, nil}, want: nil},
+		{name: "middle message failed", err: kafka.WriteErrors{nil, failed, nil}, want: []string{"a1"}},
+		{name: "last message failed", err: kafka.WriteErrors{nil, nil, failed}, want: []string{"a1", "b1"}},
+		{name: "no message failed", err: kafka.WriteErrors{nil, nil, nil}, want: []string{"a1", "b1", "a2"}},
+		{name: "write errors for another batch", err: kafka.WriteErrors{nil, failed}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labels(sentInOrder(events, tt.err)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentInOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	const quiet = 100 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		pollInterval time.Duration
+		notify       bool
+	}{
+		{name: "notification", pollInterval: time.Hour, notify: true},
+		{name: "fallback tick without a notification", pollInterval: 3 * quiet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			r := NewRelay(nil, nil, log.New(io.Discard, "", 0), Config{PollInterval: tt.pollInterval})
+			wake := make(chan struct{}, 1)
+			if tt.notify {
+				wake <- struct{}{}
+			}
+			passes := make(chan time.Time, 10)
+			pass := func(context.Context) error {
+				passes <- time.Now()
+				return nil
+			}
+
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() { done <- r.run(ctx, wake, pass) }()
+
+			select {
+			case at := <-passes:
+				if !tt.notify && at.Sub(start) < tt.pollInterval {
+					t.Errorf("pass after %s, before the poll interval of %s", at.Sub(start), tt.pollInterval)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no relay pass")
+			}
+
+			// Nothing else wakes the relay before the next poll
+			time.Sleep(quiet)
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Errorf("run error = %v, want context.Canceled", err)
+			}
+			if extra := len(passes); extra != 0 {
+				t.Errorf("relay passes = %d, want 1", 1+extra)
+			}
+		})
+	}
+}
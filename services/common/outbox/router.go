@@ -0,0 +1,22 @@
+package outbox
+
+// Router picks the Kafka topic for an outbox event type
+type Router func(eventType string) string
+
+// RouteByEventType returns a Router that sends each event type to the topic
+// listing it, and any other type to fallback
+func RouteByEventType(fallback string, topics map[string][]string) Router {
+	routes := make(map[string]string)
+	for topic, eventTypes := range topics {
+		for _, eventType := range eventTypes {
+			routes[eventType] = topic
+		}
+	}
+
+	return func(eventType string) string {
+		if topic, ok := routes[eventType]; ok {
+			return topic
+		}
+		return fallback
+	}
+}
@@ -0,0 +1,33 @@
+package outbox
+
+import "testing"
+
+func TestRouteByEventType(t *testing.T) {
+	route := RouteByEventType("ledger.entry.v1", map[string][]string{
+		"ledger.account.v1":  {"AccountCreated", "AccountStatusChanged"},
+		"ledger.transfer.v1": {"TransferInitiated", "TransferCompleted"},
+	})
+
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{eventType: "AccountCreated", want: "ledger.account.v1"},
+		{eventType: "AccountStatusChanged", want: "ledger.account.v1"},
+		{eventType: "TransferCompleted", want: "ledger.transfer.v1"},
+		{eventType: "EntryPosted", want: "ledger.entry.v1"},
+		{eventType: "", want: "ledger.entry.v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			if got := route(tt.eventType); got != tt.want {
+				t.Errorf("route(%q) = %q, want %q", tt.eventType, got, tt.want)
+			}
+		})
+	}
+
+	if got := RouteByEventType("fallback", nil)("AccountCreated"); got != "fallback" {
+		t.Errorf("route without topics = %q, want fallback", got)
+	}
+}
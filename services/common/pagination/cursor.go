@@ -0,0 +1,79 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Cursor is the keyset position of a row in a list ordered newest first,
+// sent to clients as an opaque next_cursor/prev_cursor token
+type Cursor struct {
+	Ts   time.Time `json:"t"`
+	ID   string    `json:"i"`
+	Prev bool      `json:"p,omitempty"` // page towards newer rows
+}
+
+// EncodeCursor returns the opaque token for a cursor
+func EncodeCursor(c Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token produced by EncodeCursor
+func DecodeCursor(token string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Ts.IsZero() || c.ID == "" {
+		return c, fmt.Errorf("invalid cursor")
+	}
+	return c, nil
+}
+
+// ParseLimit reads the limit query parameter; values above max are capped
+func ParseLimit(r *http.Request, defaultLimit, max int32) int32 {
+	limit := defaultLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsed, err := strconv.ParseInt(limitStr, 10, 32); err == nil && parsed > 0 {
+			limit = int32(min(parsed, int64(max)))
+		}
+	}
+	return limit
+}
+
+// KeysetPage trims rows fetched with limit+1 to a page and returns the cursors around it.
+// Rows fetched for a prev cursor arrive oldest first and are reversed to newest first.
+func KeysetPage[T any](rows []T, limit int32, cursor *Cursor, key func(T) Cursor) (page []T, next, prev string) {
+	hasMore := len(rows) > int(limit)
+	if hasMore {
+		rows = rows[:limit]
+	}
+
+	backward := cursor != nil && cursor.Prev
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	// Older rows follow when this page was cut short, or when we paged back to it
+	if hasMore || backward {
+		next = EncodeCursor(key(rows[len(rows)-1]))
+	}
+	// Newer rows precede when we paged forward to this page, or paged back and more remain
+	if (cursor != nil && !backward) || (backward && hasMore) {
+		c := key(rows[0])
+		c.Prev = true
+		prev = EncodeCursor(c)
+	}
+	return rows, next, prev
+}
@@ -65,6 +65,7 @@ export class TransfersController {
     }
   }})
   @ApiResponse({ status: 400, description: 'Invalid request body or validation error' })
+  @ApiResponse({ status: 422, description: 'Rejected by the ledger; details.error carries its code, e.g. insufficient_funds, or period_locked / period_closed when the accounting period is locked or closed' })
   async createTransfer(@Body() body: any) {
     // Validate request body
     const parseResult = CreateTransferSchema.safeParse(body);
@@ -3,20 +3,27 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/amirhf/credit-ledger/services/ledger/internal/accounts"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
 	ledgerhttp "github.com/amirhf/credit-ledger/services/ledger/internal/http"
-	"github.com/amirhf/credit-ledger/services/ledger/internal/outbox"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/reconcile"
 	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
 	"github.com/amirhf/credit-ledger/services/ledger/internal/telemetry"
 	"github.com/amirhf/credit-ledger/services/common/migrate"
+	"github.com/amirhf/credit-ledger/services/common/outbox"
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -73,11 +80,51 @@ func main() {
 	log.Printf("Kafka brokers: %v", brokers)
 
 	// Create Kafka publisher
-	publisher := outbox.NewPublisher(brokers)
+	publisher, err := outbox.NewPublisher(brokers)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka publisher: %v", err)
+	}
 	defer publisher.Close()
 
+	// Sent outbox rows are kept forever unless a retention is configured (e.g. "168h")
+	var outboxRetention time.Duration
+	if v := os.Getenv("OUTBOX_RETENTION"); v != "" {
+		outboxRetention, err = time.ParseDuration(v)
+		if err != nil || outboxRetention <= 0 {
+			log.Fatalf("Invalid OUTBOX_RETENTION: %q", v)
+		}
+	}
+
+	// The relay wakes on NOTIFY from the outbox insert trigger; OUTBOX_RELAY_MODE=poll polls every 100ms instead
+	var outboxListenURL string
+	switch mode := os.Getenv("OUTBOX_RELAY_MODE"); mode {
+	case "", "notify":
+		outboxListenURL = dbURL
+	case "poll":
+	default:
+		log.Fatalf("Invalid OUTBOX_RELAY_MODE: %q (use notify or poll)", mode)
+	}
+
 	// Create outbox relay
-	relay := outbox.NewRelay(db, publisher, log.Default())
+	relay := outbox.NewRelay(db, publisher, log.Default(), outbox.Config{
+		Router: outbox.RouteByEventType("ledger.events.v1", map[string][]string{
+			// Voids share the topic and partition key (original entry ID) with postings,
+			// so consumers always see an entry's EntryPosted before its EntryVoided
+			"ledger.entry.v1":  {"EntryPosted", "EntryVoided"},
+			"ledger.period.v1": {"PeriodStatusChanged"},
+		}),
+		Metrics: outbox.Metrics{
+			Published:       metrics.OutboxEventsPublished,
+			PublishErrors:   metrics.OutboxEventsPublishErrors,
+			Latency:         metrics.OutboxRelayLatency,
+			QueueSize:       metrics.OutboxQueueSize,
+			OldestUnsentAge: metrics.OutboxOldestUnsentAge,
+			Purged:          metrics.OutboxRowsPurged,
+		},
+		ListenURL: outboxListenURL,
+		Retention: outboxRetention,
+		Archive:   os.Getenv("OUTBOX_ARCHIVE") == "true",
+	})
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -90,8 +137,94 @@ func main() {
 		}
 	}()
 
+	// Record account types from ledger.account.v1 so balance floors apply in each account's own sign
+	accountConsumer, err := accounts.NewConsumer(db, brokers, log.Default())
+	if err != nil {
+		log.Fatalf("Failed to create account consumer: %v", err)
+	}
+	go func() {
+		if err := accountConsumer.Start(ctx); err != nil && err != context.Canceled {
+			log.Printf("Account consumer stopped with error: %v", err)
+		}
+	}()
+
+	// Start scheduled reconciliation when an interval is configured (e.g. "1h")
+	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid RECONCILE_INTERVAL: %q", v)
+		}
+		readModelURL := os.Getenv("READMODEL_DATABASE_URL")
+		orchestratorURL := os.Getenv("ORCHESTRATOR_DATABASE_URL")
+		if readModelURL == "" || orchestratorURL == "" {
+			log.Fatal("READMODEL_DATABASE_URL and ORCHESTRATOR_DATABASE_URL are required when RECONCILE_INTERVAL is set")
+		}
+
+		readModelDB, err := sql.Open("postgres", readModelURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to read-model database: %v", err)
+		}
+		defer readModelDB.Close()
+		orchestratorDB, err := sql.Open("postgres", orchestratorURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to orchestrator database: %v", err)
+		}
+		defer orchestratorDB.Close()
+
+		reconciler := reconcile.NewReconciler(db, readModelDB, orchestratorDB, log.Default())
+		go func() {
+			if err := reconciler.Start(ctx, interval); err != nil && err != context.Canceled {
+				log.Printf("Reconciliation worker stopped with error: %v", err)
+			}
+		}()
+	}
+
+	// Get default overdraft policy from environment ("unlimited" or a limit in minor units)
+	defaultOverdraft := domain.OverdraftPolicy{}
+	if v := os.Getenv("DEFAULT_OVERDRAFT_LIMIT_MINOR"); v != "" {
+		if v == "unlimited" {
+			defaultOverdraft.Unlimited = true
+		} else {
+			limit, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || limit < 0 {
+				log.Fatalf("Invalid DEFAULT_OVERDRAFT_LIMIT_MINOR: %q", v)
+			}
+			defaultOverdraft.LimitMinor = limit
+		}
+	}
+	log.Printf("Default overdraft policy: limit=%d unlimited=%v", defaultOverdraft.LimitMinor, defaultOverdraft.Unlimited)
+
+	// Get the credit lot consumption order from environment (FIFO or SOONEST_EXPIRY)
+	lotOrder := domain.LotOrderSoonestExpiry
+	if v := os.Getenv("LOT_CONSUMPTION_ORDER"); v != "" {
+		lotOrder, err = domain.ParseLotOrder(v)
+		if err != nil {
+			log.Fatalf("Invalid LOT_CONSUMPTION_ORDER: %v", err)
+		}
+	}
+	log.Printf("Credit lot consumption order: %s", lotOrder)
+
 	// Create handler
-	handler := ledgerhttp.NewHandler(db, log.Default())
+	handler := ledgerhttp.NewHandler(db, defaultOverdraft, lotOrder, log.Default())
+
+	// Start the lot expiry worker when an interval is configured (e.g. "5m").
+	// LOT_BREAKAGE_ACCOUNTS maps currencies to breakage accounts, e.g. "USD=<uuid>,PTS=<uuid>".
+	if v := os.Getenv("LOT_EXPIRY_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid LOT_EXPIRY_INTERVAL: %q", v)
+		}
+		breakage, err := parseBreakageAccounts(os.Getenv("LOT_BREAKAGE_ACCOUNTS"))
+		if err != nil {
+			log.Fatalf("Invalid LOT_BREAKAGE_ACCOUNTS: %v", err)
+		}
+
+		go func() {
+			if err := handler.StartLotExpiry(ctx, interval, breakage); err != nil && err != context.Canceled {
+				log.Printf("Lot expiry worker stopped with error: %v", err)
+			}
+		}()
+	}
 
 	// Setup router
 	r := chi.NewRouter()
@@ -124,6 +257,17 @@ func main() {
 	r.Post("/v1/entries", handler.CreateEntry)
 	r.Post("/v1/entries/{id}/void", handler.VoidEntry)
 	r.Get("/v1/entries/by-batch/{batch_id}", handler.GetEntryByBatch)
+	r.Get("/v1/accounts/{id}/balances", handler.GetAccountBalances)
+	r.Put("/v1/accounts/{id}/overdraft-limit", handler.SetOverdraftLimit)
+	r.Get("/v1/reconciliation/runs/{id}", handler.GetReconciliationRun)
+	r.Get("/v1/reports/trial-balance", handler.GetTrialBalance)
+	r.Get("/v1/reports/general-ledger", handler.GetGeneralLedger)
+	r.Post("/v1/periods", handler.CreatePeriod)
+	r.Get("/v1/periods", handler.ListPeriods)
+	r.Get("/v1/periods/{id}", handler.GetPeriod)
+	r.Post("/v1/periods/{id}/close", handler.ClosePeriod)
+	r.Post("/v1/periods/{id}/reopen", handler.ReopenPeriod)
+	r.Post("/v1/periods/{id}/lock", handler.LockPeriod)
 
 	// Setup HTTP server
 	addr := ":7102"
@@ -164,3 +308,27 @@ func main() {
 
 	log.Println("Shutdown complete")
 }
+
+// parseBreakageAccounts parses "CUR=<account uuid>" pairs separated by commas
+func parseBreakageAccounts(v string) (map[string]uuid.UUID, error) {
+	breakage := make(map[string]uuid.UUID)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		currency, account, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected CUR=<account id>, got %q", pair)
+		}
+		accountID, err := uuid.Parse(strings.TrimSpace(account))
+		if err != nil {
+			return nil, fmt.Errorf("account for %s must be a valid UUID: %w", currency, err)
+		}
+		breakage[strings.ToUpper(strings.TrimSpace(currency))] = accountID
+	}
+	if len(breakage) == 0 {
+		return nil, fmt.Errorf("at least one currency's breakage account is required")
+	}
+	return breakage, nil
+}
@@ -0,0 +1,64 @@
+// Command reconcile runs one ledger reconciliation and prints the result.
+// It exits non-zero when the run fails or finds discrepancies; details are
+// stored in the ledger database and served by GET /v1/reconciliation/runs/{id}.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/amirhf/credit-ledger/services/common/migrate"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/reconcile"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	db := openDatabase("DATABASE_URL")
+	defer db.Close()
+	readModel := openDatabase("READMODEL_DATABASE_URL")
+	defer readModel.Close()
+	orchestrator := openDatabase("ORCHESTRATOR_DATABASE_URL")
+	defer orchestrator.Close()
+
+	if err := migrate.RunMigrations(db, store.MigrationsFS, "ledger"); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	reconciler := reconcile.NewReconciler(db, readModel, orchestrator, log.Default())
+	run, err := reconciler.Run(ctx, reconcile.TriggerCommand)
+	if err != nil {
+		log.Printf("Reconciliation run %s failed: %v", run.ID, err)
+		os.Exit(1)
+	}
+
+	log.Printf("Reconciliation run %s: %d accounts, %d transfers, %d discrepancies",
+		run.ID, run.AccountsChecked, run.TransfersChecked, run.DiscrepancyCount)
+	if run.DiscrepancyCount > 0 {
+		os.Exit(1)
+	}
+}
+
+// openDatabase connects to the database named by the environment variable
+func openDatabase(env string) *sql.DB {
+	url := os.Getenv(env)
+	if url == "" {
+		log.Fatalf("%s environment variable is required", env)
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		log.Fatalf("Failed to connect to %s: %v", env, err)
+	}
+	if err := db.Ping(); err != nil {
+		log.Fatalf("Failed to ping %s: %v", env, err)
+	}
+	return db
+}
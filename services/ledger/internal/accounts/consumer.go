@@ -0,0 +1,136 @@
+package accounts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/common/events"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// Consumer records the type and normal side of each account from
+// ledger.account.v1 AccountCreated events, so balance floors apply in the
+// account's own sign
+type Consumer struct {
+	reader  *kafka.Reader
+	queries *store.Queries
+	logger  *log.Logger
+}
+
+// NewConsumer creates a Kafka consumer for the ledger.account.v1 topic
+func NewConsumer(db *sql.DB, brokers []string, logger *log.Logger) (*Consumer, error) {
+	dialer, err := events.NewDialer()
+	if err != nil {
+		return nil, err
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:        brokers,
+		Topic:          "ledger.account.v1",
+		GroupID:        "ledger-account-types",
+		MinBytes:       1,
+		MaxBytes:       10e6, // 10MB
+		CommitInterval: time.Second,
+		StartOffset:    kafka.FirstOffset, // Record the type of every account ever created
+		Dialer:         dialer,
+	})
+
+	return &Consumer{
+		reader:  reader,
+		queries: store.New(db),
+		logger:  logger,
+	}, nil
+}
+
+// Start begins consuming account events and blocks until context is canceled
+func (c *Consumer) Start(ctx context.Context) error {
+	c.logger.Println("Starting Kafka consumer for ledger.account.v1")
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.reader.Close()
+			}
+			c.logger.Printf("Error fetching account message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		envelope, err := events.Decode(msg.Value, msg.Headers)
+		if err == nil {
+			err = events.Check(envelope)
+		}
+		if err != nil {
+			// Unknown event types and newer schema versions are skipped, not retried
+			c.logger.Printf("Skipping account message at offset %d: %v", msg.Offset, err)
+			c.reader.CommitMessages(ctx, msg)
+			continue
+		}
+
+		if err := c.process(ctx, envelope); err != nil {
+			c.logger.Printf("Error processing %s event at offset %d: %v", envelope.EventType, msg.Offset, err)
+			// Don't commit on error - will retry
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			c.logger.Printf("Error committing account message: %v", err)
+		}
+	}
+}
+
+// process applies a single account event; only AccountCreated affects balances
+func (c *Consumer) process(ctx context.Context, envelope *ledgerv1.EventEnvelope) error {
+	if envelope.EventType != "AccountCreated" {
+		return nil
+	}
+
+	var event ledgerv1.AccountCreated
+	if err := events.Unpack(envelope, &event); err != nil {
+		return err
+	}
+	accountID, err := uuid.Parse(event.AccountId)
+	if err != nil {
+		return fmt.Errorf("parse account_id: %w", err)
+	}
+
+	accountType, normal := accountTypeOf(&event)
+	if err := c.queries.CreateAccountType(ctx, store.CreateAccountTypeParams{
+		AccountID:   accountID,
+		AccountType: accountType,
+		NormalSide:  string(normal),
+	}); err != nil {
+		return fmt.Errorf("create account type: %w", err)
+	}
+
+	c.logger.Printf("Account %s is %s (%s-normal)", accountID, accountType, normal)
+	return nil
+}
+
+// accountTypeOf returns the account type and normal side carried by an
+// AccountCreated event. Events from before account types carry neither and
+// describe credit-normal LIABILITY accounts.
+func accountTypeOf(event *ledgerv1.AccountCreated) (string, domain.Side) {
+	accountType := "LIABILITY"
+	if event.AccountType != ledgerv1.AccountType_ACCOUNT_TYPE_UNSPECIFIED {
+		accountType = strings.TrimPrefix(event.AccountType.String(), "ACCOUNT_TYPE_")
+	}
+
+	switch event.NormalSide {
+	case ledgerv1.Side_DEBIT:
+		return accountType, domain.SideDebit
+	case ledgerv1.Side_CREDIT:
+		return accountType, domain.SideCredit
+	}
+	return accountType, domain.NormalSideOf(accountType)
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// BalanceChange is the net effect of an entry on one account in one currency
+type BalanceChange struct {
+	AccountID  uuid.UUID
+	Currency   string
+	DeltaMinor int64
+}
+
+// OverdraftPolicy defines how far below zero an account balance may go
+type OverdraftPolicy struct {
+	// LimitMinor is the maximum overdraft in minor units; 0 means the floor is zero
+	LimitMinor int64
+	// Unlimited disables the floor entirely (e.g. for funding or system accounts)
+	Unlimited bool
+}
+
+// Floor returns the lowest balance allowed under the policy
+func (p OverdraftPolicy) Floor() int64 {
+	return -p.LimitMinor
+}
+
+// InsufficientFundsError is returned when applying an entry would take an
+// account below its balance floor. Balance and delta are in the account's own
+// sign, positive on its normal side.
+type InsufficientFundsError struct {
+	AccountID    uuid.UUID
+	Currency     string
+	NormalSide   Side
+	BalanceMinor int64
+	DeltaMinor   int64
+	FloorMinor   int64
+}
+
+func (e InsufficientFundsError) Error() string {
+	decrease := "debit"
+	if e.NormalSide == SideDebit {
+		decrease = "credit"
+	}
+	return fmt.Sprintf("insufficient funds in account %s: balance %d %s, %s %d, floor %d",
+		e.AccountID, e.BalanceMinor, e.Currency, decrease, -e.DeltaMinor, e.FloorMinor)
+}
+
+// NormalSideOf returns the side on which the balance of an account type
+// increases: DEBIT for ASSET and EXPENSE accounts, CREDIT for the others
+func NormalSideOf(accountType string) Side {
+	if accountType == "ASSET" || accountType == "EXPENSE" {
+		return SideDebit
+	}
+	return SideCredit
+}
+
+// BalanceDelta returns the signed effect of the line on its account balance.
+// CREDIT increases the balance and DEBIT decreases it, matching the
+// convention used by the orchestrator (FROM=DEBIT, TO=CREDIT).
+func (l Line) BalanceDelta() int64 {
+	if l.Side == SideDebit {
+		return -l.AmountMinor
+	}
+	return l.AmountMinor
+}
+
+// BalanceChanges returns the net balance change per account and currency.
+// Results are sorted by account ID and currency so callers that lock balance
+// rows in this order never deadlock against each other.
+func (e *Entry) BalanceChanges() []BalanceChange {
+	type key struct {
+		accountID uuid.UUID
+		currency  string
+	}
+
+	deltas := make(map[key]int64)
+	var keys []key
+	for _, line := range e.Lines {
+		k := key{accountID: line.AccountID, currency: line.Currency}
+		if _, seen := deltas[k]; !seen {
+			keys = append(keys, k)
+		}
+		deltas[k] += line.BalanceDelta()
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if c := bytes.Compare(keys[i].accountID[:], keys[j].accountID[:]); c != 0 {
+			return c < 0
+		}
+		return keys[i].currency < keys[j].currency
+	})
+
+	changes := make([]BalanceChange, len(keys))
+	for i, k := range keys {
+		changes[i] = BalanceChange{
+			AccountID:  k.accountID,
+			Currency:   k.currency,
+			DeltaMinor: deltas[k],
+		}
+	}
+	return changes
+}
+
+// CheckBalanceFloor verifies that applying the change to the current balance
+// keeps the account at or above the floor allowed by its overdraft policy.
+// Balance and change count CREDIT as positive; the floor applies in the
+// account's own sign, so for a debit-normal account credits decrease it.
+// Changes that do not decrease the balance are always allowed.
+func CheckBalanceFloor(change BalanceChange, balanceMinor int64, normal Side, policy OverdraftPolicy) error {
+	delta := change.DeltaMinor
+	if normal == SideDebit {
+		balanceMinor, delta = -balanceMinor, -delta
+	}
+	if delta >= 0 || policy.Unlimited {
+		return nil
+	}
+
+	if balanceMinor+delta < policy.Floor() {
+		return InsufficientFundsError{
+			AccountID:    change.AccountID,
+			Currency:     change.Currency,
+			NormalSide:   normal,
+			BalanceMinor: balanceMinor,
+			DeltaMinor:   delta,
+			FloorMinor:   policy.Floor(),
+		}
+	}
+
+	return nil
+}
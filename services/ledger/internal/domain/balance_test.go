@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEntry_BalanceChanges_NetsPerAccountAndCurrency(t *testing.T) {
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	entry := &Entry{
+		EntryID: uuid.New(),
+		BatchID: uuid.New(),
+		Lines: []Line{
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountA, AmountMinor: 300, Currency: "USD", Side: SideCredit},
+			{AccountID: accountB, AmountMinor: 700, Currency: "USD", Side: SideCredit},
+		},
+	}
+
+	changes := entry.BalanceChanges()
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 balance changes, got %d", len(changes))
+	}
+
+	deltas := make(map[uuid.UUID]int64)
+	for _, change := range changes {
+		deltas[change.AccountID] = change.DeltaMinor
+	}
+	if deltas[accountA] != -700 {
+		t.Errorf("expected account A delta -700, got %d", deltas[accountA])
+	}
+	if deltas[accountB] != 700 {
+		t.Errorf("expected account B delta 700, got %d", deltas[accountB])
+	}
+}
+
+func TestEntry_BalanceChanges_SortedForLocking(t *testing.T) {
+	lines := make([]Line, 0, 6)
+	for i := 0; i < 3; i++ {
+		account := uuid.New()
+		lines = append(lines,
+			Line{AccountID: account, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+			Line{AccountID: uuid.New(), AmountMinor: 100, Currency: "USD", Side: SideCredit},
+		)
+	}
+	entry := &Entry{EntryID: uuid.New(), BatchID: uuid.New(), Lines: lines}
+
+	changes := entry.BalanceChanges()
+	for i := 1; i < len(changes); i++ {
+		if bytes.Compare(changes[i-1].AccountID[:], changes[i].AccountID[:]) > 0 {
+			t.Fatalf("balance changes not sorted by account ID at index %d", i)
+		}
+	}
+}
+
+func TestCheckBalanceFloor(t *testing.T) {
+	account := uuid.New()
+
+	tests := []struct {
+		name    string
+		balance int64
+		delta   int64
+		normal  Side
+		policy  OverdraftPolicy
+		wantErr bool
+	}{
+		{name: "credit always allowed", balance: -500, delta: 100, normal: SideCredit, policy: OverdraftPolicy{}, wantErr: false},
+		{name: "debit within balance", balance: 1000, delta: -1000, normal: SideCredit, policy: OverdraftPolicy{}, wantErr: false},
+		{name: "debit below zero floor", balance: 999, delta: -1000, normal: SideCredit, policy: OverdraftPolicy{}, wantErr: true},
+		{name: "debit within overdraft", balance: 0, delta: -500, normal: SideCredit, policy: OverdraftPolicy{LimitMinor: 500}, wantErr: false},
+		{name: "debit beyond overdraft", balance: 0, delta: -501, normal: SideCredit, policy: OverdraftPolicy{LimitMinor: 500}, wantErr: true},
+		{name: "unlimited account", balance: -1_000_000, delta: -1, normal: SideCredit, policy: OverdraftPolicy{Unlimited: true}, wantErr: false},
+		// Debit-normal balances count CREDIT as positive here, so -1000 is a 1000 asset balance
+		{name: "debit to debit-normal account always allowed", balance: 0, delta: -1000, normal: SideDebit, policy: OverdraftPolicy{}, wantErr: false},
+		{name: "credit within debit-normal balance", balance: -1000, delta: 1000, normal: SideDebit, policy: OverdraftPolicy{}, wantErr: false},
+		{name: "credit below debit-normal zero floor", balance: -999, delta: 1000, normal: SideDebit, policy: OverdraftPolicy{}, wantErr: true},
+		{name: "credit within debit-normal overdraft", balance: 0, delta: 500, normal: SideDebit, policy: OverdraftPolicy{LimitMinor: 500}, wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := BalanceChange{AccountID: account, Currency: "USD", DeltaMinor: tt.delta}
+			err := CheckBalanceFloor(change, tt.balance, tt.normal, tt.policy)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected insufficient funds error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if err != nil {
+				if _, ok := err.(InsufficientFundsError); !ok {
+					t.Errorf("expected InsufficientFundsError, got %T", err)
+				}
+			}
+		})
+	}
+}
+
+func TestInsufficientFundsError_DebitNormalAccount(t *testing.T) {
+	change := BalanceChange{AccountID: uuid.New(), Currency: "USD", DeltaMinor: 300}
+	err := CheckBalanceFloor(change, -200, SideDebit, OverdraftPolicy{})
+	fundsErr, ok := err.(InsufficientFundsError)
+	if !ok {
+		t.Fatalf("expected InsufficientFundsError, got %v", err)
+	}
+	if fundsErr.BalanceMinor != 200 || fundsErr.DeltaMinor != -300 {
+		t.Errorf("expected balance 200 and delta -300 in the account's sign, got %d and %d", fundsErr.BalanceMinor, fundsErr.DeltaMinor)
+	}
+	if !strings.Contains(fundsErr.Error(), "credit 300") {
+		t.Errorf("expected the error to name the credit, got %q", fundsErr.Error())
+	}
+}
+
+func TestNormalSideOf(t *testing.T) {
+	for accountType, want := range map[string]Side{
+		"ASSET":     SideDebit,
+		"EXPENSE":   SideDebit,
+		"LIABILITY": SideCredit,
+		"EQUITY":    SideCredit,
+		"REVENUE":   SideCredit,
+	} {
+		if got := NormalSideOf(accountType); got != want {
+			t.Errorf("NormalSideOf(%s) = %s, want %s", accountType, got, want)
+		}
+	}
+}
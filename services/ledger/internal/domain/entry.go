@@ -7,6 +7,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// MaxEffectiveAtSkew is how far an entry's effective date may run ahead of its
+// booking time, to tolerate clock differences between clients and the ledger
+const MaxEffectiveAtSkew = 5 * time.Minute
+
 // Side represents the debit or credit side of a journal line
 type Side string
 
@@ -15,10 +19,20 @@ const (
 	SideCredit Side = "CREDIT"
 )
 
+// EntryKind distinguishes original postings from compensating void entries.
+// Together with the batch ID it forms the idempotency key of an entry.
+type EntryKind string
+
+const (
+	KindPosting EntryKind = "POSTING"
+	KindVoid    EntryKind = "VOID"
+)
+
 // Line represents a single debit or credit line in a journal entry
 type Line struct {
 	AccountID   uuid.UUID
 	AmountMinor int64
+	Currency    string
 	Side        Side
 }
 
@@ -26,8 +40,21 @@ type Line struct {
 type Entry struct {
 	EntryID   uuid.UUID
 	BatchID   uuid.UUID
+	Kind      EntryKind
 	Lines     []Line
-	Timestamp time.Time
+	Timestamp time.Time // when the entry was booked
+
+	// EffectiveAt is the value date the entry takes effect, at or before
+	// Timestamp for late-arriving postings
+	EffectiveAt time.Time
+
+	// Accounting period the entry is booked in, and the closed period it
+	// corrects when it was dated in one; NULL outside any period
+	PeriodID        uuid.NullUUID
+	AdjustsPeriodID uuid.NullUUID
+
+	// Grants are the credit lots the entry's credit lines open
+	Grants []*Lot
 }
 
 // ValidationError represents a domain validation error
@@ -50,8 +77,10 @@ func (e *Entry) Validate() error {
 		}
 	}
 
-	var debitSum int64
-	var creditSum int64
+	// Debits and credits are summed per currency; amounts in different
+	// currencies never offset each other
+	debitSums := make(map[string]int64)
+	creditSums := make(map[string]int64)
 	var hasDebit, hasCredit bool
 
 	for i, line := range e.Lines {
@@ -79,13 +108,21 @@ func (e *Entry) Validate() error {
 			}
 		}
 
+		// Currency must be a 3-letter ISO code
+		if len(line.Currency) != 3 {
+			return ValidationError{
+				Field:   fmt.Sprintf("lines[%d].currency", i),
+				Message: fmt.Sprintf("currency must be a 3-letter ISO code, got %q", line.Currency),
+			}
+		}
+
 		// Sum debits and credits
 		switch line.Side {
 		case SideDebit:
-			debitSum += line.AmountMinor
+			debitSums[line.Currency] += line.AmountMinor
 			hasDebit = true
 		case SideCredit:
-			creditSum += line.AmountMinor
+			creditSums[line.Currency] += line.AmountMinor
 			hasCredit = true
 		}
 	}
@@ -104,11 +141,14 @@ func (e *Entry) Validate() error {
 		}
 	}
 
-	// Double-entry invariant: debits must equal credits
-	if debitSum != creditSum {
-		return ValidationError{
-			Field:   "lines",
-			Message: fmt.Sprintf("debits (%d) must equal credits (%d)", debitSum, creditSum),
+	// Double-entry invariant: debits must equal credits in every currency
+	for _, currency := range e.Currencies() {
+		if debitSums[currency] != creditSums[currency] {
+			return ValidationError{
+				Field: "lines",
+				Message: fmt.Sprintf("debits (%d) must equal credits (%d) for currency %s",
+					debitSums[currency], creditSums[currency], currency),
+			}
 		}
 	}
 
@@ -131,13 +171,30 @@ func (e *Entry) Validate() error {
 	return nil
 }
 
+// Currencies returns the distinct currencies used by the entry's lines,
+// in the order they first appear
+func (e *Entry) Currencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, line := range e.Lines {
+		if !seen[line.Currency] {
+			seen[line.Currency] = true
+			currencies = append(currencies, line.Currency)
+		}
+	}
+	return currencies
+}
+
 // NewEntry creates a new journal entry with validation
 func NewEntry(batchID uuid.UUID, lines []Line) (*Entry, error) {
+	now := time.Now()
 	entry := &Entry{
-		EntryID:   uuid.New(),
-		BatchID:   batchID,
-		Lines:     lines,
-		Timestamp: time.Now(),
+		EntryID:     uuid.New(),
+		BatchID:     batchID,
+		Kind:        KindPosting,
+		Lines:       lines,
+		Timestamp:   now,
+		EffectiveAt: now,
 	}
 
 	if err := entry.Validate(); err != nil {
@@ -147,6 +204,26 @@ func NewEntry(batchID uuid.UUID, lines []Line) (*Entry, error) {
 	return entry, nil
 }
 
+// SetEffectiveAt value-dates the entry. The effective date may lie in the past
+// but not after the booking time, allowing MaxEffectiveAtSkew for client clocks.
+func (e *Entry) SetEffectiveAt(effectiveAt time.Time) error {
+	if effectiveAt.IsZero() {
+		return ValidationError{
+			Field:   "effective_at",
+			Message: "effective_at cannot be zero",
+		}
+	}
+	if effectiveAt.After(e.Timestamp.Add(MaxEffectiveAtSkew)) {
+		return ValidationError{
+			Field:   "effective_at",
+			Message: fmt.Sprintf("effective_at %s is after the booking time %s", effectiveAt.Format(time.RFC3339), e.Timestamp.Format(time.RFC3339)),
+		}
+	}
+
+	e.EffectiveAt = effectiveAt
+	return nil
+}
+
 // CreateVoidEntry creates a compensating entry that reverses the original entry
 // This implements the compensation pattern for SAGA transactions
 func CreateVoidEntry(originalEntry *Entry, reason string) (*Entry, error) {
@@ -170,16 +247,22 @@ func CreateVoidEntry(originalEntry *Entry, reason string) (*Entry, error) {
 		reversedLines[i] = Line{
 			AccountID:   line.AccountID,
 			AmountMinor: line.AmountMinor,
+			Currency:    line.Currency,
 			Side:        reverseSide(line.Side),
 		}
 	}
 
-	// Create void entry with same batch_id (for traceability)
+	// Create void entry with same batch_id (for traceability). The reversal
+	// takes effect when it is booked, so balances and reports for dates before
+	// the void, closed periods included, are left as they were.
+	now := time.Now()
 	voidEntry := &Entry{
-		EntryID:   uuid.New(),
-		BatchID:   originalEntry.BatchID,
-		Lines:     reversedLines,
-		Timestamp: time.Now(),
+		EntryID:     uuid.New(),
+		BatchID:     originalEntry.BatchID,
+		Kind:        KindVoid,
+		Lines:       reversedLines,
+		Timestamp:   now,
+		EffectiveAt: now,
 	}
 
 	// Validate the void entry (should always pass if original was valid)
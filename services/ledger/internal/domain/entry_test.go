@@ -2,6 +2,7 @@ package domain
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,8 +15,8 @@ func TestEntry_Validate_ValidEntry(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -34,9 +35,9 @@ func TestEntry_Validate_MultipleLines(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 500, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 500, Side: SideDebit},
-			{AccountID: accountC, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 500, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 500, Currency: "USD", Side: SideDebit},
+			{AccountID: accountC, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -54,8 +55,8 @@ func TestEntry_Validate_UnbalancedEntry(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 500, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 500, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -80,7 +81,7 @@ func TestEntry_Validate_TooFewLines(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
 		},
 	}
 
@@ -106,8 +107,8 @@ func TestEntry_Validate_NegativeAmount(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: -1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: -1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -133,8 +134,8 @@ func TestEntry_Validate_ZeroAmount(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 0, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 0, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -152,8 +153,8 @@ func TestEntry_Validate_InvalidSide(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: "INVALID"},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: "INVALID"},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -170,8 +171,8 @@ func TestEntry_Validate_NilAccountID(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: uuid.Nil, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: uuid.Nil, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -189,8 +190,8 @@ func TestEntry_Validate_NilEntryID(t *testing.T) {
 		EntryID: uuid.Nil,
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -208,8 +209,8 @@ func TestEntry_Validate_NilBatchID(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.Nil,
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -227,8 +228,8 @@ func TestEntry_Validate_NoDebitLines(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideCredit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 		},
 	}
 
@@ -246,8 +247,8 @@ func TestEntry_Validate_NoCreditLines(t *testing.T) {
 		EntryID: uuid.New(),
 		BatchID: uuid.New(),
 		Lines: []Line{
-			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-			{AccountID: accountB, AmountMinor: 1000, Side: SideDebit},
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
 		},
 	}
 
@@ -263,8 +264,8 @@ func TestNewEntry_Success(t *testing.T) {
 	batchID := uuid.New()
 
 	lines := []Line{
-		{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
-		{AccountID: accountB, AmountMinor: 1000, Side: SideCredit},
+		{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
 	}
 
 	entry, err := NewEntry(batchID, lines)
@@ -291,7 +292,7 @@ func TestNewEntry_ValidationFailure(t *testing.T) {
 
 	// Invalid: only one line
 	lines := []Line{
-		{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
+		{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
 	}
 
 	entry, err := NewEntry(batchID, lines)
@@ -303,3 +304,189 @@ func TestNewEntry_ValidationFailure(t *testing.T) {
 		t.Error("expected nil entry on validation failure")
 	}
 }
+
+func TestEntry_Validate_MissingCurrency(t *testing.T) {
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	entry := &Entry{
+		EntryID: uuid.New(),
+		BatchID: uuid.New(),
+		Lines: []Line{
+			{AccountID: accountA, AmountMinor: 1000, Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+		},
+	}
+
+	err := entry.Validate()
+	if err == nil {
+		t.Error("expected validation error for missing currency")
+	}
+
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Errorf("expected ValidationError, got %T", err)
+	}
+	if validationErr.Field != "lines[0].currency" {
+		t.Errorf("expected field 'lines[0].currency', got '%s'", validationErr.Field)
+	}
+}
+
+func TestEntry_Validate_MultiCurrencyBalanced(t *testing.T) {
+	accountA := uuid.New()
+	accountB := uuid.New()
+	accountC := uuid.New()
+	accountD := uuid.New()
+
+	entry := &Entry{
+		EntryID: uuid.New(),
+		BatchID: uuid.New(),
+		Lines: []Line{
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+			{AccountID: accountC, AmountMinor: 900, Currency: "EUR", Side: SideDebit},
+			{AccountID: accountD, AmountMinor: 900, Currency: "EUR", Side: SideCredit},
+		},
+	}
+
+	err := entry.Validate()
+	if err != nil {
+		t.Errorf("expected valid multi-currency entry, got error: %v", err)
+	}
+}
+
+func TestEntry_Validate_UnbalancedPerCurrency(t *testing.T) {
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	// Totals match (1000 == 1000) but each currency is unbalanced on its own
+	entry := &Entry{
+		EntryID: uuid.New(),
+		BatchID: uuid.New(),
+		Lines: []Line{
+			{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			{AccountID: accountB, AmountMinor: 1000, Currency: "EUR", Side: SideCredit},
+		},
+	}
+
+	err := entry.Validate()
+	if err == nil {
+		t.Error("expected validation error for entry unbalanced per currency")
+	}
+
+	validationErr, ok := err.(ValidationError)
+	if !ok {
+		t.Errorf("expected ValidationError, got %T", err)
+	}
+	if validationErr.Field != "lines" {
+		t.Errorf("expected field 'lines', got '%s'", validationErr.Field)
+	}
+}
+
+func TestCreateVoidEntry_PreservesCurrency(t *testing.T) {
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	original, err := NewEntry(uuid.New(), []Line{
+		{AccountID: accountA, AmountMinor: 1000, Currency: "EUR", Side: SideDebit},
+		{AccountID: accountB, AmountMinor: 1000, Currency: "EUR", Side: SideCredit},
+	})
+	if err != nil {
+		t.Fatalf("expected successful entry creation, got error: %v", err)
+	}
+
+	voidEntry, err := CreateVoidEntry(original, "test")
+	if err != nil {
+		t.Fatalf("expected successful void entry creation, got error: %v", err)
+	}
+
+	for i, line := range voidEntry.Lines {
+		if line.Currency != "EUR" {
+			t.Errorf("lines[%d]: expected currency EUR, got %s", i, line.Currency)
+		}
+		if line.Side == original.Lines[i].Side {
+			t.Errorf("lines[%d]: expected side to be reversed", i)
+		}
+	}
+}
+
+func TestNewEntry_EffectiveAtDefaultsToBookingTime(t *testing.T) {
+	entry, err := NewEntry(uuid.New(), []Line{
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	})
+	if err != nil {
+		t.Fatalf("expected successful entry creation, got error: %v", err)
+	}
+
+	if !entry.EffectiveAt.Equal(entry.Timestamp) {
+		t.Errorf("expected effective_at %v to equal timestamp %v", entry.EffectiveAt, entry.Timestamp)
+	}
+}
+
+func TestEntry_SetEffectiveAt(t *testing.T) {
+	booked := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		effectiveAt time.Time
+		wantErr     bool
+	}{
+		{"late-arriving usage", booked.Add(-72 * time.Hour), false},
+		{"booking time", booked, false},
+		{"within clock skew", booked.Add(MaxEffectiveAtSkew), false},
+		{"future", booked.Add(MaxEffectiveAtSkew + time.Second), true},
+		{"zero", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &Entry{Timestamp: booked, EffectiveAt: booked}
+			err := entry.SetEffectiveAt(tt.effectiveAt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetEffectiveAt(%v) error = %v, wantErr %v", tt.effectiveAt, err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := err.(ValidationError); !ok {
+					t.Errorf("expected ValidationError, got %T", err)
+				}
+				if !entry.EffectiveAt.Equal(booked) {
+					t.Errorf("expected effective_at unchanged on error, got %v", entry.EffectiveAt)
+				}
+				return
+			}
+			if !entry.EffectiveAt.Equal(tt.effectiveAt) {
+				t.Errorf("expected effective_at %v, got %v", tt.effectiveAt, entry.EffectiveAt)
+			}
+		})
+	}
+}
+
+func TestCreateVoidEntry_DatedAtBookingTime(t *testing.T) {
+	original, err := NewEntry(uuid.New(), []Line{
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	})
+	if err != nil {
+		t.Fatalf("expected successful entry creation, got error: %v", err)
+	}
+	effectiveAt := original.Timestamp.Add(-24 * time.Hour)
+	if err := original.SetEffectiveAt(effectiveAt); err != nil {
+		t.Fatalf("SetEffectiveAt: %v", err)
+	}
+
+	voidEntry, err := CreateVoidEntry(original, "test")
+	if err != nil {
+		t.Fatalf("expected successful void entry creation, got error: %v", err)
+	}
+
+	if !voidEntry.EffectiveAt.Equal(voidEntry.Timestamp) {
+		t.Errorf("expected void effective_at %v to equal its timestamp %v", voidEntry.EffectiveAt, voidEntry.Timestamp)
+	}
+	if !voidEntry.EffectiveAt.After(effectiveAt) {
+		t.Errorf("expected void effective_at after the original's %v, got %v", effectiveAt, voidEntry.EffectiveAt)
+	}
+	if voidEntry.Timestamp.Before(original.Timestamp) {
+		t.Errorf("expected void to be booked no earlier than the original")
+	}
+}
@@ -0,0 +1,56 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Fingerprint returns a stable hash of the entry's idempotency key and payload.
+// Line and grant order does not matter; entry and lot IDs and timestamps are
+// ignored, so a retried request for the same batch produces the same fingerprint.
+// The effective date counts only when the client set one, that is when it
+// differs from the booking time.
+func (e *Entry) Fingerprint() string {
+	lines := make([]string, len(e.Lines))
+	for i, line := range e.Lines {
+		lines[i] = fmt.Sprintf("%s|%s|%s|%d", line.AccountID, line.Currency, line.Side, line.AmountMinor)
+	}
+	sort.Strings(lines)
+
+	grants := make([]string, len(e.Grants))
+	for i, lot := range e.Grants {
+		var expiresAt int64
+		if !lot.ExpiresAt.IsZero() {
+			expiresAt = fingerprintTime(lot.ExpiresAt)
+		}
+		grants[i] = fmt.Sprintf("lot|%s|%s|%d|%d|%s", lot.AccountID, lot.Currency, lot.GrantedMinor, expiresAt, lot.Source)
+	}
+	sort.Strings(grants)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s\n", e.BatchID, e.Kind)
+	if effectiveAt := fingerprintTime(e.EffectiveAt); !e.EffectiveAt.IsZero() && effectiveAt != fingerprintTime(e.Timestamp) {
+		fmt.Fprintf(h, "effective_at|%d\n", effectiveAt)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(h, line)
+	}
+	for _, grant := range grants {
+		fmt.Fprintln(h, grant)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprintTime returns t in microseconds, the precision the journal stores
+func fingerprintTime(t time.Time) int64 {
+	return t.Round(time.Microsecond).UnixMicro()
+}
+
+// SamePayload reports whether two entries carry the same batch, kind, lines,
+// client-set effective date and lot grants
+func (e *Entry) SamePayload(other *Entry) bool {
+	return other != nil && e.Fingerprint() == other.Fingerprint()
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestEntry_SamePayload_IgnoresLineOrderAndIdentity(t *testing.T) {
+	batchID := uuid.New()
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	first, err := NewEntry(batchID, []Line{
+		{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	retry, err := NewEntry(batchID, []Line{
+		{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+		{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first.EntryID == retry.EntryID {
+		t.Fatal("expected distinct entry IDs")
+	}
+	if !first.SamePayload(retry) {
+		t.Error("expected retried entry to have the same payload")
+	}
+}
+
+func TestEntry_SamePayload_DetectsConflicts(t *testing.T) {
+	batchID := uuid.New()
+	accountA := uuid.New()
+	accountB := uuid.New()
+
+	original, _ := NewEntry(batchID, []Line{
+		{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	})
+
+	tests := []struct {
+		name  string
+		lines []Line
+	}{
+		{
+			name: "different amount",
+			lines: []Line{
+				{AccountID: accountA, AmountMinor: 2000, Currency: "USD", Side: SideDebit},
+				{AccountID: accountB, AmountMinor: 2000, Currency: "USD", Side: SideCredit},
+			},
+		},
+		{
+			name: "different currency",
+			lines: []Line{
+				{AccountID: accountA, AmountMinor: 1000, Currency: "EUR", Side: SideDebit},
+				{AccountID: accountB, AmountMinor: 1000, Currency: "EUR", Side: SideCredit},
+			},
+		},
+		{
+			name: "swapped sides",
+			lines: []Line{
+				{AccountID: accountA, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+				{AccountID: accountB, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicting, err := NewEntry(batchID, tt.lines)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if original.SamePayload(conflicting) {
+				t.Error("expected conflicting payload to be detected")
+			}
+		})
+	}
+}
+
+func TestCreateVoidEntry_UsesVoidKind(t *testing.T) {
+	original, _ := NewEntry(uuid.New(), []Line{
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	})
+
+	voidEntry, err := CreateVoidEntry(original, "transfer_failed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if original.Kind != KindPosting {
+		t.Errorf("expected original kind %s, got %s", KindPosting, original.Kind)
+	}
+	if voidEntry.Kind != KindVoid {
+		t.Errorf("expected void kind %s, got %s", KindVoid, voidEntry.Kind)
+	}
+	if original.SamePayload(voidEntry) {
+		t.Error("expected void entry payload to differ from the original")
+	}
+}
+
+func TestEntry_SamePayload_EffectiveAt(t *testing.T) {
+	batchID := uuid.New()
+	lines := []Line{
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	}
+	newEntry := func(effectiveAt time.Time) *Entry {
+		entry, err := NewEntry(batchID, lines)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !effectiveAt.IsZero() {
+			if err := entry.SetEffectiveAt(effectiveAt); err != nil {
+				t.Fatalf("SetEffectiveAt: %v", err)
+			}
+		}
+		return entry
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		original time.Time
+		retry    time.Time
+		want     bool
+	}{
+		{name: "neither set", want: true},
+		{name: "same effective date", original: yesterday, retry: yesterday, want: true},
+		{name: "effective date as stored, to the microsecond", original: yesterday.Round(time.Microsecond), retry: yesterday, want: true},
+		{name: "different effective date", original: yesterday, retry: yesterday.Add(-time.Hour), want: false},
+		{name: "retry drops the effective date", original: yesterday, want: false},
+		{name: "retry adds an effective date", retry: yesterday, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := newEntry(tt.original)
+			retry := newEntry(tt.retry)
+			if got := original.SamePayload(retry); got != tt.want {
+				t.Errorf("SamePayload = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntry_SamePayload_Grants(t *testing.T) {
+	batchID := uuid.New()
+	lines := []Line{
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	}
+	expiresAt := time.Now().Add(30 * 24 * time.Hour)
+	newEntry := func(expiresAt time.Time, source string) *Entry {
+		entry, err := NewEntry(batchID, lines)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		lot, err := NewLot(entry.Lines[1], entry.EffectiveAt, expiresAt, source)
+		if err != nil {
+			t.Fatalf("NewLot: %v", err)
+		}
+		entry.Grants = []*Lot{lot}
+		return entry
+	}
+
+	original := newEntry(expiresAt, "promo")
+	if !original.SamePayload(newEntry(expiresAt, "promo")) {
+		t.Error("expected a retried grant to have the same payload")
+	}
+
+	withoutLot, _ := NewEntry(batchID, lines)
+	tests := []struct {
+		name  string
+		retry *Entry
+	}{
+		{name: "different expiry", retry: newEntry(expiresAt.Add(time.Hour), "promo")},
+		{name: "no expiry", retry: newEntry(time.Time{}, "promo")},
+		{name: "different source", retry: newEntry(expiresAt, "refund")},
+		{name: "no lot", retry: withoutLot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if original.SamePayload(tt.retry) {
+				t.Error("expected conflicting grant to be detected")
+			}
+		})
+	}
+}
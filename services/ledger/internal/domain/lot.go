@@ -0,0 +1,194 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LotOrder is the order in which debits draw down an account's credit lots
+type LotOrder string
+
+const (
+	// LotOrderFIFO consumes the oldest grant first
+	LotOrderFIFO LotOrder = "FIFO"
+	// LotOrderSoonestExpiry consumes the lot closest to expiry first, then
+	// lots that never expire, oldest first
+	LotOrderSoonestExpiry LotOrder = "SOONEST_EXPIRY"
+)
+
+// ParseLotOrder parses a consumption order, case-insensitively
+func ParseLotOrder(s string) (LotOrder, error) {
+	switch order := LotOrder(strings.ToUpper(strings.TrimSpace(s))); order {
+	case LotOrderFIFO, LotOrderSoonestExpiry:
+		return order, nil
+	default:
+		return "", fmt.Errorf("invalid lot consumption order %q (use FIFO or SOONEST_EXPIRY)", s)
+	}
+}
+
+// LotMovementKind is the kind of change made to a credit lot
+type LotMovementKind string
+
+const (
+	LotGranted  LotMovementKind = "GRANTED"  // a credit line opened the lot
+	LotConsumed LotMovementKind = "CONSUMED" // a debit drew the lot down
+	LotExpired  LotMovementKind = "EXPIRED"  // the remainder lapsed to breakage
+	LotRestored LotMovementKind = "RESTORED" // a void gave back a consumption or expiry
+	LotRevoked  LotMovementKind = "REVOKED"  // the granting entry was voided
+)
+
+// Lot is a grant of credit to an account that may expire. RemainingMinor is
+// what debits have not consumed yet; a zero ExpiresAt never expires.
+type Lot struct {
+	ID             uuid.UUID
+	AccountID      uuid.UUID
+	Currency       string
+	Source         string
+	GrantedMinor   int64
+	RemainingMinor int64
+	GrantedAt      time.Time
+	ExpiresAt      time.Time
+}
+
+// LotDraw is the amount a debit takes from one lot
+type LotDraw struct {
+	LotID       uuid.UUID
+	AmountMinor int64
+}
+
+// LotMovement is a change to a lot made by a journal entry
+type LotMovement struct {
+	LotID       uuid.UUID
+	AccountID   uuid.UUID
+	Currency    string
+	AmountMinor int64
+	Kind        LotMovementKind
+	ExpiresAt   time.Time // set on LotGranted
+	Source      string    // set on LotGranted
+}
+
+// NewLot creates a lot granting the amount of a credit line, with validation
+func NewLot(line Line, grantedAt, expiresAt time.Time, source string) (*Lot, error) {
+	if line.Side != SideCredit {
+		return nil, ValidationError{Field: "lot", Message: "only credit lines can grant a lot"}
+	}
+	if line.AmountMinor <= 0 {
+		return nil, ValidationError{Field: "lot", Message: "lot amount must be positive"}
+	}
+	if !expiresAt.IsZero() && !expiresAt.After(grantedAt) {
+		return nil, ValidationError{Field: "lot.expires_at", Message: "expires_at must be after the entry's effective date"}
+	}
+
+	return &Lot{
+		ID:             uuid.New(),
+		AccountID:      line.AccountID,
+		Currency:       line.Currency,
+		Source:         strings.TrimSpace(source),
+		GrantedMinor:   line.AmountMinor,
+		RemainingMinor: line.AmountMinor,
+		GrantedAt:      grantedAt,
+		ExpiresAt:      expiresAt,
+	}, nil
+}
+
+// ExpiredAt reports whether the lot has lapsed at t
+func (l Lot) ExpiredAt(t time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !t.Before(l.ExpiresAt)
+}
+
+// SortLots orders lots for consumption. Ties fall back to grant time, then lot ID,
+// so the order is stable.
+func SortLots(lots []Lot, order LotOrder) {
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i], lots[j]
+		if order == LotOrderSoonestExpiry && !a.ExpiresAt.Equal(b.ExpiresAt) {
+			switch {
+			case a.ExpiresAt.IsZero():
+				return false
+			case b.ExpiresAt.IsZero():
+				return true
+			default:
+				return a.ExpiresAt.Before(b.ExpiresAt)
+			}
+		}
+		if !a.GrantedAt.Equal(b.GrantedAt) {
+			return a.GrantedAt.Before(b.GrantedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+}
+
+// ConsumeLots draws amountMinor from lots in the given order, skipping lots
+// not yet granted or already expired at the debit's effective date.
+// uncoveredMinor is the part of the amount no lot covered; it comes out of the
+// account's balance outside lots.
+func ConsumeLots(lots []Lot, amountMinor int64, order LotOrder, at time.Time) (draws []LotDraw, uncoveredMinor int64) {
+	sorted := make([]Lot, len(lots))
+	copy(sorted, lots)
+	SortLots(sorted, order)
+
+	uncoveredMinor = amountMinor
+	for _, lot := range sorted {
+		if uncoveredMinor == 0 {
+			break
+		}
+		if lot.RemainingMinor <= 0 || lot.GrantedAt.After(at) || lot.ExpiredAt(at) {
+			continue
+		}
+		draw := min(lot.RemainingMinor, uncoveredMinor)
+		draws = append(draws, LotDraw{LotID: lot.ID, AmountMinor: draw})
+		uncoveredMinor -= draw
+	}
+	return draws, uncoveredMinor
+}
+
+// LapsedMinor returns what remains of the lots lapsed at t once the draws are
+// taken. The remainder still counts in the account's balance until the expiry
+// worker lapses it to breakage, but it can no longer be spent.
+func LapsedMinor(lots []Lot, draws []LotDraw, t time.Time) int64 {
+	drawn := make(map[uuid.UUID]int64, len(draws))
+	for _, draw := range draws {
+		drawn[draw.LotID] += draw.AmountMinor
+	}
+
+	var lapsedMinor int64
+	for _, lot := range lots {
+		if remaining := lot.RemainingMinor - drawn[lot.ID]; remaining > 0 && lot.ExpiredAt(t) {
+			lapsedMinor += remaining
+		}
+	}
+	return lapsedMinor
+}
+
+// ExpiryEntry creates the entry lapsing a lot's remainder: a debit of the lot's
+// account and a credit of the breakage account, effective when the lot expired.
+// The batch ID is derived from the lot and what expired before, so a retried
+// expiry is idempotent while a lot re-expiring after a restore gets a new batch.
+func ExpiryEntry(lot Lot, expiredBeforeMinor int64, breakageAccountID uuid.UUID) (*Entry, error) {
+	if lot.ExpiresAt.IsZero() {
+		return nil, ValidationError{Field: "lot", Message: fmt.Sprintf("lot %s never expires", lot.ID)}
+	}
+	if breakageAccountID == lot.AccountID {
+		return nil, ValidationError{Field: "breakage_account_id", Message: "breakage account cannot be the lot's account"}
+	}
+
+	batchID := uuid.NewSHA1(lotExpiryNamespace, []byte(fmt.Sprintf("%s:%d", lot.ID, expiredBeforeMinor)))
+	entry, err := NewEntry(batchID, []Line{
+		{AccountID: lot.AccountID, AmountMinor: lot.RemainingMinor, Currency: lot.Currency, Side: SideDebit},
+		{AccountID: breakageAccountID, AmountMinor: lot.RemainingMinor, Currency: lot.Currency, Side: SideCredit},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if lot.ExpiresAt.Before(entry.Timestamp) {
+		entry.EffectiveAt = lot.ExpiresAt
+	}
+	return entry, nil
+}
+
+// lotExpiryNamespace derives the batch IDs of lot expiry entries
+var lotExpiryNamespace = uuid.MustParse("9c1d4e57-3a8b-4f62-b0d7-6e2f81a5c3b4")
@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseLotOrder(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LotOrder
+		wantErr bool
+	}{
+		{"FIFO", LotOrderFIFO, false},
+		{"fifo", LotOrderFIFO, false},
+		{" soonest_expiry ", LotOrderSoonestExpiry, false},
+		{"LIFO", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLotOrder(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLotOrder(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLotOrder(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewLot(t *testing.T) {
+	grantedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	account := uuid.New()
+
+	tests := []struct {
+		name      string
+		line      Line
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{"expiring grant", Line{AccountID: account, AmountMinor: 500, Currency: "PTS", Side: SideCredit}, grantedAt.AddDate(1, 0, 0), false},
+		{"never expires", Line{AccountID: account, AmountMinor: 500, Currency: "PTS", Side: SideCredit}, time.Time{}, false},
+		{"debit line", Line{AccountID: account, AmountMinor: 500, Currency: "PTS", Side: SideDebit}, time.Time{}, true},
+		{"zero amount", Line{AccountID: account, AmountMinor: 0, Currency: "PTS", Side: SideCredit}, time.Time{}, true},
+		{"expires at grant", Line{AccountID: account, AmountMinor: 500, Currency: "PTS", Side: SideCredit}, grantedAt, true},
+		{"already expired", Line{AccountID: account, AmountMinor: 500, Currency: "PTS", Side: SideCredit}, grantedAt.Add(-time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lot, err := NewLot(tt.line, grantedAt, tt.expiresAt, " promo ")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewLot error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := err.(ValidationError); !ok {
+					t.Errorf("expected ValidationError, got %T", err)
+				}
+				return
+			}
+			if lot.RemainingMinor != tt.line.AmountMinor || lot.GrantedMinor != tt.line.AmountMinor {
+				t.Errorf("expected granted and remaining %d, got %d and %d", tt.line.AmountMinor, lot.GrantedMinor, lot.RemainingMinor)
+			}
+			if lot.Source != "promo" {
+				t.Errorf("expected source %q, got %q", "promo", lot.Source)
+			}
+		})
+	}
+}
+
+func TestConsumeLots(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := base.AddDate(0, 2, 0)
+
+	// oldest has the latest expiry; soonest was granted last but expires first
+	oldest := Lot{ID: uuid.New(), RemainingMinor: 100, GrantedAt: base, ExpiresAt: base.AddDate(1, 0, 0)}
+	middle := Lot{ID: uuid.New(), RemainingMinor: 100, GrantedAt: base.AddDate(0, 0, 1)}
+	soonest := Lot{ID: uuid.New(), RemainingMinor: 100, GrantedAt: base.AddDate(0, 0, 2), ExpiresAt: base.AddDate(0, 3, 0)}
+	expired := Lot{ID: uuid.New(), RemainingMinor: 100, GrantedAt: base.AddDate(-1, 0, 0), ExpiresAt: base.AddDate(0, 1, 0)}
+	spent := Lot{ID: uuid.New(), RemainingMinor: 0, GrantedAt: base.AddDate(-1, 0, 0)}
+	lots := []Lot{middle, soonest, expired, spent, oldest}
+
+	tests := []struct {
+		name          string
+		amountMinor   int64
+		order         LotOrder
+		wantDraws     []LotDraw
+		wantUncovered int64
+	}{
+		{
+			name:        "FIFO within one lot",
+			amountMinor: 60,
+			order:       LotOrderFIFO,
+			wantDraws:   []LotDraw{{LotID: oldest.ID, AmountMinor: 60}},
+		},
+		{
+			name:        "FIFO across lots",
+			amountMinor: 250,
+			order:       LotOrderFIFO,
+			wantDraws: []LotDraw{
+				{LotID: oldest.ID, AmountMinor: 100},
+				{LotID: middle.ID, AmountMinor: 100},
+				{LotID: soonest.ID, AmountMinor: 50},
+			},
+		},
+		{
+			name:        "soonest expiry first, never-expiring last",
+			amountMinor: 250,
+			order:       LotOrderSoonestExpiry,
+			wantDraws: []LotDraw{
+				{LotID: soonest.ID, AmountMinor: 100},
+				{LotID: oldest.ID, AmountMinor: 100},
+				{LotID: middle.ID, AmountMinor: 50},
+			},
+		},
+		{
+			name:        "more than all lots",
+			amountMinor: 450,
+			order:       LotOrderFIFO,
+			wantDraws: []LotDraw{
+				{LotID: oldest.ID, AmountMinor: 100},
+				{LotID: middle.ID, AmountMinor: 100},
+				{LotID: soonest.ID, AmountMinor: 100},
+			},
+			wantUncovered: 150,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draws, uncovered := ConsumeLots(lots, tt.amountMinor, tt.order, now)
+			if uncovered != tt.wantUncovered {
+				t.Errorf("expected uncovered %d, got %d", tt.wantUncovered, uncovered)
+			}
+			if len(draws) != len(tt.wantDraws) {
+				t.Fatalf("expected %d draws, got %d: %+v", len(tt.wantDraws), len(draws), draws)
+			}
+			for i, draw := range draws {
+				if draw != tt.wantDraws[i] {
+					t.Errorf("draws[%d]: expected %+v, got %+v", i, tt.wantDraws[i], draw)
+				}
+			}
+		})
+	}
+
+	if lots[0].ID != middle.ID {
+		t.Error("expected ConsumeLots to leave the caller's slice order unchanged")
+	}
+}
+
+func TestConsumeLots_LateDebitUsesLotsValidAtEffectiveDate(t *testing.T) {
+	expiresAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	lot := Lot{ID: uuid.New(), RemainingMinor: 100, ExpiresAt: expiresAt}
+
+	draws, uncovered := ConsumeLots([]Lot{lot}, 40, LotOrderFIFO, expiresAt.Add(-time.Hour))
+	if len(draws) != 1 || uncovered != 0 {
+		t.Errorf("expected a debit dated before expiry to draw the lot, got %+v, uncovered %d", draws, uncovered)
+	}
+
+	draws, uncovered = ConsumeLots([]Lot{lot}, 40, LotOrderFIFO, expiresAt)
+	if len(draws) != 0 || uncovered != 40 {
+		t.Errorf("expected a debit dated at expiry to skip the lot, got %+v, uncovered %d", draws, uncovered)
+	}
+}
+
+func TestConsumeLots_BackdatedDebitSkipsLaterGrants(t *testing.T) {
+	grantedAt := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	earlier := Lot{ID: uuid.New(), RemainingMinor: 30, GrantedAt: grantedAt.AddDate(0, -1, 0)}
+	later := Lot{ID: uuid.New(), RemainingMinor: 100, GrantedAt: grantedAt}
+	lots := []Lot{earlier, later}
+
+	draws, uncovered := ConsumeLots(lots, 50, LotOrderFIFO, grantedAt.Add(-time.Hour))
+	if len(draws) != 1 || draws[0] != (LotDraw{LotID: earlier.ID, AmountMinor: 30}) || uncovered != 20 {
+		t.Errorf("expected a debit dated before the grant to draw only the earlier lot, got %+v, uncovered %d", draws, uncovered)
+	}
+
+	draws, uncovered = ConsumeLots(lots, 50, LotOrderFIFO, grantedAt)
+	if len(draws) != 2 || draws[1] != (LotDraw{LotID: later.ID, AmountMinor: 20}) || uncovered != 0 {
+		t.Errorf("expected a debit dated at the grant to draw the lot, got %+v, uncovered %d", draws, uncovered)
+	}
+}
+
+func TestLapsedMinor(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	lapsed := Lot{ID: uuid.New(), RemainingMinor: 100, ExpiresAt: now.Add(-time.Hour)}
+	drawnDown := Lot{ID: uuid.New(), RemainingMinor: 80, ExpiresAt: now.Add(-time.Minute)}
+	valid := Lot{ID: uuid.New(), RemainingMinor: 50, ExpiresAt: now.Add(time.Hour)}
+	forever := Lot{ID: uuid.New(), RemainingMinor: 40}
+	lots := []Lot{lapsed, drawnDown, valid, forever}
+
+	tests := []struct {
+		name  string
+		draws []LotDraw
+		at    time.Time
+		want  int64
+	}{
+		{name: "no draws", at: now, want: 180},
+		{
+			name:  "a backdated debit drew a lapsed lot",
+			draws: []LotDraw{{LotID: drawnDown.ID, AmountMinor: 30}, {LotID: valid.ID, AmountMinor: 50}},
+			at:    now,
+			want:  150,
+		},
+		{name: "lapsed lot drawn to zero", draws: []LotDraw{{LotID: lapsed.ID, AmountMinor: 100}}, at: now, want: 80},
+		{name: "before any expiry", at: now.Add(-2 * time.Hour), want: 0},
+		{name: "at expiry", at: now.Add(time.Hour), want: 230},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LapsedMinor(lots, tt.draws, tt.at); got != tt.want {
+				t.Errorf("expected lapsed %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpiryEntry(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Hour)
+	lot := Lot{
+		ID:             uuid.New(),
+		AccountID:      uuid.New(),
+		Currency:       "PTS",
+		RemainingMinor: 70,
+		ExpiresAt:      expiresAt,
+	}
+	breakage := uuid.New()
+
+	entry, err := ExpiryEntry(lot, 0, breakage)
+	if err != nil {
+		t.Fatalf("ExpiryEntry: %v", err)
+	}
+
+	changes := make(map[uuid.UUID]int64)
+	for _, change := range entry.BalanceChanges() {
+		changes[change.AccountID] = change.DeltaMinor
+	}
+	if changes[lot.AccountID] != -70 || changes[breakage] != 70 {
+		t.Errorf("expected the lot account debited and breakage credited 70, got %v", changes)
+	}
+	if !entry.EffectiveAt.Equal(expiresAt) {
+		t.Errorf("expected effective_at %v, got %v", expiresAt, entry.EffectiveAt)
+	}
+
+	retry, _ := ExpiryEntry(lot, 0, breakage)
+	if retry.BatchID != entry.BatchID {
+		t.Error("expected a retried expiry to reuse the batch ID")
+	}
+	again, _ := ExpiryEntry(lot, 70, breakage)
+	if again.BatchID == entry.BatchID {
+		t.Error("expected a second expiry of the lot to get a new batch ID")
+	}
+
+	if _, err := ExpiryEntry(lot, 0, lot.AccountID); err == nil {
+		t.Error("expected an error when the breakage account is the lot's account")
+	}
+	lot.ExpiresAt = time.Time{}
+	if _, err := ExpiryEntry(lot, 0, breakage); err == nil {
+		t.Error("expected an error for a lot that never expires")
+	}
+}
@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PeriodStatus is the state of an accounting period
+type PeriodStatus string
+
+const (
+	// PeriodOpen accepts postings
+	PeriodOpen PeriodStatus = "OPEN"
+	// PeriodSoftClosed has been reported on; postings dated in it are booked in
+	// the next open period with a reference back
+	PeriodSoftClosed PeriodStatus = "SOFT_CLOSED"
+	// PeriodLocked is final; postings dated in it are rejected
+	PeriodLocked PeriodStatus = "LOCKED"
+)
+
+// allowedPeriodTransitions is the accounting period state machine.
+// A period is soft-closed before it is locked, and LOCKED is terminal.
+var allowedPeriodTransitions = map[PeriodStatus][]PeriodStatus{
+	PeriodOpen:       {PeriodSoftClosed},
+	PeriodSoftClosed: {PeriodOpen, PeriodLocked},
+	PeriodLocked:     {},
+}
+
+// Period is an accounting period covering StartsAt <= t < EndsAt
+type Period struct {
+	ID       uuid.UUID
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+	Status   PeriodStatus
+}
+
+// PeriodTransitionError is returned when a status change is not allowed
+type PeriodTransitionError struct {
+	From PeriodStatus
+	To   PeriodStatus
+}
+
+func (e *PeriodTransitionError) Error() string {
+	return fmt.Sprintf("cannot change period status from %s to %s", e.From, e.To)
+}
+
+// PeriodLockedError is returned for a posting dated in a locked period
+type PeriodLockedError struct {
+	PeriodID uuid.UUID
+	Name     string
+}
+
+func (e *PeriodLockedError) Error() string {
+	return fmt.Sprintf("accounting period %s (%s) is locked", e.Name, e.PeriodID)
+}
+
+// PeriodClosedError is returned for a posting dated in a soft-closed period
+// when no later open period can take it
+type PeriodClosedError struct {
+	PeriodID uuid.UUID
+	Name     string
+}
+
+func (e *PeriodClosedError) Error() string {
+	return fmt.Sprintf("accounting period %s (%s) is closed and no later period is open", e.Name, e.PeriodID)
+}
+
+// NewPeriod creates an open accounting period with validation
+func NewPeriod(name string, startsAt, endsAt time.Time) (*Period, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ValidationError{Field: "name", Message: "name is required"}
+	}
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return nil, ValidationError{Field: "starts_at", Message: "starts_at and ends_at are required"}
+	}
+	if !startsAt.Before(endsAt) {
+		return nil, ValidationError{Field: "ends_at", Message: "ends_at must be after starts_at"}
+	}
+
+	return &Period{
+		ID:       uuid.New(),
+		Name:     name,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Status:   PeriodOpen,
+	}, nil
+}
+
+// Contains reports whether t falls in the period
+func (p *Period) Contains(t time.Time) bool {
+	return !t.Before(p.StartsAt) && t.Before(p.EndsAt)
+}
+
+// CanTransitionPeriod reports whether a period may move from one status to another
+func CanTransitionPeriod(from, to PeriodStatus) bool {
+	for _, allowed := range allowedPeriodTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Close soft-closes an open period
+func (p *Period) Close() error {
+	return p.transitionTo(PeriodSoftClosed)
+}
+
+// Reopen returns a soft-closed period to open
+func (p *Period) Reopen() error {
+	return p.transitionTo(PeriodOpen)
+}
+
+// Lock permanently locks a soft-closed period
+func (p *Period) Lock() error {
+	return p.transitionTo(PeriodLocked)
+}
+
+func (p *Period) transitionTo(to PeriodStatus) error {
+	if !CanTransitionPeriod(p.Status, to) {
+		return &PeriodTransitionError{From: p.Status, To: to}
+	}
+	p.Status = to
+	return nil
+}
+
+// BookingPeriod decides where a posting dated in period is booked. next is the
+// first open period after it, or nil. A date outside any period (period nil)
+// or in an open period is booked as dated. A date in a soft-closed period is
+// booked in next, and adjusts names the closed period it corrects. A date in a
+// locked period is rejected with a PeriodLockedError.
+func BookingPeriod(period, next *Period) (booked, adjusts *Period, err error) {
+	if period == nil {
+		return nil, nil, nil
+	}
+
+	switch period.Status {
+	case PeriodOpen:
+		return period, nil, nil
+	case PeriodSoftClosed:
+		if next == nil || next.Status != PeriodOpen {
+			return nil, nil, &PeriodClosedError{PeriodID: period.ID, Name: period.Name}
+		}
+		return next, period, nil
+	default:
+		return nil, nil, &PeriodLockedError{PeriodID: period.ID, Name: period.Name}
+	}
+}
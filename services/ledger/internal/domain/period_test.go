@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPeriod_Transitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    PeriodStatus
+		apply   func(p *Period) error
+		want    PeriodStatus
+		wantErr bool
+	}{
+		{name: "close open", from: PeriodOpen, apply: (*Period).Close, want: PeriodSoftClosed},
+		{name: "reopen soft-closed", from: PeriodSoftClosed, apply: (*Period).Reopen, want: PeriodOpen},
+		{name: "lock soft-closed", from: PeriodSoftClosed, apply: (*Period).Lock, want: PeriodLocked},
+		{name: "lock open", from: PeriodOpen, apply: (*Period).Lock, want: PeriodOpen, wantErr: true},
+		{name: "close soft-closed", from: PeriodSoftClosed, apply: (*Period).Close, want: PeriodSoftClosed, wantErr: true},
+		{name: "reopen locked", from: PeriodLocked, apply: (*Period).Reopen, want: PeriodLocked, wantErr: true},
+		{name: "close locked", from: PeriodLocked, apply: (*Period).Close, want: PeriodLocked, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			period := &Period{ID: uuid.New(), Name: "2026-01", Status: tt.from}
+			err := tt.apply(period)
+			if tt.wantErr {
+				if _, ok := err.(*PeriodTransitionError); !ok {
+					t.Fatalf("expected PeriodTransitionError, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if period.Status != tt.want {
+				t.Errorf("expected status %s, got %s", tt.want, period.Status)
+			}
+		})
+	}
+}
+
+func TestNewPeriod(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	period, err := NewPeriod(" 2026-01 ", jan, feb)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if period.Name != "2026-01" || period.Status != PeriodOpen {
+		t.Errorf("expected open period 2026-01, got %+v", period)
+	}
+	if !period.Contains(jan) || period.Contains(feb) {
+		t.Error("expected period to contain its start and not its end")
+	}
+
+	if _, err := NewPeriod("2026-01", feb, jan); err == nil {
+		t.Error("expected error for period ending before it starts")
+	}
+	if _, err := NewPeriod("", jan, feb); err == nil {
+		t.Error("expected error for missing name")
+	}
+}
+
+func TestBookingPeriod(t *testing.T) {
+	open := &Period{ID: uuid.New(), Name: "2026-02", Status: PeriodOpen}
+	closed := &Period{ID: uuid.New(), Name: "2026-01", Status: PeriodSoftClosed}
+	locked := &Period{ID: uuid.New(), Name: "2025-12", Status: PeriodLocked}
+
+	tests := []struct {
+		name        string
+		period      *Period
+		next        *Period
+		wantBooked  *Period
+		wantAdjusts *Period
+		wantErr     error
+	}{
+		{name: "no period", period: nil},
+		{name: "open period", period: open, wantBooked: open},
+		{name: "soft-closed goes to next open", period: closed, next: open, wantBooked: open, wantAdjusts: closed},
+		{name: "soft-closed without open period", period: closed, wantErr: &PeriodClosedError{}},
+		{name: "locked", period: locked, next: open, wantErr: &PeriodLockedError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booked, adjusts, err := BookingPeriod(tt.period, tt.next)
+			switch tt.wantErr.(type) {
+			case *PeriodClosedError:
+				if _, ok := err.(*PeriodClosedError); !ok {
+					t.Fatalf("expected PeriodClosedError, got %v", err)
+				}
+				return
+			case *PeriodLockedError:
+				if _, ok := err.(*PeriodLockedError); !ok {
+					t.Fatalf("expected PeriodLockedError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if booked != tt.wantBooked {
+				t.Errorf("expected booked period %v, got %v", tt.wantBooked, booked)
+			}
+			if adjusts != tt.wantAdjusts {
+				t.Errorf("expected adjusted period %v, got %v", tt.wantAdjusts, adjusts)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// DiscrepancyKind classifies a reconciliation finding
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyLedgerBalanceDrift means the stored running balance differs from the journal
+	DiscrepancyLedgerBalanceDrift DiscrepancyKind = "ledger_balance_drift"
+	// DiscrepancyReadModelBalanceMismatch means the read-model balance differs from the journal
+	DiscrepancyReadModelBalanceMismatch DiscrepancyKind = "read_model_balance_mismatch"
+	// DiscrepancyReadModelMissingBalance means the journal has a balance the read-model does not
+	DiscrepancyReadModelMissingBalance DiscrepancyKind = "read_model_missing_balance"
+	// DiscrepancyReadModelUnknownBalance means the read-model has a balance the journal does not
+	DiscrepancyReadModelUnknownBalance DiscrepancyKind = "read_model_unknown_balance"
+	// DiscrepancyTransferMissingEntry means a completed transfer has no posting entry
+	DiscrepancyTransferMissingEntry DiscrepancyKind = "transfer_missing_entry"
+	// DiscrepancyTransferEntryVoided means a completed transfer's posting entry was voided
+	DiscrepancyTransferEntryVoided DiscrepancyKind = "transfer_entry_voided"
+	// DiscrepancyTransferDuplicateEntries means a completed transfer has more than one active posting entry
+	DiscrepancyTransferDuplicateEntries DiscrepancyKind = "transfer_duplicate_entries"
+)
+
+// DiscrepancyKinds lists every kind, e.g. for resetting per-kind metrics
+var DiscrepancyKinds = []DiscrepancyKind{
+	DiscrepancyLedgerBalanceDrift,
+	DiscrepancyReadModelBalanceMismatch,
+	DiscrepancyReadModelMissingBalance,
+	DiscrepancyReadModelUnknownBalance,
+	DiscrepancyTransferMissingEntry,
+	DiscrepancyTransferEntryVoided,
+	DiscrepancyTransferDuplicateEntries,
+}
+
+// BalanceKey identifies an account balance in one currency
+type BalanceKey struct {
+	AccountID uuid.UUID
+	Currency  string
+}
+
+// Discrepancy is a difference between the ledger journal and another source.
+// Expected is the journal's value: minor units for balances, the number of
+// active posting entries for transfers. Actual is the value found instead.
+type Discrepancy struct {
+	Kind       DiscrepancyKind
+	AccountID  uuid.UUID // zero for transfer discrepancies
+	Currency   string
+	TransferID uuid.UUID // zero for balance discrepancies
+	Expected   int64
+	Actual     int64
+	Detail     string
+}
+
+// SignByNormalSide converts ledger balances, which count CREDIT as positive,
+// to the sign of each account's normal side as the read-model keeps them:
+// the balances of debit-normal accounts are negated.
+func SignByNormalSide(balances map[BalanceKey]int64, debitNormal map[uuid.UUID]bool) map[BalanceKey]int64 {
+	signed := make(map[BalanceKey]int64, len(balances))
+	for key, balance := range balances {
+		if debitNormal[key.AccountID] {
+			balance = -balance
+		}
+		signed[key] = balance
+	}
+	return signed
+}
+
+// CompareBalances checks the balances recomputed from the journal against the
+// ledger's stored running balances and the read-model's balances.
+// Missing keys count as zero, so zero balances absent from one side are not reported.
+// Results are sorted by account ID, currency and kind.
+func CompareBalances(journal, stored, readModel map[BalanceKey]int64) []Discrepancy {
+	var found []Discrepancy
+
+	ledgerKeys := make(map[BalanceKey]struct{}, len(journal))
+	for key := range journal {
+		ledgerKeys[key] = struct{}{}
+	}
+	for key := range stored {
+		ledgerKeys[key] = struct{}{}
+	}
+
+	for key := range ledgerKeys {
+		expected := journal[key]
+		if actual := stored[key]; actual != expected {
+			found = append(found, balanceDiscrepancy(DiscrepancyLedgerBalanceDrift, key, expected, actual,
+				"stored running balance differs from journal lines"))
+		}
+
+		actual, ok := readModel[key]
+		switch {
+		case !ok && expected != 0:
+			found = append(found, balanceDiscrepancy(DiscrepancyReadModelMissingBalance, key, expected, 0,
+				"read-model has no balance for this account and currency"))
+		case ok && actual != expected:
+			found = append(found, balanceDiscrepancy(DiscrepancyReadModelBalanceMismatch, key, expected, actual,
+				"read-model balance differs from journal lines"))
+		}
+	}
+
+	for key, actual := range readModel {
+		if _, ok := ledgerKeys[key]; !ok && actual != 0 {
+			found = append(found, balanceDiscrepancy(DiscrepancyReadModelUnknownBalance, key, 0, actual,
+				"read-model has a balance with no journal lines"))
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if c := bytes.Compare(found[i].AccountID[:], found[j].AccountID[:]); c != 0 {
+			return c < 0
+		}
+		if found[i].Currency != found[j].Currency {
+			return found[i].Currency < found[j].Currency
+		}
+		return found[i].Kind < found[j].Kind
+	})
+	return found
+}
+
+func balanceDiscrepancy(kind DiscrepancyKind, key BalanceKey, expected, actual int64, detail string) Discrepancy {
+	return Discrepancy{
+		Kind:      kind,
+		AccountID: key.AccountID,
+		Currency:  key.Currency,
+		Expected:  expected,
+		Actual:    actual,
+		Detail:    detail,
+	}
+}
+
+// CheckTransferEntries checks that a completed transfer has exactly one
+// non-voided posting entry. postings counts all posting entries for the
+// transfer's batch and active those not voided. Returns nil when consistent.
+func CheckTransferEntries(transferID uuid.UUID, postings, active int) *Discrepancy {
+	d := &Discrepancy{TransferID: transferID, Expected: 1, Actual: int64(active)}
+	switch {
+	case postings == 0:
+		d.Kind = DiscrepancyTransferMissingEntry
+		d.Detail = "completed transfer has no posting entry"
+	case active == 0:
+		d.Kind = DiscrepancyTransferEntryVoided
+		d.Detail = "completed transfer's posting entry was voided"
+	case active > 1:
+		d.Kind = DiscrepancyTransferDuplicateEntries
+		d.Detail = fmt.Sprintf("completed transfer has %d active posting entries", active)
+	default:
+		return nil
+	}
+	return d
+}
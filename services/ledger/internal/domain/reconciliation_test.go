@@ -0,0 +1,147 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCompareBalances(t *testing.T) {
+	account := uuid.New()
+	usd := BalanceKey{AccountID: account, Currency: "USD"}
+	eur := BalanceKey{AccountID: account, Currency: "EUR"}
+
+	tests := []struct {
+		name      string
+		journal   map[BalanceKey]int64
+		stored    map[BalanceKey]int64
+		readModel map[BalanceKey]int64
+		wantKinds []DiscrepancyKind
+	}{
+		{
+			name:      "consistent",
+			journal:   map[BalanceKey]int64{usd: 500},
+			stored:    map[BalanceKey]int64{usd: 500},
+			readModel: map[BalanceKey]int64{usd: 500},
+		},
+		{
+			name:      "stored balance drift",
+			journal:   map[BalanceKey]int64{usd: 500},
+			stored:    map[BalanceKey]int64{usd: 400},
+			readModel: map[BalanceKey]int64{usd: 500},
+			wantKinds: []DiscrepancyKind{DiscrepancyLedgerBalanceDrift},
+		},
+		{
+			name:      "read-model mismatch",
+			journal:   map[BalanceKey]int64{usd: 500},
+			stored:    map[BalanceKey]int64{usd: 500},
+			readModel: map[BalanceKey]int64{usd: 300},
+			wantKinds: []DiscrepancyKind{DiscrepancyReadModelBalanceMismatch},
+		},
+		{
+			name:      "read-model missing balance",
+			journal:   map[BalanceKey]int64{usd: 500},
+			stored:    map[BalanceKey]int64{usd: 500},
+			wantKinds: []DiscrepancyKind{DiscrepancyReadModelMissingBalance},
+		},
+		{
+			name:    "zero balance missing from read-model",
+			journal: map[BalanceKey]int64{usd: 0},
+			stored:  map[BalanceKey]int64{usd: 0},
+		},
+		{
+			name:      "read-model balance in another currency",
+			journal:   map[BalanceKey]int64{usd: 500},
+			stored:    map[BalanceKey]int64{usd: 500},
+			readModel: map[BalanceKey]int64{eur: 500},
+			wantKinds: []DiscrepancyKind{DiscrepancyReadModelUnknownBalance, DiscrepancyReadModelMissingBalance},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := CompareBalances(tt.journal, tt.stored, tt.readModel)
+			if len(found) != len(tt.wantKinds) {
+				t.Fatalf("expected %d discrepancies, got %+v", len(tt.wantKinds), found)
+			}
+			for i, d := range found {
+				if d.Kind != tt.wantKinds[i] {
+					t.Errorf("discrepancy %d: expected %s, got %s", i, tt.wantKinds[i], d.Kind)
+				}
+				if d.AccountID != account {
+					t.Errorf("discrepancy %d: expected account %s, got %s", i, account, d.AccountID)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareBalances_DebitNormalAccount(t *testing.T) {
+	asset := uuid.New()
+	liability := uuid.New()
+	assetUSD := BalanceKey{AccountID: asset, Currency: "USD"}
+	liabilityUSD := BalanceKey{AccountID: liability, Currency: "USD"}
+
+	// The asset account was debited 500 and the liability account credited 500.
+	// The ledger counts CREDIT as positive; the read-model signs by normal side.
+	journal := map[BalanceKey]int64{assetUSD: -500, liabilityUSD: 500}
+	stored := map[BalanceKey]int64{assetUSD: -500, liabilityUSD: 500}
+	readModel := map[BalanceKey]int64{assetUSD: 500, liabilityUSD: 500}
+	debitNormal := map[uuid.UUID]bool{asset: true}
+
+	if found := CompareBalances(journal, stored, readModel); len(found) != 1 || found[0].Kind != DiscrepancyReadModelBalanceMismatch {
+		t.Fatalf("expected the unsigned comparison to report a mismatch, got %+v", found)
+	}
+
+	signedJournal := SignByNormalSide(journal, debitNormal)
+	signedStored := SignByNormalSide(stored, debitNormal)
+	if found := CompareBalances(signedJournal, signedStored, readModel); len(found) != 0 {
+		t.Fatalf("expected no discrepancies, got %+v", found)
+	}
+	if signedJournal[assetUSD] != 500 || signedJournal[liabilityUSD] != 500 {
+		t.Errorf("signed journal = %v, want both accounts at 500", signedJournal)
+	}
+
+	// A real difference is still reported, in the account's own sign
+	readModel[assetUSD] = 450
+	found := CompareBalances(signedJournal, signedStored, readModel)
+	if len(found) != 1 || found[0].Kind != DiscrepancyReadModelBalanceMismatch {
+		t.Fatalf("expected one read-model mismatch, got %+v", found)
+	}
+	if found[0].AccountID != asset || found[0].Expected != 500 || found[0].Actual != 450 {
+		t.Errorf("unexpected discrepancy %+v", found[0])
+	}
+}
+
+func TestCheckTransferEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		postings int
+		active   int
+		wantKind DiscrepancyKind
+	}{
+		{name: "one active entry", postings: 1, active: 1},
+		{name: "no entry", postings: 0, active: 0, wantKind: DiscrepancyTransferMissingEntry},
+		{name: "voided entry", postings: 1, active: 0, wantKind: DiscrepancyTransferEntryVoided},
+		{name: "duplicate entries", postings: 2, active: 2, wantKind: DiscrepancyTransferDuplicateEntries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transferID := uuid.New()
+			d := CheckTransferEntries(transferID, tt.postings, tt.active)
+			if tt.wantKind == "" {
+				if d != nil {
+					t.Fatalf("expected no discrepancy, got %+v", d)
+				}
+				return
+			}
+			if d == nil || d.Kind != tt.wantKind {
+				t.Fatalf("expected %s, got %+v", tt.wantKind, d)
+			}
+			if d.TransferID != transferID {
+				t.Errorf("expected transfer %s, got %s", transferID, d.TransferID)
+			}
+		})
+	}
+}
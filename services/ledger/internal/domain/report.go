@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// LineTotals is the sum of journal lines on each side, for one account or a
+// whole currency
+type LineTotals struct {
+	DebitMinor  int64
+	CreditMinor int64
+}
+
+// BalanceMinor returns credits minus debits, the sign of the ledger's running
+// balances (CREDIT increases, DEBIT decreases)
+func (t LineTotals) BalanceMinor() int64 {
+	return t.CreditMinor - t.DebitMinor
+}
+
+// Balanced reports whether debits equal credits
+func (t LineTotals) Balanced() bool {
+	return t.DebitMinor == t.CreditMinor
+}
+
+// Add returns the sum of two totals
+func (t LineTotals) Add(other LineTotals) LineTotals {
+	return LineTotals{
+		DebitMinor:  t.DebitMinor + other.DebitMinor,
+		CreditMinor: t.CreditMinor + other.CreditMinor,
+	}
+}
+
+// AccountTotals is one account's line totals in one currency
+type AccountTotals struct {
+	AccountID uuid.UUID
+	Currency  string
+	LineTotals
+}
+
+// CurrencyTotals is the line totals of every account in one currency
+type CurrencyTotals struct {
+	Currency string
+	LineTotals
+}
+
+// TrialBalance sums account totals per currency, sorted by currency. Every
+// entry balances per currency, so each currency's debits equal its credits
+// unless the journal is corrupt.
+func TrialBalance(accounts []AccountTotals) []CurrencyTotals {
+	sums := make(map[string]LineTotals)
+	for _, a := range accounts {
+		sums[a.Currency] = sums[a.Currency].Add(a.LineTotals)
+	}
+
+	totals := make([]CurrencyTotals, 0, len(sums))
+	for currency, sum := range sums {
+		totals = append(totals, CurrencyTotals{Currency: currency, LineTotals: sum})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTrialBalance(t *testing.T) {
+	alice, bob, fees := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		accounts []AccountTotals
+		want     []CurrencyTotals
+		balanced []bool
+	}{
+		{
+			name: "empty journal",
+			want: []CurrencyTotals{},
+		},
+		{
+			name: "balanced transfer with fee",
+			accounts: []AccountTotals{
+				{AccountID: alice, Currency: "USD", LineTotals: LineTotals{DebitMinor: 1050}},
+				{AccountID: bob, Currency: "USD", LineTotals: LineTotals{CreditMinor: 1000}},
+				{AccountID: fees, Currency: "USD", LineTotals: LineTotals{CreditMinor: 50}},
+			},
+			want:     []CurrencyTotals{{Currency: "USD", LineTotals: LineTotals{DebitMinor: 1050, CreditMinor: 1050}}},
+			balanced: []bool{true},
+		},
+		{
+			name: "currencies are summed apart",
+			accounts: []AccountTotals{
+				{AccountID: alice, Currency: "USD", LineTotals: LineTotals{DebitMinor: 500, CreditMinor: 200}},
+				{AccountID: alice, Currency: "EUR", LineTotals: LineTotals{CreditMinor: 300}},
+				{AccountID: bob, Currency: "USD", LineTotals: LineTotals{CreditMinor: 300}},
+				{AccountID: bob, Currency: "EUR", LineTotals: LineTotals{DebitMinor: 300}},
+			},
+			want: []CurrencyTotals{
+				{Currency: "EUR", LineTotals: LineTotals{DebitMinor: 300, CreditMinor: 300}},
+				{Currency: "USD", LineTotals: LineTotals{DebitMinor: 500, CreditMinor: 500}},
+			},
+			balanced: []bool{true, true},
+		},
+		{
+			name: "unbalanced journal",
+			accounts: []AccountTotals{
+				{AccountID: alice, Currency: "USD", LineTotals: LineTotals{DebitMinor: 100}},
+				{AccountID: bob, Currency: "USD", LineTotals: LineTotals{CreditMinor: 90}},
+			},
+			want:     []CurrencyTotals{{Currency: "USD", LineTotals: LineTotals{DebitMinor: 100, CreditMinor: 90}}},
+			balanced: []bool{false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TrialBalance(tt.accounts)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d currencies, got %+v", len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("currency %d: expected %+v, got %+v", i, tt.want[i], got[i])
+				}
+				if got[i].Balanced() != tt.balanced[i] {
+					t.Errorf("%s: expected balanced=%v", got[i].Currency, tt.balanced[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLineTotals_BalanceMinor(t *testing.T) {
+	totals := LineTotals{DebitMinor: 300, CreditMinor: 1000}
+	if got := totals.BalanceMinor(); got != 700 {
+		t.Errorf("expected balance 700, got %d", got)
+	}
+}
@@ -0,0 +1,265 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+)
+
+// AccountBalanceResponse represents the authoritative balance of an account in one currency
+type AccountBalanceResponse struct {
+	AccountID           string `json:"account_id"`
+	Currency            string `json:"currency"`
+	BalanceMinor        int64  `json:"balance_minor"`
+	OverdraftLimitMinor *int64 `json:"overdraft_limit_minor,omitempty"` // nil means service default
+	OverdraftUnlimited  bool   `json:"overdraft_unlimited"`
+	AvailableMinor      *int64 `json:"available_minor,omitempty"` // nil when unlimited
+	UpdatedAt           string `json:"updated_at"`
+}
+
+// SetOverdraftLimitRequest represents the request to configure an account's balance floor
+type SetOverdraftLimitRequest struct {
+	Currency            string `json:"currency"`
+	OverdraftLimitMinor *int64 `json:"overdraft_limit_minor"` // nil resets to service default
+	Unlimited           bool   `json:"unlimited"`
+}
+
+// applyBalanceChanges updates the authoritative balances for an entry inside tx.
+// Balance rows are locked in a deterministic order; when enforce is true the
+// overdraft policy of each account whose balance decreases, in its own sign, is
+// checked before the delta is applied and a domain.InsufficientFundsError is
+// returned if the floor would be breached. lapsed is the remainder of lapsed
+// lots per account and currency, which the check leaves out of the balance.
+func (h *Handler) applyBalanceChanges(ctx context.Context, qtx *store.Queries, entry *domain.Entry, enforce bool, lapsed map[domain.BalanceKey]int64) error {
+	changes := entry.BalanceChanges()
+
+	var sides map[uuid.UUID]domain.Side
+	if enforce {
+		accountIDs := make([]uuid.UUID, 0, len(changes))
+		for _, change := range changes {
+			accountIDs = append(accountIDs, change.AccountID)
+		}
+		var err error
+		if sides, err = normalSides(ctx, qtx, accountIDs...); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range changes {
+		if err := qtx.EnsureAccountBalance(ctx, store.EnsureAccountBalanceParams{
+			AccountID: change.AccountID,
+			Currency:  change.Currency,
+		}); err != nil {
+			return err
+		}
+
+		balance, err := qtx.GetAccountBalanceForUpdate(ctx, store.GetAccountBalanceForUpdateParams{
+			AccountID: change.AccountID,
+			Currency:  change.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		if enforce {
+			spendable := balance.BalanceMinor - lapsed[domain.BalanceKey{AccountID: change.AccountID, Currency: change.Currency}]
+			if err := domain.CheckBalanceFloor(change, spendable, sides[change.AccountID], h.overdraftPolicy(balance)); err != nil {
+				return err
+			}
+		}
+
+		if err := qtx.ApplyBalanceDelta(ctx, store.ApplyBalanceDeltaParams{
+			DeltaMinor: change.DeltaMinor,
+			AccountID:  change.AccountID,
+			Currency:   change.Currency,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalSides returns the side on which each account's balance increases.
+// Accounts whose AccountCreated has not been consumed yet are credit-normal.
+func normalSides(ctx context.Context, q *store.Queries, accountIDs ...uuid.UUID) (map[uuid.UUID]domain.Side, error) {
+	sides := make(map[uuid.UUID]domain.Side, len(accountIDs))
+	for _, accountID := range accountIDs {
+		sides[accountID] = domain.SideCredit
+	}
+	debitNormal, err := q.ListDebitNormalAccounts(ctx, accountIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, accountID := range debitNormal {
+		sides[accountID] = domain.SideDebit
+	}
+	return sides, nil
+}
+
+// overdraftPolicy resolves the effective policy for a balance row,
+// falling back to the service default when no per-account limit is set
+func (h *Handler) overdraftPolicy(balance store.AccountBalance) domain.OverdraftPolicy {
+	if balance.OverdraftUnlimited {
+		return domain.OverdraftPolicy{Unlimited: true}
+	}
+	if balance.OverdraftLimitMinor.Valid {
+		return domain.OverdraftPolicy{LimitMinor: balance.OverdraftLimitMinor.Int64}
+	}
+	return h.defaultOverdraft
+}
+
+// lapsedLots returns what remains of an account's lots lapsed by now, per
+// currency; it still counts in the balance but cannot be spent
+func lapsedLots(ctx context.Context, q *store.Queries, accountID uuid.UUID) (map[string]int64, error) {
+	rows, err := q.ListLapsedLotTotals(ctx, store.ListLapsedLotTotalsParams{
+		AccountID: accountID,
+		AsOf:      sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	lapsed := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		lapsed[row.Currency] = row.LapsedMinor
+	}
+	return lapsed, nil
+}
+
+// toAccountBalanceResponse converts a balance row to its HTTP representation.
+// The balance counts CREDIT as positive; the available amount is in the
+// account's own sign, what it may still decrease by, leaving out lapsedMinor
+// of lapsed lots.
+func (h *Handler) toAccountBalanceResponse(balance store.AccountBalance, normal domain.Side, lapsedMinor int64) AccountBalanceResponse {
+	resp := AccountBalanceResponse{
+		AccountID:          balance.AccountID.String(),
+		Currency:           balance.Currency,
+		BalanceMinor:       balance.BalanceMinor,
+		OverdraftUnlimited: balance.OverdraftUnlimited,
+		UpdatedAt:          balance.UpdatedAt.Format(time.RFC3339),
+	}
+	if balance.OverdraftLimitMinor.Valid {
+		limit := balance.OverdraftLimitMinor.Int64
+		resp.OverdraftLimitMinor = &limit
+	}
+	if policy := h.overdraftPolicy(balance); !policy.Unlimited {
+		own := balance.BalanceMinor - lapsedMinor
+		if normal == domain.SideDebit {
+			own = -own
+		}
+		available := own - policy.Floor()
+		resp.AvailableMinor = &available
+	}
+	return resp
+}
+
+// GetAccountBalances handles GET /v1/accounts/:id/balances
+func (h *Handler) GetAccountBalances(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_account_id", "Account ID must be a valid UUID")
+		return
+	}
+
+	balances, err := h.queries.GetAccountBalances(ctx, accountID)
+	if err != nil {
+		h.logger.Printf("Failed to get account balances: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query balances")
+		return
+	}
+
+	sides, err := normalSides(ctx, h.queries, accountID)
+	if err != nil {
+		h.logger.Printf("Failed to get account type: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query balances")
+		return
+	}
+
+	lapsed, err := lapsedLots(ctx, h.queries, accountID)
+	if err != nil {
+		h.logger.Printf("Failed to get lapsed lots: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query balances")
+		return
+	}
+
+	resp := make([]AccountBalanceResponse, len(balances))
+	for i, balance := range balances {
+		resp[i] = h.toAccountBalanceResponse(balance, sides[accountID], lapsed[balance.Currency])
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"account_id": accountID.String(),
+		"balances":   resp,
+	})
+}
+
+// SetOverdraftLimit handles PUT /v1/accounts/:id/overdraft-limit
+func (h *Handler) SetOverdraftLimit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	accountID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_account_id", "Account ID must be a valid UUID")
+		return
+	}
+
+	var req SetOverdraftLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	if len(currency) != 3 {
+		h.respondError(w, http.StatusBadRequest, "invalid_currency", "Currency must be a 3-letter ISO 4217 code")
+		return
+	}
+
+	limit := sql.NullInt64{}
+	if req.OverdraftLimitMinor != nil {
+		if *req.OverdraftLimitMinor < 0 {
+			h.respondError(w, http.StatusBadRequest, "invalid_overdraft_limit", "Overdraft limit must be zero or positive")
+			return
+		}
+		limit = sql.NullInt64{Int64: *req.OverdraftLimitMinor, Valid: true}
+	}
+
+	balance, err := h.queries.SetOverdraftLimit(ctx, store.SetOverdraftLimitParams{
+		AccountID:           accountID,
+		Currency:            currency,
+		OverdraftLimitMinor: limit,
+		OverdraftUnlimited:  req.Unlimited,
+	})
+	if err != nil {
+		h.logger.Printf("Failed to set overdraft limit: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to set overdraft limit")
+		return
+	}
+
+	h.logger.Printf("Set overdraft policy for account %s %s (limit_set=%v, unlimited=%v)",
+		accountID, currency, limit.Valid, req.Unlimited)
+
+	sides, err := normalSides(ctx, h.queries, accountID)
+	if err != nil {
+		h.logger.Printf("Failed to get account type: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to set overdraft limit")
+		return
+	}
+
+	lapsed, err := lapsedLots(ctx, h.queries, accountID)
+	if err != nil {
+		h.logger.Printf("Failed to get lapsed lots: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to set overdraft limit")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, h.toAccountBalanceResponse(balance, sides[accountID], lapsed[currency]))
+}
@@ -1,11 +1,13 @@
 package http
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
@@ -18,14 +20,17 @@ import (
 
 // CreateEntryRequest represents the HTTP request body for creating a journal entry
 type CreateEntryRequest struct {
-	BatchID string         `json:"batch_id"`
-	Lines   []LineRequest  `json:"lines"`
+	BatchID     string        `json:"batch_id"`
+	Lines       []LineRequest `json:"lines"`
+	EffectiveAt string        `json:"effective_at,omitempty"` // RFC3339 value date; defaults to the booking time
 }
 
 type LineRequest struct {
-	AccountID   string `json:"account_id"`
-	AmountMinor int64  `json:"amount_minor"`
-	Side        string `json:"side"` // "DEBIT" or "CREDIT"
+	AccountID   string      `json:"account_id"`
+	AmountMinor int64       `json:"amount_minor"`
+	Currency    string      `json:"currency"`      // ISO 4217 code, e.g. "USD"
+	Side        string      `json:"side"`          // "DEBIT" or "CREDIT"
+	Lot         *LotRequest `json:"lot,omitempty"` // CREDIT lines only: grants the amount as a credit lot
 }
 
 // CreateEntryResponse represents the HTTP response
@@ -56,17 +61,23 @@ type VoidEntryResponse struct {
 
 // Handler handles HTTP requests for the ledger service
 type Handler struct {
-	db      *sql.DB
-	queries *store.Queries
-	logger  *log.Logger
+	db               *sql.DB
+	queries          *store.Queries
+	defaultOverdraft domain.OverdraftPolicy
+	lotOrder         domain.LotOrder
+	logger           *log.Logger
 }
 
-// NewHandler creates a new HTTP handler
-func NewHandler(db *sql.DB, logger *log.Logger) *Handler {
+// NewHandler creates a new HTTP handler.
+// defaultOverdraft applies to accounts without a per-account overdraft limit;
+// lotOrder is the order in which debits consume credit lots.
+func NewHandler(db *sql.DB, defaultOverdraft domain.OverdraftPolicy, lotOrder domain.LotOrder, logger *log.Logger) *Handler {
 	return &Handler{
-		db:      db,
-		queries: store.New(db),
-		logger:  logger,
+		db:               db,
+		queries:          store.New(db),
+		defaultOverdraft: defaultOverdraft,
+		lotOrder:         lotOrder,
+		logger:           logger,
 	}
 }
 
@@ -114,6 +125,7 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 		lines[i] = domain.Line{
 			AccountID:   accountID,
 			AmountMinor: lineReq.AmountMinor,
+			Currency:    strings.ToUpper(strings.TrimSpace(lineReq.Currency)),
 			Side:        side,
 		}
 	}
@@ -129,6 +141,44 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Value-date late-arriving postings to when they happened
+	if req.EffectiveAt != "" {
+		effectiveAt, err := time.Parse(time.RFC3339, req.EffectiveAt)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_effective_at", "effective_at must be an RFC3339 timestamp")
+			return
+		}
+		if err := entry.SetEffectiveAt(effectiveAt); err != nil {
+			h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+
+	// Credit lines may grant their amount as a lot, granted as of the effective date
+	var grants []*domain.Lot
+	for i, lineReq := range req.Lines {
+		if lineReq.Lot == nil {
+			continue
+		}
+		var expiresAt time.Time
+		if lineReq.Lot.ExpiresAt != "" {
+			expiresAt, err = time.Parse(time.RFC3339, lineReq.Lot.ExpiresAt)
+			if err != nil {
+				h.respondError(w, http.StatusBadRequest, "invalid_expires_at",
+					fmt.Sprintf("Line %d: lot.expires_at must be an RFC3339 timestamp", i))
+				return
+			}
+		}
+		lot, err := domain.NewLot(entry.Lines[i], entry.EffectiveAt, expiresAt, lineReq.Lot.Source)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "validation_error",
+				fmt.Sprintf("Line %d: %s", i, err.Error()))
+			return
+		}
+		grants = append(grants, lot)
+	}
+	entry.Grants = grants
+
 	// Start database transaction
 	tx, err := h.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -140,12 +190,42 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 
 	qtx := h.queries.WithTx(tx)
 
-	// Insert journal entry
+	// Book the entry in the accounting period of its effective date
+	dated, err := periodAt(ctx, qtx, entry.EffectiveAt)
+	if err == nil {
+		entry.PeriodID, entry.AdjustsPeriodID, err = bookEntry(ctx, qtx, dated)
+	}
+	if err != nil {
+		// A retry of an entry booked before its period closed is still a retry
+		if _, lookupErr := qtx.GetEntryByBatchAndKind(ctx, store.GetEntryByBatchAndKindParams{
+			BatchID: entry.BatchID,
+			Kind:    string(entry.Kind),
+		}); lookupErr == nil {
+			h.respondDuplicateEntry(ctx, w, qtx, entry)
+			return
+		}
+		if h.respondPeriodError(w, err) {
+			return
+		}
+		h.logger.Printf("Failed to resolve accounting period: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to resolve accounting period")
+		return
+	}
+
+	// Insert journal entry; (batch_id, kind) is the idempotency key
 	_, err = qtx.CreateJournalEntry(ctx, store.CreateJournalEntryParams{
-		EntryID: entry.EntryID,
-		BatchID: entry.BatchID,
-		Ts:      entry.Timestamp,
+		EntryID:         entry.EntryID,
+		BatchID:         entry.BatchID,
+		Ts:              entry.Timestamp,
+		EffectiveAt:     entry.EffectiveAt,
+		Kind:            string(entry.Kind),
+		PeriodID:        entry.PeriodID,
+		AdjustsPeriodID: entry.AdjustsPeriodID,
 	})
+	if err == sql.ErrNoRows {
+		h.respondDuplicateEntry(ctx, w, qtx, entry)
+		return
+	}
 	if err != nil {
 		h.logger.Printf("Failed to create journal entry: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create entry")
@@ -159,6 +239,7 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 			AccountID:   line.AccountID,
 			AmountMinor: line.AmountMinor,
 			Side:        string(line.Side),
+			Currency:    line.Currency,
 		})
 		if err != nil {
 			h.logger.Printf("Failed to create journal line: %v", err)
@@ -167,6 +248,26 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Draw debits down from credit lots and open granted lots
+	lotMovements, lapsed, err := h.applyLots(ctx, qtx, entry, grants)
+	if err != nil {
+		h.logger.Printf("Failed to apply credit lots: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update credit lots")
+		return
+	}
+
+	// Apply balance changes, rejecting entries that breach an overdraft limit
+	if err := h.applyBalanceChanges(ctx, qtx, entry, true, lapsed); err != nil {
+		if fundsErr, ok := err.(domain.InsufficientFundsError); ok {
+			metrics.EntriesRejected.WithLabelValues("insufficient_funds").Inc()
+			h.respondError(w, http.StatusUnprocessableEntity, "insufficient_funds", fundsErr.Error())
+			return
+		}
+		h.logger.Printf("Failed to apply balance changes: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update balances")
+		return
+	}
+
 	// Create outbox event (EntryPosted)
 	event, err := h.createEntryPostedEvent(entry)
 	if err != nil {
@@ -174,6 +275,7 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusInternalServerError, "event_error", "Failed to create event")
 		return
 	}
+	event.LotMovements = toProtoLotMovements(lotMovements)
 
 	eventPayload, err := proto.Marshal(event)
 	if err != nil {
@@ -212,7 +314,9 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Record metrics
-	metrics.EntriesCreated.WithLabelValues("USD").Inc() // TODO: extract currency from entry
+	for _, currency := range entry.Currencies() {
+		metrics.EntriesCreated.WithLabelValues(currency).Inc()
+	}
 	metrics.EntryCreationDuration.WithLabelValues("success").Observe(time.Since(start).Seconds())
 
 	// Return success response
@@ -222,6 +326,82 @@ func (h *Handler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// respondDuplicateEntry answers a CreateEntry request whose (batch_id, kind) already exists.
+// An identical payload is a retry and gets the original entry with 200;
+// a different payload reuses the key for another entry and gets 409.
+func (h *Handler) respondDuplicateEntry(ctx context.Context, w http.ResponseWriter, qtx *store.Queries, entry *domain.Entry) {
+	existing, err := qtx.GetEntryByBatchAndKind(ctx, store.GetEntryByBatchAndKindParams{
+		BatchID: entry.BatchID,
+		Kind:    string(entry.Kind),
+	})
+	if err != nil {
+		h.logger.Printf("Failed to get existing entry for batch %s: %v", entry.BatchID, err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to check existing entry")
+		return
+	}
+
+	existingEntry, err := h.loadEntry(ctx, qtx, existing)
+	if err != nil {
+		h.logger.Printf("Failed to load existing entry %s: %v", existing.EntryID, err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to check existing entry")
+		return
+	}
+
+	lots, err := qtx.ListLotsByGrantEntry(ctx, existing.EntryID)
+	if err != nil {
+		h.logger.Printf("Failed to load lots granted by entry %s: %v", existing.EntryID, err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to check existing entry")
+		return
+	}
+	for _, row := range lots {
+		lot := toDomainLot(row)
+		existingEntry.Grants = append(existingEntry.Grants, &lot)
+	}
+
+	if !existingEntry.SamePayload(entry) {
+		metrics.EntriesRejected.WithLabelValues("idempotency_conflict").Inc()
+		h.respondError(w, http.StatusConflict, "idempotency_conflict",
+			fmt.Sprintf("Batch %s already has a %s entry with a different payload", entry.BatchID, entry.Kind))
+		return
+	}
+
+	h.logger.Printf("Idempotent entry request for batch %s, returning entry %s", entry.BatchID, existing.EntryID)
+	h.respondJSON(w, http.StatusOK, CreateEntryResponse{
+		EntryID: existing.EntryID.String(),
+		BatchID: existing.BatchID.String(),
+	})
+}
+
+// loadEntry rebuilds a domain entry from a stored journal entry and its lines
+func (h *Handler) loadEntry(ctx context.Context, qtx *store.Queries, row store.JournalEntry) (*domain.Entry, error) {
+	lines, err := qtx.GetJournalLinesByEntry(ctx, row.EntryID)
+	if err != nil {
+		return nil, err
+	}
+
+	domainLines := make([]domain.Line, len(lines))
+	for i, line := range lines {
+		domainLines[i] = domain.Line{
+			AccountID:   line.AccountID,
+			AmountMinor: line.AmountMinor,
+			Currency:    line.Currency,
+			Side:        domain.Side(line.Side),
+		}
+	}
+
+	return &domain.Entry{
+		EntryID:     row.EntryID,
+		BatchID:     row.BatchID,
+		Kind:        domain.EntryKind(row.Kind),
+		Lines:       domainLines,
+		Timestamp:   row.Ts,
+		EffectiveAt: row.EffectiveAt,
+
+		PeriodID:        row.PeriodID,
+		AdjustsPeriodID: row.AdjustsPeriodID,
+	}, nil
+}
+
 // createEntryPostedEvent converts a domain entry to a protobuf event
 func (h *Handler) createEntryPostedEvent(entry *domain.Entry) (*ledgerv1.EntryPosted, error) {
 	lines := make([]*ledgerv1.EntryLine, len(entry.Lines))
@@ -240,20 +420,31 @@ func (h *Handler) createEntryPostedEvent(entry *domain.Entry) (*ledgerv1.EntryPo
 			AccountId: line.AccountID.String(),
 			Amount: &ledgerv1.Money{
 				Units:    line.AmountMinor,
-				Currency: "USD", // TODO: get from account or entry
+				Currency: line.Currency,
 			},
 			Side: side,
 		}
 	}
 
 	return &ledgerv1.EntryPosted{
-		EntryId:  entry.EntryID.String(),
-		BatchId:  entry.BatchID.String(),
-		Lines:    lines,
-		TsUnixMs: entry.Timestamp.UnixMilli(),
+		EntryId:           entry.EntryID.String(),
+		BatchId:           entry.BatchID.String(),
+		Lines:             lines,
+		TsUnixMs:          entry.Timestamp.UnixMilli(),
+		PeriodId:          nullUUIDString(entry.PeriodID),
+		AdjustsPeriodId:   nullUUIDString(entry.AdjustsPeriodID),
+		EffectiveAtUnixMs: entry.EffectiveAt.UnixMilli(),
 	}, nil
 }
 
+// nullUUIDString returns the UUID as a string, or "" when it is NULL
+func nullUUIDString(id uuid.NullUUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return id.UUID.String()
+}
+
 // respondJSON sends a JSON response
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -342,32 +533,14 @@ func (h *Handler) VoidEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get original entry lines
-	lines, err := qtx.GetJournalLinesByEntry(ctx, entryID)
+	// Load original entry with its lines
+	domainEntry, err := h.loadEntry(ctx, qtx, originalEntry)
 	if err != nil {
 		h.logger.Printf("Failed to get journal lines: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to get entry lines")
 		return
 	}
 
-	// Convert to domain lines
-	domainLines := make([]domain.Line, len(lines))
-	for i, line := range lines {
-		domainLines[i] = domain.Line{
-			AccountID:   line.AccountID,
-			AmountMinor: line.AmountMinor,
-			Side:        domain.Side(line.Side),
-		}
-	}
-
-	// Create domain entry object
-	domainEntry := &domain.Entry{
-		EntryID:   originalEntry.EntryID,
-		BatchID:   originalEntry.BatchID,
-		Lines:     domainLines,
-		Timestamp: originalEntry.Ts,
-	}
-
 	// Create void entry
 	voidEntry, err := domain.CreateVoidEntry(domainEntry, req.Reason)
 	if err != nil {
@@ -376,12 +549,36 @@ func (h *Handler) VoidEntry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Book the reversal in the period containing its own date, leaving the
+	// original entry's period untouched
+	dated, err := periodAt(ctx, qtx, voidEntry.EffectiveAt)
+	if err == nil {
+		voidEntry.PeriodID, voidEntry.AdjustsPeriodID, err = bookEntry(ctx, qtx, dated)
+	}
+	if err != nil {
+		if h.respondPeriodError(w, err) {
+			return
+		}
+		h.logger.Printf("Failed to resolve accounting period: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to resolve accounting period")
+		return
+	}
+
 	// Insert void journal entry
 	_, err = qtx.CreateJournalEntry(ctx, store.CreateJournalEntryParams{
-		EntryID: voidEntry.EntryID,
-		BatchID: voidEntry.BatchID,
-		Ts:      voidEntry.Timestamp,
+		EntryID:         voidEntry.EntryID,
+		BatchID:         voidEntry.BatchID,
+		Ts:              voidEntry.Timestamp,
+		EffectiveAt:     voidEntry.EffectiveAt,
+		Kind:            string(voidEntry.Kind),
+		PeriodID:        voidEntry.PeriodID,
+		AdjustsPeriodID: voidEntry.AdjustsPeriodID,
 	})
+	if err == sql.ErrNoRows {
+		// A concurrent request voided this batch first
+		h.respondError(w, http.StatusConflict, "void_in_progress", "Entry is being voided by another request")
+		return
+	}
 	if err != nil {
 		h.logger.Printf("Failed to create void journal entry: %v", err)
 		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create void entry")
@@ -395,6 +592,7 @@ func (h *Handler) VoidEntry(w http.ResponseWriter, r *http.Request) {
 			AccountID:   line.AccountID,
 			AmountMinor: line.AmountMinor,
 			Side:        string(line.Side),
+			Currency:    line.Currency,
 		})
 		if err != nil {
 			h.logger.Printf("Failed to create void journal line: %v", err)
@@ -403,6 +601,21 @@ func (h *Handler) VoidEntry(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Revoke lots the original granted and give back what its debits consumed
+	lotMovements, err := h.reverseLots(ctx, qtx, domainEntry, voidEntry)
+	if err != nil {
+		h.logger.Printf("Failed to reverse credit lots: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update credit lots")
+		return
+	}
+
+	// Reverse balance changes (compensations are never blocked by overdraft limits)
+	if err := h.applyBalanceChanges(ctx, qtx, voidEntry, false, nil); err != nil {
+		h.logger.Printf("Failed to apply void balance changes: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update balances")
+		return
+	}
+
 	// Mark original entry as voided
 	err = qtx.MarkEntryVoided(ctx, store.MarkEntryVoidedParams{
 		EntryID:    entryID,
@@ -417,10 +630,14 @@ func (h *Handler) VoidEntry(w http.ResponseWriter, r *http.Request) {
 
 	// Create EntryVoided event
 	voidedEvent := &ledgerv1.EntryVoided{
-		OriginalEntryId: entryID.String(),
-		VoidEntryId:     voidEntry.EntryID.String(),
-		VoidReason:      req.Reason,
-		TsUnixMs:        time.Now().UnixMilli(),
+		OriginalEntryId:   entryID.String(),
+		VoidEntryId:       voidEntry.EntryID.String(),
+		VoidReason:        req.Reason,
+		TsUnixMs:          time.Now().UnixMilli(),
+		PeriodId:          nullUUIDString(voidEntry.PeriodID),
+		AdjustsPeriodId:   nullUUIDString(voidEntry.AdjustsPeriodID),
+		EffectiveAtUnixMs: voidEntry.EffectiveAt.UnixMilli(),
+		LotMovements:      toProtoLotMovements(lotMovements),
 	}
 
 	eventPayload, err := proto.Marshal(voidedEvent)
@@ -513,6 +730,7 @@ func (h *Handler) GetEntryByBatch(w http.ResponseWriter, r *http.Request) {
 		lineResponses[i] = LineRequest{
 			AccountID:   line.AccountID.String(),
 			AmountMinor: line.AmountMinor,
+			Currency:    line.Currency,
 			Side:        line.Side,
 		}
 	}
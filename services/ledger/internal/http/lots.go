@@ -0,0 +1,449 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// Reasons recorded on lot consumptions
+const (
+	lotConsumptionDebit  = "DEBIT"  // a debit line drew the lot down
+	lotConsumptionExpiry = "EXPIRY" // the expiry worker lapsed the remainder
+)
+
+// lotExpiryBatchSize caps how many lots one expiry run picks up; the rest
+// are picked up on later ticks
+const lotExpiryBatchSize = 100
+
+// LotRequest grants the amount of a CREDIT line as a credit lot
+type LotRequest struct {
+	ExpiresAt string `json:"expires_at,omitempty"` // RFC3339; omitted never expires
+	Source    string `json:"source,omitempty"`     // e.g. "signup_bonus", "plan_renewal"
+}
+
+// applyLots draws the entry's debits down from the debited accounts' open lots
+// and opens the lots granted by its credit lines. Lots are locked before any
+// balance row, the same order the expiry worker uses. A debit larger than the
+// account's lots takes the rest from the balance held outside lots.
+// It also returns what remains of each debited account's lapsed lots, which the
+// expiry worker has not posted to breakage yet and the debit may not spend.
+func (h *Handler) applyLots(ctx context.Context, qtx *store.Queries, entry *domain.Entry, grants []*domain.Lot) ([]domain.LotMovement, map[domain.BalanceKey]int64, error) {
+	var movements []domain.LotMovement
+	lapsed := make(map[domain.BalanceKey]int64)
+
+	for _, debit := range debitTotals(entry) {
+		rows, err := qtx.ListOpenLotsForUpdate(ctx, store.ListOpenLotsForUpdateParams{
+			AccountID: debit.AccountID,
+			Currency:  debit.Currency,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		lots := make([]domain.Lot, len(rows))
+		for i, row := range rows {
+			lots[i] = toDomainLot(row)
+		}
+
+		draws, _ := domain.ConsumeLots(lots, debit.DeltaMinor, h.lotOrder, entry.EffectiveAt)
+		if lapsedMinor := domain.LapsedMinor(lots, draws, entry.Timestamp); lapsedMinor > 0 {
+			lapsed[domain.BalanceKey{AccountID: debit.AccountID, Currency: debit.Currency}] = lapsedMinor
+		}
+		for _, draw := range draws {
+			if err := qtx.ConsumeLot(ctx, store.ConsumeLotParams{
+				AmountMinor: draw.AmountMinor,
+				ID:          draw.LotID,
+			}); err != nil {
+				return nil, nil, err
+			}
+			if err := qtx.CreateLotConsumption(ctx, store.CreateLotConsumptionParams{
+				LotID:       draw.LotID,
+				EntryID:     entry.EntryID,
+				AmountMinor: draw.AmountMinor,
+				Reason:      lotConsumptionDebit,
+			}); err != nil {
+				return nil, nil, err
+			}
+			movements = append(movements, domain.LotMovement{
+				LotID:       draw.LotID,
+				AccountID:   debit.AccountID,
+				Currency:    debit.Currency,
+				AmountMinor: draw.AmountMinor,
+				Kind:        domain.LotConsumed,
+			})
+		}
+	}
+
+	for _, lot := range grants {
+		if _, err := qtx.CreateLot(ctx, store.CreateLotParams{
+			ID:           lot.ID,
+			AccountID:    lot.AccountID,
+			Currency:     lot.Currency,
+			Source:       lot.Source,
+			GrantEntryID: entry.EntryID,
+			GrantedMinor: lot.GrantedMinor,
+			GrantedAt:    lot.GrantedAt,
+			ExpiresAt:    sql.NullTime{Time: lot.ExpiresAt, Valid: !lot.ExpiresAt.IsZero()},
+		}); err != nil {
+			return nil, nil, err
+		}
+		movements = append(movements, domain.LotMovement{
+			LotID:       lot.ID,
+			AccountID:   lot.AccountID,
+			Currency:    lot.Currency,
+			AmountMinor: lot.GrantedMinor,
+			Kind:        domain.LotGranted,
+			ExpiresAt:   lot.ExpiresAt,
+			Source:      lot.Source,
+		})
+	}
+
+	return movements, lapsed, nil
+}
+
+// reverseLots undoes the lot changes of a voided entry: lots it granted are
+// revoked with whatever remains of them, and amounts its debits consumed are
+// given back to their lots. An expiry is not given back to its lot, which has
+// lapsed; voiding it returns the amount to the balance held outside lots.
+func (h *Handler) reverseLots(ctx context.Context, qtx *store.Queries, original, void *domain.Entry) ([]domain.LotMovement, error) {
+	var movements []domain.LotMovement
+
+	granted, err := qtx.ListLotsByGrantEntryForUpdate(ctx, original.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range granted {
+		revoked, err := qtx.RevokeLot(ctx, row.ID)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, domain.LotMovement{
+			LotID:       revoked.ID,
+			AccountID:   revoked.AccountID,
+			Currency:    revoked.Currency,
+			AmountMinor: revoked.RevokedMinor,
+			Kind:        domain.LotRevoked,
+		})
+	}
+
+	consumptions, err := qtx.ListLotConsumptionsByEntry(ctx, original.EntryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range consumptions {
+		if c.Reason != lotConsumptionDebit {
+			continue
+		}
+		// A revoked lot takes nothing back; the amount stays outside lots
+		restored, err := qtx.RestoreLot(ctx, store.RestoreLotParams{
+			AmountMinor: c.AmountMinor,
+			ID:          c.LotID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if restored == 0 {
+			continue
+		}
+		if err := qtx.MarkLotConsumptionRestored(ctx, store.MarkLotConsumptionRestoredParams{
+			ID:         c.ID,
+			RestoredBy: uuid.NullUUID{UUID: void.EntryID, Valid: true},
+		}); err != nil {
+			return nil, err
+		}
+		movements = append(movements, domain.LotMovement{
+			LotID:       c.LotID,
+			AccountID:   c.AccountID,
+			Currency:    c.Currency,
+			AmountMinor: c.AmountMinor,
+			Kind:        domain.LotRestored,
+		})
+	}
+
+	return movements, nil
+}
+
+// StartLotExpiry runs ExpireLots on every tick until ctx is cancelled.
+// breakage maps each currency to the account lapsed credit is posted to.
+func (h *Handler) StartLotExpiry(ctx context.Context, interval time.Duration, breakage map[string]uuid.UUID) error {
+	h.logger.Printf("Lot expiry worker started (interval %s)", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Println("Lot expiry worker stopping...")
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := h.ExpireLots(ctx, breakage); err != nil {
+				h.logger.Printf("Lot expiry run failed: %v", err)
+			}
+		}
+	}
+}
+
+// ExpireLots posts an expiry entry for each lapsed lot with a remainder and
+// returns how many lots it picked up. Each lot is expired in its own
+// transaction; a lot that cannot be expired is logged and left for the next run.
+func (h *Handler) ExpireLots(ctx context.Context, breakage map[string]uuid.UUID) (int, error) {
+	ids, err := h.queries.ListExpiredLots(ctx, store.ListExpiredLotsParams{
+		AsOf:  sql.NullTime{Time: time.Now(), Valid: true},
+		Limit: lotExpiryBatchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired lots: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := h.expireLot(ctx, id, breakage); err != nil {
+			h.logger.Printf("Failed to expire lot %s: %v", id, err)
+		}
+	}
+	return len(ids), nil
+}
+
+// expireLot lapses the remainder of one lot to the breakage account of its currency
+func (h *Handler) expireLot(ctx context.Context, lotID uuid.UUID, breakage map[string]uuid.UUID) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetLotForUpdate(ctx, lotID)
+	if err != nil {
+		return err
+	}
+
+	// A debit or revoke may have emptied the lot since it was listed
+	lot := toDomainLot(row)
+	if lot.RemainingMinor <= 0 || !lot.ExpiredAt(time.Now()) {
+		return nil
+	}
+
+	breakageAccountID, ok := breakage[lot.Currency]
+	if !ok {
+		metrics.LotExpiryErrors.WithLabelValues("no_breakage_account").Inc()
+		return fmt.Errorf("no breakage account configured for %s", lot.Currency)
+	}
+
+	entry, err := domain.ExpiryEntry(lot, row.ExpiredMinor, breakageAccountID)
+	if err != nil {
+		metrics.LotExpiryErrors.WithLabelValues("invalid_entry").Inc()
+		return err
+	}
+
+	// Book the expiry when the lot lapsed; if that period no longer takes
+	// postings, the lot lapses today instead
+	dated, err := periodAt(ctx, qtx, entry.EffectiveAt)
+	if err == nil {
+		entry.PeriodID, entry.AdjustsPeriodID, err = bookEntry(ctx, qtx, dated)
+	}
+	var lockedErr *domain.PeriodLockedError
+	var closedErr *domain.PeriodClosedError
+	if (errors.As(err, &lockedErr) || errors.As(err, &closedErr)) && !entry.EffectiveAt.Equal(entry.Timestamp) {
+		entry.EffectiveAt = entry.Timestamp
+		dated, err = periodAt(ctx, qtx, entry.EffectiveAt)
+		if err == nil {
+			entry.PeriodID, entry.AdjustsPeriodID, err = bookEntry(ctx, qtx, dated)
+		}
+	}
+	if err != nil {
+		metrics.LotExpiryErrors.WithLabelValues("period").Inc()
+		return fmt.Errorf("failed to resolve accounting period: %w", err)
+	}
+
+	_, err = qtx.CreateJournalEntry(ctx, store.CreateJournalEntryParams{
+		EntryID:         entry.EntryID,
+		BatchID:         entry.BatchID,
+		Ts:              entry.Timestamp,
+		EffectiveAt:     entry.EffectiveAt,
+		Kind:            string(entry.Kind),
+		PeriodID:        entry.PeriodID,
+		AdjustsPeriodID: entry.AdjustsPeriodID,
+	})
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("expiry batch %s already posted", entry.BatchID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create expiry entry: %w", err)
+	}
+
+	for _, line := range entry.Lines {
+		if _, err := qtx.CreateJournalLine(ctx, store.CreateJournalLineParams{
+			EntryID:     entry.EntryID,
+			AccountID:   line.AccountID,
+			AmountMinor: line.AmountMinor,
+			Side:        string(line.Side),
+			Currency:    line.Currency,
+		}); err != nil {
+			return fmt.Errorf("failed to create expiry line: %w", err)
+		}
+	}
+
+	// Expiry removes credit the account was granted, so it is never blocked by overdraft limits
+	if err := h.applyBalanceChanges(ctx, qtx, entry, false, nil); err != nil {
+		return fmt.Errorf("failed to apply expiry balance changes: %w", err)
+	}
+
+	if err := qtx.CreateLotConsumption(ctx, store.CreateLotConsumptionParams{
+		LotID:       lot.ID,
+		EntryID:     entry.EntryID,
+		AmountMinor: lot.RemainingMinor,
+		Reason:      lotConsumptionExpiry,
+	}); err != nil {
+		return err
+	}
+	if err := qtx.ExpireLot(ctx, lot.ID); err != nil {
+		return err
+	}
+
+	event, err := h.createEntryPostedEvent(entry)
+	if err != nil {
+		return err
+	}
+	event.LotMovements = toProtoLotMovements([]domain.LotMovement{{
+		LotID:       lot.ID,
+		AccountID:   lot.AccountID,
+		Currency:    lot.Currency,
+		AmountMinor: lot.RemainingMinor,
+		Kind:        domain.LotExpired,
+	}})
+
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]interface{}{
+		"event_name": "EntryPosted",
+		"schema":     "ledger.v1.EntryPosted",
+	}
+	headersJSON, _ := json.Marshal(headers)
+
+	if _, err := qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "journal_entry",
+		AggregateID:   entry.EntryID,
+		EventType:     "EntryPosted",
+		Payload:       payload,
+		Headers:       headersJSON,
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	metrics.LotsExpired.WithLabelValues(lot.Currency).Inc()
+	metrics.LotsExpiredMinor.WithLabelValues(lot.Currency).Add(float64(lot.RemainingMinor))
+	h.logger.Printf("Expired lot %s: %d %s to breakage account %s (entry %s)",
+		lot.ID, lot.RemainingMinor, lot.Currency, breakageAccountID, entry.EntryID)
+	return nil
+}
+
+// debitTotals sums the entry's debit lines per account and currency, in a
+// deterministic order so concurrent entries lock lots the same way
+func debitTotals(entry *domain.Entry) []domain.BalanceChange {
+	type key struct {
+		account  uuid.UUID
+		currency string
+	}
+	totals := make(map[key]int64)
+	for _, line := range entry.Lines {
+		if line.Side == domain.SideDebit {
+			totals[key{line.AccountID, line.Currency}] += line.AmountMinor
+		}
+	}
+
+	debits := make([]domain.BalanceChange, 0, len(totals))
+	for k, amount := range totals {
+		debits = append(debits, domain.BalanceChange{AccountID: k.account, Currency: k.currency, DeltaMinor: amount})
+	}
+	sort.Slice(debits, func(i, j int) bool {
+		if debits[i].AccountID != debits[j].AccountID {
+			return debits[i].AccountID.String() < debits[j].AccountID.String()
+		}
+		return debits[i].Currency < debits[j].Currency
+	})
+	return debits
+}
+
+func toDomainLot(row store.CreditLot) domain.Lot {
+	lot := domain.Lot{
+		ID:             row.ID,
+		AccountID:      row.AccountID,
+		Currency:       row.Currency,
+		Source:         row.Source,
+		GrantedMinor:   row.GrantedMinor,
+		RemainingMinor: row.RemainingMinor,
+		GrantedAt:      row.GrantedAt,
+	}
+	if row.ExpiresAt.Valid {
+		lot.ExpiresAt = row.ExpiresAt.Time
+	}
+	return lot
+}
+
+// toProtoLotMovements converts domain lot movements to their protobuf messages
+func toProtoLotMovements(movements []domain.LotMovement) []*ledgerv1.LotMovement {
+	if len(movements) == 0 {
+		return nil
+	}
+
+	out := make([]*ledgerv1.LotMovement, len(movements))
+	for i, m := range movements {
+		out[i] = &ledgerv1.LotMovement{
+			LotId:     m.LotID.String(),
+			AccountId: m.AccountID.String(),
+			Amount: &ledgerv1.Money{
+				Units:    m.AmountMinor,
+				Currency: m.Currency,
+			},
+			Kind:   toProtoLotMovementKind(m.Kind),
+			Source: m.Source,
+		}
+		if !m.ExpiresAt.IsZero() {
+			out[i].ExpiresAtUnixMs = m.ExpiresAt.UnixMilli()
+		}
+	}
+	return out
+}
+
+// toProtoLotMovementKind converts a domain lot movement kind to its protobuf enum
+func toProtoLotMovementKind(kind domain.LotMovementKind) ledgerv1.LotMovementKind {
+	switch kind {
+	case domain.LotGranted:
+		return ledgerv1.LotMovementKind_LOT_GRANTED
+	case domain.LotConsumed:
+		return ledgerv1.LotMovementKind_LOT_CONSUMED
+	case domain.LotExpired:
+		return ledgerv1.LotMovementKind_LOT_EXPIRED
+	case domain.LotRestored:
+		return ledgerv1.LotMovementKind_LOT_RESTORED
+	case domain.LotRevoked:
+		return ledgerv1.LotMovementKind_LOT_REVOKED
+	default:
+		return ledgerv1.LotMovementKind_LOT_MOVEMENT_UNSPECIFIED
+	}
+}
@@ -0,0 +1,402 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreatePeriodRequest represents the request to open an accounting period
+type CreatePeriodRequest struct {
+	Name     string `json:"name"`
+	StartsAt string `json:"starts_at"` // RFC3339, inclusive
+	EndsAt   string `json:"ends_at"`   // RFC3339, exclusive
+}
+
+// ChangePeriodStatusRequest represents the optional body of a period status change
+type ChangePeriodStatusRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// PeriodResponse represents an accounting period
+type PeriodResponse struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	StartsAt        string `json:"starts_at"`
+	EndsAt          string `json:"ends_at"`
+	Status          string `json:"status"`
+	PreviousStatus  string `json:"previous_status,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	StatusChangedAt string `json:"status_changed_at,omitempty"`
+}
+
+// CreatePeriod handles POST /v1/periods
+func (h *Handler) CreatePeriod(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreatePeriodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_starts_at", "starts_at must be an RFC3339 timestamp")
+		return
+	}
+	endsAt, err := time.Parse(time.RFC3339, req.EndsAt)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_ends_at", "ends_at must be an RFC3339 timestamp")
+		return
+	}
+
+	period, err := domain.NewPeriod(req.Name, startsAt, endsAt)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.logger.Printf("Failed to begin transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create period")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.CreatePeriod(ctx, store.CreatePeriodParams{
+		ID:       period.ID,
+		Name:     period.Name,
+		StartsAt: period.StartsAt,
+		EndsAt:   period.EndsAt,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code {
+			case "23P01": // exclusion_violation
+				h.respondError(w, http.StatusConflict, "period_overlap", "Period overlaps an existing period")
+				return
+			case "23505": // unique_violation
+				h.respondError(w, http.StatusConflict, "period_name_taken", "A period with this name already exists")
+				return
+			}
+		}
+		h.logger.Printf("Failed to create period: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create period")
+		return
+	}
+
+	if err := h.createPeriodEvent(ctx, qtx, row, "", ""); err != nil {
+		h.logger.Printf("Failed to create period event: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create outbox event")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		h.logger.Printf("Failed to commit transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create period")
+		return
+	}
+
+	h.logger.Printf("Opened accounting period %s (%s) from %s to %s", row.Name, row.ID,
+		row.StartsAt.Format(time.RFC3339), row.EndsAt.Format(time.RFC3339))
+	h.respondJSON(w, http.StatusCreated, toPeriodResponse(row, ""))
+}
+
+// ListPeriods handles GET /v1/periods
+func (h *Handler) ListPeriods(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.queries.ListPeriods(r.Context())
+	if err != nil {
+		h.logger.Printf("Failed to list periods: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query periods")
+		return
+	}
+
+	periods := make([]PeriodResponse, len(rows))
+	for i, row := range rows {
+		periods[i] = toPeriodResponse(row, "")
+	}
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"periods": periods,
+	})
+}
+
+// GetPeriod handles GET /v1/periods/:id
+func (h *Handler) GetPeriod(w http.ResponseWriter, r *http.Request) {
+	periodID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_period_id", "Period ID must be a valid UUID")
+		return
+	}
+
+	row, err := h.queries.GetPeriod(r.Context(), periodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.respondError(w, http.StatusNotFound, "period_not_found", "Accounting period not found")
+			return
+		}
+		h.logger.Printf("Failed to get period: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query period")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, toPeriodResponse(row, ""))
+}
+
+// ClosePeriod handles POST /v1/periods/:id/close
+// Postings dated in a soft-closed period are booked in the next open period.
+func (h *Handler) ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	h.changePeriodStatus(w, r, domain.PeriodSoftClosed)
+}
+
+// ReopenPeriod handles POST /v1/periods/:id/reopen
+func (h *Handler) ReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	h.changePeriodStatus(w, r, domain.PeriodOpen)
+}
+
+// LockPeriod handles POST /v1/periods/:id/lock
+// Postings dated in a locked period are rejected; a lock cannot be undone.
+func (h *Handler) LockPeriod(w http.ResponseWriter, r *http.Request) {
+	h.changePeriodStatus(w, r, domain.PeriodLocked)
+}
+
+// changePeriodStatus moves a period to the target status through the period
+// state machine and records a PeriodStatusChanged event in the same transaction.
+// The period row is locked, so postings share-locking it wait for the change.
+func (h *Handler) changePeriodStatus(w http.ResponseWriter, r *http.Request, target domain.PeriodStatus) {
+	ctx := r.Context()
+
+	periodID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_period_id", "Period ID must be a valid UUID")
+		return
+	}
+
+	// The body is optional; it only carries the reason
+	var req ChangePeriodStatusRequest
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+			return
+		}
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		h.logger.Printf("Failed to begin transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update period")
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetPeriodForUpdate(ctx, periodID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.respondError(w, http.StatusNotFound, "period_not_found", "Accounting period not found")
+			return
+		}
+		h.logger.Printf("Failed to get period: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update period")
+		return
+	}
+
+	period := toDomainPeriod(row)
+	previous := period.Status
+
+	switch target {
+	case domain.PeriodSoftClosed:
+		err = period.Close()
+	case domain.PeriodOpen:
+		err = period.Reopen()
+	case domain.PeriodLocked:
+		err = period.Lock()
+	}
+	if err != nil {
+		h.respondError(w, http.StatusConflict, "invalid_transition", err.Error())
+		return
+	}
+
+	updated, err := qtx.UpdatePeriodStatus(ctx, store.UpdatePeriodStatusParams{
+		ID:              period.ID,
+		Status:          string(period.Status),
+		StatusChangedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		h.logger.Printf("Failed to update period status: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update period")
+		return
+	}
+
+	if err := h.createPeriodEvent(ctx, qtx, updated, previous, req.Reason); err != nil {
+		h.logger.Printf("Failed to create period event: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to create outbox event")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		h.logger.Printf("Failed to commit transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to update period")
+		return
+	}
+
+	h.logger.Printf("Accounting period %s (%s) status changed from %s to %s", updated.Name, updated.ID, previous, updated.Status)
+	h.respondJSON(w, http.StatusOK, toPeriodResponse(updated, previous))
+}
+
+// createPeriodEvent records a PeriodStatusChanged event in the outbox;
+// previous is empty when the period is opened
+func (h *Handler) createPeriodEvent(ctx context.Context, qtx *store.Queries, period store.AccountingPeriod, previous domain.PeriodStatus, reason string) error {
+	event := &ledgerv1.PeriodStatusChanged{
+		PeriodId:       period.ID.String(),
+		Name:           period.Name,
+		StartsAtUnixMs: period.StartsAt.UnixMilli(),
+		EndsAtUnixMs:   period.EndsAt.UnixMilli(),
+		PreviousStatus: toProtoPeriodStatus(previous),
+		Status:         toProtoPeriodStatus(domain.PeriodStatus(period.Status)),
+		Reason:         reason,
+		TsUnixMs:       time.Now().UnixMilli(),
+	}
+
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]interface{}{
+		"event_name": "PeriodStatusChanged",
+		"schema":     "ledger.v1.PeriodStatusChanged",
+	}
+	headersJSON, _ := json.Marshal(headers)
+
+	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "accounting_period",
+		AggregateID:   period.ID,
+		EventType:     "PeriodStatusChanged",
+		Payload:       payload,
+		Headers:       headersJSON,
+		CreatedAt:     time.Now(),
+	})
+	return err
+}
+
+// periodAt returns the share-locked period containing ts, or nil when ts
+// falls outside every period
+func periodAt(ctx context.Context, qtx *store.Queries, ts time.Time) (*store.AccountingPeriod, error) {
+	row, err := qtx.GetPeriodAt(ctx, ts)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// bookEntry decides which period an entry dated in the given period is booked
+// in and which closed period it corrects, either NULL when there is none. The
+// periods involved stay share-locked until the caller commits. It returns a
+// domain.PeriodLockedError or domain.PeriodClosedError when the entry cannot be booked.
+func bookEntry(ctx context.Context, qtx *store.Queries, dated *store.AccountingPeriod) (periodID, adjustsPeriodID uuid.NullUUID, err error) {
+	if dated == nil {
+		return periodID, adjustsPeriodID, nil
+	}
+
+	var next *domain.Period
+	if domain.PeriodStatus(dated.Status) == domain.PeriodSoftClosed {
+		row, err := qtx.GetNextOpenPeriod(ctx, dated.EndsAt)
+		if err != nil && err != sql.ErrNoRows {
+			return periodID, adjustsPeriodID, err
+		}
+		if err == nil {
+			next = toDomainPeriod(row)
+		}
+	}
+
+	booked, adjusts, err := domain.BookingPeriod(toDomainPeriod(*dated), next)
+	if err != nil {
+		return periodID, adjustsPeriodID, err
+	}
+	if booked != nil {
+		periodID = uuid.NullUUID{UUID: booked.ID, Valid: true}
+	}
+	if adjusts != nil {
+		adjustsPeriodID = uuid.NullUUID{UUID: adjusts.ID, Valid: true}
+	}
+	return periodID, adjustsPeriodID, nil
+}
+
+// respondPeriodError answers a posting rejected by its accounting period and
+// reports whether err was such a rejection
+func (h *Handler) respondPeriodError(w http.ResponseWriter, err error) bool {
+	var lockedErr *domain.PeriodLockedError
+	if errors.As(err, &lockedErr) {
+		metrics.EntriesRejected.WithLabelValues("period_locked").Inc()
+		h.respondError(w, http.StatusUnprocessableEntity, "period_locked", lockedErr.Error())
+		return true
+	}
+	var closedErr *domain.PeriodClosedError
+	if errors.As(err, &closedErr) {
+		metrics.EntriesRejected.WithLabelValues("period_closed").Inc()
+		h.respondError(w, http.StatusUnprocessableEntity, "period_closed", closedErr.Error())
+		return true
+	}
+	return false
+}
+
+func toDomainPeriod(row store.AccountingPeriod) *domain.Period {
+	return &domain.Period{
+		ID:       row.ID,
+		Name:     row.Name,
+		StartsAt: row.StartsAt,
+		EndsAt:   row.EndsAt,
+		Status:   domain.PeriodStatus(row.Status),
+	}
+}
+
+func toPeriodResponse(row store.AccountingPeriod, previous domain.PeriodStatus) PeriodResponse {
+	resp := PeriodResponse{
+		ID:             row.ID.String(),
+		Name:           row.Name,
+		StartsAt:       row.StartsAt.Format(time.RFC3339),
+		EndsAt:         row.EndsAt.Format(time.RFC3339),
+		Status:         row.Status,
+		PreviousStatus: string(previous),
+		CreatedAt:      row.CreatedAt.Format(time.RFC3339),
+	}
+	if row.StatusChangedAt.Valid {
+		resp.StatusChangedAt = row.StatusChangedAt.Time.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// toProtoPeriodStatus converts a domain period status to its protobuf enum
+func toProtoPeriodStatus(status domain.PeriodStatus) ledgerv1.PeriodStatus {
+	switch status {
+	case domain.PeriodOpen:
+		return ledgerv1.PeriodStatus_PERIOD_OPEN
+	case domain.PeriodSoftClosed:
+		return ledgerv1.PeriodStatus_PERIOD_SOFT_CLOSED
+	case domain.PeriodLocked:
+		return ledgerv1.PeriodStatus_PERIOD_LOCKED
+	default:
+		return ledgerv1.PeriodStatus_PERIOD_STATUS_UNSPECIFIED
+	}
+}
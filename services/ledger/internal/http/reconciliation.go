@@ -0,0 +1,100 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+)
+
+// ReconciliationRunResponse represents a reconciliation run and the discrepancies it found
+type ReconciliationRunResponse struct {
+	ID               string                `json:"id"`
+	Status           string                `json:"status"`
+	TriggeredBy      string                `json:"triggered_by"`
+	StartedAt        string                `json:"started_at"`
+	FinishedAt       string                `json:"finished_at,omitempty"`
+	AccountsChecked  int32                 `json:"accounts_checked"`
+	TransfersChecked int32                 `json:"transfers_checked"`
+	DiscrepancyCount int32                 `json:"discrepancy_count"`
+	Error            string                `json:"error,omitempty"`
+	Discrepancies    []DiscrepancyResponse `json:"discrepancies"`
+}
+
+// DiscrepancyResponse represents one difference between the journal and another source
+type DiscrepancyResponse struct {
+	Kind       string `json:"kind"`
+	AccountID  string `json:"account_id,omitempty"`
+	Currency   string `json:"currency,omitempty"`
+	TransferID string `json:"transfer_id,omitempty"`
+	Expected   int64  `json:"expected"` // journal value: minor units, or active entry count for transfers
+	Actual     int64  `json:"actual"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// GetReconciliationRun handles GET /v1/reconciliation/runs/:id
+func (h *Handler) GetReconciliationRun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	runID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_run_id", "Run ID must be a valid UUID")
+		return
+	}
+
+	run, err := h.queries.GetReconciliationRun(ctx, runID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.respondError(w, http.StatusNotFound, "run_not_found", "Reconciliation run not found")
+			return
+		}
+		h.logger.Printf("Failed to get reconciliation run: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query reconciliation run")
+		return
+	}
+
+	discrepancies, err := h.queries.ListReconciliationDiscrepancies(ctx, runID)
+	if err != nil {
+		h.logger.Printf("Failed to list reconciliation discrepancies: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query discrepancies")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, toReconciliationRunResponse(run, discrepancies))
+}
+
+func toReconciliationRunResponse(run store.ReconciliationRun, discrepancies []store.ReconciliationDiscrepancy) ReconciliationRunResponse {
+	resp := ReconciliationRunResponse{
+		ID:               run.ID.String(),
+		Status:           run.Status,
+		TriggeredBy:      run.TriggeredBy,
+		StartedAt:        run.StartedAt.Format(time.RFC3339),
+		AccountsChecked:  run.AccountsChecked,
+		TransfersChecked: run.TransfersChecked,
+		DiscrepancyCount: run.DiscrepancyCount,
+		Error:            run.Error.String,
+		Discrepancies:    make([]DiscrepancyResponse, len(discrepancies)),
+	}
+	if run.FinishedAt.Valid {
+		resp.FinishedAt = run.FinishedAt.Time.Format(time.RFC3339)
+	}
+
+	for i, d := range discrepancies {
+		resp.Discrepancies[i] = DiscrepancyResponse{
+			Kind:     d.Kind,
+			Currency: d.Currency.String,
+			Expected: d.Expected,
+			Actual:   d.Actual,
+			Detail:   d.Detail,
+		}
+		if d.AccountID.Valid {
+			resp.Discrepancies[i].AccountID = d.AccountID.UUID.String()
+		}
+		if d.TransferID.Valid {
+			resp.Discrepancies[i].TransferID = d.TransferID.UUID.String()
+		}
+	}
+	return resp
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultGeneralLedgerLimit = 1000
+	maxGeneralLedgerLimit     = 10000
+)
+
+// TotalsResponse represents debit and credit totals in one currency.
+// BalanceMinor is credits minus debits, the sign of the ledger's balances.
+type TotalsResponse struct {
+	Currency     string `json:"currency"`
+	DebitMinor   int64  `json:"debit_minor"`
+	CreditMinor  int64  `json:"credit_minor"`
+	BalanceMinor int64  `json:"balance_minor"`
+}
+
+// TrialBalanceAccountResponse represents one account's totals in one currency
+type TrialBalanceAccountResponse struct {
+	AccountID string `json:"account_id"`
+	TotalsResponse
+}
+
+// TrialBalanceCurrencyResponse represents the totals of all accounts in one currency
+type TrialBalanceCurrencyResponse struct {
+	TotalsResponse
+	Balanced bool `json:"balanced"`
+}
+
+// TrialBalanceResponse represents the trial balance of the journal at a point in time
+type TrialBalanceResponse struct {
+	AsOf     string                         `json:"as_of"`
+	Balanced bool                           `json:"balanced"` // every currency's debits equal its credits
+	Totals   []TrialBalanceCurrencyResponse `json:"totals"`
+	Accounts []TrialBalanceAccountResponse  `json:"accounts"`
+}
+
+// GeneralLedgerCurrencyResponse represents an account's activity in one currency over the period
+type GeneralLedgerCurrencyResponse struct {
+	Currency            string `json:"currency"`
+	OpeningBalanceMinor int64  `json:"opening_balance_minor"`
+	DebitMinor          int64  `json:"debit_minor"`
+	CreditMinor         int64  `json:"credit_minor"`
+	ClosingBalanceMinor int64  `json:"closing_balance_minor"`
+}
+
+// GeneralLedgerLineResponse represents one journal line with the account's balance after it
+type GeneralLedgerLineResponse struct {
+	EntryID           string `json:"entry_id"`
+	BatchID           string `json:"batch_id"`
+	Kind              string `json:"kind"`
+	Ts                string `json:"ts"`
+	EffectiveAt       string `json:"effective_at"`
+	ReportedAt        string `json:"reported_at"` // start of the booked period for closed-period corrections
+	Side              string `json:"side"`
+	AmountMinor       int64  `json:"amount_minor"`
+	Currency          string `json:"currency"`
+	BalanceAfterMinor int64  `json:"balance_after_minor"`
+}
+
+// GeneralLedgerResponse represents an account's journal lines over a period
+type GeneralLedgerResponse struct {
+	AccountID  string                          `json:"account_id"`
+	From       string                          `json:"from,omitempty"`
+	To         string                          `json:"to"`
+	Currencies []GeneralLedgerCurrencyResponse `json:"currencies"`
+	Lines      []GeneralLedgerLineResponse     `json:"lines"`
+	Truncated  bool                            `json:"truncated"` // more lines than limit; totals still cover the whole period
+}
+
+// GetTrialBalance handles GET /v1/reports/trial-balance?as_of=
+// It sums every account's journal lines per currency for entries reported up
+// to as_of (default now) and reports whether debits equal credits in each currency.
+// An entry is reported at its effective date, or at the start of the period it
+// was booked in when it corrects a closed period, so closed periods never change.
+func (h *Handler) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	asOf := time.Now()
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_as_of", "as_of must be an RFC3339 timestamp")
+			return
+		}
+		asOf = parsed
+	}
+
+	rows, err := h.queries.GetTrialBalance(ctx, asOf)
+	if err != nil {
+		h.logger.Printf("Failed to compute trial balance: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to compute trial balance")
+		return
+	}
+
+	accounts := make([]domain.AccountTotals, len(rows))
+	resp := TrialBalanceResponse{
+		AsOf:     asOf.Format(time.RFC3339),
+		Balanced: true,
+		Accounts: make([]TrialBalanceAccountResponse, len(rows)),
+	}
+	for i, row := range rows {
+		accounts[i] = domain.AccountTotals{
+			AccountID:  row.AccountID,
+			Currency:   row.Currency,
+			LineTotals: domain.LineTotals{DebitMinor: row.DebitMinor, CreditMinor: row.CreditMinor},
+		}
+		resp.Accounts[i] = TrialBalanceAccountResponse{
+			AccountID:      row.AccountID.String(),
+			TotalsResponse: toTotalsResponse(row.Currency, accounts[i].LineTotals),
+		}
+	}
+
+	for _, totals := range domain.TrialBalance(accounts) {
+		resp.Totals = append(resp.Totals, TrialBalanceCurrencyResponse{
+			TotalsResponse: toTotalsResponse(totals.Currency, totals.LineTotals),
+			Balanced:       totals.Balanced(),
+		})
+		if !totals.Balanced() {
+			resp.Balanced = false
+			h.logger.Printf("Trial balance as of %s does not balance in %s: debits %d, credits %d",
+				resp.AsOf, totals.Currency, totals.DebitMinor, totals.CreditMinor)
+		}
+	}
+	if resp.Totals == nil {
+		resp.Totals = []TrialBalanceCurrencyResponse{}
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+// GetGeneralLedger handles GET /v1/reports/general-ledger?account_id=&from=&to=
+// It lists an account's journal lines for entries reported in [from, to),
+// oldest first, with opening and closing balances per currency. from defaults to the
+// start of the journal and to to now; limit caps the lines returned.
+func (h *Handler) GetGeneralLedger(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	accountID, err := uuid.Parse(query.Get("account_id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_account_id", "account_id must be a valid UUID")
+		return
+	}
+
+	var from time.Time
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_from", "from must be an RFC3339 timestamp")
+			return
+		}
+	}
+	to := time.Now()
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_to", "to must be an RFC3339 timestamp")
+			return
+		}
+	}
+	if !from.Before(to) {
+		h.respondError(w, http.StatusBadRequest, "invalid_period", "from must be before to")
+		return
+	}
+
+	limit := defaultGeneralLedgerLimit
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxGeneralLedgerLimit {
+			h.respondError(w, http.StatusBadRequest, "invalid_limit", "limit must be between 1 and 10000")
+			return
+		}
+	}
+
+	// Read the opening totals, period totals and lines from one snapshot
+	tx, err := h.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		h.logger.Printf("Failed to begin transaction: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query general ledger")
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	opening, err := qtx.GetAccountTotals(ctx, store.GetAccountTotalsParams{
+		AccountID: accountID,
+		FromTs:    time.Time{},
+		ToTs:      from,
+	})
+	if err != nil {
+		h.logger.Printf("Failed to get opening totals: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query general ledger")
+		return
+	}
+
+	period, err := qtx.GetAccountTotals(ctx, store.GetAccountTotalsParams{
+		AccountID: accountID,
+		FromTs:    from,
+		ToTs:      to,
+	})
+	if err != nil {
+		h.logger.Printf("Failed to get period totals: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query general ledger")
+		return
+	}
+
+	// Fetch one line past the limit to tell whether the page is truncated
+	lines, err := qtx.ListAccountLines(ctx, store.ListAccountLinesParams{
+		AccountID: accountID,
+		FromTs:    from,
+		ToTs:      to,
+		Limit:     int32(limit + 1),
+	})
+	if err != nil {
+		h.logger.Printf("Failed to list account lines: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "database_error", "Failed to query general ledger")
+		return
+	}
+
+	resp := GeneralLedgerResponse{
+		AccountID:  accountID.String(),
+		To:         to.Format(time.RFC3339),
+		Currencies: []GeneralLedgerCurrencyResponse{},
+		Lines:      []GeneralLedgerLineResponse{},
+	}
+	if !from.IsZero() {
+		resp.From = from.Format(time.RFC3339)
+	}
+	if len(lines) > limit {
+		lines = lines[:limit]
+		resp.Truncated = true
+	}
+
+	// Running balance per currency, starting from the opening balance
+	running := make(map[string]int64)
+	for _, row := range opening {
+		running[row.Currency] = domain.LineTotals{DebitMinor: row.DebitMinor, CreditMinor: row.CreditMinor}.BalanceMinor()
+	}
+	periodTotals := make(map[string]domain.LineTotals)
+	for _, row := range period {
+		periodTotals[row.Currency] = domain.LineTotals{DebitMinor: row.DebitMinor, CreditMinor: row.CreditMinor}
+	}
+
+	var currencies []string
+	for _, row := range opening {
+		currencies = append(currencies, row.Currency)
+	}
+	for _, row := range period {
+		if _, ok := running[row.Currency]; !ok {
+			currencies = append(currencies, row.Currency)
+		}
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		totals := periodTotals[currency]
+		resp.Currencies = append(resp.Currencies, GeneralLedgerCurrencyResponse{
+			Currency:            currency,
+			OpeningBalanceMinor: running[currency],
+			DebitMinor:          totals.DebitMinor,
+			CreditMinor:         totals.CreditMinor,
+			ClosingBalanceMinor: running[currency] + totals.BalanceMinor(),
+		})
+	}
+
+	for _, line := range lines {
+		if line.Side == string(domain.SideDebit) {
+			running[line.Currency] -= line.AmountMinor
+		} else {
+			running[line.Currency] += line.AmountMinor
+		}
+		resp.Lines = append(resp.Lines, GeneralLedgerLineResponse{
+			EntryID:           line.EntryID.String(),
+			BatchID:           line.BatchID.String(),
+			Kind:              line.Kind,
+			Ts:                line.Ts.Format(time.RFC3339),
+			EffectiveAt:       line.EffectiveAt.Format(time.RFC3339),
+			ReportedAt:        line.ReportedAt.Format(time.RFC3339),
+			Side:              line.Side,
+			AmountMinor:       line.AmountMinor,
+			Currency:          line.Currency,
+			BalanceAfterMinor: running[line.Currency],
+		})
+	}
+
+	h.respondJSON(w, http.StatusOK, resp)
+}
+
+func toTotalsResponse(currency string, totals domain.LineTotals) TotalsResponse {
+	return TotalsResponse{
+		Currency:     currency,
+		DebitMinor:   totals.DebitMinor,
+		CreditMinor:  totals.CreditMinor,
+		BalanceMinor: totals.BalanceMinor(),
+	}
+}
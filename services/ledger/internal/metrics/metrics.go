@@ -25,6 +25,15 @@ var (
 		[]string{"status"},
 	)
 
+	// EntriesRejected tracks ledger entries rejected by business rules
+	EntriesRejected = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_entries_rejected_total",
+			Help: "Total number of ledger entries rejected by business rules",
+		},
+		[]string{"reason"},
+	)
+
 	// OutboxEventsPublished tracks the total number of outbox events published
 	OutboxEventsPublished = promauto.NewCounterVec(
 		prometheus.CounterOpts{
@@ -60,6 +69,23 @@ var (
 		},
 	)
 
+	// OutboxOldestUnsentAge tracks how long the oldest unsent outbox event has waited
+	OutboxOldestUnsentAge = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ledger_outbox_oldest_unsent_age_seconds",
+			Help: "Age of the oldest unsent event in the outbox table",
+		},
+	)
+
+	// OutboxRowsPurged tracks sent outbox rows removed after the retention period
+	OutboxRowsPurged = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_outbox_rows_purged_total",
+			Help: "Total number of sent outbox rows deleted or archived after retention",
+		},
+		[]string{"mode"},
+	)
+
 	// LedgerBalance tracks the total balance across all accounts (for monitoring)
 	LedgerBalance = promauto.NewGaugeVec(
 		prometheus.GaugeOpts{
@@ -68,4 +94,64 @@ var (
 		},
 		[]string{"currency"},
 	)
+
+	// ReconciliationDiscrepancies tracks the discrepancies found by the last reconciliation run
+	ReconciliationDiscrepancies = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ledger_reconciliation_discrepancies",
+			Help: "Discrepancies found by the last reconciliation run, by kind",
+		},
+		[]string{"kind"},
+	)
+
+	// ReconciliationLastRunTimestamp tracks when the last reconciliation run finished
+	ReconciliationLastRunTimestamp = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ledger_reconciliation_last_run_timestamp_seconds",
+			Help: "Unix time the last reconciliation run finished",
+		},
+	)
+
+	// ReconciliationLastRunSuccess tracks whether the last reconciliation run completed
+	ReconciliationLastRunSuccess = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ledger_reconciliation_last_run_success",
+			Help: "1 if the last reconciliation run completed, 0 if it failed",
+		},
+	)
+
+	// ReconciliationLastRunDuration tracks how long the last reconciliation run took
+	ReconciliationLastRunDuration = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "ledger_reconciliation_last_run_duration_seconds",
+			Help: "Duration of the last reconciliation run",
+		},
+	)
+
+	// LotsExpired tracks credit lots whose remainder lapsed to breakage
+	LotsExpired = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_lots_expired_total",
+			Help: "Total number of credit lots expired to breakage",
+		},
+		[]string{"currency"},
+	)
+
+	// LotsExpiredMinor tracks the amount of expired credit, in minor units
+	LotsExpiredMinor = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_lots_expired_minor_total",
+			Help: "Total remainder of expired credit lots in minor units",
+		},
+		[]string{"currency"},
+	)
+
+	// LotExpiryErrors tracks lots the expiry worker failed to expire
+	LotExpiryErrors = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ledger_lot_expiry_errors_total",
+			Help: "Total number of failed credit lot expiries",
+		},
+		[]string{"reason"},
+	)
 )
@@ -0,0 +1,289 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/ledger/internal/store"
+	"github.com/google/uuid"
+)
+
+// Run statuses and triggers recorded in reconciliation_runs
+const (
+	StatusRunning   = "RUNNING"
+	StatusCompleted = "COMPLETED"
+	StatusFailed    = "FAILED"
+
+	TriggerSchedule = "schedule"
+	TriggerCommand  = "command"
+)
+
+// Reconciler compares the ledger journal with the read-model and orchestrator
+// databases and records what differs in the ledger's reconciliation tables
+type Reconciler struct {
+	db           *sql.DB
+	queries      *store.Queries
+	readModel    *sql.DB
+	orchestrator *sql.DB
+	logger       *log.Logger
+	settleDelay  time.Duration
+	batchSize    int32
+}
+
+// NewReconciler creates a reconciler over the ledger, read-model and orchestrator databases
+func NewReconciler(db, readModel, orchestrator *sql.DB, logger *log.Logger) *Reconciler {
+	return &Reconciler{
+		db:           db,
+		queries:      store.New(db),
+		readModel:    readModel,
+		orchestrator: orchestrator,
+		logger:       logger,
+		settleDelay:  10 * time.Second, // Let the read-model consume in-flight events before rechecking
+		batchSize:    500,              // Transfers checked per ledger query
+	}
+}
+
+// Start runs a reconciliation every interval until ctx is cancelled
+func (r *Reconciler) Start(ctx context.Context, interval time.Duration) error {
+	r.logger.Printf("Reconciliation worker started (interval %s)", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Println("Reconciliation worker stopping...")
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := r.Run(ctx, TriggerSchedule); err != nil {
+				r.logger.Printf("Reconciliation run failed: %v", err)
+			}
+		}
+	}
+}
+
+// Run performs one reconciliation and returns the finished run.
+// A run that fails part-way is still recorded, with status FAILED and the error.
+func (r *Reconciler) Run(ctx context.Context, triggeredBy string) (store.ReconciliationRun, error) {
+	started := time.Now()
+
+	run, err := r.queries.CreateReconciliationRun(ctx, store.CreateReconciliationRunParams{
+		ID:          uuid.New(),
+		TriggeredBy: triggeredBy,
+	})
+	if err != nil {
+		return run, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+
+	accountsChecked, balanceDiscrepancies, err := r.reconcileBalances(ctx)
+	var transfersChecked int
+	var transferDiscrepancies []domain.Discrepancy
+	if err == nil {
+		transfersChecked, transferDiscrepancies, err = r.reconcileTransfers(ctx)
+	}
+
+	discrepancies := append(balanceDiscrepancies, transferDiscrepancies...)
+	var finished store.ReconciliationRun
+	if err == nil {
+		finished, err = r.recordResults(ctx, run.ID, accountsChecked, transfersChecked, discrepancies)
+	}
+
+	metrics.ReconciliationLastRunTimestamp.SetToCurrentTime()
+	metrics.ReconciliationLastRunDuration.Set(time.Since(started).Seconds())
+
+	if err != nil {
+		metrics.ReconciliationLastRunSuccess.Set(0)
+		failed, finishErr := r.queries.FinishReconciliationRun(ctx, store.FinishReconciliationRunParams{
+			ID:     run.ID,
+			Status: StatusFailed,
+			Error:  sql.NullString{String: err.Error(), Valid: true},
+		})
+		if finishErr != nil {
+			r.logger.Printf("Failed to record reconciliation run %s failure: %v", run.ID, finishErr)
+			return run, err
+		}
+		return failed, err
+	}
+
+	metrics.ReconciliationLastRunSuccess.Set(1)
+	counts := make(map[domain.DiscrepancyKind]int)
+	for _, d := range discrepancies {
+		counts[d.Kind]++
+	}
+	for _, kind := range domain.DiscrepancyKinds {
+		metrics.ReconciliationDiscrepancies.WithLabelValues(string(kind)).Set(float64(counts[kind]))
+	}
+
+	r.logger.Printf("Reconciliation run %s completed: %d accounts, %d transfers, %d discrepancies",
+		run.ID, accountsChecked, transfersChecked, len(discrepancies))
+	return finished, nil
+}
+
+// reconcileBalances compares journal balances with the stored and read-model balances.
+// The read-model trails the ledger by the events still in flight, so accounts that
+// differ are rechecked after settleDelay and only persistent differences are reported.
+func (r *Reconciler) reconcileBalances(ctx context.Context) (int, []domain.Discrepancy, error) {
+	journal, stored, readModel, err := r.loadBalances(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	accounts := make(map[uuid.UUID]struct{})
+	for key := range journal {
+		accounts[key.AccountID] = struct{}{}
+	}
+
+	discrepancies := domain.CompareBalances(journal, stored, readModel)
+	if len(discrepancies) == 0 {
+		return len(accounts), nil, nil
+	}
+
+	var suspects []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, d := range discrepancies {
+		if !seen[d.AccountID] {
+			seen[d.AccountID] = true
+			suspects = append(suspects, d.AccountID)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	case <-time.After(r.settleDelay):
+	}
+
+	journal, stored, readModel, err = r.loadBalances(ctx, suspects)
+	if err != nil {
+		return 0, nil, fmt.Errorf("recheck: %w", err)
+	}
+	return len(accounts), domain.CompareBalances(journal, stored, readModel), nil
+}
+
+// loadBalances returns the journal, stored and read-model balances, limited to
+// accountIDs when non-nil. The ledger balances count CREDIT as positive and are
+// signed by each account's normal side, as the read-model keeps its balances.
+func (r *Reconciler) loadBalances(ctx context.Context, accountIDs []uuid.UUID) (journal, stored, readModel map[domain.BalanceKey]int64, err error) {
+	journal, stored, err = r.loadLedgerBalances(ctx, accountIDs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load ledger balances: %w", err)
+	}
+	readModel, err = loadReadModelBalances(ctx, r.readModel, accountIDs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load read-model balances: %w", err)
+	}
+	debitNormal, err := loadReadModelDebitNormal(ctx, r.readModel, accountIDs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load read-model account sides: %w", err)
+	}
+	return domain.SignByNormalSide(journal, debitNormal), domain.SignByNormalSide(stored, debitNormal), readModel, nil
+}
+
+// loadLedgerBalances returns the journal and stored balances, limited to accountIDs when non-nil
+func (r *Reconciler) loadLedgerBalances(ctx context.Context, accountIDs []uuid.UUID) (journal, stored map[domain.BalanceKey]int64, err error) {
+	var rows []store.ListLedgerBalancesRow
+	if accountIDs == nil {
+		rows, err = r.queries.ListLedgerBalances(ctx)
+	} else {
+		var filtered []store.ListLedgerBalancesForAccountsRow
+		filtered, err = r.queries.ListLedgerBalancesForAccounts(ctx, accountIDs)
+		for _, row := range filtered {
+			rows = append(rows, store.ListLedgerBalancesRow(row))
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	journal = make(map[domain.BalanceKey]int64, len(rows))
+	stored = make(map[domain.BalanceKey]int64, len(rows))
+	for _, row := range rows {
+		key := domain.BalanceKey{AccountID: row.AccountID, Currency: row.Currency}
+		journal[key] = row.JournalMinor
+		stored[key] = row.StoredMinor
+	}
+	return journal, stored, nil
+}
+
+// reconcileTransfers checks every completed orchestrator transfer against the journal, in batches
+func (r *Reconciler) reconcileTransfers(ctx context.Context) (int, []domain.Discrepancy, error) {
+	var checked int
+	var discrepancies []domain.Discrepancy
+	afterID := uuid.Nil
+
+	for {
+		ids, err := listCompletedTransfers(ctx, r.orchestrator, afterID, r.batchSize)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to list completed transfers: %w", err)
+		}
+		if len(ids) == 0 {
+			return checked, discrepancies, nil
+		}
+
+		counts, err := r.queries.CountPostingsByBatch(ctx, ids)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to count transfer entries: %w", err)
+		}
+		byBatch := make(map[uuid.UUID]store.CountPostingsByBatchRow, len(counts))
+		for _, c := range counts {
+			byBatch[c.BatchID] = c
+		}
+
+		for _, id := range ids {
+			c := byBatch[id]
+			if d := domain.CheckTransferEntries(id, int(c.Postings), int(c.Active)); d != nil {
+				discrepancies = append(discrepancies, *d)
+			}
+		}
+
+		checked += len(ids)
+		afterID = ids[len(ids)-1]
+	}
+}
+
+// recordResults stores the discrepancies and marks the run completed in one transaction
+func (r *Reconciler) recordResults(ctx context.Context, runID uuid.UUID, accountsChecked, transfersChecked int, discrepancies []domain.Discrepancy) (store.ReconciliationRun, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.ReconciliationRun{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := r.queries.WithTx(tx)
+
+	for _, d := range discrepancies {
+		if err := qtx.CreateReconciliationDiscrepancy(ctx, store.CreateReconciliationDiscrepancyParams{
+			RunID:      runID,
+			Kind:       string(d.Kind),
+			AccountID:  uuid.NullUUID{UUID: d.AccountID, Valid: d.AccountID != uuid.Nil},
+			Currency:   sql.NullString{String: d.Currency, Valid: d.Currency != ""},
+			TransferID: uuid.NullUUID{UUID: d.TransferID, Valid: d.TransferID != uuid.Nil},
+			Expected:   d.Expected,
+			Actual:     d.Actual,
+			Detail:     d.Detail,
+		}); err != nil {
+			return store.ReconciliationRun{}, fmt.Errorf("failed to record discrepancy: %w", err)
+		}
+	}
+
+	run, err := qtx.FinishReconciliationRun(ctx, store.FinishReconciliationRunParams{
+		ID:               runID,
+		Status:           StatusCompleted,
+		AccountsChecked:  int32(accountsChecked),
+		TransfersChecked: int32(transfersChecked),
+		DiscrepancyCount: int32(len(discrepancies)),
+	})
+	if err != nil {
+		return store.ReconciliationRun{}, fmt.Errorf("failed to finish reconciliation run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.ReconciliationRun{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return run, nil
+}
@@ -0,0 +1,112 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/amirhf/credit-ledger/services/ledger/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// The read-model and orchestrator databases belong to other services, so they
+// are read with plain SQL against their published schemas rather than sqlc.
+
+const readModelBalances = `
+SELECT account_id, currency, balance_minor FROM balances
+`
+
+const readModelBalancesForAccounts = `
+SELECT account_id, currency, balance_minor FROM balances
+WHERE account_id = ANY($1::uuid[])
+`
+
+// Read-model balances are signed by the account's normal side
+const readModelDebitNormalAccounts = `
+SELECT account_id FROM accounts WHERE normal_side = 'DEBIT'
+`
+
+const readModelDebitNormalAccountsIn = `
+SELECT account_id FROM accounts
+WHERE normal_side = 'DEBIT' AND account_id = ANY($1::uuid[])
+`
+
+// The ledger saga records completion in the state column
+const completedTransfers = `
+SELECT id FROM transfers
+WHERE (state = 'COMPLETED' OR status = 'COMPLETED')
+  AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+// loadReadModelBalances returns the read-model balances, limited to accountIDs when non-nil
+func loadReadModelBalances(ctx context.Context, db *sql.DB, accountIDs []uuid.UUID) (map[domain.BalanceKey]int64, error) {
+	var rows *sql.Rows
+	var err error
+	if accountIDs == nil {
+		rows, err = db.QueryContext(ctx, readModelBalances)
+	} else {
+		rows, err = db.QueryContext(ctx, readModelBalancesForAccounts, pq.Array(accountIDs))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[domain.BalanceKey]int64)
+	for rows.Next() {
+		var key domain.BalanceKey
+		var balanceMinor int64
+		if err := rows.Scan(&key.AccountID, &key.Currency, &balanceMinor); err != nil {
+			return nil, err
+		}
+		balances[key] = balanceMinor
+	}
+	return balances, rows.Err()
+}
+
+// loadReadModelDebitNormal returns the debit-normal accounts known to the read-model,
+// limited to accountIDs when non-nil
+func loadReadModelDebitNormal(ctx context.Context, db *sql.DB, accountIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	var rows *sql.Rows
+	var err error
+	if accountIDs == nil {
+		rows, err = db.QueryContext(ctx, readModelDebitNormalAccounts)
+	} else {
+		rows, err = db.QueryContext(ctx, readModelDebitNormalAccountsIn, pq.Array(accountIDs))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	debitNormal := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var accountID uuid.UUID
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		debitNormal[accountID] = true
+	}
+	return debitNormal, rows.Err()
+}
+
+// listCompletedTransfers returns up to limit completed transfer IDs ordered after afterID
+func listCompletedTransfers(ctx context.Context, db *sql.DB, afterID uuid.UUID, limit int32) ([]uuid.UUID, error) {
+	rows, err := db.QueryContext(ctx, completedTransfers, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
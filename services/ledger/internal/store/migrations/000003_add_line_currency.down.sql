@@ -0,0 +1,6 @@
+-- Remove currency from journal lines
+
+DROP INDEX IF EXISTS idx_journal_lines_account_currency;
+
+ALTER TABLE journal_lines
+  DROP COLUMN IF EXISTS currency;
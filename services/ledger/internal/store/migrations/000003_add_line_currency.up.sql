@@ -0,0 +1,18 @@
+-- Add currency to journal lines
+-- Every line carries the currency of its amount so that multi-currency entries
+-- can be balanced per currency and projected with the correct currency
+
+-- Existing lines were all posted as USD before currency was tracked
+ALTER TABLE journal_lines
+  ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'USD'
+  CHECK (char_length(currency) = 3);
+
+-- New lines must always state their currency explicitly
+ALTER TABLE journal_lines
+  ALTER COLUMN currency DROP DEFAULT;
+
+-- Index for per-account, per-currency lookups
+CREATE INDEX IF NOT EXISTS idx_journal_lines_account_currency
+  ON journal_lines(account_id, currency);
+
+COMMENT ON COLUMN journal_lines.currency IS 'ISO 4217 currency code of amount_minor';
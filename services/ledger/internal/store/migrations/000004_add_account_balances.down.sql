@@ -0,0 +1,2 @@
+-- Remove authoritative account balances
+DROP TABLE IF EXISTS account_balances;
@@ -0,0 +1,26 @@
+-- Authoritative per-account running balances
+-- Rows are locked (SELECT ... FOR UPDATE) while an entry is posted so that
+-- concurrent debits cannot take an account below its balance floor
+
+CREATE TABLE IF NOT EXISTS account_balances (
+    account_id UUID NOT NULL,
+    currency TEXT NOT NULL CHECK (char_length(currency) = 3),
+    balance_minor BIGINT NOT NULL DEFAULT 0,
+    overdraft_limit_minor BIGINT CHECK (overdraft_limit_minor >= 0),
+    overdraft_unlimited BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (account_id, currency)
+);
+
+-- Backfill balances from existing journal lines (CREDIT increases, DEBIT decreases)
+INSERT INTO account_balances (account_id, currency, balance_minor)
+SELECT account_id,
+       currency,
+       SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END)
+FROM journal_lines
+GROUP BY account_id, currency
+ON CONFLICT (account_id, currency) DO NOTHING;
+
+COMMENT ON COLUMN account_balances.balance_minor IS 'Running balance in minor units (CREDIT increases, DEBIT decreases)';
+COMMENT ON COLUMN account_balances.overdraft_limit_minor IS 'Maximum overdraft in minor units; NULL uses the service default';
+COMMENT ON COLUMN account_balances.overdraft_unlimited IS 'Disables the balance floor (funding and system accounts)';
@@ -0,0 +1,4 @@
+-- Remove (batch_id, kind) idempotency key
+CREATE INDEX IF NOT EXISTS idx_journal_entries_batch ON journal_entries(batch_id);
+DROP INDEX IF EXISTS idx_journal_entries_batch_kind;
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS kind;
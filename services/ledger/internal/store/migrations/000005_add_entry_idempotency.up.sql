@@ -0,0 +1,47 @@
+-- Make (batch_id, kind) the idempotency key for journal entries
+-- A batch has at most one posting entry and at most one void entry
+
+ALTER TABLE journal_entries
+  ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'POSTING' CHECK (kind IN ('POSTING', 'VOID'));
+
+-- Classify existing void entries (entries referenced as voided_by)
+UPDATE journal_entries
+SET kind = 'VOID'
+WHERE entry_id IN (SELECT voided_by FROM journal_entries WHERE voided_by IS NOT NULL);
+
+-- Detect duplicates created by retried requests before the unique index existed.
+-- These must be resolved by hand (void the extra entry and remove it) before migrating.
+DO $$
+DECLARE
+  duplicate_batches INT;
+  sample TEXT;
+BEGIN
+  SELECT count(*) INTO duplicate_batches
+  FROM (
+    SELECT batch_id, kind FROM journal_entries
+    GROUP BY batch_id, kind
+    HAVING count(*) > 1
+  ) d;
+
+  IF duplicate_batches > 0 THEN
+    SELECT string_agg(batch_id::text || ' (' || kind || ': ' || entries || ')', ', ') INTO sample
+    FROM (
+      SELECT batch_id, kind, string_agg(entry_id::text, ' ' ORDER BY ts) AS entries
+      FROM journal_entries
+      GROUP BY batch_id, kind
+      HAVING count(*) > 1
+      ORDER BY batch_id
+      LIMIT 20
+    ) d;
+
+    RAISE EXCEPTION 'found % batches with duplicate journal entries: %', duplicate_batches, sample
+      USING HINT = 'Keep the oldest entry per (batch_id, kind), reverse and delete the others, then rerun the migration';
+  END IF;
+END $$;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_batch_kind ON journal_entries(batch_id, kind);
+
+-- Superseded by the unique index (batch_id is its leading column)
+DROP INDEX IF EXISTS idx_journal_entries_batch;
+
+COMMENT ON COLUMN journal_entries.kind IS 'POSTING for original entries, VOID for compensating entries';
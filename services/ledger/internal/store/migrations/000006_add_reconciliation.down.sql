@@ -0,0 +1,3 @@
+-- Remove reconciliation report tables
+DROP TABLE IF EXISTS reconciliation_discrepancies;
+DROP TABLE IF EXISTS reconciliation_runs;
@@ -0,0 +1,33 @@
+-- Reconciliation report: one row per run, one row per discrepancy found
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+  id UUID PRIMARY KEY,
+  status TEXT NOT NULL DEFAULT 'RUNNING' CHECK (status IN ('RUNNING', 'COMPLETED', 'FAILED')),
+  triggered_by TEXT NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ,
+  accounts_checked INT NOT NULL DEFAULT 0,
+  transfers_checked INT NOT NULL DEFAULT 0,
+  discrepancy_count INT NOT NULL DEFAULT 0,
+  error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_started ON reconciliation_runs(started_at DESC);
+
+CREATE TABLE IF NOT EXISTS reconciliation_discrepancies (
+  id BIGSERIAL PRIMARY KEY,
+  run_id UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  account_id UUID,
+  currency TEXT,
+  transfer_id UUID,
+  expected BIGINT NOT NULL,
+  actual BIGINT NOT NULL,
+  detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_discrepancies_run ON reconciliation_discrepancies(run_id, id);
+
+COMMENT ON COLUMN reconciliation_runs.triggered_by IS 'schedule for the in-service job, command for cmd/reconcile';
+COMMENT ON COLUMN reconciliation_discrepancies.expected IS 'Value per the ledger journal: minor units for balances, entry count for transfers';
+COMMENT ON COLUMN reconciliation_discrepancies.actual IS 'Value found in the compared source';
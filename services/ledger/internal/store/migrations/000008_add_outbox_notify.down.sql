@@ -0,0 +1,3 @@
+-- Remove outbox insert notifications
+DROP TRIGGER IF EXISTS outbox_notify ON outbox;
+DROP FUNCTION IF EXISTS notify_outbox();
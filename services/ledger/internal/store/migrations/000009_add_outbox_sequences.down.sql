@@ -0,0 +1,7 @@
+-- Remove per-aggregate event sequences
+DROP TRIGGER IF EXISTS outbox_assign_seq ON outbox;
+DROP FUNCTION IF EXISTS assign_outbox_seq();
+DROP INDEX IF EXISTS idx_outbox_aggregate_seq;
+ALTER TABLE outbox_archive DROP COLUMN IF EXISTS aggregate_seq;
+ALTER TABLE outbox DROP COLUMN IF EXISTS aggregate_seq;
+DROP TABLE IF EXISTS outbox_sequences;
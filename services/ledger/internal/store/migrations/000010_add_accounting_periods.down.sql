@@ -0,0 +1,6 @@
+-- Remove accounting periods
+DROP INDEX IF EXISTS idx_journal_entries_period;
+ALTER TABLE journal_entries
+  DROP COLUMN IF EXISTS adjusts_period_id,
+  DROP COLUMN IF EXISTS period_id;
+DROP TABLE IF EXISTS accounting_periods;
@@ -0,0 +1,27 @@
+-- Accounting periods: postings dated in a soft-closed period are booked in the
+-- next open period with a reference back; postings dated in a locked period are rejected
+
+CREATE TABLE IF NOT EXISTS accounting_periods (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  starts_at TIMESTAMPTZ NOT NULL,
+  ends_at TIMESTAMPTZ NOT NULL,
+  status TEXT NOT NULL DEFAULT 'OPEN' CHECK (status IN ('OPEN', 'SOFT_CLOSED', 'LOCKED')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  status_changed_at TIMESTAMPTZ,
+  CHECK (starts_at < ends_at),
+  CONSTRAINT accounting_periods_no_overlap EXCLUDE USING gist (tstzrange(starts_at, ends_at) WITH &&)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_periods_open ON accounting_periods(starts_at) WHERE status = 'OPEN';
+
+ALTER TABLE journal_entries
+  ADD COLUMN IF NOT EXISTS period_id UUID REFERENCES accounting_periods(id),
+  ADD COLUMN IF NOT EXISTS adjusts_period_id UUID REFERENCES accounting_periods(id);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_period ON journal_entries(period_id);
+
+COMMENT ON COLUMN accounting_periods.ends_at IS 'Exclusive end; a period covers starts_at <= t < ends_at';
+COMMENT ON COLUMN accounting_periods.status_changed_at IS 'Timestamp of the last status change; NULL if never changed';
+COMMENT ON COLUMN journal_entries.period_id IS 'Accounting period the entry is booked in; NULL when its date falls outside every period';
+COMMENT ON COLUMN journal_entries.adjusts_period_id IS 'Closed period this entry corrects when it was moved to the next open period';
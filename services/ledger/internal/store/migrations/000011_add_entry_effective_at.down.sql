@@ -0,0 +1,3 @@
+-- Remove entry effective dates
+DROP INDEX IF EXISTS idx_journal_entries_effective_at;
+ALTER TABLE journal_entries DROP COLUMN IF EXISTS effective_at;
@@ -0,0 +1,14 @@
+-- Effective (value) date of journal entries, next to the ts booking time.
+-- Late-arriving postings are dated to when they happened; accounting periods
+-- and reports use the effective date.
+
+ALTER TABLE journal_entries ADD COLUMN IF NOT EXISTS effective_at TIMESTAMPTZ;
+
+-- Entries booked before value dating take effect when they were booked
+UPDATE journal_entries SET effective_at = ts WHERE effective_at IS NULL;
+
+ALTER TABLE journal_entries ALTER COLUMN effective_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_effective_at ON journal_entries(effective_at);
+
+COMMENT ON COLUMN journal_entries.effective_at IS 'Value date the entry takes effect; ts is when it was booked';
@@ -0,0 +1,3 @@
+-- Remove credit lots
+DROP TABLE IF EXISTS lot_consumptions;
+DROP TABLE IF EXISTS credit_lots;
@@ -0,0 +1,42 @@
+-- Credit lots: grants of credit with an amount, an optional expiry and a source.
+-- Debits draw lots down in FIFO or soonest-expiry order; the expiry worker posts
+-- the remainder of a lapsed lot to a breakage account.
+
+CREATE TABLE IF NOT EXISTS credit_lots (
+  id UUID PRIMARY KEY,
+  account_id UUID NOT NULL,
+  currency TEXT NOT NULL,
+  source TEXT NOT NULL DEFAULT '',
+  grant_entry_id UUID NOT NULL REFERENCES journal_entries(entry_id),
+  granted_minor BIGINT NOT NULL CHECK (granted_minor > 0),
+  remaining_minor BIGINT NOT NULL CHECK (remaining_minor >= 0),
+  expired_minor BIGINT NOT NULL DEFAULT 0,
+  revoked_minor BIGINT NOT NULL DEFAULT 0,
+  granted_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_lots_open ON credit_lots(account_id, currency) WHERE remaining_minor > 0;
+CREATE INDEX IF NOT EXISTS idx_credit_lots_expiring ON credit_lots(expires_at) WHERE remaining_minor > 0;
+CREATE INDEX IF NOT EXISTS idx_credit_lots_grant_entry ON credit_lots(grant_entry_id);
+
+-- Amounts taken from lots by debits and expiries, so a void can give them back
+CREATE TABLE IF NOT EXISTS lot_consumptions (
+  id BIGSERIAL PRIMARY KEY,
+  lot_id UUID NOT NULL REFERENCES credit_lots(id),
+  entry_id UUID NOT NULL REFERENCES journal_entries(entry_id),
+  amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+  reason TEXT NOT NULL CHECK (reason IN ('DEBIT', 'EXPIRY')),
+  restored_by UUID REFERENCES journal_entries(entry_id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_consumptions_entry ON lot_consumptions(entry_id);
+CREATE INDEX IF NOT EXISTS idx_lot_consumptions_restored_by ON lot_consumptions(restored_by) WHERE restored_by IS NOT NULL;
+
+COMMENT ON COLUMN credit_lots.granted_at IS 'Effective date of the granting entry; FIFO consumes the oldest grant first';
+COMMENT ON COLUMN credit_lots.expires_at IS 'When the remainder lapses to breakage; NULL never expires';
+COMMENT ON COLUMN credit_lots.revoked_minor IS 'Remainder dropped when the granting entry was voided';
+COMMENT ON COLUMN lot_consumptions.restored_by IS 'Entry ID of the void entry that gave this amount back to the lot';
@@ -0,0 +1,2 @@
+-- Remove account types
+DROP TABLE IF EXISTS account_types;
@@ -0,0 +1,11 @@
+-- Account types and normal sides, projected from ledger.account.v1 AccountCreated events.
+-- Balance floors apply in the account's own sign; accounts not listed are credit-normal.
+
+CREATE TABLE IF NOT EXISTS account_types (
+  account_id UUID PRIMARY KEY,
+  account_type TEXT NOT NULL CHECK (account_type IN ('ASSET', 'LIABILITY', 'EQUITY', 'REVENUE', 'EXPENSE')),
+  normal_side TEXT NOT NULL CHECK (normal_side IN ('DEBIT', 'CREDIT')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON COLUMN account_types.normal_side IS 'Side on which the balance increases: DEBIT for ASSET and EXPENSE, CREDIT otherwise';
@@ -12,6 +12,57 @@ import (
 	"github.com/google/uuid"
 )
 
+type AccountBalance struct {
+	AccountID uuid.UUID
+	Currency  string
+	// Running balance in minor units (CREDIT increases, DEBIT decreases)
+	BalanceMinor int64
+	// Maximum overdraft in minor units; NULL uses the service default
+	OverdraftLimitMinor sql.NullInt64
+	// Disables the balance floor (funding and system accounts)
+	OverdraftUnlimited bool
+	UpdatedAt          time.Time
+}
+
+type AccountType struct {
+	AccountID   uuid.UUID
+	AccountType string
+	// Side on which the balance increases: DEBIT for ASSET and EXPENSE, CREDIT otherwise
+	NormalSide string
+	CreatedAt  time.Time
+}
+
+type AccountingPeriod struct {
+	ID       uuid.UUID
+	Name     string
+	StartsAt time.Time
+	// Exclusive end; a period covers starts_at <= t < ends_at
+	EndsAt    time.Time
+	Status    string
+	CreatedAt time.Time
+	// Timestamp of the last status change; NULL if never changed
+	StatusChangedAt sql.NullTime
+}
+
+type CreditLot struct {
+	ID             uuid.UUID
+	AccountID      uuid.UUID
+	Currency       string
+	Source         string
+	GrantEntryID   uuid.UUID
+	GrantedMinor   int64
+	RemainingMinor int64
+	ExpiredMinor   int64
+	// Remainder dropped when the granting entry was voided
+	RevokedMinor int64
+	// Effective date of the granting entry; FIFO consumes the oldest grant first
+	GrantedAt time.Time
+	// When the remainder lapses to breakage; NULL never expires
+	ExpiresAt sql.NullTime
+	RevokedAt sql.NullTime
+	CreatedAt time.Time
+}
+
 type JournalEntry struct {
 	EntryID uuid.UUID
 	BatchID uuid.UUID
@@ -22,6 +73,14 @@ type JournalEntry struct {
 	VoidedAt sql.NullTime
 	// Reason for voiding (e.g., transfer_failed, transfer_rollback)
 	VoidReason sql.NullString
+	// POSTING for original entries, VOID for compensating entries
+	Kind string
+	// Accounting period the entry is booked in; NULL when its date falls outside every period
+	PeriodID uuid.NullUUID
+	// Closed period this entry corrects when it was moved to the next open period
+	AdjustsPeriodID uuid.NullUUID
+	// Value date the entry takes effect; ts is when it was booked
+	EffectiveAt time.Time
 }
 
 type JournalLine struct {
@@ -30,6 +89,19 @@ type JournalLine struct {
 	AccountID   uuid.UUID
 	AmountMinor int64
 	Side        string
+	// ISO 4217 currency code of amount_minor
+	Currency string
+}
+
+type LotConsumption struct {
+	ID          int64
+	LotID       uuid.UUID
+	EntryID     uuid.UUID
+	AmountMinor int64
+	Reason      string
+	// Entry ID of the void entry that gave this amount back to the lot
+	RestoredBy uuid.NullUUID
+	CreatedAt  time.Time
 }
 
 type Outbox struct {
@@ -41,4 +113,45 @@ type Outbox struct {
 	Headers       json.RawMessage
 	CreatedAt     time.Time
 	SentAt        sql.NullTime
+	AggregateSeq  int64
+}
+
+type OutboxArchive struct {
+	ID            uuid.UUID
+	AggregateType string
+	AggregateID   uuid.UUID
+	EventType     string
+	Payload       []byte
+	Headers       json.RawMessage
+	CreatedAt     time.Time
+	SentAt        time.Time
+	ArchivedAt    time.Time
+	AggregateSeq  sql.NullInt64
+}
+
+type ReconciliationDiscrepancy struct {
+	ID         int64
+	RunID      uuid.UUID
+	Kind       string
+	AccountID  uuid.NullUUID
+	Currency   sql.NullString
+	TransferID uuid.NullUUID
+	// Value per the ledger journal: minor units for balances, entry count for transfers
+	Expected int64
+	// Value found in the compared source
+	Actual int64
+	Detail string
+}
+
+type ReconciliationRun struct {
+	ID     uuid.UUID
+	Status string
+	// schedule for the in-service job, command for cmd/reconcile
+	TriggeredBy      string
+	StartedAt        time.Time
+	FinishedAt       sql.NullTime
+	AccountsChecked  int32
+	TransfersChecked int32
+	DiscrepancyCount int32
+	Error            sql.NullString
 }
@@ -1,14 +1,20 @@
 -- Journal Entry Operations
 
 -- name: CreateJournalEntry :one
-INSERT INTO journal_entries (entry_id, batch_id, ts)
-VALUES ($1, $2, $3)
+-- Returns no rows when an entry with the same (batch_id, kind) already exists
+INSERT INTO journal_entries (entry_id, batch_id, ts, effective_at, kind, period_id, adjusts_period_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (batch_id, kind) DO NOTHING
 RETURNING *;
 
 -- name: GetJournalEntry :one
 SELECT * FROM journal_entries
 WHERE entry_id = $1;
 
+-- name: GetEntryByBatchAndKind :one
+SELECT * FROM journal_entries
+WHERE batch_id = $1 AND kind = $2;
+
 -- name: GetJournalEntriesByBatch :many
 SELECT * FROM journal_entries
 WHERE batch_id = $1
@@ -17,8 +23,8 @@ ORDER BY ts;
 -- Journal Line Operations
 
 -- name: CreateJournalLine :one
-INSERT INTO journal_lines (entry_id, account_id, amount_minor, side)
-VALUES ($1, $2, $3, $4)
+INSERT INTO journal_lines (entry_id, account_id, amount_minor, side, currency)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING *;
 
 -- name: GetJournalLinesByEntry :many
@@ -67,12 +73,14 @@ WHERE entry_id = $1;
 -- name: GetEntryByBatch :one
 SELECT * FROM journal_entries
 WHERE batch_id = $1
+  AND kind = 'POSTING'
 ORDER BY ts ASC
 LIMIT 1;
 
 -- name: GetActiveEntryByBatch :one
 SELECT * FROM journal_entries
 WHERE batch_id = $1
+  AND kind = 'POSTING'
   AND voided_by IS NULL
 LIMIT 1;
 
@@ -81,3 +89,297 @@ SELECT EXISTS(
   SELECT 1 FROM journal_entries
   WHERE entry_id = $1 AND voided_by IS NOT NULL
 ) AS is_voided;
+
+-- Account Balance Operations
+
+-- name: EnsureAccountBalance :exec
+INSERT INTO account_balances (account_id, currency)
+VALUES ($1, $2)
+ON CONFLICT (account_id, currency) DO NOTHING;
+
+-- name: GetAccountBalanceForUpdate :one
+SELECT * FROM account_balances
+WHERE account_id = $1 AND currency = $2
+FOR UPDATE;
+
+-- name: ApplyBalanceDelta :exec
+UPDATE account_balances
+SET balance_minor = balance_minor + sqlc.arg('delta_minor'), updated_at = now()
+WHERE account_id = sqlc.arg('account_id') AND currency = sqlc.arg('currency');
+
+-- name: GetAccountBalances :many
+SELECT * FROM account_balances
+WHERE account_id = $1
+ORDER BY currency;
+
+-- name: SetOverdraftLimit :one
+INSERT INTO account_balances (account_id, currency, overdraft_limit_minor, overdraft_unlimited)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id, currency) DO UPDATE
+SET overdraft_limit_minor = EXCLUDED.overdraft_limit_minor,
+    overdraft_unlimited = EXCLUDED.overdraft_unlimited,
+    updated_at = now()
+RETURNING *;
+
+-- Reconciliation
+
+-- name: ListLedgerBalances :many
+-- Recomputes every account balance from journal_lines alongside the stored running balance
+SELECT COALESCE(j.account_id, b.account_id)::uuid AS account_id,
+       COALESCE(j.currency, b.currency)::text AS currency,
+       COALESCE(j.balance_minor, 0)::bigint AS journal_minor,
+       COALESCE(b.balance_minor, 0)::bigint AS stored_minor
+FROM (
+  SELECT account_id, currency,
+         SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END) AS balance_minor
+  FROM journal_lines
+  GROUP BY account_id, currency
+) j
+FULL OUTER JOIN account_balances b ON b.account_id = j.account_id AND b.currency = j.currency
+ORDER BY 1, 2;
+
+-- name: ListLedgerBalancesForAccounts :many
+SELECT COALESCE(j.account_id, b.account_id)::uuid AS account_id,
+       COALESCE(j.currency, b.currency)::text AS currency,
+       COALESCE(j.balance_minor, 0)::bigint AS journal_minor,
+       COALESCE(b.balance_minor, 0)::bigint AS stored_minor
+FROM (
+  SELECT account_id, currency,
+         SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END) AS balance_minor
+  FROM journal_lines
+  WHERE account_id = ANY(sqlc.arg('account_ids')::uuid[])
+  GROUP BY account_id, currency
+) j
+FULL OUTER JOIN (
+  SELECT * FROM account_balances
+  WHERE account_id = ANY(sqlc.arg('account_ids')::uuid[])
+) b ON b.account_id = j.account_id AND b.currency = j.currency
+ORDER BY 1, 2;
+
+-- name: CountPostingsByBatch :many
+SELECT batch_id,
+       count(*)::int AS postings,
+       (count(*) FILTER (WHERE voided_by IS NULL))::int AS active
+FROM journal_entries
+WHERE kind = 'POSTING'
+  AND batch_id = ANY(sqlc.arg('batch_ids')::uuid[])
+GROUP BY batch_id;
+
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (id, triggered_by)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: FinishReconciliationRun :one
+UPDATE reconciliation_runs
+SET status = $2,
+    finished_at = now(),
+    accounts_checked = $3,
+    transfers_checked = $4,
+    discrepancy_count = $5,
+    error = $6
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateReconciliationDiscrepancy :exec
+INSERT INTO reconciliation_discrepancies (run_id, kind, account_id, currency, transfer_id, expected, actual, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY id;
+
+-- Reports
+
+-- name: GetTrialBalance :many
+-- Debit and credit totals of every account per currency for entries reported up to as_of.
+-- Entries correcting a closed period are reported at the start of the period they were booked in.
+SELECT jl.account_id, jl.currency,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'DEBIT'), 0)::bigint AS debit_minor,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'CREDIT'), 0)::bigint AS credit_minor
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE COALESCE(bp.starts_at, je.effective_at) <= sqlc.arg('as_of')
+GROUP BY jl.account_id, jl.currency
+ORDER BY jl.account_id, jl.currency;
+
+-- name: GetAccountTotals :many
+-- Debit and credit totals of an account per currency for entries reported in [from_ts, to_ts).
+-- Entries correcting a closed period are reported at the start of the period they were booked in.
+SELECT jl.currency,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'DEBIT'), 0)::bigint AS debit_minor,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'CREDIT'), 0)::bigint AS credit_minor
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE jl.account_id = sqlc.arg('account_id')
+  AND COALESCE(bp.starts_at, je.effective_at) >= sqlc.arg('from_ts')
+  AND COALESCE(bp.starts_at, je.effective_at) < sqlc.arg('to_ts')
+GROUP BY jl.currency
+ORDER BY jl.currency;
+
+-- name: ListAccountLines :many
+-- An account's journal lines for entries reported in [from_ts, to_ts), oldest first.
+-- Entries correcting a closed period are reported at the start of the period they were booked in.
+SELECT jl.id, jl.entry_id, je.batch_id, je.kind, je.ts, je.effective_at,
+       COALESCE(bp.starts_at, je.effective_at)::timestamptz AS reported_at,
+       jl.side, jl.amount_minor, jl.currency
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE jl.account_id = sqlc.arg('account_id')
+  AND COALESCE(bp.starts_at, je.effective_at) >= sqlc.arg('from_ts')
+  AND COALESCE(bp.starts_at, je.effective_at) < sqlc.arg('to_ts')
+ORDER BY reported_at, je.effective_at, jl.id
+LIMIT sqlc.arg('limit');
+
+-- Accounting Periods
+
+-- name: CreatePeriod :one
+INSERT INTO accounting_periods (id, name, starts_at, ends_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetPeriod :one
+SELECT * FROM accounting_periods
+WHERE id = $1;
+
+-- name: GetPeriodForUpdate :one
+SELECT * FROM accounting_periods
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetPeriodForShare :one
+-- Share-locks the period so its status cannot change until the caller commits
+SELECT * FROM accounting_periods
+WHERE id = $1
+FOR SHARE;
+
+-- name: GetPeriodAt :one
+-- The period containing ts, share-locked
+SELECT * FROM accounting_periods
+WHERE starts_at <= sqlc.arg('ts') AND ends_at > sqlc.arg('ts')
+FOR SHARE;
+
+-- name: GetNextOpenPeriod :one
+-- The first open period starting at or after ts, share-locked
+SELECT * FROM accounting_periods
+WHERE starts_at >= sqlc.arg('ts') AND status = 'OPEN'
+ORDER BY starts_at
+LIMIT 1
+FOR SHARE;
+
+-- name: ListPeriods :many
+SELECT * FROM accounting_periods
+ORDER BY starts_at;
+
+-- name: UpdatePeriodStatus :one
+UPDATE accounting_periods
+SET status = $2, status_changed_at = $3
+WHERE id = $1
+RETURNING *;
+
+-- Credit Lots
+
+-- name: CreateLot :one
+INSERT INTO credit_lots (id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, granted_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8)
+RETURNING *;
+
+-- name: GetLotForUpdate :one
+SELECT * FROM credit_lots
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListOpenLotsForUpdate :many
+-- Lots of an account with a remainder, locked so concurrent debits draw them down one at a time
+SELECT * FROM credit_lots
+WHERE account_id = $1 AND currency = $2 AND remaining_minor > 0
+ORDER BY granted_at, id
+FOR UPDATE;
+
+-- name: ListLotsByGrantEntry :many
+-- Every lot an entry granted, revoked ones included
+SELECT * FROM credit_lots
+WHERE grant_entry_id = $1
+ORDER BY id;
+
+-- name: ListLotsByGrantEntryForUpdate :many
+SELECT * FROM credit_lots
+WHERE grant_entry_id = $1 AND revoked_at IS NULL
+ORDER BY id
+FOR UPDATE;
+
+-- name: ListExpiredLots :many
+-- Lots whose remainder has lapsed by as_of, soonest expiry first
+SELECT id FROM credit_lots
+WHERE remaining_minor > 0 AND expires_at <= sqlc.arg('as_of')
+ORDER BY expires_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListLapsedLotTotals :many
+-- What remains of an account's lots lapsed by as_of, per currency, until the expiry worker posts it to breakage
+SELECT currency, COALESCE(SUM(remaining_minor), 0)::bigint AS lapsed_minor
+FROM credit_lots
+WHERE account_id = sqlc.arg('account_id') AND remaining_minor > 0 AND expires_at <= sqlc.arg('as_of')
+GROUP BY currency
+ORDER BY currency;
+
+-- name: ConsumeLot :exec
+UPDATE credit_lots
+SET remaining_minor = remaining_minor - sqlc.arg('amount_minor')
+WHERE id = sqlc.arg('id');
+
+-- name: RestoreLot :execrows
+-- Returns 0 when the lot was revoked, so nothing is given back to it
+UPDATE credit_lots
+SET remaining_minor = remaining_minor + sqlc.arg('amount_minor')
+WHERE id = sqlc.arg('id') AND revoked_at IS NULL;
+
+-- name: ExpireLot :exec
+UPDATE credit_lots
+SET expired_minor = expired_minor + remaining_minor, remaining_minor = 0
+WHERE id = $1;
+
+-- name: RevokeLot :one
+UPDATE credit_lots
+SET revoked_minor = remaining_minor, remaining_minor = 0, revoked_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateLotConsumption :exec
+INSERT INTO lot_consumptions (lot_id, entry_id, amount_minor, reason)
+VALUES ($1, $2, $3, $4);
+
+-- name: ListLotConsumptionsByEntry :many
+-- Amounts an entry took from lots that no void has given back yet, locked with their lots
+SELECT c.id, c.lot_id, c.amount_minor, c.reason, l.account_id, l.currency
+FROM lot_consumptions c
+JOIN credit_lots l ON l.id = c.lot_id
+WHERE c.entry_id = $1 AND c.restored_by IS NULL
+ORDER BY c.id
+FOR UPDATE OF c, l;
+
+-- name: MarkLotConsumptionRestored :exec
+UPDATE lot_consumptions
+SET restored_by = $2
+WHERE id = $1;
+
+-- Account Types
+
+-- name: CreateAccountType :exec
+-- An account's type never changes, so replayed AccountCreated events are no-ops
+INSERT INTO account_types (account_id, account_type, normal_side)
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id) DO NOTHING;
+
+-- name: ListDebitNormalAccounts :many
+-- The accounts among account_ids whose balance increases with debits
+SELECT account_id FROM account_types
+WHERE account_id = ANY(sqlc.arg('account_ids')::uuid[]) AND normal_side = 'DEBIT';
@@ -12,24 +12,129 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createJournalEntry = `-- name: CreateJournalEntry :one
+const applyBalanceDelta = `-- name: ApplyBalanceDelta :exec
+UPDATE account_balances
+SET balance_minor = balance_minor + $1, updated_at = now()
+WHERE account_id = $2 AND currency = $3
+`
+
+type ApplyBalanceDeltaParams struct {
+	DeltaMinor int64
+	AccountID  uuid.UUID
+	Currency   string
+}
+
+func (q *Queries) ApplyBalanceDelta(ctx context.Context, arg ApplyBalanceDeltaParams) error {
+	_, err := q.db.ExecContext(ctx, applyBalanceDelta, arg.DeltaMinor, arg.AccountID, arg.Currency)
+	return err
+}
+
+const consumeLot = `-- name: ConsumeLot :exec
+UPDATE credit_lots
+SET remaining_minor = remaining_minor - $1
+WHERE id = $2
+`
+
+type ConsumeLotParams struct {
+	AmountMinor int64
+	ID          uuid.UUID
+}
+
+func (q *Queries) ConsumeLot(ctx context.Context, arg ConsumeLotParams) error {
+	_, err := q.db.ExecContext(ctx, consumeLot, arg.AmountMinor, arg.ID)
+	return err
+}
+
+const countPostingsByBatch = `-- name: CountPostingsByBatch :many
+SELECT batch_id,
+       count(*)::int AS postings,
+       (count(*) FILTER (WHERE voided_by IS NULL))::int AS active
+FROM journal_entries
+WHERE kind = 'POSTING'
+  AND batch_id = ANY($1::uuid[])
+GROUP BY batch_id
+`
+
+type CountPostingsByBatchRow struct {
+	BatchID  uuid.UUID
+	Postings int32
+	Active   int32
+}
+
+func (q *Queries) CountPostingsByBatch(ctx context.Context, batchIds []uuid.UUID) ([]CountPostingsByBatchRow, error) {
+	rows, err := q.db.QueryContext(ctx, countPostingsByBatch, pq.Array(batchIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountPostingsByBatchRow
+	for rows.Next() {
+		var i CountPostingsByBatchRow
+		if err := rows.Scan(&i.BatchID, &i.Postings, &i.Active); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
 
-INSERT INTO journal_entries (entry_id, batch_id, ts)
+const createAccountType = `-- name: CreateAccountType :exec
+INSERT INTO account_types (account_id, account_type, normal_side)
 VALUES ($1, $2, $3)
-RETURNING entry_id, batch_id, ts, voided_by, voided_at, void_reason
+ON CONFLICT (account_id) DO NOTHING
+`
+
+type CreateAccountTypeParams struct {
+	AccountID   uuid.UUID
+	AccountType string
+	NormalSide  string
+}
+
+// An account's type never changes, so replayed AccountCreated events are no-ops
+func (q *Queries) CreateAccountType(ctx context.Context, arg CreateAccountTypeParams) error {
+	_, err := q.db.ExecContext(ctx, createAccountType, arg.AccountID, arg.AccountType, arg.NormalSide)
+	return err
+}
+
+const createJournalEntry = `-- name: CreateJournalEntry :one
+
+INSERT INTO journal_entries (entry_id, batch_id, ts, effective_at, kind, period_id, adjusts_period_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (batch_id, kind) DO NOTHING
+RETURNING entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at
 `
 
 type CreateJournalEntryParams struct {
-	EntryID uuid.UUID
-	BatchID uuid.UUID
-	Ts      time.Time
+	EntryID         uuid.UUID
+	BatchID         uuid.UUID
+	Ts              time.Time
+	EffectiveAt     time.Time
+	Kind            string
+	PeriodID        uuid.NullUUID
+	AdjustsPeriodID uuid.NullUUID
 }
 
 // Journal Entry Operations
+// Returns no rows when an entry with the same (batch_id, kind) already exists
 func (q *Queries) CreateJournalEntry(ctx context.Context, arg CreateJournalEntryParams) (JournalEntry, error) {
-	row := q.db.QueryRowContext(ctx, createJournalEntry, arg.EntryID, arg.BatchID, arg.Ts)
+	row := q.db.QueryRowContext(ctx, createJournalEntry,
+		arg.EntryID,
+		arg.BatchID,
+		arg.Ts,
+		arg.EffectiveAt,
+		arg.Kind,
+		arg.PeriodID,
+		arg.AdjustsPeriodID,
+	)
 	var i JournalEntry
 	err := row.Scan(
 		&i.EntryID,
@@ -38,15 +143,19 @@ func (q *Queries) CreateJournalEntry(ctx context.Context, arg CreateJournalEntry
 		&i.VoidedBy,
 		&i.VoidedAt,
 		&i.VoidReason,
+		&i.Kind,
+		&i.PeriodID,
+		&i.AdjustsPeriodID,
+		&i.EffectiveAt,
 	)
 	return i, err
 }
 
 const createJournalLine = `-- name: CreateJournalLine :one
 
-INSERT INTO journal_lines (entry_id, account_id, amount_minor, side)
-VALUES ($1, $2, $3, $4)
-RETURNING id, entry_id, account_id, amount_minor, side
+INSERT INTO journal_lines (entry_id, account_id, amount_minor, side, currency)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, entry_id, account_id, amount_minor, side, currency
 `
 
 type CreateJournalLineParams struct {
@@ -54,6 +163,7 @@ type CreateJournalLineParams struct {
 	AccountID   uuid.UUID
 	AmountMinor int64
 	Side        string
+	Currency    string
 }
 
 // Journal Line Operations
@@ -63,6 +173,7 @@ func (q *Queries) CreateJournalLine(ctx context.Context, arg CreateJournalLinePa
 		arg.AccountID,
 		arg.AmountMinor,
 		arg.Side,
+		arg.Currency,
 	)
 	var i JournalLine
 	err := row.Scan(
@@ -71,15 +182,85 @@ func (q *Queries) CreateJournalLine(ctx context.Context, arg CreateJournalLinePa
 		&i.AccountID,
 		&i.AmountMinor,
 		&i.Side,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const createLot = `-- name: CreateLot :one
+INSERT INTO credit_lots (id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, granted_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8)
+RETURNING id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at
+`
+
+type CreateLotParams struct {
+	ID           uuid.UUID
+	AccountID    uuid.UUID
+	Currency     string
+	Source       string
+	GrantEntryID uuid.UUID
+	GrantedMinor int64
+	GrantedAt    time.Time
+	ExpiresAt    sql.NullTime
+}
+
+func (q *Queries) CreateLot(ctx context.Context, arg CreateLotParams) (CreditLot, error) {
+	row := q.db.QueryRowContext(ctx, createLot,
+		arg.ID,
+		arg.AccountID,
+		arg.Currency,
+		arg.Source,
+		arg.GrantEntryID,
+		arg.GrantedMinor,
+		arg.GrantedAt,
+		arg.ExpiresAt,
+	)
+	var i CreditLot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Currency,
+		&i.Source,
+		&i.GrantEntryID,
+		&i.GrantedMinor,
+		&i.RemainingMinor,
+		&i.ExpiredMinor,
+		&i.RevokedMinor,
+		&i.GrantedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const createLotConsumption = `-- name: CreateLotConsumption :exec
+INSERT INTO lot_consumptions (lot_id, entry_id, amount_minor, reason)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateLotConsumptionParams struct {
+	LotID       uuid.UUID
+	EntryID     uuid.UUID
+	AmountMinor int64
+	Reason      string
+}
+
+func (q *Queries) CreateLotConsumption(ctx context.Context, arg CreateLotConsumptionParams) error {
+	_, err := q.db.ExecContext(ctx, createLotConsumption,
+		arg.LotID,
+		arg.EntryID,
+		arg.AmountMinor,
+		arg.Reason,
+	)
+	return err
+}
+
 const createOutboxEvent = `-- name: CreateOutboxEvent :one
 
 INSERT INTO outbox (id, aggregate_type, aggregate_id, event_type, payload, headers, created_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq
 `
 
 type CreateOutboxEventParams struct {
@@ -113,13 +294,292 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
+	)
+	return i, err
+}
+
+const createPeriod = `-- name: CreatePeriod :one
+INSERT INTO accounting_periods (id, name, starts_at, ends_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, starts_at, ends_at, status, created_at, status_changed_at
+`
+
+type CreatePeriodParams struct {
+	ID       uuid.UUID
+	Name     string
+	StartsAt time.Time
+	EndsAt   time.Time
+}
+
+func (q *Queries) CreatePeriod(ctx context.Context, arg CreatePeriodParams) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, createPeriod,
+		arg.ID,
+		arg.Name,
+		arg.StartsAt,
+		arg.EndsAt,
+	)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const createReconciliationDiscrepancy = `-- name: CreateReconciliationDiscrepancy :exec
+INSERT INTO reconciliation_discrepancies (run_id, kind, account_id, currency, transfer_id, expected, actual, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type CreateReconciliationDiscrepancyParams struct {
+	RunID      uuid.UUID
+	Kind       string
+	AccountID  uuid.NullUUID
+	Currency   sql.NullString
+	TransferID uuid.NullUUID
+	Expected   int64
+	Actual     int64
+	Detail     string
+}
+
+func (q *Queries) CreateReconciliationDiscrepancy(ctx context.Context, arg CreateReconciliationDiscrepancyParams) error {
+	_, err := q.db.ExecContext(ctx, createReconciliationDiscrepancy,
+		arg.RunID,
+		arg.Kind,
+		arg.AccountID,
+		arg.Currency,
+		arg.TransferID,
+		arg.Expected,
+		arg.Actual,
+		arg.Detail,
+	)
+	return err
+}
+
+const createReconciliationRun = `-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs (id, triggered_by)
+VALUES ($1, $2)
+RETURNING id, status, triggered_by, started_at, finished_at, accounts_checked, transfers_checked, discrepancy_count, error
+`
+
+type CreateReconciliationRunParams struct {
+	ID          uuid.UUID
+	TriggeredBy string
+}
+
+func (q *Queries) CreateReconciliationRun(ctx context.Context, arg CreateReconciliationRunParams) (ReconciliationRun, error) {
+	row := q.db.QueryRowContext(ctx, createReconciliationRun, arg.ID, arg.TriggeredBy)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.AccountsChecked,
+		&i.TransfersChecked,
+		&i.DiscrepancyCount,
+		&i.Error,
+	)
+	return i, err
+}
+
+const ensureAccountBalance = `-- name: EnsureAccountBalance :exec
+
+INSERT INTO account_balances (account_id, currency)
+VALUES ($1, $2)
+ON CONFLICT (account_id, currency) DO NOTHING
+`
+
+type EnsureAccountBalanceParams struct {
+	AccountID uuid.UUID
+	Currency  string
+}
+
+// Account Balance Operations
+func (q *Queries) EnsureAccountBalance(ctx context.Context, arg EnsureAccountBalanceParams) error {
+	_, err := q.db.ExecContext(ctx, ensureAccountBalance, arg.AccountID, arg.Currency)
+	return err
+}
+
+const expireLot = `-- name: ExpireLot :exec
+UPDATE credit_lots
+SET expired_minor = expired_minor + remaining_minor, remaining_minor = 0
+WHERE id = $1
+`
+
+func (q *Queries) ExpireLot(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, expireLot, id)
+	return err
+}
+
+const finishReconciliationRun = `-- name: FinishReconciliationRun :one
+UPDATE reconciliation_runs
+SET status = $2,
+    finished_at = now(),
+    accounts_checked = $3,
+    transfers_checked = $4,
+    discrepancy_count = $5,
+    error = $6
+WHERE id = $1
+RETURNING id, status, triggered_by, started_at, finished_at, accounts_checked, transfers_checked, discrepancy_count, error
+`
+
+type FinishReconciliationRunParams struct {
+	ID               uuid.UUID
+	Status           string
+	AccountsChecked  int32
+	TransfersChecked int32
+	DiscrepancyCount int32
+	Error            sql.NullString
+}
+
+func (q *Queries) FinishReconciliationRun(ctx context.Context, arg FinishReconciliationRunParams) (ReconciliationRun, error) {
+	row := q.db.QueryRowContext(ctx, finishReconciliationRun,
+		arg.ID,
+		arg.Status,
+		arg.AccountsChecked,
+		arg.TransfersChecked,
+		arg.DiscrepancyCount,
+		arg.Error,
+	)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.AccountsChecked,
+		&i.TransfersChecked,
+		&i.DiscrepancyCount,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getAccountBalanceForUpdate = `-- name: GetAccountBalanceForUpdate :one
+SELECT account_id, currency, balance_minor, overdraft_limit_minor, overdraft_unlimited, updated_at FROM account_balances
+WHERE account_id = $1 AND currency = $2
+FOR UPDATE
+`
+
+type GetAccountBalanceForUpdateParams struct {
+	AccountID uuid.UUID
+	Currency  string
+}
+
+func (q *Queries) GetAccountBalanceForUpdate(ctx context.Context, arg GetAccountBalanceForUpdateParams) (AccountBalance, error) {
+	row := q.db.QueryRowContext(ctx, getAccountBalanceForUpdate, arg.AccountID, arg.Currency)
+	var i AccountBalance
+	err := row.Scan(
+		&i.AccountID,
+		&i.Currency,
+		&i.BalanceMinor,
+		&i.OverdraftLimitMinor,
+		&i.OverdraftUnlimited,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
+const getAccountBalances = `-- name: GetAccountBalances :many
+SELECT account_id, currency, balance_minor, overdraft_limit_minor, overdraft_unlimited, updated_at FROM account_balances
+WHERE account_id = $1
+ORDER BY currency
+`
+
+func (q *Queries) GetAccountBalances(ctx context.Context, accountID uuid.UUID) ([]AccountBalance, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountBalances, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountBalance
+	for rows.Next() {
+		var i AccountBalance
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.BalanceMinor,
+			&i.OverdraftLimitMinor,
+			&i.OverdraftUnlimited,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAccountTotals = `-- name: GetAccountTotals :many
+SELECT jl.currency,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'DEBIT'), 0)::bigint AS debit_minor,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'CREDIT'), 0)::bigint AS credit_minor
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE jl.account_id = $1
+  AND COALESCE(bp.starts_at, je.effective_at) >= $2
+  AND COALESCE(bp.starts_at, je.effective_at) < $3
+GROUP BY jl.currency
+ORDER BY jl.currency
+`
+
+type GetAccountTotalsParams struct {
+	AccountID uuid.UUID
+	FromTs    time.Time
+	ToTs      time.Time
+}
+
+type GetAccountTotalsRow struct {
+	Currency    string
+	DebitMinor  int64
+	CreditMinor int64
+}
+
+// Debit and credit totals of an account per currency for entries reported in [from_ts, to_ts).
+// Entries correcting a closed period are reported at the start of the period they were booked in.
+func (q *Queries) GetAccountTotals(ctx context.Context, arg GetAccountTotalsParams) ([]GetAccountTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getAccountTotals, arg.AccountID, arg.FromTs, arg.ToTs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAccountTotalsRow
+	for rows.Next() {
+		var i GetAccountTotalsRow
+		if err := rows.Scan(&i.Currency, &i.DebitMinor, &i.CreditMinor); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getActiveEntryByBatch = `-- name: GetActiveEntryByBatch :one
-SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason FROM journal_entries
+SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at FROM journal_entries
 WHERE batch_id = $1
+  AND kind = 'POSTING'
   AND voided_by IS NULL
 LIMIT 1
 `
@@ -134,13 +594,18 @@ func (q *Queries) GetActiveEntryByBatch(ctx context.Context, batchID uuid.UUID)
 		&i.VoidedBy,
 		&i.VoidedAt,
 		&i.VoidReason,
+		&i.Kind,
+		&i.PeriodID,
+		&i.AdjustsPeriodID,
+		&i.EffectiveAt,
 	)
 	return i, err
 }
 
 const getEntryByBatch = `-- name: GetEntryByBatch :one
-SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason FROM journal_entries
+SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at FROM journal_entries
 WHERE batch_id = $1
+  AND kind = 'POSTING'
 ORDER BY ts ASC
 LIMIT 1
 `
@@ -155,12 +620,44 @@ func (q *Queries) GetEntryByBatch(ctx context.Context, batchID uuid.UUID) (Journ
 		&i.VoidedBy,
 		&i.VoidedAt,
 		&i.VoidReason,
+		&i.Kind,
+		&i.PeriodID,
+		&i.AdjustsPeriodID,
+		&i.EffectiveAt,
+	)
+	return i, err
+}
+
+const getEntryByBatchAndKind = `-- name: GetEntryByBatchAndKind :one
+SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at FROM journal_entries
+WHERE batch_id = $1 AND kind = $2
+`
+
+type GetEntryByBatchAndKindParams struct {
+	BatchID uuid.UUID
+	Kind    string
+}
+
+func (q *Queries) GetEntryByBatchAndKind(ctx context.Context, arg GetEntryByBatchAndKindParams) (JournalEntry, error) {
+	row := q.db.QueryRowContext(ctx, getEntryByBatchAndKind, arg.BatchID, arg.Kind)
+	var i JournalEntry
+	err := row.Scan(
+		&i.EntryID,
+		&i.BatchID,
+		&i.Ts,
+		&i.VoidedBy,
+		&i.VoidedAt,
+		&i.VoidReason,
+		&i.Kind,
+		&i.PeriodID,
+		&i.AdjustsPeriodID,
+		&i.EffectiveAt,
 	)
 	return i, err
 }
 
 const getJournalEntriesByBatch = `-- name: GetJournalEntriesByBatch :many
-SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason FROM journal_entries
+SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at FROM journal_entries
 WHERE batch_id = $1
 ORDER BY ts
 `
@@ -181,6 +678,10 @@ func (q *Queries) GetJournalEntriesByBatch(ctx context.Context, batchID uuid.UUI
 			&i.VoidedBy,
 			&i.VoidedAt,
 			&i.VoidReason,
+			&i.Kind,
+			&i.PeriodID,
+			&i.AdjustsPeriodID,
+			&i.EffectiveAt,
 		); err != nil {
 			return nil, err
 		}
@@ -196,7 +697,7 @@ func (q *Queries) GetJournalEntriesByBatch(ctx context.Context, batchID uuid.UUI
 }
 
 const getJournalEntry = `-- name: GetJournalEntry :one
-SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason FROM journal_entries
+SELECT entry_id, batch_id, ts, voided_by, voided_at, void_reason, kind, period_id, adjusts_period_id, effective_at FROM journal_entries
 WHERE entry_id = $1
 `
 
@@ -210,12 +711,16 @@ func (q *Queries) GetJournalEntry(ctx context.Context, entryID uuid.UUID) (Journ
 		&i.VoidedBy,
 		&i.VoidedAt,
 		&i.VoidReason,
+		&i.Kind,
+		&i.PeriodID,
+		&i.AdjustsPeriodID,
+		&i.EffectiveAt,
 	)
 	return i, err
 }
 
 const getJournalLinesByAccount = `-- name: GetJournalLinesByAccount :many
-SELECT jl.id, jl.entry_id, jl.account_id, jl.amount_minor, jl.side, je.ts
+SELECT jl.id, jl.entry_id, jl.account_id, jl.amount_minor, jl.side, jl.currency, je.ts
 FROM journal_lines jl
 JOIN journal_entries je ON jl.entry_id = je.entry_id
 WHERE jl.account_id = $1
@@ -234,6 +739,7 @@ type GetJournalLinesByAccountRow struct {
 	AccountID   uuid.UUID
 	AmountMinor int64
 	Side        string
+	Currency    string
 	Ts          time.Time
 }
 
@@ -252,6 +758,7 @@ func (q *Queries) GetJournalLinesByAccount(ctx context.Context, arg GetJournalLi
 			&i.AccountID,
 			&i.AmountMinor,
 			&i.Side,
+			&i.Currency,
 			&i.Ts,
 		); err != nil {
 			return nil, err
@@ -268,7 +775,7 @@ func (q *Queries) GetJournalLinesByAccount(ctx context.Context, arg GetJournalLi
 }
 
 const getJournalLinesByEntry = `-- name: GetJournalLinesByEntry :many
-SELECT id, entry_id, account_id, amount_minor, side FROM journal_lines
+SELECT id, entry_id, account_id, amount_minor, side, currency FROM journal_lines
 WHERE entry_id = $1
 ORDER BY id
 `
@@ -288,6 +795,7 @@ func (q *Queries) GetJournalLinesByEntry(ctx context.Context, entryID uuid.UUID)
 			&i.AccountID,
 			&i.AmountMinor,
 			&i.Side,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -302,8 +810,59 @@ func (q *Queries) GetJournalLinesByEntry(ctx context.Context, entryID uuid.UUID)
 	return items, nil
 }
 
+const getLotForUpdate = `-- name: GetLotForUpdate :one
+SELECT id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at FROM credit_lots
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetLotForUpdate(ctx context.Context, id uuid.UUID) (CreditLot, error) {
+	row := q.db.QueryRowContext(ctx, getLotForUpdate, id)
+	var i CreditLot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Currency,
+		&i.Source,
+		&i.GrantEntryID,
+		&i.GrantedMinor,
+		&i.RemainingMinor,
+		&i.ExpiredMinor,
+		&i.RevokedMinor,
+		&i.GrantedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getNextOpenPeriod = `-- name: GetNextOpenPeriod :one
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+WHERE starts_at >= $1 AND status = 'OPEN'
+ORDER BY starts_at
+LIMIT 1
+FOR SHARE
+`
+
+// The first open period starting at or after ts, share-locked
+func (q *Queries) GetNextOpenPeriod(ctx context.Context, ts time.Time) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getNextOpenPeriod, ts)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
 const getOutboxEvent = `-- name: GetOutboxEvent :one
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
 WHERE id = $1
 `
 
@@ -319,27 +878,184 @@ func (q *Queries) GetOutboxEvent(ctx context.Context, id uuid.UUID) (Outbox, err
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
 	)
 	return i, err
 }
 
-const getUnsentOutboxEvents = `-- name: GetUnsentOutboxEvents :many
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
-WHERE sent_at IS NULL
-ORDER BY created_at
-LIMIT $1
-FOR UPDATE SKIP LOCKED
+const getPeriod = `-- name: GetPeriod :one
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+WHERE id = $1
 `
 
-func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error) {
-	rows, err := q.db.QueryContext(ctx, getUnsentOutboxEvents, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Outbox
-	for rows.Next() {
-		var i Outbox
+func (q *Queries) GetPeriod(ctx context.Context, id uuid.UUID) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getPeriod, id)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const getPeriodAt = `-- name: GetPeriodAt :one
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+WHERE starts_at <= $1 AND ends_at > $1
+FOR SHARE
+`
+
+// The period containing ts, share-locked
+func (q *Queries) GetPeriodAt(ctx context.Context, ts time.Time) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getPeriodAt, ts)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const getPeriodForShare = `-- name: GetPeriodForShare :one
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+WHERE id = $1
+FOR SHARE
+`
+
+// Share-locks the period so its status cannot change until the caller commits
+func (q *Queries) GetPeriodForShare(ctx context.Context, id uuid.UUID) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getPeriodForShare, id)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const getPeriodForUpdate = `-- name: GetPeriodForUpdate :one
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetPeriodForUpdate(ctx context.Context, id uuid.UUID) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, getPeriodForUpdate, id)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
+
+const getReconciliationRun = `-- name: GetReconciliationRun :one
+SELECT id, status, triggered_by, started_at, finished_at, accounts_checked, transfers_checked, discrepancy_count, error FROM reconciliation_runs
+WHERE id = $1
+`
+
+func (q *Queries) GetReconciliationRun(ctx context.Context, id uuid.UUID) (ReconciliationRun, error) {
+	row := q.db.QueryRowContext(ctx, getReconciliationRun, id)
+	var i ReconciliationRun
+	err := row.Scan(
+		&i.ID,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.AccountsChecked,
+		&i.TransfersChecked,
+		&i.DiscrepancyCount,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getTrialBalance = `-- name: GetTrialBalance :many
+SELECT jl.account_id, jl.currency,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'DEBIT'), 0)::bigint AS debit_minor,
+       COALESCE(SUM(jl.amount_minor) FILTER (WHERE jl.side = 'CREDIT'), 0)::bigint AS credit_minor
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE COALESCE(bp.starts_at, je.effective_at) <= $1
+GROUP BY jl.account_id, jl.currency
+ORDER BY jl.account_id, jl.currency
+`
+
+type GetTrialBalanceRow struct {
+	AccountID   uuid.UUID
+	Currency    string
+	DebitMinor  int64
+	CreditMinor int64
+}
+
+// Debit and credit totals of every account per currency for entries reported up to as_of.
+// Entries correcting a closed period are reported at the start of the period they were booked in.
+func (q *Queries) GetTrialBalance(ctx context.Context, asOf time.Time) ([]GetTrialBalanceRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrialBalance, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrialBalanceRow
+	for rows.Next() {
+		var i GetTrialBalanceRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.DebitMinor,
+			&i.CreditMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnsentOutboxEvents = `-- name: GetUnsentOutboxEvents :many
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
+WHERE sent_at IS NULL
+ORDER BY created_at
+LIMIT $1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Outbox, error) {
+	rows, err := q.db.QueryContext(ctx, getUnsentOutboxEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
 		if err := rows.Scan(
 			&i.ID,
 			&i.AggregateType,
@@ -349,6 +1065,7 @@ func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Out
 			&i.Headers,
 			&i.CreatedAt,
 			&i.SentAt,
+			&i.AggregateSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -377,6 +1094,555 @@ func (q *Queries) IsEntryVoided(ctx context.Context, entryID uuid.UUID) (bool, e
 	return is_voided, err
 }
 
+const listAccountLines = `-- name: ListAccountLines :many
+SELECT jl.id, jl.entry_id, je.batch_id, je.kind, je.ts, je.effective_at,
+       COALESCE(bp.starts_at, je.effective_at)::timestamptz AS reported_at,
+       jl.side, jl.amount_minor, jl.currency
+FROM journal_lines jl
+JOIN journal_entries je ON jl.entry_id = je.entry_id
+LEFT JOIN accounting_periods bp ON je.adjusts_period_id IS NOT NULL AND bp.id = je.period_id
+WHERE jl.account_id = $1
+  AND COALESCE(bp.starts_at, je.effective_at) >= $2
+  AND COALESCE(bp.starts_at, je.effective_at) < $3
+ORDER BY reported_at, je.effective_at, jl.id
+LIMIT $4
+`
+
+type ListAccountLinesParams struct {
+	AccountID uuid.UUID
+	FromTs    time.Time
+	ToTs      time.Time
+	Limit     int32
+}
+
+type ListAccountLinesRow struct {
+	ID          int64
+	EntryID     uuid.UUID
+	BatchID     uuid.UUID
+	Kind        string
+	Ts          time.Time
+	EffectiveAt time.Time
+	ReportedAt  time.Time
+	Side        string
+	AmountMinor int64
+	Currency    string
+}
+
+// An account's journal lines for entries reported in [from_ts, to_ts), oldest first.
+// Entries correcting a closed period are reported at the start of the period they were booked in.
+func (q *Queries) ListAccountLines(ctx context.Context, arg ListAccountLinesParams) ([]ListAccountLinesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountLines,
+		arg.AccountID,
+		arg.FromTs,
+		arg.ToTs,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAccountLinesRow
+	for rows.Next() {
+		var i ListAccountLinesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.EntryID,
+			&i.BatchID,
+			&i.Kind,
+			&i.Ts,
+			&i.EffectiveAt,
+			&i.ReportedAt,
+			&i.Side,
+			&i.AmountMinor,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDebitNormalAccounts = `-- name: ListDebitNormalAccounts :many
+SELECT account_id FROM account_types
+WHERE account_id = ANY($1::uuid[]) AND normal_side = 'DEBIT'
+`
+
+// The accounts among account_ids whose balance increases with debits
+func (q *Queries) ListDebitNormalAccounts(ctx context.Context, accountIds []uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listDebitNormalAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var account_id uuid.UUID
+		if err := rows.Scan(&account_id); err != nil {
+			return nil, err
+		}
+		items = append(items, account_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredLots = `-- name: ListExpiredLots :many
+SELECT id FROM credit_lots
+WHERE remaining_minor > 0 AND expires_at <= $1
+ORDER BY expires_at, id
+LIMIT $2
+`
+
+type ListExpiredLotsParams struct {
+	AsOf  sql.NullTime
+	Limit int32
+}
+
+// Lots whose remainder has lapsed by as_of, soonest expiry first
+func (q *Queries) ListExpiredLots(ctx context.Context, arg ListExpiredLotsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredLots, arg.AsOf, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLapsedLotTotals = `-- name: ListLapsedLotTotals :many
+SELECT currency, COALESCE(SUM(remaining_minor), 0)::bigint AS lapsed_minor
+FROM credit_lots
+WHERE account_id = $1 AND remaining_minor > 0 AND expires_at <= $2
+GROUP BY currency
+ORDER BY currency
+`
+
+type ListLapsedLotTotalsParams struct {
+	AccountID uuid.UUID
+	AsOf      sql.NullTime
+}
+
+type ListLapsedLotTotalsRow struct {
+	Currency    string
+	LapsedMinor int64
+}
+
+// What remains of an account's lots lapsed by as_of, per currency, until the expiry worker posts it to breakage
+func (q *Queries) ListLapsedLotTotals(ctx context.Context, arg ListLapsedLotTotalsParams) ([]ListLapsedLotTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLapsedLotTotals, arg.AccountID, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLapsedLotTotalsRow
+	for rows.Next() {
+		var i ListLapsedLotTotalsRow
+		if err := rows.Scan(&i.Currency, &i.LapsedMinor); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerBalances = `-- name: ListLedgerBalances :many
+SELECT COALESCE(j.account_id, b.account_id)::uuid AS account_id,
+       COALESCE(j.currency, b.currency)::text AS currency,
+       COALESCE(j.balance_minor, 0)::bigint AS journal_minor,
+       COALESCE(b.balance_minor, 0)::bigint AS stored_minor
+FROM (
+  SELECT account_id, currency,
+         SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END) AS balance_minor
+  FROM journal_lines
+  GROUP BY account_id, currency
+) j
+FULL OUTER JOIN account_balances b ON b.account_id = j.account_id AND b.currency = j.currency
+ORDER BY 1, 2
+`
+
+type ListLedgerBalancesRow struct {
+	AccountID    uuid.UUID
+	Currency     string
+	JournalMinor int64
+	StoredMinor  int64
+}
+
+// Recomputes every account balance from journal_lines alongside the stored running balance
+func (q *Queries) ListLedgerBalances(ctx context.Context) ([]ListLedgerBalancesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLedgerBalances)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLedgerBalancesRow
+	for rows.Next() {
+		var i ListLedgerBalancesRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.JournalMinor,
+			&i.StoredMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLedgerBalancesForAccounts = `-- name: ListLedgerBalancesForAccounts :many
+SELECT COALESCE(j.account_id, b.account_id)::uuid AS account_id,
+       COALESCE(j.currency, b.currency)::text AS currency,
+       COALESCE(j.balance_minor, 0)::bigint AS journal_minor,
+       COALESCE(b.balance_minor, 0)::bigint AS stored_minor
+FROM (
+  SELECT account_id, currency,
+         SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END) AS balance_minor
+  FROM journal_lines
+  WHERE account_id = ANY($1::uuid[])
+  GROUP BY account_id, currency
+) j
+FULL OUTER JOIN (
+  SELECT account_id, currency, balance_minor, overdraft_limit_minor, overdraft_unlimited, updated_at FROM account_balances
+  WHERE account_id = ANY($1::uuid[])
+) b ON b.account_id = j.account_id AND b.currency = j.currency
+ORDER BY 1, 2
+`
+
+type ListLedgerBalancesForAccountsRow struct {
+	AccountID    uuid.UUID
+	Currency     string
+	JournalMinor int64
+	StoredMinor  int64
+}
+
+func (q *Queries) ListLedgerBalancesForAccounts(ctx context.Context, accountIds []uuid.UUID) ([]ListLedgerBalancesForAccountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLedgerBalancesForAccounts, pq.Array(accountIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLedgerBalancesForAccountsRow
+	for rows.Next() {
+		var i ListLedgerBalancesForAccountsRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Currency,
+			&i.JournalMinor,
+			&i.StoredMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLotConsumptionsByEntry = `-- name: ListLotConsumptionsByEntry :many
+SELECT c.id, c.lot_id, c.amount_minor, c.reason, l.account_id, l.currency
+FROM lot_consumptions c
+JOIN credit_lots l ON l.id = c.lot_id
+WHERE c.entry_id = $1 AND c.restored_by IS NULL
+ORDER BY c.id
+FOR UPDATE OF c, l
+`
+
+type ListLotConsumptionsByEntryRow struct {
+	ID          int64
+	LotID       uuid.UUID
+	AmountMinor int64
+	Reason      string
+	AccountID   uuid.UUID
+	Currency    string
+}
+
+// Amounts an entry took from lots that no void has given back yet, locked with their lots
+func (q *Queries) ListLotConsumptionsByEntry(ctx context.Context, entryID uuid.UUID) ([]ListLotConsumptionsByEntryRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLotConsumptionsByEntry, entryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLotConsumptionsByEntryRow
+	for rows.Next() {
+		var i ListLotConsumptionsByEntryRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LotID,
+			&i.AmountMinor,
+			&i.Reason,
+			&i.AccountID,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLotsByGrantEntry = `-- name: ListLotsByGrantEntry :many
+SELECT id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at FROM credit_lots
+WHERE grant_entry_id = $1
+ORDER BY id
+`
+
+// Every lot an entry granted, revoked ones included
+func (q *Queries) ListLotsByGrantEntry(ctx context.Context, grantEntryID uuid.UUID) ([]CreditLot, error) {
+	rows, err := q.db.QueryContext(ctx, listLotsByGrantEntry, grantEntryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLot
+	for rows.Next() {
+		var i CreditLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Currency,
+			&i.Source,
+			&i.GrantEntryID,
+			&i.GrantedMinor,
+			&i.RemainingMinor,
+			&i.ExpiredMinor,
+			&i.RevokedMinor,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLotsByGrantEntryForUpdate = `-- name: ListLotsByGrantEntryForUpdate :many
+SELECT id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at FROM credit_lots
+WHERE grant_entry_id = $1 AND revoked_at IS NULL
+ORDER BY id
+FOR UPDATE
+`
+
+func (q *Queries) ListLotsByGrantEntryForUpdate(ctx context.Context, grantEntryID uuid.UUID) ([]CreditLot, error) {
+	rows, err := q.db.QueryContext(ctx, listLotsByGrantEntryForUpdate, grantEntryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLot
+	for rows.Next() {
+		var i CreditLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Currency,
+			&i.Source,
+			&i.GrantEntryID,
+			&i.GrantedMinor,
+			&i.RemainingMinor,
+			&i.ExpiredMinor,
+			&i.RevokedMinor,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenLotsForUpdate = `-- name: ListOpenLotsForUpdate :many
+SELECT id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at FROM credit_lots
+WHERE account_id = $1 AND currency = $2 AND remaining_minor > 0
+ORDER BY granted_at, id
+FOR UPDATE
+`
+
+type ListOpenLotsForUpdateParams struct {
+	AccountID uuid.UUID
+	Currency  string
+}
+
+// Lots of an account with a remainder, locked so concurrent debits draw them down one at a time
+func (q *Queries) ListOpenLotsForUpdate(ctx context.Context, arg ListOpenLotsForUpdateParams) ([]CreditLot, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenLotsForUpdate, arg.AccountID, arg.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLot
+	for rows.Next() {
+		var i CreditLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Currency,
+			&i.Source,
+			&i.GrantEntryID,
+			&i.GrantedMinor,
+			&i.RemainingMinor,
+			&i.ExpiredMinor,
+			&i.RevokedMinor,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPeriods = `-- name: ListPeriods :many
+SELECT id, name, starts_at, ends_at, status, created_at, status_changed_at FROM accounting_periods
+ORDER BY starts_at
+`
+
+func (q *Queries) ListPeriods(ctx context.Context) ([]AccountingPeriod, error) {
+	rows, err := q.db.QueryContext(ctx, listPeriods)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AccountingPeriod
+	for rows.Next() {
+		var i AccountingPeriod
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Status,
+			&i.CreatedAt,
+			&i.StatusChangedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReconciliationDiscrepancies = `-- name: ListReconciliationDiscrepancies :many
+SELECT id, run_id, kind, account_id, currency, transfer_id, expected, actual, detail FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListReconciliationDiscrepancies(ctx context.Context, runID uuid.UUID) ([]ReconciliationDiscrepancy, error) {
+	rows, err := q.db.QueryContext(ctx, listReconciliationDiscrepancies, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReconciliationDiscrepancy
+	for rows.Next() {
+		var i ReconciliationDiscrepancy
+		if err := rows.Scan(
+			&i.ID,
+			&i.RunID,
+			&i.Kind,
+			&i.AccountID,
+			&i.Currency,
+			&i.TransferID,
+			&i.Expected,
+			&i.Actual,
+			&i.Detail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const markEntryVoided = `-- name: MarkEntryVoided :exec
 
 UPDATE journal_entries
@@ -396,6 +1662,22 @@ func (q *Queries) MarkEntryVoided(ctx context.Context, arg MarkEntryVoidedParams
 	return err
 }
 
+const markLotConsumptionRestored = `-- name: MarkLotConsumptionRestored :exec
+UPDATE lot_consumptions
+SET restored_by = $2
+WHERE id = $1
+`
+
+type MarkLotConsumptionRestoredParams struct {
+	ID         int64
+	RestoredBy uuid.NullUUID
+}
+
+func (q *Queries) MarkLotConsumptionRestored(ctx context.Context, arg MarkLotConsumptionRestoredParams) error {
+	_, err := q.db.ExecContext(ctx, markLotConsumptionRestored, arg.ID, arg.RestoredBy)
+	return err
+}
+
 const markOutboxEventSent = `-- name: MarkOutboxEventSent :exec
 UPDATE outbox
 SET sent_at = now()
@@ -406,3 +1688,115 @@ func (q *Queries) MarkOutboxEventSent(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, markOutboxEventSent, id)
 	return err
 }
+
+const restoreLot = `-- name: RestoreLot :execrows
+UPDATE credit_lots
+SET remaining_minor = remaining_minor + $1
+WHERE id = $2 AND revoked_at IS NULL
+`
+
+type RestoreLotParams struct {
+	AmountMinor int64
+	ID          uuid.UUID
+}
+
+// Returns 0 when the lot was revoked, so nothing is given back to it
+func (q *Queries) RestoreLot(ctx context.Context, arg RestoreLotParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreLot, arg.AmountMinor, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeLot = `-- name: RevokeLot :one
+UPDATE credit_lots
+SET revoked_minor = remaining_minor, remaining_minor = 0, revoked_at = now()
+WHERE id = $1
+RETURNING id, account_id, currency, source, grant_entry_id, granted_minor, remaining_minor, expired_minor, revoked_minor, granted_at, expires_at, revoked_at, created_at
+`
+
+func (q *Queries) RevokeLot(ctx context.Context, id uuid.UUID) (CreditLot, error) {
+	row := q.db.QueryRowContext(ctx, revokeLot, id)
+	var i CreditLot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Currency,
+		&i.Source,
+		&i.GrantEntryID,
+		&i.GrantedMinor,
+		&i.RemainingMinor,
+		&i.ExpiredMinor,
+		&i.RevokedMinor,
+		&i.GrantedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setOverdraftLimit = `-- name: SetOverdraftLimit :one
+INSERT INTO account_balances (account_id, currency, overdraft_limit_minor, overdraft_unlimited)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id, currency) DO UPDATE
+SET overdraft_limit_minor = EXCLUDED.overdraft_limit_minor,
+    overdraft_unlimited = EXCLUDED.overdraft_unlimited,
+    updated_at = now()
+RETURNING account_id, currency, balance_minor, overdraft_limit_minor, overdraft_unlimited, updated_at
+`
+
+type SetOverdraftLimitParams struct {
+	AccountID           uuid.UUID
+	Currency            string
+	OverdraftLimitMinor sql.NullInt64
+	OverdraftUnlimited  bool
+}
+
+func (q *Queries) SetOverdraftLimit(ctx context.Context, arg SetOverdraftLimitParams) (AccountBalance, error) {
+	row := q.db.QueryRowContext(ctx, setOverdraftLimit,
+		arg.AccountID,
+		arg.Currency,
+		arg.OverdraftLimitMinor,
+		arg.OverdraftUnlimited,
+	)
+	var i AccountBalance
+	err := row.Scan(
+		&i.AccountID,
+		&i.Currency,
+		&i.BalanceMinor,
+		&i.OverdraftLimitMinor,
+		&i.OverdraftUnlimited,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePeriodStatus = `-- name: UpdatePeriodStatus :one
+UPDATE accounting_periods
+SET status = $2, status_changed_at = $3
+WHERE id = $1
+RETURNING id, name, starts_at, ends_at, status, created_at, status_changed_at
+`
+
+type UpdatePeriodStatusParams struct {
+	ID              uuid.UUID
+	Status          string
+	StatusChangedAt sql.NullTime
+}
+
+func (q *Queries) UpdatePeriodStatus(ctx context.Context, arg UpdatePeriodStatusParams) (AccountingPeriod, error) {
+	row := q.db.QueryRowContext(ctx, updatePeriodStatus, arg.ID, arg.Status, arg.StatusChangedAt)
+	var i AccountingPeriod
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Status,
+		&i.CreatedAt,
+		&i.StatusChangedAt,
+	)
+	return i, err
+}
@@ -12,12 +12,15 @@ import (
 	"time"
 
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/compensator"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/directory"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/holds"
 	orchestratorhttp "github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/http"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/idem"
-	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/outbox"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/telemetry"
 	"github.com/amirhf/credit-ledger/services/common/migrate"
+	"github.com/amirhf/credit-ledger/services/common/outbox"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -84,11 +87,49 @@ func main() {
 	log.Printf("Kafka brokers: %v", brokers)
 
 	// Create Kafka publisher
-	publisher := outbox.NewPublisher(brokers)
+	publisher, err := outbox.NewPublisher(brokers)
+	if err != nil {
+		log.Fatalf("Failed to create Kafka publisher: %v", err)
+	}
 	defer publisher.Close()
 
+	// Sent outbox rows are kept forever unless a retention is configured (e.g. "168h")
+	var outboxRetention time.Duration
+	if v := os.Getenv("OUTBOX_RETENTION"); v != "" {
+		outboxRetention, err = time.ParseDuration(v)
+		if err != nil || outboxRetention <= 0 {
+			log.Fatalf("Invalid OUTBOX_RETENTION: %q", v)
+		}
+	}
+
+	// The relay wakes on NOTIFY from the outbox insert trigger; OUTBOX_RELAY_MODE=poll polls every 100ms instead
+	var outboxListenURL string
+	switch mode := os.Getenv("OUTBOX_RELAY_MODE"); mode {
+	case "", "notify":
+		outboxListenURL = dbURL
+	case "poll":
+	default:
+		log.Fatalf("Invalid OUTBOX_RELAY_MODE: %q (use notify or poll)", mode)
+	}
+
 	// Create outbox relay
-	relay := outbox.NewRelay(db, publisher, log.Default())
+	relay := outbox.NewRelay(db, publisher, log.Default(), outbox.Config{
+		Router: outbox.RouteByEventType("ledger.events.v1", map[string][]string{
+			"ledger.transfer.v1": {"TransferInitiated", "TransferCompleted", "TransferFailed", "TransferRefunded"},
+			"ledger.hold.v1":     {"HoldPlaced", "HoldCaptured", "HoldReleased"},
+		}),
+		Metrics: outbox.Metrics{
+			Published:       metrics.OutboxEventsPublished,
+			PublishErrors:   metrics.OutboxEventsPublishErrors,
+			Latency:         metrics.OutboxRelayLatency,
+			QueueSize:       metrics.OutboxQueueSize,
+			OldestUnsentAge: metrics.OutboxOldestUnsentAge,
+			Purged:          metrics.OutboxRowsPurged,
+		},
+		ListenURL: outboxListenURL,
+		Retention: outboxRetention,
+		Archive:   os.Getenv("OUTBOX_ARCHIVE") == "true",
+	})
 
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
@@ -115,8 +156,28 @@ func main() {
 		}
 	}()
 
+	// Create and start hold expirer worker in background
+	expirer := holds.NewExpirer(db, log.Default())
+	go func() {
+		if err := expirer.Start(ctx); err != nil && err != context.Canceled {
+			log.Printf("Hold expirer stopped with error: %v", err)
+		}
+	}()
+
+	// Get accounts service URL (optional; used when an account is not in the directory yet)
+	accountsURL := os.Getenv("ACCOUNTS_URL")
+
+	// Create account directory and start its consumer in background
+	accountDirectory := directory.NewDirectory(db, accountsURL, log.Default())
+	accountConsumer := directory.NewConsumer(brokers, accountDirectory, log.Default())
+	go func() {
+		if err := accountConsumer.Start(ctx); err != nil && err != context.Canceled {
+			log.Printf("Account directory consumer stopped with error: %v", err)
+		}
+	}()
+
 	// Create handler
-	handler := orchestratorhttp.NewHandler(db, idemGuard, ledgerURL, log.Default())
+	handler := orchestratorhttp.NewHandler(db, idemGuard, accountDirectory, ledgerURL, log.Default())
 
 	// Setup router
 	r := chi.NewRouter()
@@ -148,6 +209,12 @@ func main() {
 	r.Handle("/metrics", promhttp.Handler())
 	r.Post("/v1/transfers", handler.CreateTransfer)
 	r.Get("/v1/transfers/{id}", handler.GetTransfer)
+	r.Post("/v1/transfers/{id}/refund", handler.RefundTransfer)
+	r.Post("/v1/postings", handler.CreatePosting)
+	r.Post("/v1/holds", handler.CreateHold)
+	r.Get("/v1/holds/{id}", handler.GetHold)
+	r.Post("/v1/holds/{id}/capture", handler.CaptureHold)
+	r.Post("/v1/holds/{id}/release", handler.ReleaseHold)
 
 	// Setup HTTP server
 	addr := ":7103"
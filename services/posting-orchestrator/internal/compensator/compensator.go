@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/refunds"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
 	"github.com/google/uuid"
 )
@@ -133,7 +134,15 @@ func (c *Compensator) markCompleted(ctx context.Context, transferID, entryID str
 		return err
 	}
 
-	if err := c.queries.RecordLedgerSuccess(ctx, store.RecordLedgerSuccessParams{
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := c.queries.WithTx(tx)
+
+	if err := qtx.RecordLedgerSuccess(ctx, store.RecordLedgerSuccessParams{
 		ID:            transferUUID,
 		LedgerEntryID: uuid.NullUUID{UUID: entryUUID, Valid: true},
 		LedgerResponse: sql.NullString{
@@ -144,6 +153,15 @@ func (c *Compensator) markCompleted(ctx context.Context, transferID, entryID str
 		return err
 	}
 
+	// A recovered refund also updates the refunded total of its original
+	if err := refunds.RecordCompleted(ctx, qtx, transferUUID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	c.logger.Printf("Transfer %s recovered and marked as COMPLETED", transferID)
 	return nil
 }
@@ -0,0 +1,135 @@
+package directory
+
+import (
+	"context"
+	"crypto/tls"
+	"log"
+	"os"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/common/events"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Consumer reads account events from Kafka and applies them to the directory
+type Consumer struct {
+	reader    *kafka.Reader
+	directory *Directory
+	logger    *log.Logger
+}
+
+// NewConsumer creates a Kafka consumer for the ledger.account.v1 topic
+func NewConsumer(brokers []string, directory *Directory, logger *log.Logger) *Consumer {
+	config := kafka.ReaderConfig{
+		Brokers:        brokers,
+		Topic:          "ledger.account.v1",
+		GroupID:        "orchestrator-account-directory",
+		MinBytes:       1,
+		MaxBytes:       10e6, // 10MB
+		CommitInterval: time.Second,
+		StartOffset:    kafka.FirstOffset, // Build the directory from the full account history
+	}
+
+	// Add SASL authentication if credentials provided (for managed Kafka providers)
+	if username := os.Getenv("KAFKA_SASL_USERNAME"); username != "" {
+		password := os.Getenv("KAFKA_SASL_PASSWORD")
+		mechanismType := os.Getenv("KAFKA_SASL_MECHANISM") // "PLAIN" or "SCRAM-SHA-256"
+		if mechanismType == "" {
+			mechanismType = "PLAIN"
+		}
+
+		var mechanism sasl.Mechanism
+		var err error
+
+		switch mechanismType {
+		case "PLAIN":
+			mechanism = plain.Mechanism{
+				Username: username,
+				Password: password,
+			}
+		case "SCRAM-SHA-256":
+			mechanism, err = scram.Mechanism(scram.SHA256, username, password)
+			if err != nil {
+				log.Fatalf("Failed to create SCRAM mechanism: %v", err)
+			}
+		default:
+			log.Fatalf("Unsupported SASL mechanism: %s. Use PLAIN or SCRAM-SHA-256", mechanismType)
+		}
+
+		config.Dialer = &kafka.Dialer{
+			SASLMechanism: mechanism,
+			TLS:           &tls.Config{},
+		}
+		log.Printf("Kafka account consumer SASL authentication configured with %s", mechanismType)
+	}
+
+	return &Consumer{
+		reader:    kafka.NewReader(config),
+		directory: directory,
+		logger:    logger,
+	}
+}
+
+// Start begins consuming account events and blocks until context is canceled
+func (c *Consumer) Start(ctx context.Context) error {
+	c.logger.Println("Starting Kafka consumer for ledger.account.v1")
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.reader.Close()
+			}
+			c.logger.Printf("Error fetching account message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		envelope, err := events.Decode(msg.Value, msg.Headers)
+		if err == nil {
+			err = events.Check(envelope)
+		}
+		if err != nil {
+			// Unknown event types and newer schema versions are skipped, not retried
+			c.logger.Printf("Skipping account message at offset %d: %v", msg.Offset, err)
+			c.reader.CommitMessages(ctx, msg)
+			continue
+		}
+
+		if err := c.process(ctx, envelope); err != nil {
+			c.logger.Printf("Error processing %s event at offset %d: %v", envelope.EventType, msg.Offset, err)
+			// Don't commit on error - will retry
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			c.logger.Printf("Error committing account message: %v", err)
+		}
+	}
+}
+
+// process unpacks and applies a single account event
+func (c *Consumer) process(ctx context.Context, envelope *ledgerv1.EventEnvelope) error {
+	switch envelope.EventType {
+	case "AccountCreated":
+		var event ledgerv1.AccountCreated
+		if err := events.Unpack(envelope, &event); err != nil {
+			return err
+		}
+		return c.directory.ApplyAccountCreated(ctx, &event)
+	case "AccountStatusChanged":
+		var event ledgerv1.AccountStatusChanged
+		if err := events.Unpack(envelope, &event); err != nil {
+			return err
+		}
+		return c.directory.ApplyAccountStatusChanged(ctx, &event)
+	default:
+		c.logger.Printf("Unknown account event type %q, skipping", envelope.EventType)
+		return nil
+	}
+}
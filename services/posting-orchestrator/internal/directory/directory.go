@@ -0,0 +1,176 @@
+package directory
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// Directory is the orchestrator's local copy of account existence, status and currency.
+// It is fed by ledger.account.v1 events. When an account is not known yet (the
+// AccountCreated event is still in flight) and an accounts service URL is
+// configured, the account is fetched from the accounts service and cached.
+type Directory struct {
+	queries     *store.Queries
+	accountsURL string
+	httpClient  *http.Client
+	logger      *log.Logger
+}
+
+// accountResponse represents the response from the accounts service GET /v1/accounts/{id}
+type accountResponse struct {
+	ID        string `json:"id"`
+	Currency  string `json:"currency"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NewDirectory creates a new account directory; accountsURL may be empty to disable fallback lookups
+func NewDirectory(db *sql.DB, accountsURL string, logger *log.Logger) *Directory {
+	return &Directory{
+		queries:     store.New(db),
+		accountsURL: accountsURL,
+		httpClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger: logger,
+	}
+}
+
+// Lookup returns the account, or nil if it does not exist
+func (d *Directory) Lookup(ctx context.Context, accountID uuid.UUID) (*domain.Account, error) {
+	row, err := d.queries.GetDirectoryAccount(ctx, accountID)
+	if err == nil {
+		return &domain.Account{
+			ID:       row.AccountID,
+			Currency: row.Currency,
+			Status:   domain.AccountStatus(row.Status),
+		}, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("get directory account: %w", err)
+	}
+
+	if d.accountsURL == "" {
+		return nil, nil
+	}
+	return d.fetch(ctx, accountID)
+}
+
+// fetch reads an account from the accounts service and caches it in the directory
+func (d *Directory) fetch(ctx context.Context, accountID uuid.UUID) (*domain.Account, error) {
+	url := fmt.Sprintf("%s/v1/accounts/%s", d.accountsURL, accountID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("accounts request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("accounts service returned status %d", resp.StatusCode)
+	}
+
+	var accountResp accountResponse
+	if err := json.NewDecoder(resp.Body).Decode(&accountResp); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts response: %w", err)
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, accountResp.CreatedAt)
+	if err != nil {
+		createdAt = time.Now()
+	}
+
+	// Cache the account as created; the status is refreshed by AccountStatusChanged events
+	if err := d.queries.CreateDirectoryAccount(ctx, store.CreateDirectoryAccountParams{
+		AccountID: accountID,
+		Currency:  accountResp.Currency,
+		Status:    accountResp.Status,
+		CreatedAt: createdAt,
+	}); err != nil {
+		d.logger.Printf("Failed to cache account %s in directory: %v", accountID, err)
+	}
+
+	return &domain.Account{
+		ID:       accountID,
+		Currency: accountResp.Currency,
+		Status:   domain.AccountStatus(accountResp.Status),
+	}, nil
+}
+
+// ApplyAccountCreated adds a new ACTIVE account to the directory (no-op if already present)
+func (d *Directory) ApplyAccountCreated(ctx context.Context, event *ledgerv1.AccountCreated) error {
+	accountID, err := uuid.Parse(event.AccountId)
+	if err != nil {
+		return fmt.Errorf("parse account_id: %w", err)
+	}
+
+	if err := d.queries.CreateDirectoryAccount(ctx, store.CreateDirectoryAccountParams{
+		AccountID: accountID,
+		Currency:  event.Currency,
+		Status:    string(domain.AccountActive),
+		CreatedAt: time.UnixMilli(event.TsUnixMs),
+	}); err != nil {
+		return fmt.Errorf("create directory account: %w", err)
+	}
+
+	d.logger.Printf("Directory: account %s created (%s)", accountID, event.Currency)
+	return nil
+}
+
+// ApplyAccountStatusChanged updates an account's status.
+// Events older than the last applied change are ignored, so replays are harmless.
+func (d *Directory) ApplyAccountStatusChanged(ctx context.Context, event *ledgerv1.AccountStatusChanged) error {
+	accountID, err := uuid.Parse(event.AccountId)
+	if err != nil {
+		return fmt.Errorf("parse account_id: %w", err)
+	}
+
+	var status domain.AccountStatus
+	switch event.Status {
+	case ledgerv1.AccountStatus_ACCOUNT_STATUS_ACTIVE:
+		status = domain.AccountActive
+	case ledgerv1.AccountStatus_ACCOUNT_STATUS_SUSPENDED:
+		status = domain.AccountSuspended
+	case ledgerv1.AccountStatus_ACCOUNT_STATUS_CLOSED:
+		status = domain.AccountClosed
+	default:
+		return fmt.Errorf("unknown account status %s", event.Status)
+	}
+
+	updated, err := d.queries.UpdateDirectoryAccountStatus(ctx, store.UpdateDirectoryAccountStatusParams{
+		AccountID:       accountID,
+		Status:          string(status),
+		StatusChangedAt: sql.NullTime{Time: time.UnixMilli(event.TsUnixMs), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("update directory account status: %w", err)
+	}
+
+	if updated == 0 {
+		d.logger.Printf("Directory: status change for account %s to %s not applied (unknown account or stale event)", accountID, status)
+		return nil
+	}
+
+	d.logger.Printf("Directory: account %s is now %s", accountID, status)
+	return nil
+}
@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AccountStatus mirrors the lifecycle status owned by the accounts service
+type AccountStatus string
+
+const (
+	AccountActive    AccountStatus = "ACTIVE"
+	AccountSuspended AccountStatus = "SUSPENDED"
+	AccountClosed    AccountStatus = "CLOSED"
+)
+
+// Account rejection codes returned to API clients
+const (
+	CodeAccountNotFound  = "account_not_found"
+	CodeAccountSuspended = "account_suspended"
+	CodeAccountClosed    = "account_closed"
+	CodeCurrencyMismatch = "currency_mismatch"
+)
+
+// Account is the orchestrator's view of an account from the local directory
+type Account struct {
+	ID       uuid.UUID
+	Currency string
+	Status   AccountStatus
+}
+
+// AccountError is returned when a transfer references an account that cannot take part in it
+type AccountError struct {
+	Code      string
+	Field     string
+	AccountID uuid.UUID
+	Message   string
+}
+
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidateAccounts checks that both accounts of the transfer exist, are ACTIVE
+// and hold the transfer's currency. A nil account means it was not found.
+func (t *Transfer) ValidateAccounts(from, to *Account) error {
+	if err := validateAccount("from_account_id", t.FromAccountID, from, t.Currency); err != nil {
+		return err
+	}
+	return validateAccount("to_account_id", t.ToAccountID, to, t.Currency)
+}
+
+// validateAccount checks a single account can take part in a transfer in currency
+func validateAccount(field string, accountID uuid.UUID, account *Account, currency string) error {
+	if account == nil {
+		return &AccountError{
+			Code:      CodeAccountNotFound,
+			Field:     field,
+			AccountID: accountID,
+			Message:   fmt.Sprintf("account %s not found", accountID),
+		}
+	}
+
+	switch account.Status {
+	case AccountActive:
+	case AccountClosed:
+		return &AccountError{
+			Code:      CodeAccountClosed,
+			Field:     field,
+			AccountID: accountID,
+			Message:   fmt.Sprintf("account %s is closed", accountID),
+		}
+	default:
+		return &AccountError{
+			Code:      CodeAccountSuspended,
+			Field:     field,
+			AccountID: accountID,
+			Message:   fmt.Sprintf("account %s is %s", accountID, account.Status),
+		}
+	}
+
+	if account.Currency != currency {
+		return &AccountError{
+			Code:      CodeCurrencyMismatch,
+			Field:     field,
+			AccountID: accountID,
+			Message:   fmt.Sprintf("account %s holds %s, not %s", accountID, account.Currency, currency),
+		}
+	}
+
+	return nil
+}
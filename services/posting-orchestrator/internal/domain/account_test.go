@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestTransfer_ValidateAccounts(t *testing.T) {
+	fromID := uuid.New()
+	toID := uuid.New()
+	transfer, err := NewTransfer(fromID, toID, 1000, "USD", "idem-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	active := func(id uuid.UUID, currency string) *Account {
+		return &Account{ID: id, Currency: currency, Status: AccountActive}
+	}
+
+	tests := []struct {
+		name     string
+		from     *Account
+		to       *Account
+		wantCode string
+	}{
+		{name: "both active", from: active(fromID, "USD"), to: active(toID, "USD")},
+		{name: "missing source", from: nil, to: active(toID, "USD"), wantCode: CodeAccountNotFound},
+		{name: "missing destination", from: active(fromID, "USD"), to: nil, wantCode: CodeAccountNotFound},
+		{name: "suspended source", from: &Account{ID: fromID, Currency: "USD", Status: AccountSuspended}, to: active(toID, "USD"), wantCode: CodeAccountSuspended},
+		{name: "closed destination", from: active(fromID, "USD"), to: &Account{ID: toID, Currency: "USD", Status: AccountClosed}, wantCode: CodeAccountClosed},
+		{name: "currency mismatch", from: active(fromID, "USD"), to: active(toID, "EUR"), wantCode: CodeCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := transfer.ValidateAccounts(tt.from, tt.to)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+			accountErr, ok := err.(*AccountError)
+			if !ok {
+				t.Fatalf("expected AccountError, got %v", err)
+			}
+			if accountErr.Code != tt.wantCode {
+				t.Errorf("expected code %s, got %s", tt.wantCode, accountErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HoldStatus represents the status of an authorization hold
+type HoldStatus string
+
+const (
+	HoldPending  HoldStatus = "PENDING"
+	HoldCaptured HoldStatus = "CAPTURED"
+	HoldReleased HoldStatus = "RELEASED"
+	HoldExpired  HoldStatus = "EXPIRED"
+)
+
+// Hold rejection codes returned to API clients
+const (
+	CodeHoldNotPending     = "hold_not_pending"
+	CodeHoldExpired        = "hold_expired"
+	CodeCaptureExceedsHold = "capture_exceeds_hold"
+	CodeInsufficientFunds  = "insufficient_funds"
+)
+
+// Hold expiry bounds
+const (
+	DefaultHoldTTL = 24 * time.Hour
+	MaxHoldTTL     = 30 * 24 * time.Hour
+)
+
+// Hold reserves funds on an account until they are captured into a ledger
+// entry (debiting AccountID and crediting CounterpartyAccountID) or released.
+// A hold may be captured in several parts; whatever is not captured stays
+// reserved until the hold is released or expires.
+type Hold struct {
+	ID                    uuid.UUID
+	AccountID             uuid.UUID
+	CounterpartyAccountID uuid.UUID
+	AmountMinor           int64
+	CapturedMinor         int64
+	Currency              string
+	IdempotencyKey        string
+	Status                HoldStatus
+	ExpiresAt             time.Time
+}
+
+// HoldError is returned when an operation is not allowed in the hold's current state
+type HoldError struct {
+	Code    string
+	HoldID  uuid.UUID
+	Message string
+}
+
+func (e *HoldError) Error() string {
+	return fmt.Sprintf("hold %s: %s", e.HoldID, e.Message)
+}
+
+// NewHold creates a new pending hold with validation; a zero ttl selects DefaultHoldTTL
+func NewHold(accountID, counterpartyAccountID uuid.UUID, amountMinor int64, currency, idempotencyKey string, ttl time.Duration, now time.Time) (*Hold, error) {
+	// Reuse the transfer rules for amount, currency, accounts and idempotency key
+	if _, err := NewTransfer(accountID, counterpartyAccountID, amountMinor, currency, idempotencyKey); err != nil {
+		return nil, err
+	}
+
+	if ttl == 0 {
+		ttl = DefaultHoldTTL
+	}
+	if ttl < 0 || ttl > MaxHoldTTL {
+		return nil, &ValidationError{Field: "expires_in_seconds", Message: fmt.Sprintf("hold expiry must be between 1s and %s", MaxHoldTTL)}
+	}
+
+	return &Hold{
+		ID:                    uuid.New(),
+		AccountID:             accountID,
+		CounterpartyAccountID: counterpartyAccountID,
+		AmountMinor:           amountMinor,
+		Currency:              currency,
+		IdempotencyKey:        idempotencyKey,
+		Status:                HoldPending,
+		ExpiresAt:             now.Add(ttl),
+	}, nil
+}
+
+// CheckFunds verifies the account can cover the hold. availableMinor is what the
+// ledger allows the account to spend and heldMinor is already reserved on the
+// same account by other pending holds and by debits still in flight to the ledger.
+func (h *Hold) CheckFunds(availableMinor, heldMinor int64) error {
+	if availableMinor-heldMinor < h.AmountMinor {
+		return &HoldError{
+			Code:    CodeInsufficientFunds,
+			HoldID:  h.ID,
+			Message: fmt.Sprintf("account %s has %d %s available after pending holds, hold needs %d", h.AccountID, availableMinor-heldMinor, h.Currency, h.AmountMinor),
+		}
+	}
+	return nil
+}
+
+// FundsError is returned when a debit would spend funds reserved by pending holds
+type FundsError struct {
+	AccountID      uuid.UUID
+	Currency       string
+	AvailableMinor int64
+	ReservedMinor  int64
+	AmountMinor    int64
+}
+
+func (e *FundsError) Error() string {
+	return fmt.Sprintf("account %s has %d %s available after pending holds, debit needs %d",
+		e.AccountID, e.AvailableMinor-e.ReservedMinor, e.Currency, e.AmountMinor)
+}
+
+// CheckDebit verifies the account can cover a debit leg. availableMinor is what
+// the ledger allows the account to spend and reservedMinor is reserved on it by
+// pending holds and by debits still in flight to the ledger.
+func CheckDebit(debit Leg, availableMinor, reservedMinor int64) error {
+	if availableMinor-reservedMinor < debit.AmountMinor {
+		return &FundsError{
+			AccountID:      debit.AccountID,
+			Currency:       debit.Currency,
+			AvailableMinor: availableMinor,
+			ReservedMinor:  reservedMinor,
+			AmountMinor:    debit.AmountMinor,
+		}
+	}
+	return nil
+}
+
+// RemainingMinor returns the amount still reserved by the hold
+func (h *Hold) RemainingMinor() int64 {
+	if h.Status != HoldPending {
+		return 0
+	}
+	return h.AmountMinor - h.CapturedMinor
+}
+
+// Capture records a capture of amountMinor; zero captures everything that remains.
+// It returns the captured amount. The hold becomes CAPTURED once nothing remains.
+func (h *Hold) Capture(amountMinor int64, now time.Time) (int64, error) {
+	if err := h.checkPending(now); err != nil {
+		return 0, err
+	}
+
+	remaining := h.RemainingMinor()
+	if amountMinor == 0 {
+		amountMinor = remaining
+	}
+	if amountMinor < 0 {
+		return 0, &ValidationError{Field: "amount_minor", Message: "amount must be positive"}
+	}
+	if amountMinor > remaining {
+		return 0, &HoldError{
+			Code:    CodeCaptureExceedsHold,
+			HoldID:  h.ID,
+			Message: fmt.Sprintf("capture of %d exceeds remaining %d %s", amountMinor, remaining, h.Currency),
+		}
+	}
+
+	h.CapturedMinor += amountMinor
+	if h.CapturedMinor == h.AmountMinor {
+		h.Status = HoldCaptured
+	}
+	return amountMinor, nil
+}
+
+// UndoCapture reverses a capture of amountMinor the ledger rejected, so the
+// amount is reserved by the hold again
+func (h *Hold) UndoCapture(amountMinor int64) {
+	h.CapturedMinor -= amountMinor
+	if h.Status == HoldCaptured {
+		h.Status = HoldPending
+	}
+}
+
+// Release frees whatever the hold still reserves and returns the released amount
+func (h *Hold) Release(now time.Time) (int64, error) {
+	if err := h.checkPending(now); err != nil {
+		return 0, err
+	}
+
+	released := h.RemainingMinor()
+	h.Status = HoldReleased
+	return released, nil
+}
+
+// Expire marks a pending hold whose expiry has passed as EXPIRED and returns the released amount
+func (h *Hold) Expire(now time.Time) (int64, error) {
+	if h.Status != HoldPending {
+		return 0, h.notPendingError()
+	}
+	if now.Before(h.ExpiresAt) {
+		return 0, &HoldError{Code: CodeHoldNotPending, HoldID: h.ID, Message: "hold has not expired yet"}
+	}
+
+	released := h.RemainingMinor()
+	h.Status = HoldExpired
+	return released, nil
+}
+
+// checkPending verifies the hold is PENDING and has not passed its expiry
+func (h *Hold) checkPending(now time.Time) error {
+	if h.Status != HoldPending {
+		return h.notPendingError()
+	}
+	if !now.Before(h.ExpiresAt) {
+		return &HoldError{
+			Code:    CodeHoldExpired,
+			HoldID:  h.ID,
+			Message: fmt.Sprintf("hold expired at %s", h.ExpiresAt.Format(time.RFC3339)),
+		}
+	}
+	return nil
+}
+
+func (h *Hold) notPendingError() error {
+	return &HoldError{
+		Code:    CodeHoldNotPending,
+		HoldID:  h.ID,
+		Message: fmt.Sprintf("hold is %s", h.Status),
+	}
+}
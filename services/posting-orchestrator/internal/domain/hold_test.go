@@ -0,0 +1,187 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestHold(t *testing.T, amountMinor int64, now time.Time) *Hold {
+	t.Helper()
+	hold, err := NewHold(uuid.New(), uuid.New(), amountMinor, "USD", "hold-1", time.Hour, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return hold
+}
+
+func holdErrorCode(t *testing.T, err error) string {
+	t.Helper()
+	holdErr, ok := err.(*HoldError)
+	if !ok {
+		t.Fatalf("expected *HoldError, got %T (%v)", err, err)
+	}
+	return holdErr.Code
+}
+
+func TestNewHold_Validation(t *testing.T) {
+	now := time.Now()
+	accountID := uuid.New()
+
+	tests := []struct {
+		name         string
+		counterparty uuid.UUID
+		amount       int64
+		ttl          time.Duration
+		wantErr      bool
+	}{
+		{name: "valid", counterparty: uuid.New(), amount: 500, ttl: time.Minute},
+		{name: "default ttl", counterparty: uuid.New(), amount: 500},
+		{name: "zero amount", counterparty: uuid.New(), amount: 0, wantErr: true},
+		{name: "same account", counterparty: accountID, amount: 500, wantErr: true},
+		{name: "negative ttl", counterparty: uuid.New(), amount: 500, ttl: -time.Second, wantErr: true},
+		{name: "ttl too long", counterparty: uuid.New(), amount: 500, ttl: MaxHoldTTL + time.Second, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hold, err := NewHold(accountID, tt.counterparty, tt.amount, "USD", "hold-1", tt.ttl, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected validation error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			wantTTL := tt.ttl
+			if wantTTL == 0 {
+				wantTTL = DefaultHoldTTL
+			}
+			if !hold.ExpiresAt.Equal(now.Add(wantTTL)) {
+				t.Errorf("expected expiry %s, got %s", now.Add(wantTTL), hold.ExpiresAt)
+			}
+		})
+	}
+}
+
+func TestHold_PartialCaptureThenRelease(t *testing.T) {
+	now := time.Now()
+	hold := newTestHold(t, 1000, now)
+
+	captured, err := hold.Capture(300, now)
+	if err != nil {
+		t.Fatalf("unexpected capture error: %v", err)
+	}
+	if captured != 300 || hold.Status != HoldPending || hold.RemainingMinor() != 700 {
+		t.Fatalf("expected 300 captured and 700 pending, got captured=%d status=%s remaining=%d",
+			captured, hold.Status, hold.RemainingMinor())
+	}
+
+	if _, err := hold.Capture(701, now); holdErrorCode(t, err) != CodeCaptureExceedsHold {
+		t.Fatalf("expected %s, got %v", CodeCaptureExceedsHold, err)
+	}
+
+	released, err := hold.Release(now)
+	if err != nil {
+		t.Fatalf("unexpected release error: %v", err)
+	}
+	if released != 700 || hold.Status != HoldReleased || hold.RemainingMinor() != 0 {
+		t.Fatalf("expected 700 released, got released=%d status=%s remaining=%d",
+			released, hold.Status, hold.RemainingMinor())
+	}
+
+	if _, err := hold.Capture(1, now); holdErrorCode(t, err) != CodeHoldNotPending {
+		t.Fatalf("expected %s, got %v", CodeHoldNotPending, err)
+	}
+}
+
+func TestHold_FullCapture(t *testing.T) {
+	now := time.Now()
+	hold := newTestHold(t, 1000, now)
+
+	captured, err := hold.Capture(0, now)
+	if err != nil {
+		t.Fatalf("unexpected capture error: %v", err)
+	}
+	if captured != 1000 || hold.Status != HoldCaptured {
+		t.Fatalf("expected full capture, got captured=%d status=%s", captured, hold.Status)
+	}
+
+	if _, err := hold.Release(now); holdErrorCode(t, err) != CodeHoldNotPending {
+		t.Fatalf("expected %s, got %v", CodeHoldNotPending, err)
+	}
+}
+
+func TestHold_Expiry(t *testing.T) {
+	now := time.Now()
+	hold := newTestHold(t, 1000, now)
+	later := hold.ExpiresAt
+
+	if _, err := hold.Expire(now); err == nil {
+		t.Fatal("expected error expiring a hold before its expiry")
+	}
+	if _, err := hold.Capture(100, later); holdErrorCode(t, err) != CodeHoldExpired {
+		t.Fatalf("expected %s, got %v", CodeHoldExpired, err)
+	}
+
+	released, err := hold.Expire(later)
+	if err != nil {
+		t.Fatalf("unexpected expire error: %v", err)
+	}
+	if released != 1000 || hold.Status != HoldExpired {
+		t.Fatalf("expected 1000 released on expiry, got released=%d status=%s", released, hold.Status)
+	}
+}
+
+func TestHold_CheckFunds(t *testing.T) {
+	hold := newTestHold(t, 1000, time.Now())
+
+	if err := hold.CheckFunds(1500, 500); err != nil {
+		t.Fatalf("expected funds to cover hold, got %v", err)
+	}
+	if err := hold.CheckFunds(1500, 501); holdErrorCode(t, err) != CodeInsufficientFunds {
+		t.Fatalf("expected %s, got %v", CodeInsufficientFunds, err)
+	}
+}
+
+func TestHold_UndoCapture(t *testing.T) {
+	now := time.Now()
+	hold := newTestHold(t, 1000, now)
+
+	captured, err := hold.Capture(0, now)
+	if err != nil {
+		t.Fatalf("unexpected capture error: %v", err)
+	}
+	hold.UndoCapture(captured)
+	if hold.Status != HoldPending || hold.RemainingMinor() != 1000 {
+		t.Fatalf("expected 1000 reserved again, got remaining=%d status=%s", hold.RemainingMinor(), hold.Status)
+	}
+
+	if _, err := hold.Release(now); err != nil {
+		t.Fatalf("unexpected release error: %v", err)
+	}
+	hold.UndoCapture(0)
+	if hold.Status != HoldReleased {
+		t.Fatalf("expected a released hold to stay released, got %s", hold.Status)
+	}
+}
+
+func TestCheckDebit(t *testing.T) {
+	debit := Leg{AccountID: uuid.New(), AmountMinor: 1000, Currency: "USD", Side: SideDebit}
+
+	if err := CheckDebit(debit, 1500, 500); err != nil {
+		t.Fatalf("expected funds to cover debit, got %v", err)
+	}
+
+	err := CheckDebit(debit, 1500, 501)
+	fundsErr, ok := err.(*FundsError)
+	if !ok {
+		t.Fatalf("expected *FundsError, got %T (%v)", err, err)
+	}
+	if fundsErr.AccountID != debit.AccountID || fundsErr.ReservedMinor != 501 || fundsErr.AmountMinor != 1000 {
+		t.Errorf("unexpected funds error: %+v", fundsErr)
+	}
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Side represents the debit or credit side of a leg
+type Side string
+
+const (
+	SideDebit  Side = "DEBIT"
+	SideCredit Side = "CREDIT"
+)
+
+// Leg is one debit or credit of a transfer; it becomes one ledger journal line
+type Leg struct {
+	AccountID   uuid.UUID
+	AmountMinor int64
+	Currency    string
+	Side        Side
+}
+
+// NewPosting creates a multi-leg transfer, validated with the same rules the
+// ledger applies to journal entries: at least one debit and one credit,
+// positive amounts, and debits equal to credits in every currency.
+//
+// The transfer's FromAccountID, ToAccountID, AmountMinor and Currency summarize
+// the posting: the first debit and credit accounts, and the total debited in
+// the first leg's currency.
+func NewPosting(legs []Leg, idempotencyKey string) (*Transfer, error) {
+	if err := ValidateLegs(legs); err != nil {
+		return nil, err
+	}
+
+	// Validate idempotency key
+	if idempotencyKey == "" {
+		return nil, &ValidationError{Field: "idempotency_key", Message: "idempotency key is required"}
+	}
+
+	transfer := &Transfer{
+		ID:             uuid.New(),
+		Currency:       legs[0].Currency,
+		IdempotencyKey: idempotencyKey,
+		Status:         StatusInitiated,
+		Legs:           legs,
+	}
+	for _, leg := range legs {
+		switch leg.Side {
+		case SideDebit:
+			if transfer.FromAccountID == uuid.Nil {
+				transfer.FromAccountID = leg.AccountID
+			}
+			if leg.Currency == transfer.Currency {
+				transfer.AmountMinor += leg.AmountMinor
+			}
+		case SideCredit:
+			if transfer.ToAccountID == uuid.Nil {
+				transfer.ToAccountID = leg.AccountID
+			}
+		}
+	}
+
+	return transfer, nil
+}
+
+// ValidateLegs checks the double-entry invariants of a list of legs
+func ValidateLegs(legs []Leg) error {
+	// Must have at least 2 legs (one debit, one credit minimum)
+	if len(legs) < 2 {
+		return &ValidationError{Field: "legs", Message: fmt.Sprintf("posting must have at least 2 legs, got %d", len(legs))}
+	}
+
+	// Debits and credits are summed per currency; amounts in different
+	// currencies never offset each other
+	debitSums := make(map[string]int64)
+	creditSums := make(map[string]int64)
+	var currencies []string
+
+	for i, leg := range legs {
+		if leg.AmountMinor <= 0 {
+			return &ValidationError{Field: fmt.Sprintf("legs[%d].amount_minor", i), Message: "amount must be positive"}
+		}
+		if leg.AccountID == uuid.Nil {
+			return &ValidationError{Field: fmt.Sprintf("legs[%d].account_id", i), Message: "account_id cannot be nil"}
+		}
+		if len(leg.Currency) != 3 {
+			return &ValidationError{Field: fmt.Sprintf("legs[%d].currency", i), Message: "currency must be a 3-letter ISO code"}
+		}
+
+		if _, seen := debitSums[leg.Currency]; !seen {
+			currencies = append(currencies, leg.Currency)
+			debitSums[leg.Currency] = 0
+		}
+
+		switch leg.Side {
+		case SideDebit:
+			debitSums[leg.Currency] += leg.AmountMinor
+		case SideCredit:
+			creditSums[leg.Currency] += leg.AmountMinor
+		default:
+			return &ValidationError{Field: fmt.Sprintf("legs[%d].side", i), Message: fmt.Sprintf("invalid side: %s", leg.Side)}
+		}
+	}
+
+	// Double-entry invariant: debits must equal credits in every currency
+	for _, currency := range currencies {
+		if debitSums[currency] == 0 || creditSums[currency] == 0 {
+			return &ValidationError{Field: "legs", Message: fmt.Sprintf("posting must have at least one debit and one credit leg in %s", currency)}
+		}
+		if debitSums[currency] != creditSums[currency] {
+			return &ValidationError{
+				Field:   "legs",
+				Message: fmt.Sprintf("debits (%d) must equal credits (%d) for currency %s", debitSums[currency], creditSums[currency], currency),
+			}
+		}
+	}
+
+	return nil
+}
+
+// Lines returns the legs posted to the ledger for the transfer. A simple
+// transfer debits FromAccountID and credits ToAccountID.
+func (t *Transfer) Lines() []Leg {
+	if len(t.Legs) > 0 {
+		return t.Legs
+	}
+	return []Leg{
+		{AccountID: t.FromAccountID, AmountMinor: t.AmountMinor, Currency: t.Currency, Side: SideDebit},
+		{AccountID: t.ToAccountID, AmountMinor: t.AmountMinor, Currency: t.Currency, Side: SideCredit},
+	}
+}
+
+// Debits returns one leg per account and currency the transfer debits, totalling
+// its debit lines, in the order the accounts first appear
+func (t *Transfer) Debits() []Leg {
+	var debits []Leg
+	index := make(map[Leg]int)
+	for _, line := range t.Lines() {
+		if line.Side != SideDebit {
+			continue
+		}
+		key := Leg{AccountID: line.AccountID, Currency: line.Currency}
+		if i, ok := index[key]; ok {
+			debits[i].AmountMinor += line.AmountMinor
+			continue
+		}
+		index[key] = len(debits)
+		debits = append(debits, line)
+	}
+	return debits
+}
+
+// ValidateLegAccounts checks that every leg's account exists, is ACTIVE and holds
+// the leg's currency. accounts maps account IDs to directory entries; a missing or
+// nil entry means the account was not found.
+func (t *Transfer) ValidateLegAccounts(accounts map[uuid.UUID]*Account) error {
+	for i, leg := range t.Lines() {
+		if err := validateAccount(fmt.Sprintf("legs[%d].account_id", i), leg.AccountID, accounts[leg.AccountID], leg.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
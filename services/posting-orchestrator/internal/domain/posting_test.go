@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewPosting_MarketplaceSplit(t *testing.T) {
+	buyer, seller, platform := uuid.New(), uuid.New(), uuid.New()
+
+	posting, err := NewPosting([]Leg{
+		{AccountID: buyer, AmountMinor: 10000, Currency: "USD", Side: SideDebit},
+		{AccountID: seller, AmountMinor: 9000, Currency: "USD", Side: SideCredit},
+		{AccountID: platform, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	}, "order-42")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if posting.FromAccountID != buyer || posting.ToAccountID != seller {
+		t.Errorf("expected summary from buyer to seller, got %s -> %s", posting.FromAccountID, posting.ToAccountID)
+	}
+	if posting.AmountMinor != 10000 || posting.Currency != "USD" {
+		t.Errorf("expected summary amount 10000 USD, got %d %s", posting.AmountMinor, posting.Currency)
+	}
+	if len(posting.Lines()) != 3 {
+		t.Errorf("expected 3 ledger lines, got %d", len(posting.Lines()))
+	}
+}
+
+func TestTransfer_Debits(t *testing.T) {
+	buyer, seller, platform := uuid.New(), uuid.New(), uuid.New()
+
+	posting, err := NewPosting([]Leg{
+		{AccountID: buyer, AmountMinor: 9000, Currency: "USD", Side: SideDebit},
+		{AccountID: seller, AmountMinor: 9000, Currency: "USD", Side: SideCredit},
+		{AccountID: buyer, AmountMinor: 1000, Currency: "USD", Side: SideDebit},
+		{AccountID: platform, AmountMinor: 1000, Currency: "USD", Side: SideCredit},
+	}, "order-43")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	debits := posting.Debits()
+	if len(debits) != 1 || debits[0].AccountID != buyer || debits[0].AmountMinor != 10000 {
+		t.Fatalf("expected one 10000 debit of the buyer, got %+v", debits)
+	}
+
+	transfer, err := NewTransfer(buyer, seller, 500, "USD", "transfer-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	debits = transfer.Debits()
+	if len(debits) != 1 || debits[0].AccountID != buyer || debits[0].AmountMinor != 500 {
+		t.Fatalf("expected one 500 debit of the sender, got %+v", debits)
+	}
+}
+
+func TestValidateLegs(t *testing.T) {
+	a, b, c := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		legs    []Leg
+		wantErr bool
+	}{
+		{
+			name: "balanced two legs",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+				{AccountID: b, AmountMinor: 100, Currency: "USD", Side: SideCredit},
+			},
+		},
+		{
+			name: "balanced per currency",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+				{AccountID: b, AmountMinor: 100, Currency: "USD", Side: SideCredit},
+				{AccountID: b, AmountMinor: 90, Currency: "EUR", Side: SideDebit},
+				{AccountID: c, AmountMinor: 90, Currency: "EUR", Side: SideCredit},
+			},
+		},
+		{
+			name:    "single leg",
+			legs:    []Leg{{AccountID: a, AmountMinor: 100, Currency: "USD", Side: SideDebit}},
+			wantErr: true,
+		},
+		{
+			name: "unbalanced",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+				{AccountID: b, AmountMinor: 99, Currency: "USD", Side: SideCredit},
+			},
+			wantErr: true,
+		},
+		{
+			name: "currencies do not offset",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+				{AccountID: b, AmountMinor: 100, Currency: "EUR", Side: SideCredit},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid side",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 100, Currency: "USD", Side: "SIDEWAYS"},
+				{AccountID: b, AmountMinor: 100, Currency: "USD", Side: SideCredit},
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-positive amount",
+			legs: []Leg{
+				{AccountID: a, AmountMinor: 0, Currency: "USD", Side: SideDebit},
+				{AccountID: b, AmountMinor: 0, Currency: "USD", Side: SideCredit},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLegs(tt.legs)
+			if tt.wantErr && err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTransfer_ValidateLegAccounts(t *testing.T) {
+	buyer, seller, platform := uuid.New(), uuid.New(), uuid.New()
+	posting, err := NewPosting([]Leg{
+		{AccountID: buyer, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+		{AccountID: seller, AmountMinor: 90, Currency: "USD", Side: SideCredit},
+		{AccountID: platform, AmountMinor: 10, Currency: "USD", Side: SideCredit},
+	}, "order-43")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	accounts := map[uuid.UUID]*Account{
+		buyer:  {ID: buyer, Currency: "USD", Status: AccountActive},
+		seller: {ID: seller, Currency: "USD", Status: AccountActive},
+	}
+	err = posting.ValidateLegAccounts(accounts)
+	accountErr, ok := err.(*AccountError)
+	if !ok || accountErr.Code != CodeAccountNotFound || accountErr.AccountID != platform {
+		t.Fatalf("expected platform account not found, got %v", err)
+	}
+
+	accounts[platform] = &Account{ID: platform, Currency: "USD", Status: AccountActive}
+	if err := posting.ValidateLegAccounts(accounts); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Refund rejection codes returned to API clients
+const (
+	CodeTransferNotCompleted     = "transfer_not_completed"
+	CodeRefundNotRefundable      = "refund_not_refundable"
+	CodeTransferFullyRefunded    = "transfer_fully_refunded"
+	CodeRefundExceedsTransfer    = "refund_exceeds_transfer"
+	CodePartialRefundUnsupported = "partial_refund_unsupported"
+)
+
+// RefundError is returned when a transfer cannot be refunded as requested
+type RefundError struct {
+	Code       string
+	TransferID uuid.UUID
+	Message    string
+}
+
+func (e *RefundError) Error() string {
+	return fmt.Sprintf("transfer %s: %s", e.TransferID, e.Message)
+}
+
+// NewRefund creates a transfer that reverses amountMinor of the original; zero
+// refunds everything not refunded yet. committedMinor is the amount already
+// taken by completed and in-flight refunds of the original.
+//
+// A simple transfer can be refunded in several parts. A multi-leg posting can
+// only be refunded in full, since a partial amount has no unique split across legs.
+func NewRefund(original *Transfer, committedMinor, amountMinor int64, idempotencyKey, reason string) (*Transfer, error) {
+	if original.RefundOf != nil {
+		return nil, &RefundError{Code: CodeRefundNotRefundable, TransferID: original.ID, Message: "a refund cannot itself be refunded"}
+	}
+	if original.Status != StatusCompleted {
+		return nil, &RefundError{
+			Code:       CodeTransferNotCompleted,
+			TransferID: original.ID,
+			Message:    fmt.Sprintf("only COMPLETED transfers can be refunded, transfer is %s", original.Status),
+		}
+	}
+	if idempotencyKey == "" {
+		return nil, &ValidationError{Field: "idempotency_key", Message: "idempotency key is required"}
+	}
+
+	remaining := original.AmountMinor - committedMinor
+	if remaining <= 0 {
+		return nil, &RefundError{Code: CodeTransferFullyRefunded, TransferID: original.ID, Message: "transfer is already fully refunded"}
+	}
+	if amountMinor == 0 {
+		amountMinor = remaining
+	}
+	if amountMinor < 0 {
+		return nil, &ValidationError{Field: "amount_minor", Message: "amount must be positive"}
+	}
+	if amountMinor > remaining {
+		return nil, &RefundError{
+			Code:       CodeRefundExceedsTransfer,
+			TransferID: original.ID,
+			Message:    fmt.Sprintf("refund of %d exceeds refundable %d %s", amountMinor, remaining, original.Currency),
+		}
+	}
+
+	originalID := original.ID
+	refund := &Transfer{
+		ID:             uuid.New(),
+		FromAccountID:  original.ToAccountID,
+		ToAccountID:    original.FromAccountID,
+		AmountMinor:    amountMinor,
+		Currency:       original.Currency,
+		IdempotencyKey: idempotencyKey,
+		Status:         StatusInitiated,
+		RefundOf:       &originalID,
+	}
+	if reason != "" {
+		refund.RefundReason = &reason
+	}
+
+	if len(original.Legs) > 0 {
+		if amountMinor != original.AmountMinor {
+			return nil, &RefundError{
+				Code:       CodePartialRefundUnsupported,
+				TransferID: original.ID,
+				Message:    "multi-leg postings can only be refunded in full",
+			}
+		}
+		refund.Legs = make([]Leg, len(original.Legs))
+		for i, leg := range original.Legs {
+			refund.Legs[i] = leg
+			refund.Legs[i].Side = SideDebit
+			if leg.Side == SideDebit {
+				refund.Legs[i].Side = SideCredit
+			}
+		}
+	}
+
+	return refund, nil
+}
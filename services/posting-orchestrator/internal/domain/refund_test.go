@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func completedTransfer(t *testing.T, amountMinor int64) *Transfer {
+	t.Helper()
+	transfer, err := NewTransfer(uuid.New(), uuid.New(), amountMinor, "USD", "order-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	transfer.Status = StatusCompleted
+	return transfer
+}
+
+func TestNewRefund(t *testing.T) {
+	tests := []struct {
+		name           string
+		committedMinor int64
+		amountMinor    int64
+		wantAmount     int64
+		wantCode       string
+	}{
+		{name: "full refund", amountMinor: 0, wantAmount: 1000},
+		{name: "partial refund", amountMinor: 400, wantAmount: 400},
+		{name: "remainder after partial refund", committedMinor: 400, amountMinor: 0, wantAmount: 600},
+		{name: "exact remainder", committedMinor: 400, amountMinor: 600, wantAmount: 600},
+		{name: "exceeds remainder", committedMinor: 400, amountMinor: 601, wantCode: CodeRefundExceedsTransfer},
+		{name: "exceeds transfer", amountMinor: 1001, wantCode: CodeRefundExceedsTransfer},
+		{name: "already fully refunded", committedMinor: 1000, amountMinor: 0, wantCode: CodeTransferFullyRefunded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := completedTransfer(t, 1000)
+			refund, err := NewRefund(original, tt.committedMinor, tt.amountMinor, "refund-1", "customer request")
+
+			if tt.wantCode != "" {
+				refundErr, ok := err.(*RefundError)
+				if !ok || refundErr.Code != tt.wantCode {
+					t.Fatalf("expected refund error %s, got %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if refund.AmountMinor != tt.wantAmount {
+				t.Errorf("expected refund amount %d, got %d", tt.wantAmount, refund.AmountMinor)
+			}
+			if refund.FromAccountID != original.ToAccountID || refund.ToAccountID != original.FromAccountID {
+				t.Error("expected refund to reverse the transfer direction")
+			}
+			if refund.RefundOf == nil || *refund.RefundOf != original.ID {
+				t.Error("expected refund to be linked to the original transfer")
+			}
+			if refund.ID == original.ID {
+				t.Error("expected refund to be a new transfer")
+			}
+		})
+	}
+}
+
+func TestNewRefund_Rejected(t *testing.T) {
+	pending := completedTransfer(t, 1000)
+	pending.Status = StatusInitiated
+	if _, err := NewRefund(pending, 0, 0, "refund-1", ""); err == nil {
+		t.Error("expected error refunding a transfer that is not completed")
+	}
+
+	refund, err := NewRefund(completedTransfer(t, 1000), 0, 0, "refund-1", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	refund.Status = StatusCompleted
+	if _, err := NewRefund(refund, 0, 0, "refund-2", ""); err == nil {
+		t.Error("expected error refunding a refund")
+	}
+
+	if _, err := NewRefund(completedTransfer(t, 1000), 0, 0, "", ""); err == nil {
+		t.Error("expected error for missing idempotency key")
+	}
+	if _, err := NewRefund(completedTransfer(t, 1000), 0, -5, "refund-1", ""); err == nil {
+		t.Error("expected error for negative amount")
+	}
+}
+
+func TestNewRefund_Posting(t *testing.T) {
+	buyer, seller, platform := uuid.New(), uuid.New(), uuid.New()
+	posting, err := NewPosting([]Leg{
+		{AccountID: buyer, AmountMinor: 100, Currency: "USD", Side: SideDebit},
+		{AccountID: seller, AmountMinor: 90, Currency: "USD", Side: SideCredit},
+		{AccountID: platform, AmountMinor: 10, Currency: "USD", Side: SideCredit},
+	}, "order-44")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	posting.Status = StatusCompleted
+
+	if _, err := NewRefund(posting, 0, 50, "refund-1", ""); err == nil {
+		t.Fatal("expected error for partial refund of a posting")
+	}
+
+	refund, err := NewRefund(posting, 0, 0, "refund-1", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ValidateLegs(refund.Lines()); err != nil {
+		t.Fatalf("expected balanced refund legs, got %v", err)
+	}
+	for i, leg := range refund.Legs {
+		if leg.Side == posting.Legs[i].Side {
+			t.Errorf("expected leg %d side to be reversed", i)
+		}
+	}
+}
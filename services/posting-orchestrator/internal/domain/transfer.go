@@ -26,6 +26,9 @@ type Transfer struct {
 	Status          TransferStatus
 	EntryID         *uuid.UUID
 	FailureReason   *string
+	Legs            []Leg      // explicit legs of a multi-leg posting; empty for a simple transfer
+	RefundOf        *uuid.UUID // original transfer when this transfer is a refund
+	RefundReason    *string
 }
 
 // ValidationError represents validation errors
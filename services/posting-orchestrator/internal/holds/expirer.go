@@ -0,0 +1,103 @@
+package holds
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+)
+
+// Expirer is the background worker that releases pending holds past their expiry
+type Expirer struct {
+	db           *sql.DB
+	queries      *store.Queries
+	pollInterval time.Duration // how often to look for expired holds
+	batchSize    int32         // maximum holds expired per transaction
+	logger       *log.Logger
+}
+
+// NewExpirer creates a new hold expirer instance
+func NewExpirer(db *sql.DB, logger *log.Logger) *Expirer {
+	return &Expirer{
+		db:           db,
+		queries:      store.New(db),
+		pollInterval: 15 * time.Second,
+		batchSize:    100,
+		logger:       logger,
+	}
+}
+
+// Start begins the expirer background worker
+func (e *Expirer) Start(ctx context.Context) error {
+	e.logger.Println("Hold expirer started")
+	ticker := time.NewTicker(e.pollInterval)
+	defer ticker.Stop()
+
+	// Run immediately on start
+	e.expireHolds(ctx)
+
+	for {
+		select {
+		case <-ticker.C:
+			e.expireHolds(ctx)
+		case <-ctx.Done():
+			e.logger.Println("Hold expirer stopped")
+			return ctx.Err()
+		}
+	}
+}
+
+// expireHolds releases batches of expired holds until none are left
+func (e *Expirer) expireHolds(ctx context.Context) {
+	for {
+		expired, err := e.expireBatch(ctx)
+		if err != nil {
+			e.logger.Printf("Failed to expire holds: %v", err)
+			return
+		}
+		if expired > 0 {
+			e.logger.Printf("Expired %d hold(s)", expired)
+		}
+		if expired < int(e.batchSize) {
+			return
+		}
+	}
+}
+
+// expireBatch expires one batch of holds in a single transaction and returns how many were expired
+func (e *Expirer) expireBatch(ctx context.Context) (int, error) {
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	qtx := e.queries.WithTx(tx)
+
+	now := time.Now()
+	rows, err := qtx.GetExpiredHolds(ctx, store.GetExpiredHoldsParams{
+		ExpiresAt: now,
+		Limit:     e.batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, row := range rows {
+		hold := FromRow(row)
+		released, err := hold.Expire(now)
+		if err != nil {
+			return 0, err
+		}
+		if err := RecordRelease(ctx, qtx, hold, released, ReasonExpired, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,78 @@
+package holds
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// Release reasons recorded on HoldReleased events
+const (
+	ReasonReleased = "released"
+	ReasonExpired  = "expired"
+)
+
+// FromRow converts a stored hold to its domain representation
+func FromRow(row store.Hold) *domain.Hold {
+	return &domain.Hold{
+		ID:                    row.ID,
+		AccountID:             row.AccountID,
+		CounterpartyAccountID: row.CounterpartyAccountID,
+		AmountMinor:           row.AmountMinor,
+		CapturedMinor:         row.CapturedMinor,
+		Currency:              row.Currency,
+		IdempotencyKey:        row.IdempotencyKey,
+		Status:                domain.HoldStatus(row.Status),
+		ExpiresAt:             row.ExpiresAt,
+	}
+}
+
+// RecordRelease persists a released or expired hold and emits HoldReleased inside the caller's transaction
+func RecordRelease(ctx context.Context, qtx *store.Queries, hold *domain.Hold, releasedMinor int64, reason string, now time.Time) error {
+	if err := qtx.UpdateHoldReleased(ctx, store.UpdateHoldReleasedParams{
+		ID:         hold.ID,
+		Status:     string(hold.Status),
+		ReleasedAt: sql.NullTime{Time: now, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	releasedEvent := &ledgerv1.HoldReleased{
+		HoldId: hold.ID.String(),
+		Amount: &ledgerv1.Money{
+			Units:    releasedMinor,
+			Currency: hold.Currency,
+		},
+		Reason:   reason,
+		TsUnixMs: now.UnixMilli(),
+	}
+
+	releasedPayload, _ := proto.Marshal(releasedEvent)
+	releasedHeaders := map[string]interface{}{
+		"event_name": "HoldReleased",
+		"schema":     "ledger.v1.HoldReleased",
+	}
+	releasedHeadersJSON, _ := json.Marshal(releasedHeaders)
+
+	if _, err := qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "Hold",
+		AggregateID:   hold.ID,
+		EventType:     "HoldReleased",
+		Payload:       releasedPayload,
+		Headers:       releasedHeadersJSON,
+		CreatedAt:     now,
+	}); err != nil {
+		return fmt.Errorf("failed to create released event: %w", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,9 +13,11 @@ import (
 	"time"
 
 	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/directory"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/idem"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/resilience"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/refunds"
 	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -52,6 +55,7 @@ type LedgerEntryRequest struct {
 type LedgerLineRequest struct {
 	AccountID   string `json:"account_id"`
 	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
 	Side        string `json:"side"`
 }
 
@@ -61,11 +65,24 @@ type LedgerEntryResponse struct {
 	BatchID string `json:"batch_id"`
 }
 
+// LedgerRejectionError is returned when the ledger service rejects an entry
+// with a 4xx response. Rejections are business outcomes and are not retried.
+type LedgerRejectionError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *LedgerRejectionError) Error() string {
+	return fmt.Sprintf("ledger service rejected entry (%d %s): %s", e.StatusCode, e.Code, e.Message)
+}
+
 // Handler handles HTTP requests for the orchestrator service
 type Handler struct {
 	db             *sql.DB
 	queries        *store.Queries
 	idemGuard      *idem.Guard
+	accounts       *directory.Directory
 	ledgerURL      string
 	httpClient     *http.Client
 	logger         *log.Logger
@@ -74,11 +91,12 @@ type Handler struct {
 }
 
 // NewHandler creates a new HTTP handler
-func NewHandler(db *sql.DB, idemGuard *idem.Guard, ledgerURL string, logger *log.Logger) *Handler {
+func NewHandler(db *sql.DB, idemGuard *idem.Guard, accounts *directory.Directory, ledgerURL string, logger *log.Logger) *Handler {
 	return &Handler{
 		db:        db,
 		queries:   store.New(db),
 		idemGuard: idemGuard,
+		accounts:  accounts,
 		ledgerURL: ledgerURL,
 		httpClient: &http.Client{
 			Timeout:   10 * time.Second,
@@ -114,11 +132,24 @@ func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Create transfer with validation
+	transfer, err := domain.NewTransfer(fromAccountID, toAccountID, req.AmountMinor, req.Currency, req.IdempotencyKey)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	h.submitTransfer(ctx, w, transfer)
+}
+
+// submitTransfer runs a validated transfer or posting through idempotency checks,
+// account validation and the ledger saga, and writes the HTTP response
+func (h *Handler) submitTransfer(ctx context.Context, w http.ResponseWriter, transfer *domain.Transfer) {
 	// Check idempotency - first check database for existing transfer
-	existingTransfer, err := h.queries.GetTransferByIdempotencyKey(ctx, req.IdempotencyKey)
+	existingTransfer, err := h.queries.GetTransferByIdempotencyKey(ctx, transfer.IdempotencyKey)
 	if err == nil {
 		// Transfer already exists, return the existing result
-		h.logger.Printf("Idempotent request detected: %s", req.IdempotencyKey)
+		h.logger.Printf("Idempotent request detected: %s", transfer.IdempotencyKey)
 		h.respondTransfer(w, existingTransfer)
 		return
 	} else if err != sql.ErrNoRows {
@@ -127,8 +158,21 @@ func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Check all accounts exist, are active and hold the transfer currency
+	// (before claiming the idempotency key, so a rejected request can be retried)
+	if err := h.validateTransferAccounts(ctx, transfer); err != nil {
+		var accountErr *domain.AccountError
+		if errors.As(err, &accountErr) {
+			h.respondError(w, http.StatusUnprocessableEntity, accountErr.Code, accountErr.Error())
+			return
+		}
+		h.logger.Printf("Failed to validate accounts: %v", err)
+		h.respondError(w, http.StatusServiceUnavailable, "account_directory_unavailable", "Failed to verify accounts")
+		return
+	}
+
 	// Try to claim idempotency key in Redis (optional, falls back to database)
-	claimed, err := h.idemGuard.Claim(ctx, fmt.Sprintf("transfer:%s", req.IdempotencyKey), 5*time.Minute)
+	claimed, err := h.idemGuard.Claim(ctx, fmt.Sprintf("transfer:%s", transfer.IdempotencyKey), 5*time.Minute)
 	if err != nil {
 		// Redis is unavailable, log warning and continue (database idempotency check already passed)
 		h.logger.Printf("Redis unavailable for idempotency check (continuing with database fallback): %v", err)
@@ -138,16 +182,20 @@ func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create transfer with validation
-	transfer, err := domain.NewTransfer(fromAccountID, toAccountID, req.AmountMinor, req.Currency, req.IdempotencyKey)
-	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
-		return
-	}
-
 	// Execute transfer coordination
 	if err := h.executeTransfer(ctx, transfer); err != nil {
 		h.logger.Printf("Failed to execute transfer: %v", err)
+		// The ledger's 4xx rejections are final answers, passed through with their code
+		var rejection *LedgerRejectionError
+		if errors.As(err, &rejection) {
+			h.respondError(w, http.StatusUnprocessableEntity, rejection.Code, rejection.Message)
+			return
+		}
+		var fundsErr *domain.FundsError
+		if errors.As(err, &fundsErr) {
+			h.respondError(w, http.StatusUnprocessableEntity, domain.CodeInsufficientFunds, fundsErr.Error())
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "transfer_failed", err.Error())
 		return
 	}
@@ -163,7 +211,48 @@ func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
 	h.respondTransfer(w, dbTransfer)
 }
 
-// executeTransfer coordinates the transfer by calling ledger and emitting events
+// validateTransferAccounts checks every account the transfer posts to against the directory
+func (h *Handler) validateTransferAccounts(ctx context.Context, transfer *domain.Transfer) error {
+	if len(transfer.Legs) == 0 {
+		return h.validateAccounts(ctx, transfer.FromAccountID, transfer.ToAccountID, transfer.Currency)
+	}
+
+	accounts := make(map[uuid.UUID]*domain.Account)
+	for _, leg := range transfer.Legs {
+		if _, seen := accounts[leg.AccountID]; seen {
+			continue
+		}
+		account, err := h.accounts.Lookup(ctx, leg.AccountID)
+		if err != nil {
+			return err
+		}
+		accounts[leg.AccountID] = account
+	}
+	return transfer.ValidateLegAccounts(accounts)
+}
+
+// validateAccounts looks up both accounts in the directory and checks they can take part in the transfer
+func (h *Handler) validateAccounts(ctx context.Context, fromAccountID, toAccountID uuid.UUID, currency string) error {
+	from, err := h.accounts.Lookup(ctx, fromAccountID)
+	if err != nil {
+		return err
+	}
+	to, err := h.accounts.Lookup(ctx, toAccountID)
+	if err != nil {
+		return err
+	}
+
+	transfer := &domain.Transfer{
+		FromAccountID: fromAccountID,
+		ToAccountID:   toAccountID,
+		Currency:      currency,
+	}
+	return transfer.ValidateAccounts(from, to)
+}
+
+// executeTransfer coordinates the transfer by calling ledger and emitting events.
+// A debit that would spend funds reserved by pending holds is recorded as failed
+// without calling the ledger.
 func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer) error {
 	// Start transaction
 	tx, err := h.db.BeginTx(ctx, nil)
@@ -174,9 +263,37 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 
 	qtx := h.queries.WithTx(tx)
 
+	// Recording the transfer under the funds lock reserves its debits
+	// against holds and other debits until the ledger saga finishes
+	var fundsErr *domain.FundsError
+	if err := h.checkDebits(ctx, qtx, transfer, time.Now()); err != nil && !errors.As(err, &fundsErr) {
+		return err
+	}
+
+	if err := h.recordTransfer(ctx, qtx, transfer); err != nil {
+		return err
+	}
+
+	// Commit transaction
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if fundsErr != nil {
+		if err := h.markTransferFailed(ctx, transfer.ID, fmt.Sprintf("%s: %s", domain.CodeInsufficientFunds, fundsErr.Error())); err != nil {
+			h.logger.Printf("Failed to mark transfer as failed: %v", err)
+		}
+		return fundsErr
+	}
+
+	return h.runLedgerSaga(ctx, transfer)
+}
+
+// recordTransfer inserts the transfer record, its legs and the TransferInitiated event inside qtx's transaction
+func (h *Handler) recordTransfer(ctx context.Context, qtx *store.Queries, transfer *domain.Transfer) error {
 	// Insert transfer record
 	now := time.Now()
-	_, err = qtx.CreateTransfer(ctx, store.CreateTransferParams{
+	params := store.CreateTransferParams{
 		ID:             transfer.ID,
 		FromAccountID:  transfer.FromAccountID,
 		ToAccountID:    transfer.ToAccountID,
@@ -186,11 +303,31 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 		Status:         string(domain.StatusInitiated),
 		CreatedAt:      now,
 		UpdatedAt:      now,
-	})
-	if err != nil {
+	}
+	if transfer.RefundOf != nil {
+		params.RefundOf = uuid.NullUUID{UUID: *transfer.RefundOf, Valid: true}
+	}
+	if transfer.RefundReason != nil {
+		params.RefundReason = sql.NullString{String: *transfer.RefundReason, Valid: true}
+	}
+	if _, err := qtx.CreateTransfer(ctx, params); err != nil {
 		return fmt.Errorf("failed to create transfer record: %w", err)
 	}
 
+	// Store the explicit legs of a multi-leg posting
+	for i, leg := range transfer.Legs {
+		if err := qtx.CreateTransferLeg(ctx, store.CreateTransferLegParams{
+			TransferID:  transfer.ID,
+			LegIndex:    int32(i),
+			AccountID:   leg.AccountID,
+			AmountMinor: leg.AmountMinor,
+			Currency:    leg.Currency,
+			Side:        string(leg.Side),
+		}); err != nil {
+			return fmt.Errorf("failed to create transfer leg: %w", err)
+		}
+	}
+
 	// Create TransferInitiated event
 	initiatedEvent := &ledgerv1.TransferInitiated{
 		TransferId: transfer.ID.String(),
@@ -203,6 +340,20 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 		IdemKey:  transfer.IdempotencyKey,
 		TsUnixMs: now.UnixMilli(),
 	}
+	for _, leg := range transfer.Lines() {
+		side := ledgerv1.Side_DEBIT
+		if leg.Side == domain.SideCredit {
+			side = ledgerv1.Side_CREDIT
+		}
+		initiatedEvent.Legs = append(initiatedEvent.Legs, &ledgerv1.EntryLine{
+			AccountId: leg.AccountID.String(),
+			Amount: &ledgerv1.Money{
+				Units:    leg.AmountMinor,
+				Currency: leg.Currency,
+			},
+			Side: side,
+		})
+	}
 
 	initiatedPayload, _ := proto.Marshal(initiatedEvent)
 	initiatedHeaders := map[string]interface{}{
@@ -211,7 +362,7 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 	}
 	initiatedHeadersJSON, _ := json.Marshal(initiatedHeaders)
 
-	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+	_, err := qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
 		ID:            uuid.New(),
 		AggregateType: "Transfer",
 		AggregateID:   transfer.ID,
@@ -224,11 +375,11 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 		return fmt.Errorf("failed to create initiated event: %w", err)
 	}
 
-	// Commit transaction
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
+	return nil
+}
 
+// runLedgerSaga posts a recorded transfer to the ledger and marks it completed or failed
+func (h *Handler) runLedgerSaga(ctx context.Context, transfer *domain.Transfer) error {
 	// Record that we're about to call the ledger (enables compensator recovery)
 	ledgerCallTime := time.Now()
 	if err := h.queries.RecordLedgerCall(ctx, store.RecordLedgerCallParams{
@@ -242,8 +393,13 @@ func (h *Handler) executeTransfer(ctx context.Context, transfer *domain.Transfer
 	// Call ledger service to create journal entry
 	entryID, ledgerResponse, err := h.callLedgerService(ctx, transfer)
 	if err != nil {
-		// Mark transfer as failed
-		if err := h.markTransferFailed(ctx, transfer.ID, err.Error()); err != nil {
+		// Mark transfer as failed, recording the ledger's rejection code when there is one
+		reason := err.Error()
+		var rejection *LedgerRejectionError
+		if errors.As(err, &rejection) {
+			reason = fmt.Sprintf("%s: %s", rejection.Code, rejection.Message)
+		}
+		if err := h.markTransferFailed(ctx, transfer.ID, reason); err != nil {
 			h.logger.Printf("Failed to mark transfer as failed: %v", err)
 		}
 		return fmt.Errorf("ledger service call failed: %w", err)
@@ -285,22 +441,18 @@ func (h *Handler) callLedgerService(ctx context.Context, transfer *domain.Transf
 
 // doLedgerCall performs the actual HTTP call to ledger service
 func (h *Handler) doLedgerCall(ctx context.Context, transfer *domain.Transfer) (uuid.UUID, string, error) {
-	// Create ledger entry request with double-entry lines
+	// Create ledger entry request with one double-entry line per leg
 	batchID := transfer.ID // Use transfer ID as batch ID
 	ledgerReq := LedgerEntryRequest{
 		BatchID: batchID.String(),
-		Lines: []LedgerLineRequest{
-			{
-				AccountID:   transfer.FromAccountID.String(),
-				AmountMinor: transfer.AmountMinor,
-				Side:        "DEBIT",
-			},
-			{
-				AccountID:   transfer.ToAccountID.String(),
-				AmountMinor: transfer.AmountMinor,
-				Side:        "CREDIT",
-			},
-		},
+	}
+	for _, leg := range transfer.Lines() {
+		ledgerReq.Lines = append(ledgerReq.Lines, LedgerLineRequest{
+			AccountID:   leg.AccountID.String(),
+			AmountMinor: leg.AmountMinor,
+			Currency:    leg.Currency,
+			Side:        string(leg.Side),
+		})
 	}
 
 	reqBody, err := json.Marshal(ledgerReq)
@@ -325,9 +477,21 @@ func (h *Handler) doLedgerCall(ctx context.Context, transfer *domain.Transfer) (
 	respBody, _ := io.ReadAll(resp.Body)
 	respBodyStr := string(respBody)
 
-	if resp.StatusCode != http.StatusCreated {
+	// 200 means the ledger already had an identical entry for this batch (retried request)
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		var errResp ErrorResponse
 		json.Unmarshal(respBody, &errResp)
+
+		// Client errors are final answers from the ledger; don't retry or trip the breaker.
+		// 408 and 429 are transient and fall through to the retry path.
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+			return uuid.Nil, respBodyStr, resilience.Permanent(&LedgerRejectionError{
+				StatusCode: resp.StatusCode,
+				Code:       errResp.Error,
+				Message:    errResp.Message,
+			})
+		}
 		return uuid.Nil, respBodyStr, fmt.Errorf("ledger service returned %d: %s", resp.StatusCode, errResp.Message)
 	}
 
@@ -389,6 +553,11 @@ func (h *Handler) markTransferCompletedWithResponse(ctx context.Context, transfe
 		return err
 	}
 
+	// A completed refund also updates the refunded total of its original
+	if err := refunds.RecordCompleted(ctx, qtx, transferID); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -554,6 +723,41 @@ func (h *Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
 	if transfer.FailureReason.Valid {
 		resp["failure_reason"] = transfer.FailureReason.String
 	}
+	if transfer.RefundOf.Valid {
+		resp["refund_of"] = transfer.RefundOf.UUID.String()
+		if transfer.RefundReason.Valid {
+			resp["refund_reason"] = transfer.RefundReason.String
+		}
+	} else {
+		// Include how much of the transfer has been refunded so far
+		refunds, err := h.queries.SumRefunds(ctx, uuid.NullUUID{UUID: transfer.ID, Valid: true})
+		if err != nil {
+			h.logger.Printf("Failed to sum transfer refunds: %v", err)
+			h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to get transfer")
+			return
+		}
+		resp["refunded_minor"] = refunds.RefundedMinor
+	}
+
+	// Include the legs of a multi-leg posting
+	legs, err := h.queries.ListTransferLegs(ctx, transfer.ID)
+	if err != nil {
+		h.logger.Printf("Failed to get transfer legs: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to get transfer")
+		return
+	}
+	if len(legs) > 0 {
+		legResponses := make([]TransferLegResponse, len(legs))
+		for i, leg := range legs {
+			legResponses[i] = TransferLegResponse{
+				AccountID:   leg.AccountID.String(),
+				AmountMinor: leg.AmountMinor,
+				Currency:    leg.Currency,
+				Side:        leg.Side,
+			}
+		}
+		resp["legs"] = legResponses
+	}
 
 	// Respond with transfer data
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,758 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/holds"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// CreateHoldRequest represents the request to reserve funds on an account
+type CreateHoldRequest struct {
+	AccountID             string `json:"account_id"`
+	CounterpartyAccountID string `json:"counterparty_account_id"` // credited on capture
+	AmountMinor           int64  `json:"amount_minor"`
+	Currency              string `json:"currency"`
+	IdempotencyKey        string `json:"idempotency_key"`
+	ExpiresInSeconds      int64  `json:"expires_in_seconds,omitempty"` // 0 selects the default expiry
+}
+
+// CaptureHoldRequest represents the request to capture all or part of a hold
+type CaptureHoldRequest struct {
+	AmountMinor    int64  `json:"amount_minor,omitempty"` // 0 captures everything that remains
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// HoldResponse represents a hold and its captures
+type HoldResponse struct {
+	HoldID                string                `json:"hold_id"`
+	AccountID             string                `json:"account_id"`
+	CounterpartyAccountID string                `json:"counterparty_account_id"`
+	AmountMinor           int64                 `json:"amount_minor"`
+	CapturedMinor         int64                 `json:"captured_minor"`
+	RemainingMinor        int64                 `json:"remaining_minor"`
+	Currency              string                `json:"currency"`
+	Status                string                `json:"status"`
+	IdempotencyKey        string                `json:"idempotency_key"`
+	ExpiresAt             string                `json:"expires_at"`
+	ReleasedAt            string                `json:"released_at,omitempty"`
+	CreatedAt             string                `json:"created_at"`
+	Captures              []HoldCaptureResponse `json:"captures"`
+}
+
+// HoldCaptureResponse represents a single capture posted against a hold
+type HoldCaptureResponse struct {
+	CaptureID      string `json:"capture_id"`
+	AmountMinor    int64  `json:"amount_minor"`
+	EntryID        string `json:"entry_id,omitempty"` // empty while the capture is being posted
+	IdempotencyKey string `json:"idempotency_key"`
+	CreatedAt      string `json:"created_at"`
+}
+
+// ledgerBalancesResponse represents the response from the ledger GET /v1/accounts/{id}/balances
+type ledgerBalancesResponse struct {
+	Balances []struct {
+		Currency       string `json:"currency"`
+		BalanceMinor   int64  `json:"balance_minor"`
+		AvailableMinor *int64 `json:"available_minor"` // nil when the account has no floor
+	} `json:"balances"`
+}
+
+// CreateHold handles POST /v1/holds
+func (h *Handler) CreateHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+
+	accountID, err := uuid.Parse(req.AccountID)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_account_id", "account_id must be a valid UUID")
+		return
+	}
+
+	counterpartyAccountID, err := uuid.Parse(req.CounterpartyAccountID)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_counterparty_account_id", "counterparty_account_id must be a valid UUID")
+		return
+	}
+
+	// Check idempotency - an existing hold for the key is returned as-is
+	existing, err := h.queries.GetHoldByIdempotencyKey(ctx, req.IdempotencyKey)
+	if err == nil {
+		h.logger.Printf("Idempotent hold request detected: %s", req.IdempotencyKey)
+		h.respondHold(ctx, w, http.StatusOK, existing)
+		return
+	} else if err != sql.ErrNoRows {
+		h.logger.Printf("Failed to check hold idempotency: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to process hold")
+		return
+	}
+
+	now := time.Now()
+	hold, err := domain.NewHold(accountID, counterpartyAccountID, req.AmountMinor, req.Currency, req.IdempotencyKey,
+		time.Duration(req.ExpiresInSeconds)*time.Second, now)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// The hold is captured as a transfer from the account to the counterparty
+	if err := h.validateAccounts(ctx, accountID, counterpartyAccountID, req.Currency); err != nil {
+		var accountErr *domain.AccountError
+		if errors.As(err, &accountErr) {
+			h.respondError(w, http.StatusUnprocessableEntity, accountErr.Code, accountErr.Error())
+			return
+		}
+		h.logger.Printf("Failed to validate accounts: %v", err)
+		h.respondError(w, http.StatusServiceUnavailable, "account_directory_unavailable", "Failed to verify accounts")
+		return
+	}
+
+	row, err := h.placeHold(ctx, hold, now)
+	if err != nil {
+		var holdErr *domain.HoldError
+		if errors.As(err, &holdErr) {
+			h.respondError(w, http.StatusUnprocessableEntity, holdErr.Code, holdErr.Message)
+			return
+		}
+		h.logger.Printf("Failed to place hold: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "hold_failed", "Failed to place hold")
+		return
+	}
+
+	h.logger.Printf("Hold %s placed on account %s for %d %s (expires %s)",
+		hold.ID, hold.AccountID, hold.AmountMinor, hold.Currency, hold.ExpiresAt.Format(time.RFC3339))
+	h.respondHold(ctx, w, http.StatusCreated, row)
+}
+
+// placeHold checks the account can cover the hold and stores it with a HoldPlaced event.
+// Placement takes the account's funds lock, which debits take too, so concurrent
+// holds and debits cannot over-reserve funds.
+func (h *Handler) placeHold(ctx context.Context, hold *domain.Hold, now time.Time) (store.Hold, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Hold{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	if err := lockAccounts(ctx, qtx, hold.AccountID); err != nil {
+		return store.Hold{}, err
+	}
+
+	held, inFlight, err := reservedFunds(ctx, qtx, hold.AccountID, hold.Currency, now)
+	if err != nil {
+		return store.Hold{}, err
+	}
+	available, limited, err := h.fetchAvailable(ctx, hold.AccountID, hold.Currency)
+	if err != nil {
+		return store.Hold{}, err
+	}
+	if limited {
+		if err := hold.CheckFunds(available, held+inFlight); err != nil {
+			return store.Hold{}, err
+		}
+	}
+
+	row, err := qtx.CreateHold(ctx, store.CreateHoldParams{
+		ID:                    hold.ID,
+		AccountID:             hold.AccountID,
+		CounterpartyAccountID: hold.CounterpartyAccountID,
+		AmountMinor:           hold.AmountMinor,
+		Currency:              hold.Currency,
+		IdempotencyKey:        hold.IdempotencyKey,
+		Status:                string(hold.Status),
+		ExpiresAt:             hold.ExpiresAt,
+		CreatedAt:             now,
+	})
+	if err != nil {
+		return store.Hold{}, fmt.Errorf("failed to create hold record: %w", err)
+	}
+
+	placedEvent := &ledgerv1.HoldPlaced{
+		HoldId:                hold.ID.String(),
+		AccountId:             hold.AccountID.String(),
+		CounterpartyAccountId: hold.CounterpartyAccountID.String(),
+		Amount: &ledgerv1.Money{
+			Units:    hold.AmountMinor,
+			Currency: hold.Currency,
+		},
+		IdemKey:         hold.IdempotencyKey,
+		ExpiresAtUnixMs: hold.ExpiresAt.UnixMilli(),
+		TsUnixMs:        now.UnixMilli(),
+	}
+
+	placedPayload, _ := proto.Marshal(placedEvent)
+	placedHeaders := map[string]interface{}{
+		"event_name": "HoldPlaced",
+		"schema":     "ledger.v1.HoldPlaced",
+	}
+	placedHeadersJSON, _ := json.Marshal(placedHeaders)
+
+	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "Hold",
+		AggregateID:   hold.ID,
+		EventType:     "HoldPlaced",
+		Payload:       placedPayload,
+		Headers:       placedHeadersJSON,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return store.Hold{}, fmt.Errorf("failed to create placed event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Hold{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return row, nil
+}
+
+// checkDebits takes the funds lock of every account the transfer debits and
+// checks each debit leaves what pending holds reserve on the account. Accounts
+// without pending holds are left to the ledger's own balance check. Available
+// funds are read from the ledger under the lock, so a debit applied meanwhile
+// cannot be missed; the read is bounded by fundsCheckTimeout so a slow ledger
+// fails the request instead of holding the lock and its connection.
+func (h *Handler) checkDebits(ctx context.Context, qtx *store.Queries, transfer *domain.Transfer, now time.Time) error {
+	debits := transfer.Debits()
+	accountIDs := make([]uuid.UUID, len(debits))
+	for i, debit := range debits {
+		accountIDs[i] = debit.AccountID
+	}
+	if err := lockAccounts(ctx, qtx, accountIDs...); err != nil {
+		return err
+	}
+
+	for _, debit := range debits {
+		held, inFlight, err := reservedFunds(ctx, qtx, debit.AccountID, debit.Currency, now)
+		if err != nil {
+			return err
+		}
+		if held == 0 {
+			continue
+		}
+
+		available, limited, err := h.fetchAvailable(ctx, debit.AccountID, debit.Currency)
+		if err != nil {
+			return err
+		}
+		if !limited {
+			continue
+		}
+		if err := domain.CheckDebit(debit, available, held+inFlight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockAccounts takes the funds lock of each account inside qtx's transaction,
+// in a deterministic order so concurrent multi-leg debits cannot deadlock
+func lockAccounts(ctx context.Context, qtx *store.Queries, accountIDs ...uuid.UUID) error {
+	keys := make([]string, 0, len(accountIDs))
+	seen := make(map[uuid.UUID]bool, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if !seen[accountID] {
+			seen[accountID] = true
+			keys = append(keys, accountID.String())
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := qtx.LockAccountFunds(ctx, key); err != nil {
+			return fmt.Errorf("failed to lock account funds: %w", err)
+		}
+	}
+	return nil
+}
+
+// reservedFunds returns what the account's pending holds (held) and its debits
+// still in flight to the ledger (inFlight) reserve in currency. A debit the ledger has already
+// applied but the orchestrator has not completed yet counts twice, which errs
+// on the side of rejecting.
+func reservedFunds(ctx context.Context, qtx *store.Queries, accountID uuid.UUID, currency string, now time.Time) (held, inFlight int64, err error) {
+	held, err = qtx.SumPendingHolds(ctx, store.SumPendingHoldsParams{
+		AccountID: accountID,
+		Currency:  currency,
+		ExpiresAt: now,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum pending holds: %w", err)
+	}
+	inFlight, err = qtx.SumInFlightDebits(ctx, store.SumInFlightDebitsParams{
+		FromAccountID: accountID,
+		Currency:      currency,
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sum in-flight debits: %w", err)
+	}
+	return held, inFlight, nil
+}
+
+// fundsCheckTimeout bounds the ledger balance read made under an account's funds lock
+const fundsCheckTimeout = 2 * time.Second
+
+// fetchAvailable returns what the ledger allows the account to spend in currency.
+// limited is false when the account has no balance floor. An account without a
+// balance row in the currency has nothing available. Callers hold the account's
+// funds lock, so the read gives up after fundsCheckTimeout.
+func (h *Handler) fetchAvailable(ctx context.Context, accountID uuid.UUID, currency string) (available int64, limited bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, fundsCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/accounts/%s/balances", h.ledgerURL, accountID), nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to call ledger service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("ledger service returned %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var balances ledgerBalancesResponse
+	if err := json.Unmarshal(respBody, &balances); err != nil {
+		return 0, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	for _, balance := range balances.Balances {
+		if balance.Currency != currency {
+			continue
+		}
+		if balance.AvailableMinor == nil {
+			return 0, false, nil
+		}
+		return *balance.AvailableMinor, true, nil
+	}
+	return 0, true, nil
+}
+
+// GetHold handles GET /v1/holds/:id
+func (h *Handler) GetHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	holdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_hold_id", "Hold ID must be a valid UUID")
+		return
+	}
+
+	row, err := h.queries.GetHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.respondError(w, http.StatusNotFound, "hold_not_found", "Hold not found")
+			return
+		}
+		h.logger.Printf("Failed to get hold: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to get hold")
+		return
+	}
+
+	h.respondHold(ctx, w, http.StatusOK, row)
+}
+
+// CaptureHold handles POST /v1/holds/:id/capture
+func (h *Handler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	holdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_hold_id", "Hold ID must be a valid UUID")
+		return
+	}
+
+	var req CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+	if req.IdempotencyKey == "" {
+		h.respondError(w, http.StatusBadRequest, "validation_error", "idempotency_key: idempotency key is required")
+		return
+	}
+
+	row, err := h.captureHold(ctx, holdID, req)
+	if err != nil {
+		h.respondHoldError(w, "capture", err)
+		return
+	}
+
+	h.respondHold(ctx, w, http.StatusOK, row)
+}
+
+// captureHold reserves a capture under the hold's row lock, posts it to the ledger
+// once that lock is released and then records the ledger entry. The capture ID
+// (and ledger batch_id) is derived from the hold and the request's idempotency
+// key, so a retried capture maps to the same ledger entry. A capture whose ledger
+// call fails stays pending, still reserving its amount, until it is retried; one
+// the ledger rejects is undone.
+func (h *Handler) captureHold(ctx context.Context, holdID uuid.UUID, req CaptureHoldRequest) (store.Hold, error) {
+	capture, hold, err := h.reserveCapture(ctx, holdID, req)
+	if err != nil {
+		return store.Hold{}, err
+	}
+	if capture.EntryID.Valid {
+		h.logger.Printf("Idempotent capture request detected: %s", req.IdempotencyKey)
+		return h.queries.GetHold(ctx, holdID)
+	}
+
+	entryID, _, err := h.callLedgerService(ctx, &domain.Transfer{
+		ID:            capture.ID,
+		FromAccountID: hold.AccountID,
+		ToAccountID:   hold.CounterpartyAccountID,
+		AmountMinor:   capture.AmountMinor,
+		Currency:      hold.Currency,
+	})
+	if err != nil {
+		var rejection *LedgerRejectionError
+		if errors.As(err, &rejection) {
+			if err := h.cancelCapture(ctx, capture); err != nil {
+				h.logger.Printf("Failed to undo rejected capture %s: %v", capture.ID, err)
+			}
+		}
+		return store.Hold{}, fmt.Errorf("ledger service call failed: %w", err)
+	}
+
+	if err := h.completeCapture(ctx, capture, entryID); err != nil {
+		return store.Hold{}, err
+	}
+	return h.queries.GetHold(ctx, holdID)
+}
+
+// reserveCapture records a pending capture against the hold and commits it, so
+// the captured amount stays reserved while it is posted. A capture already
+// recorded under the request's idempotency key is returned as-is.
+func (h *Handler) reserveCapture(ctx context.Context, holdID uuid.UUID, req CaptureHoldRequest) (store.HoldCapture, *domain.Hold, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.HoldCapture{}, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return store.HoldCapture{}, nil, err
+	}
+	hold := holds.FromRow(row)
+
+	existing, err := qtx.GetHoldCaptureByIdempotencyKey(ctx, store.GetHoldCaptureByIdempotencyKeyParams{
+		HoldID:         holdID,
+		IdempotencyKey: req.IdempotencyKey,
+	})
+	if err == nil {
+		// Zero asked for the rest of the hold, which the first request resolved
+		if req.AmountMinor != 0 && req.AmountMinor != existing.AmountMinor {
+			return store.HoldCapture{}, nil, errCaptureConflict
+		}
+		return existing, hold, nil
+	} else if err != sql.ErrNoRows {
+		return store.HoldCapture{}, nil, fmt.Errorf("failed to check capture idempotency: %w", err)
+	}
+
+	now := time.Now()
+	capturedMinor, err := hold.Capture(req.AmountMinor, now)
+	if err != nil {
+		return store.HoldCapture{}, nil, err
+	}
+
+	capture, err := qtx.CreateHoldCapture(ctx, store.CreateHoldCaptureParams{
+		ID:             uuid.NewSHA1(hold.ID, []byte(req.IdempotencyKey)),
+		HoldID:         hold.ID,
+		AmountMinor:    capturedMinor,
+		IdempotencyKey: req.IdempotencyKey,
+		CreatedAt:      now,
+	})
+	if err != nil {
+		return store.HoldCapture{}, nil, fmt.Errorf("failed to create capture record: %w", err)
+	}
+
+	if err := qtx.UpdateHoldCaptured(ctx, store.UpdateHoldCapturedParams{
+		ID:            hold.ID,
+		CapturedMinor: hold.CapturedMinor,
+		Status:        string(hold.Status),
+	}); err != nil {
+		return store.HoldCapture{}, nil, fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.HoldCapture{}, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return capture, hold, nil
+}
+
+// completeCapture records the ledger entry of a pending capture with a
+// HoldCaptured event; a capture a concurrent retry completed first is left as-is
+func (h *Handler) completeCapture(ctx context.Context, capture store.HoldCapture, entryID uuid.UUID) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetHoldForUpdate(ctx, capture.HoldID)
+	if err != nil {
+		return err
+	}
+
+	current, err := qtx.GetHoldCaptureByIdempotencyKey(ctx, store.GetHoldCaptureByIdempotencyKeyParams{
+		HoldID:         capture.HoldID,
+		IdempotencyKey: capture.IdempotencyKey,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get capture: %w", err)
+	}
+	if current.EntryID.Valid {
+		return nil
+	}
+
+	if err := qtx.RecordHoldCaptureEntry(ctx, store.RecordHoldCaptureEntryParams{
+		ID:      capture.ID,
+		EntryID: uuid.NullUUID{UUID: entryID, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to record capture entry: %w", err)
+	}
+
+	now := time.Now()
+	hold := holds.FromRow(row)
+	capturedEvent := &ledgerv1.HoldCaptured{
+		HoldId:    hold.ID.String(),
+		CaptureId: capture.ID.String(),
+		EntryId:   entryID.String(),
+		Amount: &ledgerv1.Money{
+			Units:    capture.AmountMinor,
+			Currency: hold.Currency,
+		},
+		RemainingMinor: hold.RemainingMinor(),
+		TsUnixMs:       now.UnixMilli(),
+	}
+
+	capturedPayload, _ := proto.Marshal(capturedEvent)
+	capturedHeaders := map[string]interface{}{
+		"event_name": "HoldCaptured",
+		"schema":     "ledger.v1.HoldCaptured",
+	}
+	capturedHeadersJSON, _ := json.Marshal(capturedHeaders)
+
+	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "Hold",
+		AggregateID:   hold.ID,
+		EventType:     "HoldCaptured",
+		Payload:       capturedPayload,
+		Headers:       capturedHeadersJSON,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create captured event: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	h.logger.Printf("Hold %s captured %d %s with entry %s (%d remaining)",
+		hold.ID, capture.AmountMinor, hold.Currency, entryID, hold.RemainingMinor())
+	return nil
+}
+
+// cancelCapture removes a pending capture the ledger rejected and returns its
+// amount to the hold
+func (h *Handler) cancelCapture(ctx context.Context, capture store.HoldCapture) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetHoldForUpdate(ctx, capture.HoldID)
+	if err != nil {
+		return err
+	}
+
+	current, err := qtx.GetHoldCaptureByIdempotencyKey(ctx, store.GetHoldCaptureByIdempotencyKeyParams{
+		HoldID:         capture.HoldID,
+		IdempotencyKey: capture.IdempotencyKey,
+	})
+	if err == sql.ErrNoRows || (err == nil && current.EntryID.Valid) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get capture: %w", err)
+	}
+
+	hold := holds.FromRow(row)
+	hold.UndoCapture(capture.AmountMinor)
+
+	if err := qtx.DeleteHoldCapture(ctx, capture.ID); err != nil {
+		return fmt.Errorf("failed to delete capture record: %w", err)
+	}
+	if err := qtx.UpdateHoldCaptured(ctx, store.UpdateHoldCapturedParams{
+		ID:            hold.ID,
+		CapturedMinor: hold.CapturedMinor,
+		Status:        string(hold.Status),
+	}); err != nil {
+		return fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	h.logger.Printf("Hold %s capture %s rejected by the ledger; %d %s reserved again",
+		hold.ID, capture.ID, capture.AmountMinor, hold.Currency)
+	return nil
+}
+
+// ReleaseHold handles POST /v1/holds/:id/release
+func (h *Handler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	holdID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_hold_id", "Hold ID must be a valid UUID")
+		return
+	}
+
+	row, err := h.releaseHold(ctx, holdID)
+	if err != nil {
+		h.respondHoldError(w, "release", err)
+		return
+	}
+
+	h.respondHold(ctx, w, http.StatusOK, row)
+}
+
+// releaseHold frees the uncaptured remainder of a hold; releasing a released hold is a no-op
+func (h *Handler) releaseHold(ctx context.Context, holdID uuid.UUID) (store.Hold, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.Hold{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetHoldForUpdate(ctx, holdID)
+	if err != nil {
+		return store.Hold{}, err
+	}
+	if row.Status == string(domain.HoldReleased) {
+		return row, nil
+	}
+
+	now := time.Now()
+	hold := holds.FromRow(row)
+	released, err := hold.Release(now)
+	if err != nil {
+		return store.Hold{}, err
+	}
+
+	if err := holds.RecordRelease(ctx, qtx, hold, released, holds.ReasonReleased, now); err != nil {
+		return store.Hold{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return store.Hold{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	h.logger.Printf("Hold %s released %d %s", hold.ID, released, hold.Currency)
+	return h.queries.GetHold(ctx, hold.ID)
+}
+
+// errCaptureConflict is returned when a capture's idempotency key was already used for a different amount
+var errCaptureConflict = errors.New("idempotency key already used for a capture of a different amount")
+
+// respondHoldError maps capture and release failures to HTTP responses
+func (h *Handler) respondHoldError(w http.ResponseWriter, op string, err error) {
+	var holdErr *domain.HoldError
+	var validationErr *domain.ValidationError
+	var rejection *LedgerRejectionError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.respondError(w, http.StatusNotFound, "hold_not_found", "Hold not found")
+	case errors.Is(err, errCaptureConflict):
+		h.respondError(w, http.StatusConflict, "idempotency_conflict", err.Error())
+	case errors.As(err, &holdErr):
+		h.respondError(w, http.StatusConflict, holdErr.Code, holdErr.Message)
+	case errors.As(err, &validationErr):
+		h.respondError(w, http.StatusBadRequest, "validation_error", validationErr.Error())
+	case errors.As(err, &rejection):
+		h.respondError(w, http.StatusUnprocessableEntity, rejection.Code, rejection.Message)
+	default:
+		h.logger.Printf("Failed to %s hold: %v", op, err)
+		h.respondError(w, http.StatusInternalServerError, fmt.Sprintf("%s_failed", op), err.Error())
+	}
+}
+
+// respondHold sends a hold response including its captures
+func (h *Handler) respondHold(ctx context.Context, w http.ResponseWriter, status int, row store.Hold) {
+	captures, err := h.queries.ListHoldCaptures(ctx, row.ID)
+	if err != nil {
+		h.logger.Printf("Failed to list hold captures: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to get hold")
+		return
+	}
+
+	resp := HoldResponse{
+		HoldID:                row.ID.String(),
+		AccountID:             row.AccountID.String(),
+		CounterpartyAccountID: row.CounterpartyAccountID.String(),
+		AmountMinor:           row.AmountMinor,
+		CapturedMinor:         row.CapturedMinor,
+		RemainingMinor:        holds.FromRow(row).RemainingMinor(),
+		Currency:              row.Currency,
+		Status:                row.Status,
+		IdempotencyKey:        row.IdempotencyKey,
+		ExpiresAt:             row.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:             row.CreatedAt.Format(time.RFC3339),
+		Captures:              make([]HoldCaptureResponse, len(captures)),
+	}
+	if row.ReleasedAt.Valid {
+		resp.ReleasedAt = row.ReleasedAt.Time.Format(time.RFC3339)
+	}
+	for i, capture := range captures {
+		resp.Captures[i] = HoldCaptureResponse{
+			CaptureID:      capture.ID.String(),
+			AmountMinor:    capture.AmountMinor,
+			IdempotencyKey: capture.IdempotencyKey,
+			CreatedAt:      capture.CreatedAt.Format(time.RFC3339),
+		}
+		if capture.EntryID.Valid {
+			resp.Captures[i].EntryID = capture.EntryID.UUID.String()
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
+	"github.com/google/uuid"
+)
+
+// CreatePostingRequest represents a multi-leg transfer: any number of balanced debits and credits
+type CreatePostingRequest struct {
+	Legs           []PostingLegRequest `json:"legs"`
+	IdempotencyKey string              `json:"idempotency_key"`
+}
+
+// PostingLegRequest represents one debit or credit of a posting
+type PostingLegRequest struct {
+	AccountID   string `json:"account_id"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	Side        string `json:"side"` // DEBIT or CREDIT
+}
+
+// TransferLegResponse represents one leg of a multi-leg posting
+type TransferLegResponse struct {
+	AccountID   string `json:"account_id"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	Side        string `json:"side"`
+}
+
+// CreatePosting handles POST /v1/postings
+func (h *Handler) CreatePosting(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Parse request body
+	var req CreatePostingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+
+	legs := make([]domain.Leg, len(req.Legs))
+	for i, leg := range req.Legs {
+		accountID, err := uuid.Parse(leg.AccountID)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "invalid_account_id", fmt.Sprintf("legs[%d].account_id must be a valid UUID", i))
+			return
+		}
+		legs[i] = domain.Leg{
+			AccountID:   accountID,
+			AmountMinor: leg.AmountMinor,
+			Currency:    leg.Currency,
+			Side:        domain.Side(strings.ToUpper(leg.Side)),
+		}
+	}
+
+	// Create posting with the ledger's double-entry validation rules
+	transfer, err := domain.NewPosting(legs, req.IdempotencyKey)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	h.submitTransfer(ctx, w, transfer)
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/domain"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+	"github.com/google/uuid"
+)
+
+// RefundTransferRequest represents the request to refund all or part of a transfer
+type RefundTransferRequest struct {
+	AmountMinor    int64  `json:"amount_minor,omitempty"` // 0 refunds everything not refunded yet
+	IdempotencyKey string `json:"idempotency_key"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// RefundTransfer handles POST /v1/transfers/{id}/refund
+func (h *Handler) RefundTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	originalID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_transfer_id", "Transfer ID must be a valid UUID")
+		return
+	}
+
+	var req RefundTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalid_request", "Failed to parse request body")
+		return
+	}
+	if req.IdempotencyKey == "" {
+		h.respondError(w, http.StatusBadRequest, "validation_error", "idempotency_key: idempotency key is required")
+		return
+	}
+
+	refund, err := h.createRefund(ctx, originalID, req)
+	if err != nil {
+		h.respondRefundError(w, err)
+		return
+	}
+
+	// A refund already recorded under this key is returned as-is
+	if refund == nil {
+		h.logger.Printf("Idempotent refund request detected: %s", req.IdempotencyKey)
+		existing, err := h.queries.GetTransferByIdempotencyKey(ctx, req.IdempotencyKey)
+		if err != nil {
+			h.logger.Printf("Failed to fetch refund: %v", err)
+			h.respondError(w, http.StatusInternalServerError, "internal_error", "Failed to process refund")
+			return
+		}
+		h.respondTransfer(w, existing)
+		return
+	}
+
+	if err := h.runLedgerSaga(ctx, refund); err != nil {
+		h.logger.Printf("Failed to execute refund: %v", err)
+		h.respondRefundError(w, err)
+		return
+	}
+
+	dbTransfer, err := h.queries.GetTransfer(ctx, refund.ID)
+	if err != nil {
+		h.logger.Printf("Failed to fetch refund: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "internal_error", "Refund completed but failed to fetch result")
+		return
+	}
+
+	h.respondTransfer(w, dbTransfer)
+}
+
+// createRefund records a refund of the original transfer while holding the
+// original's row lock, so concurrent refunds cannot exceed the refundable amount.
+// Returns a nil transfer when a refund of the same original already exists for
+// the idempotency key.
+func (h *Handler) createRefund(ctx context.Context, originalID uuid.UUID, req RefundTransferRequest) (*domain.Transfer, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+
+	row, err := qtx.GetTransferForUpdate(ctx, originalID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check idempotency under the lock; the key must belong to a refund of this transfer
+	existing, err := qtx.GetTransferByIdempotencyKey(ctx, req.IdempotencyKey)
+	if err == nil {
+		if existing.RefundOf.Valid && existing.RefundOf.UUID == originalID {
+			return nil, nil
+		}
+		return nil, errIdempotencyConflict
+	} else if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check refund idempotency: %w", err)
+	}
+
+	original, err := h.loadTransfer(ctx, qtx, row)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := qtx.SumRefunds(ctx, uuid.NullUUID{UUID: originalID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum refunds: %w", err)
+	}
+
+	refund, err := domain.NewRefund(original, sums.RefundedMinor+sums.PendingMinor, req.AmountMinor, req.IdempotencyKey, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reversed lines must still post to accounts that are open in the currency
+	if err := h.validateTransferAccounts(ctx, refund); err != nil {
+		return nil, err
+	}
+
+	// The refund debits the original's credited accounts, which may hold funds on hold
+	if err := h.checkDebits(ctx, qtx, refund, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if err := h.recordTransfer(ctx, qtx, refund); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return refund, nil
+}
+
+// loadTransfer converts a transfer row and its legs to the domain model
+func (h *Handler) loadTransfer(ctx context.Context, qtx *store.Queries, row store.Transfer) (*domain.Transfer, error) {
+	transfer := &domain.Transfer{
+		ID:             row.ID,
+		FromAccountID:  row.FromAccountID,
+		ToAccountID:    row.ToAccountID,
+		AmountMinor:    row.AmountMinor,
+		Currency:       row.Currency,
+		IdempotencyKey: row.IdempotencyKey,
+		Status:         domain.TransferStatus(row.Status),
+	}
+	// The ledger saga records completion in the state column
+	if row.State.Valid && row.State.String == string(domain.StatusCompleted) {
+		transfer.Status = domain.StatusCompleted
+	}
+	if row.RefundOf.Valid {
+		refundOf := row.RefundOf.UUID
+		transfer.RefundOf = &refundOf
+	}
+
+	legs, err := qtx.ListTransferLegs(ctx, row.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfer legs: %w", err)
+	}
+	for _, leg := range legs {
+		transfer.Legs = append(transfer.Legs, domain.Leg{
+			AccountID:   leg.AccountID,
+			AmountMinor: leg.AmountMinor,
+			Currency:    leg.Currency,
+			Side:        domain.Side(leg.Side),
+		})
+	}
+
+	return transfer, nil
+}
+
+// errIdempotencyConflict is returned when a refund's idempotency key was already used for another transfer
+var errIdempotencyConflict = errors.New("idempotency key already used for a different transfer")
+
+// respondRefundError maps refund failures to HTTP responses
+func (h *Handler) respondRefundError(w http.ResponseWriter, err error) {
+	var refundErr *domain.RefundError
+	var validationErr *domain.ValidationError
+	var accountErr *domain.AccountError
+	var fundsErr *domain.FundsError
+	var rejection *LedgerRejectionError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		h.respondError(w, http.StatusNotFound, "transfer_not_found", "Transfer not found")
+	case errors.Is(err, errIdempotencyConflict):
+		h.respondError(w, http.StatusConflict, "idempotency_conflict", err.Error())
+	case errors.As(err, &refundErr):
+		h.respondError(w, http.StatusConflict, refundErr.Code, refundErr.Message)
+	case errors.As(err, &validationErr):
+		h.respondError(w, http.StatusBadRequest, "validation_error", validationErr.Error())
+	case errors.As(err, &accountErr):
+		h.respondError(w, http.StatusUnprocessableEntity, accountErr.Code, accountErr.Error())
+	case errors.As(err, &fundsErr):
+		h.respondError(w, http.StatusUnprocessableEntity, domain.CodeInsufficientFunds, fundsErr.Error())
+	case errors.As(err, &rejection):
+		h.respondError(w, http.StatusUnprocessableEntity, rejection.Code, rejection.Message)
+	default:
+		h.logger.Printf("Failed to refund transfer: %v", err)
+		h.respondError(w, http.StatusInternalServerError, "refund_failed", err.Error())
+	}
+}
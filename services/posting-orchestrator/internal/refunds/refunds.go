@@ -0,0 +1,60 @@
+package refunds
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/posting-orchestrator/internal/store"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// RecordCompleted emits TransferRefunded for the original transfer when the
+// completed transfer is a refund. Called inside the completion transaction.
+func RecordCompleted(ctx context.Context, qtx *store.Queries, transferID uuid.UUID) error {
+	row, err := qtx.GetTransfer(ctx, transferID)
+	if err != nil {
+		return err
+	}
+	if !row.RefundOf.Valid {
+		return nil
+	}
+
+	sums, err := qtx.SumRefunds(ctx, row.RefundOf)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	refundedEvent := &ledgerv1.TransferRefunded{
+		TransferId:       row.RefundOf.UUID.String(),
+		RefundTransferId: row.ID.String(),
+		Amount: &ledgerv1.Money{
+			Units:    row.AmountMinor,
+			Currency: row.Currency,
+		},
+		RefundedTotalMinor: sums.RefundedMinor,
+		Reason:             row.RefundReason.String,
+		TsUnixMs:           now.UnixMilli(),
+	}
+
+	refundedPayload, _ := proto.Marshal(refundedEvent)
+	refundedHeaders := map[string]interface{}{
+		"event_name": "TransferRefunded",
+		"schema":     "ledger.v1.TransferRefunded",
+	}
+	refundedHeadersJSON, _ := json.Marshal(refundedHeaders)
+
+	_, err = qtx.CreateOutboxEvent(ctx, store.CreateOutboxEventParams{
+		ID:            uuid.New(),
+		AggregateType: "Transfer",
+		AggregateID:   row.RefundOf.UUID,
+		EventType:     "TransferRefunded",
+		Payload:       refundedPayload,
+		Headers:       refundedHeadersJSON,
+		CreatedAt:     now,
+	})
+	return err
+}
@@ -75,14 +75,14 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 	// Execute the function
 	err := fn()
 
-	// Record the result
-	if err != nil {
+	// Record the result (permanent errors mean the service answered, so they count as success)
+	if err != nil && !IsPermanent(err) {
 		cb.recordFailure()
 		return err
 	}
 
 	cb.recordSuccess()
-	return nil
+	return err
 }
 
 // allowRequest checks if a request is allowed based on circuit state
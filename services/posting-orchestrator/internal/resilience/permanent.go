@@ -0,0 +1,33 @@
+package resilience
+
+import "errors"
+
+// PermanentError marks an error that must not be retried.
+// The remote service answered and rejected the request, so retrying cannot
+// succeed and the failure says nothing about the health of the service.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so that Retry stops immediately and the circuit breaker
+// does not count it as a failure
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err (or any error it wraps) is permanent
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
@@ -45,7 +45,13 @@ func Retry(ctx context.Context, config RetryConfig, fn RetryableFunc, logger *lo
 		}
 
 		lastErr = err
-		
+
+		// Permanent errors are never retried
+		if IsPermanent(err) {
+			logger.Printf("Attempt %d failed with permanent error, not retrying: %v", attempt, err)
+			return err
+		}
+
 		// Check if we've exhausted attempts
 		if attempt >= config.MaxAttempts {
 			logger.Printf("Retry exhausted after %d attempts: %v", config.MaxAttempts, err)
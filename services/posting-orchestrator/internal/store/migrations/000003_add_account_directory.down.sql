@@ -0,0 +1,2 @@
+-- Rollback account directory
+DROP TABLE IF EXISTS account_directory;
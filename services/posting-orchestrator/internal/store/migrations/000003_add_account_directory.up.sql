@@ -0,0 +1,13 @@
+-- Local directory of accounts, projected from ledger.account.v1 events
+-- Used to reject transfers to unknown, suspended or closed accounts before calling the ledger
+
+CREATE TABLE IF NOT EXISTS account_directory (
+    account_id UUID PRIMARY KEY,
+    currency TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('ACTIVE', 'SUSPENDED', 'CLOSED')),
+    status_changed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON COLUMN account_directory.status_changed_at IS 'Timestamp of the last applied AccountStatusChanged event (guards against stale replays)';
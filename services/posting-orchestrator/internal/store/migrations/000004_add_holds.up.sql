@@ -0,0 +1,37 @@
+-- Authorization holds: funds reserved on an account until captured into a ledger entry or released
+-- Pending holds count against the account's available balance when new holds are placed
+
+CREATE TABLE IF NOT EXISTS holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    counterparty_account_id UUID NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    captured_minor BIGINT NOT NULL DEFAULT 0 CHECK (captured_minor >= 0 AND captured_minor <= amount_minor),
+    currency TEXT NOT NULL,
+    idempotency_key TEXT NOT NULL UNIQUE,
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    released_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Captures posted against a hold; each capture is one ledger entry
+CREATE TABLE IF NOT EXISTS hold_captures (
+    id UUID PRIMARY KEY,
+    hold_id UUID NOT NULL REFERENCES holds(id),
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    idempotency_key TEXT NOT NULL,
+    entry_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (hold_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_holds_account_pending ON holds(account_id, currency) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_holds_expiry ON holds(expires_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_hold_captures_hold ON hold_captures(hold_id, created_at);
+
+COMMENT ON COLUMN holds.counterparty_account_id IS 'Account credited when the hold is captured';
+COMMENT ON COLUMN holds.captured_minor IS 'Total amount captured so far; the rest stays reserved while PENDING';
+COMMENT ON COLUMN holds.released_at IS 'When the uncaptured remainder was released (by request or expiry)';
+COMMENT ON COLUMN hold_captures.id IS 'Capture ID, also used as the ledger batch_id';
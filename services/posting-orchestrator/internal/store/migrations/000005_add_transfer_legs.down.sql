@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS transfer_legs;
+
+COMMENT ON COLUMN transfers.from_account_id IS NULL;
+COMMENT ON COLUMN transfers.to_account_id IS NULL;
+COMMENT ON COLUMN transfers.amount_minor IS NULL;
+COMMENT ON COLUMN transfers.currency IS NULL;
@@ -0,0 +1,19 @@
+-- Legs of multi-leg postings (POST /v1/postings); each leg becomes one ledger journal line
+-- Simple transfers have no rows here: their legs are derived from from_account_id and to_account_id
+
+CREATE TABLE IF NOT EXISTS transfer_legs (
+    transfer_id UUID NOT NULL REFERENCES transfers(id),
+    leg_index INT NOT NULL,
+    account_id UUID NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency TEXT NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('DEBIT', 'CREDIT')),
+    PRIMARY KEY (transfer_id, leg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_legs_account ON transfer_legs(account_id);
+
+COMMENT ON COLUMN transfers.from_account_id IS 'Debited account; for multi-leg postings the first debit leg';
+COMMENT ON COLUMN transfers.to_account_id IS 'Credited account; for multi-leg postings the first credit leg';
+COMMENT ON COLUMN transfers.amount_minor IS 'Transfer amount; for multi-leg postings the total debited in currency';
+COMMENT ON COLUMN transfers.currency IS 'Transfer currency; for multi-leg postings the currency of the first leg';
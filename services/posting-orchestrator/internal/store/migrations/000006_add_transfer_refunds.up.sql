@@ -0,0 +1,10 @@
+-- Refunds: a refund is a new transfer linked to the original that posts reversed lines
+-- Refunds that are pending or COMPLETED count against the original's refundable amount
+
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS refund_of UUID REFERENCES transfers(id);
+ALTER TABLE transfers ADD COLUMN IF NOT EXISTS refund_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_transfers_refund_of ON transfers(refund_of) WHERE refund_of IS NOT NULL;
+
+COMMENT ON COLUMN transfers.refund_of IS 'Original transfer this transfer refunds (NULL for regular transfers)';
+COMMENT ON COLUMN transfers.refund_reason IS 'Client-supplied reason for a refund';
@@ -0,0 +1,58 @@
+-- Per-aggregate event sequence: every outbox row gets the next number of its
+-- aggregate, and the relay publishes each aggregate's events strictly in that
+-- order. The counter row is locked until the inserting transaction ends, so
+-- concurrent writers to one aggregate commit in sequence order and a rolled
+-- back insert gives its number back.
+
+CREATE TABLE IF NOT EXISTS outbox_sequences (
+  aggregate_id UUID PRIMARY KEY,
+  last_seq BIGINT NOT NULL,
+  last_sent_seq BIGINT NOT NULL DEFAULT 0
+);
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS aggregate_seq BIGINT;
+ALTER TABLE outbox_archive ADD COLUMN IF NOT EXISTS aggregate_seq BIGINT;
+
+-- Number existing rows, archived ones first, in creation order
+WITH numbered AS (
+  SELECT id, row_number() OVER (PARTITION BY aggregate_id ORDER BY archived, created_at, id) AS seq
+  FROM (
+    SELECT id, aggregate_id, created_at, true AS archived FROM outbox_archive
+    UNION ALL
+    SELECT id, aggregate_id, created_at, false AS archived FROM outbox
+  ) AS events
+)
+UPDATE outbox o SET aggregate_seq = n.seq FROM numbered n WHERE o.id = n.id;
+
+WITH numbered AS (
+  SELECT id, row_number() OVER (PARTITION BY aggregate_id ORDER BY created_at, id) AS seq
+  FROM outbox_archive
+)
+UPDATE outbox_archive a SET aggregate_seq = n.seq FROM numbered n WHERE a.id = n.id;
+
+INSERT INTO outbox_sequences (aggregate_id, last_seq, last_sent_seq)
+SELECT aggregate_id, max(aggregate_seq), COALESCE(max(aggregate_seq) FILTER (WHERE sent), 0)
+FROM (
+  SELECT aggregate_id, aggregate_seq, true AS sent FROM outbox_archive
+  UNION ALL
+  SELECT aggregate_id, aggregate_seq, sent_at IS NOT NULL AS sent FROM outbox
+) AS events
+GROUP BY aggregate_id
+ON CONFLICT (aggregate_id) DO NOTHING;
+
+ALTER TABLE outbox ALTER COLUMN aggregate_seq SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_aggregate_seq ON outbox(aggregate_id, aggregate_seq);
+
+CREATE OR REPLACE FUNCTION assign_outbox_seq() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO outbox_sequences (aggregate_id, last_seq)
+  VALUES (NEW.aggregate_id, 1)
+  ON CONFLICT (aggregate_id) DO UPDATE SET last_seq = outbox_sequences.last_seq + 1
+  RETURNING last_seq INTO NEW.aggregate_seq;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS outbox_assign_seq ON outbox;
+CREATE TRIGGER outbox_assign_seq BEFORE INSERT ON outbox
+FOR EACH ROW EXECUTE FUNCTION assign_outbox_seq();
@@ -0,0 +1,6 @@
+-- Remove pending hold captures; captures still being posted are dropped
+DROP INDEX IF EXISTS idx_transfers_in_flight;
+DROP INDEX IF EXISTS idx_hold_captures_pending;
+DELETE FROM hold_captures WHERE entry_id IS NULL;
+ALTER TABLE hold_captures ALTER COLUMN entry_id SET NOT NULL;
+COMMENT ON COLUMN hold_captures.entry_id IS NULL;
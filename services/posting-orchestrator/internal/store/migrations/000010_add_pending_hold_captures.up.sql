@@ -0,0 +1,12 @@
+-- A capture is recorded before it is posted to the ledger and gets its entry
+-- once the ledger accepts it, so its amount stays reserved while the ledger
+-- call is in flight.
+ALTER TABLE hold_captures ALTER COLUMN entry_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_hold_captures_pending ON hold_captures(hold_id) WHERE entry_id IS NULL;
+
+-- Debits still in flight to the ledger are reserved per account
+CREATE INDEX IF NOT EXISTS idx_transfers_in_flight ON transfers(from_account_id, currency)
+  WHERE state NOT IN ('COMPLETED', 'FAILED', 'COMPENSATED');
+
+COMMENT ON COLUMN hold_captures.entry_id IS 'Ledger entry of the capture; NULL while it is being posted';
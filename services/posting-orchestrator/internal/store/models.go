@@ -12,6 +12,45 @@ import (
 	"github.com/google/uuid"
 )
 
+type AccountDirectory struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	// Timestamp of the last applied AccountStatusChanged event (guards against stale replays)
+	StatusChangedAt sql.NullTime `json:"status_changed_at"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+}
+
+type Hold struct {
+	ID        uuid.UUID `json:"id"`
+	AccountID uuid.UUID `json:"account_id"`
+	// Account credited when the hold is captured
+	CounterpartyAccountID uuid.UUID `json:"counterparty_account_id"`
+	AmountMinor           int64     `json:"amount_minor"`
+	// Total amount captured so far; the rest stays reserved while PENDING
+	CapturedMinor  int64     `json:"captured_minor"`
+	Currency       string    `json:"currency"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	Status         string    `json:"status"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// When the uncaptured remainder was released (by request or expiry)
+	ReleasedAt sql.NullTime `json:"released_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+type HoldCapture struct {
+	// Capture ID, also used as the ledger batch_id
+	ID             uuid.UUID `json:"id"`
+	HoldID         uuid.UUID `json:"hold_id"`
+	AmountMinor    int64     `json:"amount_minor"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	// Ledger entry of the capture; NULL while it is being posted
+	EntryID   uuid.NullUUID `json:"entry_id"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
 type Outbox struct {
 	ID            uuid.UUID       `json:"id"`
 	AggregateType string          `json:"aggregate_type"`
@@ -21,13 +60,31 @@ type Outbox struct {
 	Headers       json.RawMessage `json:"headers"`
 	CreatedAt     time.Time       `json:"created_at"`
 	SentAt        sql.NullTime    `json:"sent_at"`
+	AggregateSeq  int64           `json:"aggregate_seq"`
+}
+
+type OutboxArchive struct {
+	ID            uuid.UUID       `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   uuid.UUID       `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       []byte          `json:"payload"`
+	Headers       json.RawMessage `json:"headers"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SentAt        time.Time       `json:"sent_at"`
+	ArchivedAt    time.Time       `json:"archived_at"`
+	AggregateSeq  sql.NullInt64   `json:"aggregate_seq"`
 }
 
 type Transfer struct {
-	ID             uuid.UUID      `json:"id"`
-	FromAccountID  uuid.UUID      `json:"from_account_id"`
-	ToAccountID    uuid.UUID      `json:"to_account_id"`
-	AmountMinor    int64          `json:"amount_minor"`
+	ID uuid.UUID `json:"id"`
+	// Debited account; for multi-leg postings the first debit leg
+	FromAccountID uuid.UUID `json:"from_account_id"`
+	// Credited account; for multi-leg postings the first credit leg
+	ToAccountID uuid.UUID `json:"to_account_id"`
+	// Transfer amount; for multi-leg postings the total debited in currency
+	AmountMinor int64 `json:"amount_minor"`
+	// Transfer currency; for multi-leg postings the currency of the first leg
 	Currency       string         `json:"currency"`
 	IdempotencyKey string         `json:"idempotency_key"`
 	Status         string         `json:"status"`
@@ -51,4 +108,17 @@ type Transfer struct {
 	RecoveryAttempts sql.NullInt32 `json:"recovery_attempts"`
 	// Timestamp of last recovery attempt
 	LastRecoveryAt sql.NullTime `json:"last_recovery_at"`
+	// Original transfer this transfer refunds (NULL for regular transfers)
+	RefundOf uuid.NullUUID `json:"refund_of"`
+	// Client-supplied reason for a refund
+	RefundReason sql.NullString `json:"refund_reason"`
+}
+
+type TransferLeg struct {
+	TransferID  uuid.UUID `json:"transfer_id"`
+	LegIndex    int32     `json:"leg_index"`
+	AccountID   uuid.UUID `json:"account_id"`
+	AmountMinor int64     `json:"amount_minor"`
+	Currency    string    `json:"currency"`
+	Side        string    `json:"side"`
 }
@@ -1,8 +1,8 @@
 -- Transfer Operations
 
 -- name: CreateTransfer :one
-INSERT INTO transfers (id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+INSERT INTO transfers (id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, created_at, updated_at, refund_of, refund_reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetTransfer :one
@@ -21,6 +21,33 @@ UPDATE transfers
 SET status = 'FAILED', state = 'FAILED', failure_reason = $2, updated_at = now()
 WHERE id = $1;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: SumInFlightDebits :one
+-- What the account is debited by transfers recorded but not yet completed or failed, which the ledger may not have applied yet
+SELECT COALESCE(SUM(COALESCE(l.amount_minor, t.amount_minor)), 0)::bigint AS debit_minor
+FROM transfers t
+LEFT JOIN transfer_legs l ON l.transfer_id = t.id AND l.side = 'DEBIT'
+WHERE t.state NOT IN ('COMPLETED', 'FAILED', 'COMPENSATED')
+  AND ((l.transfer_id IS NULL AND t.from_account_id = $1 AND t.currency = $2)
+    OR (l.account_id = $1 AND l.currency = $2));
+
+-- name: SumRefunds :one
+SELECT COALESCE(SUM(amount_minor) FILTER (WHERE state = 'COMPLETED'), 0)::bigint AS refunded_minor,
+       COALESCE(SUM(amount_minor) FILTER (WHERE state NOT IN ('COMPLETED', 'FAILED', 'COMPENSATED')), 0)::bigint AS pending_minor
+FROM transfers
+WHERE refund_of = $1;
+
+-- name: CreateTransferLeg :exec
+INSERT INTO transfer_legs (transfer_id, leg_index, account_id, amount_minor, currency, side)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: ListTransferLegs :many
+SELECT * FROM transfer_legs
+WHERE transfer_id = $1
+ORDER BY leg_index ASC;
+
 -- Outbox Operations
 
 -- name: CreateOutboxEvent :one
@@ -104,3 +131,85 @@ SELECT * FROM transfers
 WHERE state = $1
 ORDER BY created_at DESC
 LIMIT $2;
+
+-- Account Directory Operations
+
+-- name: CreateDirectoryAccount :exec
+INSERT INTO account_directory (account_id, currency, status, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO NOTHING;
+
+-- name: UpdateDirectoryAccountStatus :execrows
+UPDATE account_directory
+SET status = $2, status_changed_at = $3, updated_at = now()
+WHERE account_id = $1
+  AND (status_changed_at IS NULL OR status_changed_at <= $3);
+
+-- name: GetDirectoryAccount :one
+SELECT * FROM account_directory WHERE account_id = $1 LIMIT 1;
+
+-- Hold Operations
+
+-- name: CreateHold :one
+INSERT INTO holds (id, account_id, counterparty_account_id, amount_minor, currency, idempotency_key, status, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds WHERE id = $1 LIMIT 1 FOR UPDATE;
+
+-- name: GetHoldByIdempotencyKey :one
+SELECT * FROM holds WHERE idempotency_key = $1 LIMIT 1;
+
+-- name: LockAccountFunds :exec
+-- Serializes holds and debits per account so pending holds cannot be over-reserved or spent
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0));
+
+-- name: SumPendingHolds :one
+-- Funds reserved by the account's pending holds and by captures still being posted to the ledger
+SELECT (
+  COALESCE((SELECT SUM(h.amount_minor - h.captured_minor) FROM holds h
+            WHERE h.account_id = $1 AND h.currency = $2 AND h.status = 'PENDING' AND h.expires_at > $3), 0) +
+  COALESCE((SELECT SUM(c.amount_minor) FROM hold_captures c JOIN holds h ON h.id = c.hold_id
+            WHERE h.account_id = $1 AND h.currency = $2 AND c.entry_id IS NULL), 0)
+)::bigint AS held_minor;
+
+-- name: UpdateHoldCaptured :exec
+UPDATE holds
+SET captured_minor = $2, status = $3, updated_at = now()
+WHERE id = $1;
+
+-- name: UpdateHoldReleased :exec
+UPDATE holds
+SET status = $2, released_at = $3, updated_at = now()
+WHERE id = $1;
+
+-- name: GetExpiredHolds :many
+SELECT * FROM holds
+WHERE status = 'PENDING'
+  AND expires_at <= $1
+ORDER BY expires_at ASC
+LIMIT $2
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateHoldCapture :one
+INSERT INTO hold_captures (id, hold_id, amount_minor, idempotency_key, entry_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetHoldCaptureByIdempotencyKey :one
+SELECT * FROM hold_captures WHERE hold_id = $1 AND idempotency_key = $2 LIMIT 1;
+
+-- name: RecordHoldCaptureEntry :exec
+UPDATE hold_captures SET entry_id = $2 WHERE id = $1;
+
+-- name: DeleteHoldCapture :exec
+DELETE FROM hold_captures WHERE id = $1;
+
+-- name: ListHoldCaptures :many
+SELECT * FROM hold_captures
+WHERE hold_id = $1
+ORDER BY created_at ASC;
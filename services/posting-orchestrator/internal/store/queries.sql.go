@@ -14,11 +14,122 @@ import (
 	"github.com/google/uuid"
 )
 
+const createDirectoryAccount = `-- name: CreateDirectoryAccount :exec
+
+INSERT INTO account_directory (account_id, currency, status, created_at)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (account_id) DO NOTHING
+`
+
+type CreateDirectoryAccountParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Account Directory Operations
+func (q *Queries) CreateDirectoryAccount(ctx context.Context, arg CreateDirectoryAccountParams) error {
+	_, err := q.db.ExecContext(ctx, createDirectoryAccount,
+		arg.AccountID,
+		arg.Currency,
+		arg.Status,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createHold = `-- name: CreateHold :one
+
+INSERT INTO holds (id, account_id, counterparty_account_id, amount_minor, currency, idempotency_key, status, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, account_id, counterparty_account_id, amount_minor, captured_minor, currency, idempotency_key, status, expires_at, released_at, created_at, updated_at
+`
+
+type CreateHoldParams struct {
+	ID                    uuid.UUID `json:"id"`
+	AccountID             uuid.UUID `json:"account_id"`
+	CounterpartyAccountID uuid.UUID `json:"counterparty_account_id"`
+	AmountMinor           int64     `json:"amount_minor"`
+	Currency              string    `json:"currency"`
+	IdempotencyKey        string    `json:"idempotency_key"`
+	Status                string    `json:"status"`
+	ExpiresAt             time.Time `json:"expires_at"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+// Hold Operations
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, createHold,
+		arg.ID,
+		arg.AccountID,
+		arg.CounterpartyAccountID,
+		arg.AmountMinor,
+		arg.Currency,
+		arg.IdempotencyKey,
+		arg.Status,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.CounterpartyAccountID,
+		&i.AmountMinor,
+		&i.CapturedMinor,
+		&i.Currency,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReleasedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createHoldCapture = `-- name: CreateHoldCapture :one
+INSERT INTO hold_captures (id, hold_id, amount_minor, idempotency_key, entry_id, created_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, hold_id, amount_minor, idempotency_key, entry_id, created_at
+`
+
+type CreateHoldCaptureParams struct {
+	ID             uuid.UUID     `json:"id"`
+	HoldID         uuid.UUID     `json:"hold_id"`
+	AmountMinor    int64         `json:"amount_minor"`
+	IdempotencyKey string        `json:"idempotency_key"`
+	EntryID        uuid.NullUUID `json:"entry_id"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+func (q *Queries) CreateHoldCapture(ctx context.Context, arg CreateHoldCaptureParams) (HoldCapture, error) {
+	row := q.db.QueryRowContext(ctx, createHoldCapture,
+		arg.ID,
+		arg.HoldID,
+		arg.AmountMinor,
+		arg.IdempotencyKey,
+		arg.EntryID,
+		arg.CreatedAt,
+	)
+	var i HoldCapture
+	err := row.Scan(
+		&i.ID,
+		&i.HoldID,
+		&i.AmountMinor,
+		&i.IdempotencyKey,
+		&i.EntryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createOutboxEvent = `-- name: CreateOutboxEvent :one
 
 INSERT INTO outbox (id, aggregate_type, aggregate_id, event_type, payload, headers, created_at)
 VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at
+RETURNING id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq
 `
 
 type CreateOutboxEventParams struct {
@@ -52,27 +163,30 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
 	)
 	return i, err
 }
 
 const createTransfer = `-- name: CreateTransfer :one
 
-INSERT INTO transfers (id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-RETURNING id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at
+INSERT INTO transfers (id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, created_at, updated_at, refund_of, refund_reason)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason
 `
 
 type CreateTransferParams struct {
-	ID             uuid.UUID `json:"id"`
-	FromAccountID  uuid.UUID `json:"from_account_id"`
-	ToAccountID    uuid.UUID `json:"to_account_id"`
-	AmountMinor    int64     `json:"amount_minor"`
-	Currency       string    `json:"currency"`
-	IdempotencyKey string    `json:"idempotency_key"`
-	Status         string    `json:"status"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID             uuid.UUID      `json:"id"`
+	FromAccountID  uuid.UUID      `json:"from_account_id"`
+	ToAccountID    uuid.UUID      `json:"to_account_id"`
+	AmountMinor    int64          `json:"amount_minor"`
+	Currency       string         `json:"currency"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	Status         string         `json:"status"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	RefundOf       uuid.NullUUID  `json:"refund_of"`
+	RefundReason   sql.NullString `json:"refund_reason"`
 }
 
 // Transfer Operations
@@ -87,6 +201,8 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		arg.Status,
 		arg.CreatedAt,
 		arg.UpdatedAt,
+		arg.RefundOf,
+		arg.RefundReason,
 	)
 	var i Transfer
 	err := row.Scan(
@@ -109,12 +225,212 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.CompensatedAt,
 		&i.RecoveryAttempts,
 		&i.LastRecoveryAt,
+		&i.RefundOf,
+		&i.RefundReason,
+	)
+	return i, err
+}
+
+const createTransferLeg = `-- name: CreateTransferLeg :exec
+INSERT INTO transfer_legs (transfer_id, leg_index, account_id, amount_minor, currency, side)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type CreateTransferLegParams struct {
+	TransferID  uuid.UUID `json:"transfer_id"`
+	LegIndex    int32     `json:"leg_index"`
+	AccountID   uuid.UUID `json:"account_id"`
+	AmountMinor int64     `json:"amount_minor"`
+	Currency    string    `json:"currency"`
+	Side        string    `json:"side"`
+}
+
+func (q *Queries) CreateTransferLeg(ctx context.Context, arg CreateTransferLegParams) error {
+	_, err := q.db.ExecContext(ctx, createTransferLeg,
+		arg.TransferID,
+		arg.LegIndex,
+		arg.AccountID,
+		arg.AmountMinor,
+		arg.Currency,
+		arg.Side,
+	)
+	return err
+}
+
+const deleteHoldCapture = `-- name: DeleteHoldCapture :exec
+DELETE FROM hold_captures WHERE id = $1
+`
+
+func (q *Queries) DeleteHoldCapture(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteHoldCapture, id)
+	return err
+}
+
+const getDirectoryAccount = `-- name: GetDirectoryAccount :one
+SELECT account_id, currency, status, status_changed_at, created_at, updated_at FROM account_directory WHERE account_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetDirectoryAccount(ctx context.Context, accountID uuid.UUID) (AccountDirectory, error) {
+	row := q.db.QueryRowContext(ctx, getDirectoryAccount, accountID)
+	var i AccountDirectory
+	err := row.Scan(
+		&i.AccountID,
+		&i.Currency,
+		&i.Status,
+		&i.StatusChangedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExpiredHolds = `-- name: GetExpiredHolds :many
+SELECT id, account_id, counterparty_account_id, amount_minor, captured_minor, currency, idempotency_key, status, expires_at, released_at, created_at, updated_at FROM holds
+WHERE status = 'PENDING'
+  AND expires_at <= $1
+ORDER BY expires_at ASC
+LIMIT $2
+FOR UPDATE SKIP LOCKED
+`
+
+type GetExpiredHoldsParams struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	Limit     int32     `json:"limit"`
+}
+
+func (q *Queries) GetExpiredHolds(ctx context.Context, arg GetExpiredHoldsParams) ([]Hold, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredHolds, arg.ExpiresAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Hold
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.CounterpartyAccountID,
+			&i.AmountMinor,
+			&i.CapturedMinor,
+			&i.Currency,
+			&i.IdempotencyKey,
+			&i.Status,
+			&i.ExpiresAt,
+			&i.ReleasedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, account_id, counterparty_account_id, amount_minor, captured_minor, currency, idempotency_key, status, expires_at, released_at, created_at, updated_at FROM holds WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id uuid.UUID) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.CounterpartyAccountID,
+		&i.AmountMinor,
+		&i.CapturedMinor,
+		&i.Currency,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReleasedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHoldByIdempotencyKey = `-- name: GetHoldByIdempotencyKey :one
+SELECT id, account_id, counterparty_account_id, amount_minor, captured_minor, currency, idempotency_key, status, expires_at, released_at, created_at, updated_at FROM holds WHERE idempotency_key = $1 LIMIT 1
+`
+
+func (q *Queries) GetHoldByIdempotencyKey(ctx context.Context, idempotencyKey string) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHoldByIdempotencyKey, idempotencyKey)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.CounterpartyAccountID,
+		&i.AmountMinor,
+		&i.CapturedMinor,
+		&i.Currency,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReleasedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getHoldCaptureByIdempotencyKey = `-- name: GetHoldCaptureByIdempotencyKey :one
+SELECT id, hold_id, amount_minor, idempotency_key, entry_id, created_at FROM hold_captures WHERE hold_id = $1 AND idempotency_key = $2 LIMIT 1
+`
+
+type GetHoldCaptureByIdempotencyKeyParams struct {
+	HoldID         uuid.UUID `json:"hold_id"`
+	IdempotencyKey string    `json:"idempotency_key"`
+}
+
+func (q *Queries) GetHoldCaptureByIdempotencyKey(ctx context.Context, arg GetHoldCaptureByIdempotencyKeyParams) (HoldCapture, error) {
+	row := q.db.QueryRowContext(ctx, getHoldCaptureByIdempotencyKey, arg.HoldID, arg.IdempotencyKey)
+	var i HoldCapture
+	err := row.Scan(
+		&i.ID,
+		&i.HoldID,
+		&i.AmountMinor,
+		&i.IdempotencyKey,
+		&i.EntryID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, account_id, counterparty_account_id, amount_minor, captured_minor, currency, idempotency_key, status, expires_at, released_at, created_at, updated_at FROM holds WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id uuid.UUID) (Hold, error) {
+	row := q.db.QueryRowContext(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.CounterpartyAccountID,
+		&i.AmountMinor,
+		&i.CapturedMinor,
+		&i.Currency,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.ExpiresAt,
+		&i.ReleasedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
 
 const getOutboxEvent = `-- name: GetOutboxEvent :one
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
 WHERE id = $1
 `
 
@@ -130,12 +446,13 @@ func (q *Queries) GetOutboxEvent(ctx context.Context, id uuid.UUID) (Outbox, err
 		&i.Headers,
 		&i.CreatedAt,
 		&i.SentAt,
+		&i.AggregateSeq,
 	)
 	return i, err
 }
 
 const getStaleTransfers = `-- name: GetStaleTransfers :many
-SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason FROM transfers
 WHERE state IN ('LEDGER_CALLED', 'RECOVERING')
   AND ledger_call_at < $1
   AND recovery_attempts < 5
@@ -172,6 +489,8 @@ func (q *Queries) GetStaleTransfers(ctx context.Context, ledgerCallAt sql.NullTi
 			&i.CompensatedAt,
 			&i.RecoveryAttempts,
 			&i.LastRecoveryAt,
+			&i.RefundOf,
+			&i.RefundReason,
 		); err != nil {
 			return nil, err
 		}
@@ -187,7 +506,7 @@ func (q *Queries) GetStaleTransfers(ctx context.Context, ledgerCallAt sql.NullTi
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at FROM transfers WHERE id = $1 LIMIT 1
+SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason FROM transfers WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id uuid.UUID) (Transfer, error) {
@@ -213,12 +532,14 @@ func (q *Queries) GetTransfer(ctx context.Context, id uuid.UUID) (Transfer, erro
 		&i.CompensatedAt,
 		&i.RecoveryAttempts,
 		&i.LastRecoveryAt,
+		&i.RefundOf,
+		&i.RefundReason,
 	)
 	return i, err
 }
 
 const getTransferByIdempotencyKey = `-- name: GetTransferByIdempotencyKey :one
-SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at FROM transfers WHERE idempotency_key = $1 LIMIT 1
+SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason FROM transfers WHERE idempotency_key = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransferByIdempotencyKey(ctx context.Context, idempotencyKey string) (Transfer, error) {
@@ -244,12 +565,47 @@ func (q *Queries) GetTransferByIdempotencyKey(ctx context.Context, idempotencyKe
 		&i.CompensatedAt,
 		&i.RecoveryAttempts,
 		&i.LastRecoveryAt,
+		&i.RefundOf,
+		&i.RefundReason,
+	)
+	return i, err
+}
+
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason FROM transfers WHERE id = $1 LIMIT 1 FOR UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id uuid.UUID) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.AmountMinor,
+		&i.Currency,
+		&i.IdempotencyKey,
+		&i.Status,
+		&i.EntryID,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.State,
+		&i.LedgerCallAt,
+		&i.LedgerEntryID,
+		&i.LedgerResponse,
+		&i.CompensationAttempts,
+		&i.CompensatedAt,
+		&i.RecoveryAttempts,
+		&i.LastRecoveryAt,
+		&i.RefundOf,
+		&i.RefundReason,
 	)
 	return i, err
 }
 
 const getTransfersByState = `-- name: GetTransfersByState :many
-SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount_minor, currency, idempotency_key, status, entry_id, failure_reason, created_at, updated_at, state, ledger_call_at, ledger_entry_id, ledger_response, compensation_attempts, compensated_at, recovery_attempts, last_recovery_at, refund_of, refund_reason FROM transfers
 WHERE state = $1
 ORDER BY created_at DESC
 LIMIT $2
@@ -289,6 +645,8 @@ func (q *Queries) GetTransfersByState(ctx context.Context, arg GetTransfersBySta
 			&i.CompensatedAt,
 			&i.RecoveryAttempts,
 			&i.LastRecoveryAt,
+			&i.RefundOf,
+			&i.RefundReason,
 		); err != nil {
 			return nil, err
 		}
@@ -304,7 +662,7 @@ func (q *Queries) GetTransfersByState(ctx context.Context, arg GetTransfersBySta
 }
 
 const getUnsentOutboxEvents = `-- name: GetUnsentOutboxEvents :many
-SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at FROM outbox
+SELECT id, aggregate_type, aggregate_id, event_type, payload, headers, created_at, sent_at, aggregate_seq FROM outbox
 WHERE sent_at IS NULL
 ORDER BY created_at
 LIMIT $1
@@ -329,6 +687,7 @@ func (q *Queries) GetUnsentOutboxEvents(ctx context.Context, limit int32) ([]Out
 			&i.Headers,
 			&i.CreatedAt,
 			&i.SentAt,
+			&i.AggregateSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -356,6 +715,88 @@ func (q *Queries) IncrementRecoveryAttempt(ctx context.Context, id uuid.UUID) er
 	return err
 }
 
+const listHoldCaptures = `-- name: ListHoldCaptures :many
+SELECT id, hold_id, amount_minor, idempotency_key, entry_id, created_at FROM hold_captures
+WHERE hold_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) ListHoldCaptures(ctx context.Context, holdID uuid.UUID) ([]HoldCapture, error) {
+	rows, err := q.db.QueryContext(ctx, listHoldCaptures, holdID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HoldCapture
+	for rows.Next() {
+		var i HoldCapture
+		if err := rows.Scan(
+			&i.ID,
+			&i.HoldID,
+			&i.AmountMinor,
+			&i.IdempotencyKey,
+			&i.EntryID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransferLegs = `-- name: ListTransferLegs :many
+SELECT transfer_id, leg_index, account_id, amount_minor, currency, side FROM transfer_legs
+WHERE transfer_id = $1
+ORDER BY leg_index ASC
+`
+
+func (q *Queries) ListTransferLegs(ctx context.Context, transferID uuid.UUID) ([]TransferLeg, error) {
+	rows, err := q.db.QueryContext(ctx, listTransferLegs, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransferLeg
+	for rows.Next() {
+		var i TransferLeg
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.LegIndex,
+			&i.AccountID,
+			&i.AmountMinor,
+			&i.Currency,
+			&i.Side,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAccountFunds = `-- name: LockAccountFunds :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))
+`
+
+// Serializes holds and debits per account so pending holds cannot be over-reserved or spent
+func (q *Queries) LockAccountFunds(ctx context.Context, dollar_1 string) error {
+	_, err := q.db.ExecContext(ctx, lockAccountFunds, dollar_1)
+	return err
+}
+
 const markOutboxEventSent = `-- name: MarkOutboxEventSent :exec
 UPDATE outbox
 SET sent_at = now()
@@ -405,6 +846,20 @@ func (q *Queries) MarkTransferRecovering(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
+const recordHoldCaptureEntry = `-- name: RecordHoldCaptureEntry :exec
+UPDATE hold_captures SET entry_id = $2 WHERE id = $1
+`
+
+type RecordHoldCaptureEntryParams struct {
+	ID      uuid.UUID     `json:"id"`
+	EntryID uuid.NullUUID `json:"entry_id"`
+}
+
+func (q *Queries) RecordHoldCaptureEntry(ctx context.Context, arg RecordHoldCaptureEntryParams) error {
+	_, err := q.db.ExecContext(ctx, recordHoldCaptureEntry, arg.ID, arg.EntryID)
+	return err
+}
+
 const recordLedgerCall = `-- name: RecordLedgerCall :exec
 UPDATE transfers
 SET state = 'LEDGER_CALLED', ledger_call_at = $2, updated_at = now()
@@ -441,6 +896,125 @@ func (q *Queries) RecordLedgerSuccess(ctx context.Context, arg RecordLedgerSucce
 	return err
 }
 
+const sumInFlightDebits = `-- name: SumInFlightDebits :one
+SELECT COALESCE(SUM(COALESCE(l.amount_minor, t.amount_minor)), 0)::bigint AS debit_minor
+FROM transfers t
+LEFT JOIN transfer_legs l ON l.transfer_id = t.id AND l.side = 'DEBIT'
+WHERE t.state NOT IN ('COMPLETED', 'FAILED', 'COMPENSATED')
+  AND ((l.transfer_id IS NULL AND t.from_account_id = $1 AND t.currency = $2)
+    OR (l.account_id = $1 AND l.currency = $2))
+`
+
+type SumInFlightDebitsParams struct {
+	FromAccountID uuid.UUID `json:"from_account_id"`
+	Currency      string    `json:"currency"`
+}
+
+// What the account is debited by transfers recorded but not yet completed or failed, which the ledger may not have applied yet
+func (q *Queries) SumInFlightDebits(ctx context.Context, arg SumInFlightDebitsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumInFlightDebits, arg.FromAccountID, arg.Currency)
+	var debit_minor int64
+	err := row.Scan(&debit_minor)
+	return debit_minor, err
+}
+
+const sumPendingHolds = `-- name: SumPendingHolds :one
+SELECT (
+  COALESCE((SELECT SUM(h.amount_minor - h.captured_minor) FROM holds h
+            WHERE h.account_id = $1 AND h.currency = $2 AND h.status = 'PENDING' AND h.expires_at > $3), 0) +
+  COALESCE((SELECT SUM(c.amount_minor) FROM hold_captures c JOIN holds h ON h.id = c.hold_id
+            WHERE h.account_id = $1 AND h.currency = $2 AND c.entry_id IS NULL), 0)
+)::bigint AS held_minor
+`
+
+type SumPendingHoldsParams struct {
+	AccountID uuid.UUID `json:"account_id"`
+	Currency  string    `json:"currency"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Funds reserved by the account's pending holds and by captures still being posted to the ledger
+func (q *Queries) SumPendingHolds(ctx context.Context, arg SumPendingHoldsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, sumPendingHolds, arg.AccountID, arg.Currency, arg.ExpiresAt)
+	var held_minor int64
+	err := row.Scan(&held_minor)
+	return held_minor, err
+}
+
+const sumRefunds = `-- name: SumRefunds :one
+SELECT COALESCE(SUM(amount_minor) FILTER (WHERE state = 'COMPLETED'), 0)::bigint AS refunded_minor,
+       COALESCE(SUM(amount_minor) FILTER (WHERE state NOT IN ('COMPLETED', 'FAILED', 'COMPENSATED')), 0)::bigint AS pending_minor
+FROM transfers
+WHERE refund_of = $1
+`
+
+type SumRefundsRow struct {
+	RefundedMinor int64 `json:"refunded_minor"`
+	PendingMinor  int64 `json:"pending_minor"`
+}
+
+func (q *Queries) SumRefunds(ctx context.Context, refundOf uuid.NullUUID) (SumRefundsRow, error) {
+	row := q.db.QueryRowContext(ctx, sumRefunds, refundOf)
+	var i SumRefundsRow
+	err := row.Scan(&i.RefundedMinor, &i.PendingMinor)
+	return i, err
+}
+
+const updateDirectoryAccountStatus = `-- name: UpdateDirectoryAccountStatus :execrows
+UPDATE account_directory
+SET status = $2, status_changed_at = $3, updated_at = now()
+WHERE account_id = $1
+  AND (status_changed_at IS NULL OR status_changed_at <= $3)
+`
+
+type UpdateDirectoryAccountStatusParams struct {
+	AccountID       uuid.UUID    `json:"account_id"`
+	Status          string       `json:"status"`
+	StatusChangedAt sql.NullTime `json:"status_changed_at"`
+}
+
+func (q *Queries) UpdateDirectoryAccountStatus(ctx context.Context, arg UpdateDirectoryAccountStatusParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateDirectoryAccountStatus, arg.AccountID, arg.Status, arg.StatusChangedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateHoldCaptured = `-- name: UpdateHoldCaptured :exec
+UPDATE holds
+SET captured_minor = $2, status = $3, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateHoldCapturedParams struct {
+	ID            uuid.UUID `json:"id"`
+	CapturedMinor int64     `json:"captured_minor"`
+	Status        string    `json:"status"`
+}
+
+func (q *Queries) UpdateHoldCaptured(ctx context.Context, arg UpdateHoldCapturedParams) error {
+	_, err := q.db.ExecContext(ctx, updateHoldCaptured, arg.ID, arg.CapturedMinor, arg.Status)
+	return err
+}
+
+const updateHoldReleased = `-- name: UpdateHoldReleased :exec
+UPDATE holds
+SET status = $2, released_at = $3, updated_at = now()
+WHERE id = $1
+`
+
+type UpdateHoldReleasedParams struct {
+	ID         uuid.UUID    `json:"id"`
+	Status     string       `json:"status"`
+	ReleasedAt sql.NullTime `json:"released_at"`
+}
+
+func (q *Queries) UpdateHoldReleased(ctx context.Context, arg UpdateHoldReleasedParams) error {
+	_, err := q.db.ExecContext(ctx, updateHoldReleased, arg.ID, arg.Status, arg.ReleasedAt)
+	return err
+}
+
 const updateTransferCompleted = `-- name: UpdateTransferCompleted :exec
 UPDATE transfers
 SET status = 'COMPLETED', state = 'COMPLETED', entry_id = $2, updated_at = now()
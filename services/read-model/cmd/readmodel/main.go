@@ -82,12 +82,32 @@ func main() {
 	}
 	log.Println("Connected to database")
 
+	// `readmodel rebuild` replays the ledger history into fresh tables and exits
+	if len(os.Args) > 1 && os.Args[1] == "rebuild" {
+		if err := runRebuild(dbPool, dbURL, brokers, os.Args[2:]); err != nil {
+			log.Fatalf("Rebuild failed: %v", err)
+		}
+		return
+	}
+
 	// Create projector
 	projector := projection.NewProjector(dbPool)
 
+	// Each aggregate's events are applied in sequence; early ones wait in parked_events
+	sequencer := consumer.NewSequencer(dbPool)
+
+	// Messages that keep failing are moved to <topic>.dlq instead of blocking their partition
+	deadLetters, err := consumer.NewDeadLetterQueue(brokers, sequencer)
+	if err != nil {
+		log.Fatalf("Failed to create dead-letter queue: %v", err)
+	}
+	defer deadLetters.Close()
+
 	// Create Kafka consumers
-	kafkaConsumer := consumer.NewConsumer(brokers, projector)
-	transferConsumer := consumer.NewTransferConsumer(brokers, projector)
+	kafkaConsumer := consumer.NewConsumer(brokers, projector, deadLetters)
+	transferConsumer := consumer.NewTransferConsumer(brokers, projector, deadLetters)
+	holdConsumer := consumer.NewHoldConsumer(brokers, projector, deadLetters)
+	accountConsumer := consumer.NewAccountConsumer(brokers, projector, deadLetters)
 
 	// Start Kafka consumers in background
 	consumerCtx, cancelConsumer := context.WithCancel(ctx)
@@ -107,6 +127,23 @@ func main() {
 		}
 	}()
 
+	// Start hold consumer
+	go func() {
+		if err := holdConsumer.Start(consumerCtx); err != nil {
+			log.Printf("Kafka hold consumer error: %v", err)
+		}
+	}()
+
+	// Start account consumer
+	go func() {
+		if err := accountConsumer.Start(consumerCtx); err != nil {
+			log.Printf("Kafka account consumer error: %v", err)
+		}
+	}()
+
+	// Apply parked events once their gap is filled or has timed out
+	go sequencer.Run(consumerCtx, deadLetters.Handler)
+
 	// Setup HTTP server
 	r := chi.NewRouter()
 	
@@ -140,8 +177,17 @@ func main() {
 	handler := readmodelhttp.NewHandler(dbPool)
 	r.Get("/v1/accounts/{id}/balance", handler.GetBalance)
 	r.Get("/v1/accounts/{id}/statements", handler.GetStatements)
+	r.Get("/v1/accounts/{id}/lots", handler.GetLots)
+	r.Get("/v1/accounts/{id}/lots/expiring", handler.GetExpiringLots)
 	r.Get("/v1/transfers", handler.ListTransfers)
 
+	// Dead-letter admin endpoints
+	dlqHandler := readmodelhttp.NewDeadLetterHandler(dbPool, deadLetters)
+	r.Get("/v1/admin/dlq", dlqHandler.ListPartitions)
+	r.Get("/v1/admin/dlq/{topic}/messages", dlqHandler.ListMessages)
+	r.Get("/v1/admin/dlq/{topic}/messages/{partition}/{offset}", dlqHandler.GetMessage)
+	r.Post("/v1/admin/dlq/{topic}/messages/{partition}/{offset}/redrive", dlqHandler.RedriveMessage)
+
 	// Start HTTP server
 	server := &http.Server{
 		Addr:    ":" + port,
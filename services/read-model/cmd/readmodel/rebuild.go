@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/amirhf/credit-ledger/services/read-model/internal/consumer"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/rebuild"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runRebuild handles `readmodel rebuild [-source kafka|ledger] [-swap=false]`.
+// It runs alongside the serving instance: the live tables keep answering
+// queries until the verified rebuild is swapped in.
+func runRebuild(dbPool *pgxpool.Pool, dbURL string, brokers []string, args []string) error {
+	flags := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	sourceName := flags.String("source", "kafka", `event history to replay: "kafka" (ledger.entry.v1 from offset 0) or "ledger" (LEDGER_DATABASE_URL outbox and journal tables)`)
+	swap := flags.Bool("swap", true, "swap the verified tables in; false leaves them in the "+rebuild.ShadowSchema+" schema")
+	flags.Parse(args)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	var source rebuild.Source
+	switch *sourceName {
+	case "kafka":
+		dialer, err := consumer.NewDialer()
+		if err != nil {
+			return err
+		}
+		source = rebuild.NewKafkaSource(brokers, dialer)
+	case "ledger":
+		ledgerURL := os.Getenv("LEDGER_DATABASE_URL")
+		if ledgerURL == "" {
+			return fmt.Errorf("LEDGER_DATABASE_URL is required to rebuild from the ledger")
+		}
+		ledgerPool, err := pgxpool.New(ctx, ledgerURL)
+		if err != nil {
+			return fmt.Errorf("connect to ledger database: %w", err)
+		}
+		defer ledgerPool.Close()
+		source = rebuild.NewLedgerSource(ledgerPool)
+	default:
+		return fmt.Errorf("unknown source %q", *sourceName)
+	}
+
+	log.Printf("Rebuilding read model from %s", *sourceName)
+	return rebuild.NewRebuilder(dbPool, dbURL, source, log.Default()).Run(ctx, *swap)
+}
@@ -3,11 +3,14 @@ package consumer
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/common/events"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/projection"
 	"github.com/google/uuid"
@@ -23,12 +26,13 @@ import (
 
 // Consumer reads events from Kafka and applies them to projections
 type Consumer struct {
-	reader    *kafka.Reader
-	projector *projection.Projector
+	reader      *kafka.Reader
+	projector   *projection.Projector
+	deadLetters *DeadLetterQueue
 }
 
-// NewConsumer creates a Kafka consumer for the ledger.entry.v1 topic
-func NewConsumer(brokers []string, projector *projection.Projector) *Consumer {
+// NewConsumer creates a Kafka consumer for the ledger.entry.v1 topic (EntryPosted and EntryVoided)
+func NewConsumer(brokers []string, projector *projection.Projector, deadLetters *DeadLetterQueue) *Consumer {
 	config := kafka.ReaderConfig{
 		Brokers:        brokers,
 		Topic:          "ledger.entry.v1",
@@ -76,10 +80,13 @@ func NewConsumer(brokers []string, projector *projection.Projector) *Consumer {
 
 	reader := kafka.NewReader(config)
 
-	return &Consumer{
-		reader:    reader,
-		projector: projector,
+	c := &Consumer{
+		reader:      reader,
+		projector:   projector,
+		deadLetters: deadLetters,
 	}
+	deadLetters.Register(config.Topic, c.Handle)
+	return c
 }
 
 // Start begins consuming messages and blocks until context is canceled
@@ -112,46 +119,42 @@ func (c *Consumer) Start(ctx context.Context) error {
 		}
 		msgCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
 
+		// Decode the event envelope, skipping events this build cannot read. Messages
+		// published before EntryVoided was routed here carry no type and are always EntryPosted
+		envelope, eventID, err := decodeEvent(msg, "EntryPosted")
+		if err != nil {
+			log.Printf("Skipping message at partition %d offset %d: %v", msg.Partition, msg.Offset, err)
+			c.reader.CommitMessages(ctx, msg)
+			continue
+		}
+		eventType := envelope.EventType
+
 		// Create span for message processing
 		tracer := otel.Tracer("kafka-consumer")
-		msgCtx, span := tracer.Start(msgCtx, "consume EntryPosted",
+		msgCtx, span := tracer.Start(msgCtx, fmt.Sprintf("consume %s", eventType),
 			trace.WithAttributes(
 				attribute.String("kafka.topic", msg.Topic),
 				attribute.Int("kafka.partition", msg.Partition),
 				attribute.Int64("kafka.offset", msg.Offset),
+				attribute.String("event_type", eventType),
 			),
 		)
 		defer span.End()
-
-		// Extract event_id from headers
-		eventID, err := extractEventID(msg.Headers)
-		if err != nil {
-			span.RecordError(err)
-			log.Printf("Error extracting event_id: %v, skipping message", err)
-			c.reader.CommitMessages(ctx, msg)
-			continue
-		}
 		span.SetAttributes(attribute.String("event_id", eventID.String()))
 
-		// Process the event
-		start := time.Now()
-		err = c.projector.ProcessEntryPosted(msgCtx, eventID, msg.Value)
-		duration := time.Since(start).Seconds()
-		
-		if err != nil {
+		// Process the event, dead-lettering it once its retries are exhausted
+		if err := c.deadLetters.Process(msgCtx, msg, envelope, eventID, c.Handle); err != nil {
+			if errors.Is(err, ErrUnknownEventType) {
+				log.Printf("Unknown event type: %s, skipping", eventType)
+				c.reader.CommitMessages(ctx, msg)
+				continue
+			}
+			// Canceled before the event was applied or dead-lettered; leave it uncommitted
 			span.RecordError(err)
-			metrics.EventProcessingErrors.WithLabelValues("EntryPosted", "processing_error").Inc()
-			metrics.EventProcessingDuration.WithLabelValues("EntryPosted", "error").Observe(duration)
-			log.Printf("Error processing event %s: %v", eventID, err)
-			// Don't commit on error - will retry
-			time.Sleep(time.Second)
+			log.Printf("Stopped processing event %s (%s): %v", eventID, eventType, err)
 			continue
 		}
 
-		// Record successful processing
-		metrics.EventsProcessed.WithLabelValues("EntryPosted").Inc()
-		metrics.EventProcessingDuration.WithLabelValues("EntryPosted", "success").Observe(duration)
-
 		// Commit the message
 		if err := c.reader.CommitMessages(ctx, msg); err != nil {
 			span.RecordError(err)
@@ -160,12 +163,44 @@ func (c *Consumer) Start(ctx context.Context) error {
 	}
 }
 
-// extractEventID extracts the event_id from Kafka message headers
-func extractEventID(headers []kafka.Header) (uuid.UUID, error) {
-	for _, h := range headers {
-		if h.Key == "event_id" {
-			return uuid.Parse(string(h.Value))
+// Handle applies one ledger.entry.v1 event to the projections
+func (c *Consumer) Handle(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error {
+	switch eventType {
+	case "EntryPosted", "":
+		return c.projector.ProcessEntryPosted(ctx, eventID, payload)
+	case "EntryVoided":
+		return c.projector.ProcessEntryVoided(ctx, eventID, payload)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+}
+
+// decodeEvent reads a message's event envelope and event ID, giving events
+// without a type defaultType. It fails for messages to skip, counting them:
+// undecodable ones and event types or schema versions this build does not know.
+func decodeEvent(msg kafka.Message, defaultType string) (*ledgerv1.EventEnvelope, uuid.UUID, error) {
+	envelope, err := events.Decode(msg.Value, msg.Headers)
+	if err != nil {
+		metrics.EventsSkipped.WithLabelValues("", "undecodable").Inc()
+		return nil, uuid.Nil, err
+	}
+	if envelope.EventType == "" {
+		envelope.EventType = defaultType
+	}
+
+	if err := events.Check(envelope); err != nil {
+		reason := "unknown_type"
+		if errors.Is(err, events.ErrUnsupportedVersion) {
+			reason = "unsupported_version"
 		}
+		metrics.EventsSkipped.WithLabelValues(envelope.EventType, reason).Inc()
+		return nil, uuid.Nil, err
+	}
+
+	eventID, err := uuid.Parse(envelope.EventId)
+	if err != nil {
+		metrics.EventsSkipped.WithLabelValues(envelope.EventType, "invalid_event_id").Inc()
+		return nil, uuid.Nil, fmt.Errorf("invalid event_id %q: %w", envelope.EventId, err)
 	}
-	return uuid.Nil, fmt.Errorf("event_id header not found")
+	return envelope, eventID, nil
 }
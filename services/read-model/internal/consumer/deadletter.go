@@ -0,0 +1,475 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/common/events"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// DeadLetterSuffix is appended to a topic name to form its dead-letter topic
+const DeadLetterSuffix = ".dlq"
+
+// Headers added to a dead-lettered message next to the original headers
+const (
+	HeaderDeadLetterError     = "dlq_error"
+	HeaderDeadLetterAttempts  = "dlq_attempts"
+	HeaderDeadLetterFailedAt  = "dlq_failed_at"
+	HeaderDeadLetterTopic     = "dlq_original_topic"
+	HeaderDeadLetterPartition = "dlq_original_partition"
+	HeaderDeadLetterOffset    = "dlq_original_offset"
+)
+
+var (
+	// ErrUnknownEventType is returned by an EventHandler for event types it does not project
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrUnknownTopic is returned for topics no consumer has registered
+	ErrUnknownTopic = errors.New("unknown topic")
+	// ErrDeadLetterNotFound is returned when no dead-lettered message exists at an offset
+	ErrDeadLetterNotFound = errors.New("dead letter not found")
+	// ErrRedriveFailed is returned when a dead letter was read but could not be applied
+	ErrRedriveFailed = errors.New("redrive failed")
+)
+
+// EventHandler applies one event from a topic to the projections
+type EventHandler func(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error
+
+// RetryPolicy bounds how often a failing message is retried before it is dead-lettered
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy gives up on a message after about six seconds of retries
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+}
+
+// backoff returns the wait before the attempt after the given one
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// DeadLetter is a message that exhausted its retries, as read back from a dead-letter topic
+type DeadLetter struct {
+	Partition       int
+	Offset          int64
+	Key             []byte
+	Payload         []byte
+	Headers         []kafka.Header // original headers followed by the dlq_ headers
+	EventType       string
+	EventID         string // raw header value; may not be a valid UUID
+	Error           string
+	Attempts        int
+	FailedAt        time.Time
+	SourceTopic     string
+	SourcePartition int
+	SourceOffset    int64
+}
+
+// DeadLetterPartition describes the retained range of one dead-letter topic partition
+type DeadLetterPartition struct {
+	Topic       string // source topic
+	Partition   int
+	FirstOffset int64
+	NextOffset  int64
+}
+
+// DeadLetterQueue retries failing messages, publishes the ones that exhaust
+// their retries to <topic>.dlq and reads them back for inspection and re-drive.
+// Dead-letter topics are read by partition and offset outside any consumer
+// group, so inspecting them never moves a committed offset. Messages and
+// re-drives are applied through the sequencer, in their aggregate's order.
+type DeadLetterQueue struct {
+	brokers   []string
+	dialer    *kafka.Dialer
+	writer    *kafka.Writer
+	policy    RetryPolicy
+	sequencer *Sequencer
+
+	mu       sync.RWMutex
+	handlers map[string]EventHandler // by source topic
+}
+
+// NewDeadLetterQueue creates a dead-letter queue using the default retry policy
+func NewDeadLetterQueue(brokers []string, sequencer *Sequencer) (*DeadLetterQueue, error) {
+	dialer, err := NewDialer()
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Balancer:               &kafka.Hash{}, // Keep each aggregate's messages in order
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafka.RequireAll,
+	}
+	if dialer.SASLMechanism != nil {
+		writer.Transport = &kafka.Transport{
+			SASL: dialer.SASLMechanism,
+			TLS:  dialer.TLS,
+		}
+	}
+
+	return &DeadLetterQueue{
+		brokers:   brokers,
+		dialer:    dialer,
+		writer:    writer,
+		policy:    DefaultRetryPolicy,
+		sequencer: sequencer,
+		handlers:  make(map[string]EventHandler),
+	}, nil
+}
+
+// Register makes a topic's dead letters available for re-drive through handler
+func (q *DeadLetterQueue) Register(topic string, handler EventHandler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[topic] = handler
+}
+
+// Topics returns the registered source topics in name order
+func (q *DeadLetterQueue) Topics() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	topics := make([]string, 0, len(q.handlers))
+	for topic := range q.handlers {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Handler returns the handler registered for a topic
+func (q *DeadLetterQueue) Handler(topic string) (EventHandler, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	handler, ok := q.handlers[topic]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTopic, topic)
+	}
+	return handler, nil
+}
+
+// Close flushes and closes the dead-letter writer
+func (q *DeadLetterQueue) Close() error {
+	return q.writer.Close()
+}
+
+// Process applies a message's event, decoded into envelope, through handler,
+// retrying with backoff. Once the
+// retries are exhausted the message is dead-lettered, so the caller can commit
+// it either way. It returns ErrUnknownEventType unretried, and otherwise only
+// fails when ctx is canceled before the message was applied or dead-lettered.
+func (q *DeadLetterQueue) Process(ctx context.Context, msg kafka.Message, envelope *ledgerv1.EventEnvelope, eventID uuid.UUID, handler EventHandler) error {
+	handler = q.sequencer.Wrap(msg.Topic, envelope, handler)
+	eventType := envelope.EventType
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		err = handler(ctx, eventType, eventID, envelope.Payload.Value)
+		duration := time.Since(start).Seconds()
+		if err == nil {
+			metrics.EventsProcessed.WithLabelValues(eventType).Inc()
+			metrics.EventProcessingDuration.WithLabelValues(eventType, "success").Observe(duration)
+			return nil
+		}
+		if errors.Is(err, ErrUnknownEventType) {
+			return err
+		}
+
+		metrics.EventProcessingErrors.WithLabelValues(eventType, "processing_error").Inc()
+		metrics.EventProcessingDuration.WithLabelValues(eventType, "error").Observe(duration)
+		log.Printf("Error processing event %s (%s), attempt %d of %d: %v", eventID, eventType, attempt, q.policy.MaxAttempts, err)
+
+		if attempt >= q.policy.MaxAttempts {
+			return q.deadLetter(ctx, msg, eventType, attempt, err)
+		}
+		metrics.EventRetries.WithLabelValues(eventType).Inc()
+		if err := sleep(ctx, q.policy.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+}
+
+// deadLetter publishes msg to its dead-letter topic, retrying until it succeeds
+// or ctx is canceled; an unpublished message must never be committed
+func (q *DeadLetterQueue) deadLetter(ctx context.Context, msg kafka.Message, eventType string, attempts int, cause error) error {
+	dlqMsg := kafka.Message{
+		Topic:   msg.Topic + DeadLetterSuffix,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: deadLetterHeaders(msg, attempts, cause, time.Now()),
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := q.writer.WriteMessages(ctx, dlqMsg)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		metrics.EventProcessingErrors.WithLabelValues(eventType, "dead_letter_error").Inc()
+		log.Printf("Error publishing to %s (partition %d offset %d): %v", dlqMsg.Topic, msg.Partition, msg.Offset, err)
+		if err := sleep(ctx, q.policy.backoff(attempt)); err != nil {
+			return err
+		}
+	}
+
+	metrics.DeadLetteredEvents.WithLabelValues(msg.Topic, eventType).Inc()
+	log.Printf("Dead-lettered %s message at partition %d offset %d to %s after %d attempts: %v",
+		eventType, msg.Partition, msg.Offset, dlqMsg.Topic, attempts, cause)
+	return nil
+}
+
+// Partitions returns the retained offset range of every partition of the
+// registered topics' dead-letter topics; topics never dead-lettered to are omitted
+func (q *DeadLetterQueue) Partitions(ctx context.Context) ([]DeadLetterPartition, error) {
+	conn, err := q.dialer.DialContext(ctx, "tcp", q.brokers[0])
+	if err != nil {
+		return nil, fmt.Errorf("dial kafka: %w", err)
+	}
+	defer conn.Close()
+
+	var result []DeadLetterPartition
+	for _, topic := range q.Topics() {
+		partitions, err := conn.ReadPartitions(topic + DeadLetterSuffix)
+		if errors.Is(err, kafka.UnknownTopicOrPartition) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read partitions of %s: %w", topic+DeadLetterSuffix, err)
+		}
+		sort.Slice(partitions, func(i, j int) bool { return partitions[i].ID < partitions[j].ID })
+
+		for _, partition := range partitions {
+			first, next, err := q.readOffsets(ctx, topic, partition.ID)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, DeadLetterPartition{
+				Topic:       topic,
+				Partition:   partition.ID,
+				FirstOffset: first,
+				NextOffset:  next,
+			})
+		}
+	}
+	return result, nil
+}
+
+// List returns up to limit dead letters of a topic's partition starting at
+// offset, and the offset to continue from (-1 at the end of the partition)
+func (q *DeadLetterQueue) List(ctx context.Context, topic string, partition int, offset int64, limit int) ([]DeadLetter, int64, error) {
+	if _, err := q.Handler(topic); err != nil {
+		return nil, -1, err
+	}
+
+	first, next, err := q.readOffsets(ctx, topic, partition)
+	if err != nil {
+		if errors.Is(err, kafka.UnknownTopicOrPartition) {
+			return nil, -1, nil
+		}
+		return nil, -1, err
+	}
+
+	start := max(offset, first)
+	end := min(start+int64(limit), next)
+	if start >= end {
+		return nil, -1, nil
+	}
+
+	letters, err := q.read(ctx, topic, partition, start, end)
+	if err != nil {
+		return nil, -1, err
+	}
+	if end == next {
+		return letters, -1, nil
+	}
+	return letters, end, nil
+}
+
+// Get returns the dead letter at an offset of a topic's partition
+func (q *DeadLetterQueue) Get(ctx context.Context, topic string, partition int, offset int64) (DeadLetter, error) {
+	if _, err := q.Handler(topic); err != nil {
+		return DeadLetter{}, err
+	}
+
+	first, next, err := q.readOffsets(ctx, topic, partition)
+	if err != nil {
+		if errors.Is(err, kafka.UnknownTopicOrPartition) {
+			return DeadLetter{}, ErrDeadLetterNotFound
+		}
+		return DeadLetter{}, err
+	}
+	if offset < first || offset >= next {
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+
+	letters, err := q.read(ctx, topic, partition, offset, offset+1)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	if len(letters) == 0 || letters[0].Offset != offset {
+		// The offset was compacted away or held a transaction marker
+		return DeadLetter{}, ErrDeadLetterNotFound
+	}
+	return letters[0], nil
+}
+
+// Redrive applies a dead letter to the projections through its topic's
+// handler. The projector skips events it already applied, so re-driving a
+// message twice is harmless; the message stays in the dead-letter topic.
+func (q *DeadLetterQueue) Redrive(ctx context.Context, topic string, partition int, offset int64) (DeadLetter, error) {
+	handler, err := q.Handler(topic)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	letter, err := q.Get(ctx, topic, partition, offset)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	envelope, err := events.Decode(letter.Payload, letter.Headers)
+	if err != nil {
+		metrics.DeadLetterRedrives.WithLabelValues(topic, "invalid").Inc()
+		return letter, fmt.Errorf("%w: %w", ErrRedriveFailed, err)
+	}
+	eventID, err := uuid.Parse(envelope.EventId)
+	if err != nil {
+		metrics.DeadLetterRedrives.WithLabelValues(topic, "invalid").Inc()
+		return letter, fmt.Errorf("%w: invalid event_id %q: %w", ErrRedriveFailed, envelope.EventId, err)
+	}
+	if envelope.EventType == "" {
+		envelope.EventType = letter.EventType
+	}
+
+	handler = q.sequencer.Wrap(topic, envelope, handler)
+	if err := handler(ctx, envelope.EventType, eventID, envelope.Payload.Value); err != nil {
+		metrics.DeadLetterRedrives.WithLabelValues(topic, "error").Inc()
+		return letter, fmt.Errorf("%w: %w", ErrRedriveFailed, err)
+	}
+	metrics.DeadLetterRedrives.WithLabelValues(topic, "success").Inc()
+	log.Printf("Re-drove %s event %s from %s partition %d offset %d", letter.EventType, eventID, topic+DeadLetterSuffix, partition, offset)
+	return letter, nil
+}
+
+// readOffsets returns the first and next-to-be-written offsets of a dead-letter topic partition
+func (q *DeadLetterQueue) readOffsets(ctx context.Context, topic string, partition int) (int64, int64, error) {
+	conn, err := q.dialer.DialLeader(ctx, "tcp", q.brokers[0], topic+DeadLetterSuffix, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dial leader of %s partition %d: %w", topic+DeadLetterSuffix, partition, err)
+	}
+	defer conn.Close()
+
+	first, next, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read offsets of %s partition %d: %w", topic+DeadLetterSuffix, partition, err)
+	}
+	return first, next, nil
+}
+
+// read returns the dead letters in [start, end) of a dead-letter topic partition
+func (q *DeadLetterQueue) read(ctx context.Context, topic string, partition int, start, end int64) ([]DeadLetter, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   q.brokers,
+		Topic:     topic + DeadLetterSuffix,
+		Partition: partition,
+		Dialer:    q.dialer,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10MB
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return nil, fmt.Errorf("seek partition %d: %w", partition, err)
+	}
+
+	var letters []DeadLetter
+	for offset := start; offset < end; {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("read partition %d at offset %d: %w", partition, offset, err)
+		}
+		offset = msg.Offset + 1
+		if msg.Offset >= end {
+			break
+		}
+		letters = append(letters, toDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// deadLetterHeaders returns msg's headers followed by the dlq_ headers describing its failure
+func deadLetterHeaders(msg kafka.Message, attempts int, cause error, failedAt time.Time) []kafka.Header {
+	headers := append([]kafka.Header{}, msg.Headers...)
+	return append(headers,
+		kafka.Header{Key: HeaderDeadLetterError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDeadLetterAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDeadLetterFailedAt, Value: []byte(failedAt.UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: HeaderDeadLetterTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDeadLetterPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDeadLetterOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+}
+
+func toDeadLetter(msg kafka.Message) DeadLetter {
+	letter := DeadLetter{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Payload:   msg.Value,
+		Headers:   msg.Headers,
+		Error:     headerValue(msg.Headers, HeaderDeadLetterError),
+		EventID:   headerValue(msg.Headers, "event_id"),
+	}
+	letter.EventType, _ = extractEventType(msg.Headers)
+	letter.SourceTopic = headerValue(msg.Headers, HeaderDeadLetterTopic)
+	letter.Attempts, _ = strconv.Atoi(headerValue(msg.Headers, HeaderDeadLetterAttempts))
+	letter.SourcePartition, _ = strconv.Atoi(headerValue(msg.Headers, HeaderDeadLetterPartition))
+	letter.SourceOffset, _ = strconv.ParseInt(headerValue(msg.Headers, HeaderDeadLetterOffset), 10, 64)
+	letter.FailedAt, _ = time.Parse(time.RFC3339Nano, headerValue(msg.Headers, HeaderDeadLetterFailedAt))
+	return letter
+}
+
+// headerValue returns the value of a Kafka message header, or "" when absent
+func headerValue(headers []kafka.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// sleep waits for d or until ctx is canceled
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
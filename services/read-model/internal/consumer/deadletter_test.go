@@ -0,0 +1,110 @@
+package consumer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+	}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 400 * time.Millisecond},
+		{attempt: 4, want: 800 * time.Millisecond},
+		{attempt: 5, want: time.Second},
+		{attempt: 50, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := policy.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryPolicy_BackoffStartsAtMax(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 2 * time.Second, MaxBackoff: time.Second}
+
+	if got := policy.backoff(1); got != time.Second {
+		t.Errorf("backoff(1) = %s, want the %s cap", got, time.Second)
+	}
+}
+
+func TestToDeadLetter_RoundTripsHeaders(t *testing.T) {
+	original := []kafka.Header{
+		{Key: "event_name", Value: []byte("TransferCompleted")},
+		{Key: "event_id", Value: []byte("not-a-uuid")},
+		{Key: "traceparent", Value: []byte("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")},
+	}
+	msg := kafka.Message{
+		Topic:     "ledger.transfer.v1",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte("aggregate"),
+		Value:     []byte("payload"),
+		Headers:   original,
+	}
+	failedAt := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	headers := deadLetterHeaders(msg, 5, errors.New("projection failed"), failedAt)
+	if !reflect.DeepEqual(headers[:len(original)], original) {
+		t.Fatalf("expected the original headers first, got %v", headers[:len(original)])
+	}
+	if len(msg.Headers) != len(original) {
+		t.Fatalf("expected the message's headers to be left as they were, got %d", len(msg.Headers))
+	}
+
+	letter := toDeadLetter(kafka.Message{
+		Partition: 0,
+		Offset:    7,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+	})
+
+	want := DeadLetter{
+		Partition:       0,
+		Offset:          7,
+		Key:             msg.Key,
+		Payload:         msg.Value,
+		Headers:         headers,
+		EventType:       "TransferCompleted",
+		EventID:         "not-a-uuid",
+		Error:           "projection failed",
+		Attempts:        5,
+		FailedAt:        failedAt,
+		SourceTopic:     "ledger.transfer.v1",
+		SourcePartition: 3,
+		SourceOffset:    42,
+	}
+	if !reflect.DeepEqual(letter, want) {
+		t.Errorf("toDeadLetter = %+v, want %+v", letter, want)
+	}
+}
+
+func TestHeaderValue(t *testing.T) {
+	headers := []kafka.Header{
+		{Key: "event_id", Value: []byte("first")},
+		{Key: "event_id", Value: []byte("second")},
+	}
+
+	if got := headerValue(headers, "event_id"); got != "first" {
+		t.Errorf("headerValue = %q, want the first occurrence", got)
+	}
+	if got := headerValue(headers, HeaderDeadLetterError); got != "" {
+		t.Errorf("headerValue of a missing header = %q, want empty", got)
+	}
+}
@@ -0,0 +1,54 @@
+package consumer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// NewDialer returns a Kafka dialer for direct connections (e.g. replaying a
+// topic outside a consumer group), with SASL configured from the same
+// environment variables as the consumers
+func NewDialer() (*kafka.Dialer, error) {
+	dialer := &kafka.Dialer{
+		Timeout:   10 * time.Second,
+		DualStack: true,
+	}
+
+	username := os.Getenv("KAFKA_SASL_USERNAME")
+	if username == "" {
+		return dialer, nil
+	}
+	password := os.Getenv("KAFKA_SASL_PASSWORD")
+	mechanismType := os.Getenv("KAFKA_SASL_MECHANISM")
+	if mechanismType == "" {
+		mechanismType = "PLAIN"
+	}
+
+	var mechanism sasl.Mechanism
+	switch mechanismType {
+	case "PLAIN":
+		mechanism = plain.Mechanism{
+			Username: username,
+			Password: password,
+		}
+	case "SCRAM-SHA-256":
+		var err error
+		mechanism, err = scram.Mechanism(scram.SHA256, username, password)
+		if err != nil {
+			return nil, fmt.Errorf("create SCRAM mechanism: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s", mechanismType)
+	}
+
+	dialer.SASLMechanism = mechanism
+	dialer.TLS = &tls.Config{}
+	return dialer, nil
+}
@@ -0,0 +1,174 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/projection"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/proto"
+)
+
+// HoldConsumer reads hold events from Kafka and applies them to projections
+type HoldConsumer struct {
+	reader      *kafka.Reader
+	projector   *projection.Projector
+	deadLetters *DeadLetterQueue
+}
+
+// NewHoldConsumer creates a Kafka consumer for the ledger.hold.v1 topic
+func NewHoldConsumer(brokers []string, projector *projection.Projector, deadLetters *DeadLetterQueue) *HoldConsumer {
+	config := kafka.ReaderConfig{
+		Brokers:        brokers,
+		Topic:          "ledger.hold.v1",
+		GroupID:        "read-model-hold-projections",
+		MinBytes:       1,
+		MaxBytes:       10e6, // 10MB
+		CommitInterval: time.Second,
+		StartOffset:    kafka.FirstOffset, // Start from beginning for new consumers
+	}
+
+	// SASL authentication comes from the same environment as the other consumers
+	dialer, err := NewDialer()
+	if err != nil {
+		log.Fatalf("Failed to configure Kafka hold consumer: %v", err)
+	}
+	config.Dialer = dialer
+
+	reader := kafka.NewReader(config)
+
+	c := &HoldConsumer{
+		reader:      reader,
+		projector:   projector,
+		deadLetters: deadLetters,
+	}
+	deadLetters.Register(config.Topic, c.Handle)
+	return c
+}
+
+// Start begins consuming hold messages and blocks until context is canceled
+func (c *HoldConsumer) Start(ctx context.Context) error {
+	log.Println("Starting Kafka consumer for ledger.hold.v1")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Shutting down Kafka hold consumer")
+			return c.reader.Close()
+		default:
+		}
+
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return c.reader.Close()
+			}
+			log.Printf("Error fetching hold message: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		c.consume(ctx, msg)
+	}
+}
+
+// consume applies and commits one ledger.hold.v1 message; the span ends with it
+func (c *HoldConsumer) consume(ctx context.Context, msg kafka.Message) {
+	// Extract trace context from Kafka headers
+	carrier := propagation.MapCarrier{}
+	for _, h := range msg.Headers {
+		carrier[h.Key] = string(h.Value)
+	}
+	msgCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
+
+	// Decode the event envelope, skipping events this build cannot read
+	envelope, eventID, err := decodeEvent(msg, "")
+	if err != nil {
+		log.Printf("Skipping message at partition %d offset %d: %v", msg.Partition, msg.Offset, err)
+		c.reader.CommitMessages(ctx, msg)
+		return
+	}
+	eventType := envelope.EventType
+
+	// Create span for message processing
+	tracer := otel.Tracer("kafka-hold-consumer")
+	msgCtx, span := tracer.Start(msgCtx, fmt.Sprintf("consume %s", eventType),
+		trace.WithAttributes(
+			attribute.String("kafka.topic", msg.Topic),
+			attribute.Int("kafka.partition", msg.Partition),
+			attribute.Int64("kafka.offset", msg.Offset),
+			attribute.String("event_type", eventType),
+		),
+	)
+	defer span.End()
+	span.SetAttributes(attribute.String("event_id", eventID.String()))
+
+	// Process the event, dead-lettering it once its retries are exhausted
+	if err := c.deadLetters.Process(msgCtx, msg, envelope, eventID, c.Handle); err != nil {
+		if errors.Is(err, ErrUnknownEventType) {
+			log.Printf("Unknown event type: %s, skipping", eventType)
+			c.reader.CommitMessages(ctx, msg)
+			return
+		}
+		// Canceled before the event was applied or dead-lettered; leave it uncommitted
+		span.RecordError(err)
+		log.Printf("Stopped processing event %s (%s): %v", eventID, eventType, err)
+		return
+	}
+
+	// Commit the message
+	if err := c.reader.CommitMessages(ctx, msg); err != nil {
+		span.RecordError(err)
+		log.Printf("Error committing message: %v", err)
+	}
+}
+
+// Handle applies one ledger.hold.v1 event to the projections
+func (c *HoldConsumer) Handle(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error {
+	switch eventType {
+	case "HoldPlaced":
+		return c.processHoldPlaced(ctx, eventID, payload)
+	case "HoldCaptured":
+		return c.processHoldCaptured(ctx, eventID, payload)
+	case "HoldReleased":
+		return c.processHoldReleased(ctx, eventID, payload)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+}
+
+func (c *HoldConsumer) processHoldPlaced(ctx context.Context, eventID uuid.UUID, payload []byte) error {
+	var event ledgerv1.HoldPlaced
+	if err := proto.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("unmarshal HoldPlaced: %w", err)
+	}
+
+	return c.projector.ProcessHoldPlaced(ctx, eventID, &event)
+}
+
+func (c *HoldConsumer) processHoldCaptured(ctx context.Context, eventID uuid.UUID, payload []byte) error {
+	var event ledgerv1.HoldCaptured
+	if err := proto.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("unmarshal HoldCaptured: %w", err)
+	}
+
+	return c.projector.ProcessHoldCaptured(ctx, eventID, &event)
+}
+
+func (c *HoldConsumer) processHoldReleased(ctx context.Context, eventID uuid.UUID, payload []byte) error {
+	var event ledgerv1.HoldReleased
+	if err := proto.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("unmarshal HoldReleased: %w", err)
+	}
+
+	return c.projector.ProcessHoldReleased(ctx, eventID, &event)
+}
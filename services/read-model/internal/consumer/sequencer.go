@@ -0,0 +1,209 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Sequencer applies each aggregate's events in aggregate_seq order. An event
+// that arrives ahead of a missing earlier one is parked in parked_events and
+// applied as soon as the gap is filled. Events without an aggregate sequence
+// predate sequencing and are applied as they come.
+//
+// A gap that stays open for MaxWait, for example because the missing event was
+// dead-lettered, is given up on: the parked events are applied and the missing
+// one is applied late if it ever arrives or is re-driven. The projector skips
+// events it already applied, so re-applying an event is harmless.
+type Sequencer struct {
+	queries sequenceStore
+
+	MaxWait       time.Duration // how long a gap may hold back parked events
+	SweepInterval time.Duration // how often parked events are checked
+}
+
+// sequenceStore is the part of store.Queries the sequencer keeps its state in
+type sequenceStore interface {
+	GetAggregateSeq(ctx context.Context, aggregateID pgtype.UUID) (int64, error)
+	AdvanceAggregateSeq(ctx context.Context, arg store.AdvanceAggregateSeqParams) error
+	ParkEvent(ctx context.Context, arg store.ParkEventParams) error
+	GetParkedEvent(ctx context.Context, arg store.GetParkedEventParams) (store.ParkedEvent, error)
+	DeleteParkedEvent(ctx context.Context, arg store.DeleteParkedEventParams) error
+	ListStalledAggregates(ctx context.Context, arg store.ListStalledAggregatesParams) ([]store.ListStalledAggregatesRow, error)
+	CountParkedEvents(ctx context.Context) (int64, error)
+}
+
+// NewSequencer creates a sequencer that waits up to five minutes for a missing event
+func NewSequencer(db *pgxpool.Pool) *Sequencer {
+	return &Sequencer{
+		queries:       store.New(db),
+		MaxWait:       5 * time.Minute,
+		SweepInterval: 10 * time.Second,
+	}
+}
+
+// Wrap returns a handler applying the event of envelope through handler in its
+// aggregate's sequence. A nil Sequencer returns handler as is.
+func (s *Sequencer) Wrap(topic string, envelope *ledgerv1.EventEnvelope, handler EventHandler) EventHandler {
+	aggregateID, err := uuid.Parse(envelope.AggregateId)
+	seq := envelope.AggregateSeq
+	if s == nil || err != nil || seq < 1 {
+		return handler
+	}
+
+	return func(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error {
+		last, err := s.lastSeq(ctx, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if seq > last+1 {
+			err := s.queries.ParkEvent(ctx, store.ParkEventParams{
+				AggregateID: pgtype.UUID{Bytes: aggregateID, Valid: true},
+				Seq:         seq,
+				Topic:       topic,
+				EventType:   eventType,
+				EventID:     pgtype.UUID{Bytes: eventID, Valid: true},
+				Payload:     payload,
+			})
+			if err != nil {
+				return fmt.Errorf("park event: %w", err)
+			}
+			metrics.EventsParked.WithLabelValues(eventType).Inc()
+			log.Printf("Parked %s event %s (aggregate %s seq %d) until seq %d arrives", eventType, eventID, aggregateID, seq, last+1)
+			return nil
+		}
+
+		if err := handler(ctx, eventType, eventID, payload); err != nil {
+			return err
+		}
+		if seq <= last {
+			// Redelivered, or arriving after its gap was given up on
+			return nil
+		}
+		if err := s.advance(ctx, aggregateID, seq); err != nil {
+			return err
+		}
+
+		// Events parked behind this one are left to the sweep if they fail
+		lookup := func(string) (EventHandler, error) { return handler, nil }
+		if err := s.release(ctx, aggregateID, seq+1, lookup, "in_order"); err != nil {
+			log.Printf("Error applying events parked behind aggregate %s seq %d: %v", aggregateID, seq, err)
+		}
+		return nil
+	}
+}
+
+// Run applies parked events whose gap has been filled or has timed out every
+// SweepInterval until ctx is canceled, looking up each event's handler by topic
+func (s *Sequencer) Run(ctx context.Context, handlers func(topic string) (EventHandler, error)) {
+	ticker := time.NewTicker(s.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sweep(ctx, handlers); err != nil && ctx.Err() == nil {
+			log.Printf("Error sweeping parked events: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sequencer) sweep(ctx context.Context, handlers func(topic string) (EventHandler, error)) error {
+	stalled, err := s.queries.ListStalledAggregates(ctx, store.ListStalledAggregatesParams{
+		ParkedBefore:  pgtype.Timestamptz{Time: time.Now().Add(-s.MaxWait), Valid: true},
+		MaxAggregates: 100,
+	})
+	if err != nil {
+		return fmt.Errorf("list stalled aggregates: %w", err)
+	}
+
+	for _, row := range stalled {
+		aggregateID := uuid.UUID(row.AggregateID.Bytes)
+		reason := "in_order"
+		if row.FirstSeq > row.LastSeq+1 {
+			reason = "gap_timeout"
+			log.Printf("Gave up waiting for aggregate %s seq %d to %d after %s; applying parked events from seq %d",
+				aggregateID, row.LastSeq+1, row.FirstSeq-1, s.MaxWait, row.FirstSeq)
+		}
+		if err := s.release(ctx, aggregateID, row.FirstSeq, handlers, reason); err != nil {
+			log.Printf("Error applying parked events of aggregate %s: %v", aggregateID, err)
+		}
+	}
+
+	count, err := s.queries.CountParkedEvents(ctx)
+	if err != nil {
+		return fmt.Errorf("count parked events: %w", err)
+	}
+	metrics.ParkedEvents.Set(float64(count))
+	return nil
+}
+
+// release applies the parked events of an aggregate from seq on, stopping at
+// the next missing sequence number
+func (s *Sequencer) release(ctx context.Context, aggregateID uuid.UUID, seq int64, handlers func(topic string) (EventHandler, error), reason string) error {
+	id := pgtype.UUID{Bytes: aggregateID, Valid: true}
+	for ; ; seq++ {
+		event, err := s.queries.GetParkedEvent(ctx, store.GetParkedEventParams{AggregateID: id, Seq: seq})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("get parked event seq %d: %w", seq, err)
+		}
+
+		handler, err := handlers(event.Topic)
+		if err != nil {
+			return err
+		}
+		eventID := uuid.UUID(event.EventID.Bytes)
+		if err := handler(ctx, event.EventType, eventID, event.Payload); err != nil {
+			return fmt.Errorf("apply parked %s event %s seq %d: %w", event.EventType, eventID, seq, err)
+		}
+		if err := s.advance(ctx, aggregateID, seq); err != nil {
+			return err
+		}
+		if err := s.queries.DeleteParkedEvent(ctx, store.DeleteParkedEventParams{AggregateID: id, Seq: seq}); err != nil {
+			return fmt.Errorf("delete parked event seq %d: %w", seq, err)
+		}
+		metrics.ParkedEventsReleased.WithLabelValues(event.EventType, reason).Inc()
+		reason = "in_order"
+	}
+}
+
+// lastSeq returns the last sequence number applied for an aggregate, 0 if none
+func (s *Sequencer) lastSeq(ctx context.Context, aggregateID uuid.UUID) (int64, error) {
+	last, err := s.queries.GetAggregateSeq(ctx, pgtype.UUID{Bytes: aggregateID, Valid: true})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get aggregate sequence: %w", err)
+	}
+	return last, nil
+}
+
+func (s *Sequencer) advance(ctx context.Context, aggregateID uuid.UUID, seq int64) error {
+	err := s.queries.AdvanceAggregateSeq(ctx, store.AdvanceAggregateSeqParams{
+		AggregateID: pgtype.UUID{Bytes: aggregateID, Valid: true},
+		LastSeq:     seq,
+	})
+	if err != nil {
+		return fmt.Errorf("advance aggregate sequence: %w", err)
+	}
+	return nil
+}
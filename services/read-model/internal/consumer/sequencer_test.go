@@ -0,0 +1,254 @@
+package consumer
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// memSequenceStore keeps sequencer state in memory, mirroring the SQL queries
+type memSequenceStore struct {
+	lastSeq map[uuid.UUID]int64
+	parked  map[uuid.UUID]map[int64]store.ParkedEvent
+}
+
+func newMemSequenceStore() *memSequenceStore {
+	return &memSequenceStore{
+		lastSeq: make(map[uuid.UUID]int64),
+		parked:  make(map[uuid.UUID]map[int64]store.ParkedEvent),
+	}
+}
+
+func (m *memSequenceStore) GetAggregateSeq(_ context.Context, aggregateID pgtype.UUID) (int64, error) {
+	last, ok := m.lastSeq[aggregateID.Bytes]
+	if !ok {
+		return 0, pgx.ErrNoRows
+	}
+	return last, nil
+}
+
+func (m *memSequenceStore) AdvanceAggregateSeq(_ context.Context, arg store.AdvanceAggregateSeqParams) error {
+	m.lastSeq[arg.AggregateID.Bytes] = max(m.lastSeq[arg.AggregateID.Bytes], arg.LastSeq)
+	return nil
+}
+
+func (m *memSequenceStore) ParkEvent(_ context.Context, arg store.ParkEventParams) error {
+	events := m.parked[arg.AggregateID.Bytes]
+	if events == nil {
+		events = make(map[int64]store.ParkedEvent)
+		m.parked[arg.AggregateID.Bytes] = events
+	}
+	if _, ok := events[arg.Seq]; ok {
+		return nil
+	}
+	events[arg.Seq] = store.ParkedEvent{
+		AggregateID: arg.AggregateID,
+		Seq:         arg.Seq,
+		Topic:       arg.Topic,
+		EventType:   arg.EventType,
+		EventID:     arg.EventID,
+		Payload:     arg.Payload,
+		ParkedAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	return nil
+}
+
+func (m *memSequenceStore) GetParkedEvent(_ context.Context, arg store.GetParkedEventParams) (store.ParkedEvent, error) {
+	event, ok := m.parked[arg.AggregateID.Bytes][arg.Seq]
+	if !ok {
+		return store.ParkedEvent{}, pgx.ErrNoRows
+	}
+	return event, nil
+}
+
+func (m *memSequenceStore) DeleteParkedEvent(_ context.Context, arg store.DeleteParkedEventParams) error {
+	delete(m.parked[arg.AggregateID.Bytes], arg.Seq)
+	if len(m.parked[arg.AggregateID.Bytes]) == 0 {
+		delete(m.parked, arg.AggregateID.Bytes)
+	}
+	return nil
+}
+
+func (m *memSequenceStore) ListStalledAggregates(_ context.Context, arg store.ListStalledAggregatesParams) ([]store.ListStalledAggregatesRow, error) {
+	var rows []store.ListStalledAggregatesRow
+	firstParked := make(map[uuid.UUID]time.Time)
+	for aggregateID, events := range m.parked {
+		var first int64
+		var parkedAt time.Time
+		for seq, event := range events {
+			if first == 0 || seq < first {
+				first = seq
+			}
+			if parkedAt.IsZero() || event.ParkedAt.Time.Before(parkedAt) {
+				parkedAt = event.ParkedAt.Time
+			}
+		}
+		last := m.lastSeq[aggregateID]
+		if first <= last+1 || parkedAt.Before(arg.ParkedBefore.Time) {
+			firstParked[aggregateID] = parkedAt
+			rows = append(rows, store.ListStalledAggregatesRow{
+				AggregateID: pgtype.UUID{Bytes: aggregateID, Valid: true},
+				FirstSeq:    first,
+				LastSeq:     last,
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return firstParked[rows[i].AggregateID.Bytes].Before(firstParked[rows[j].AggregateID.Bytes])
+	})
+	if len(rows) > int(arg.MaxAggregates) {
+		rows = rows[:arg.MaxAggregates]
+	}
+	return rows, nil
+}
+
+func (m *memSequenceStore) CountParkedEvents(context.Context) (int64, error) {
+	var count int64
+	for _, events := range m.parked {
+		count += int64(len(events))
+	}
+	return count, nil
+}
+
+// age moves the parked time of every parked event d into the past
+func (m *memSequenceStore) age(d time.Duration) {
+	for _, events := range m.parked {
+		for seq, event := range events {
+			event.ParkedAt.Time = event.ParkedAt.Time.Add(-d)
+			events[seq] = event
+		}
+	}
+}
+
+// sequencerHarness delivers events of one aggregate through a sequencer and
+// records the payloads its handler applied, in order
+type sequencerHarness struct {
+	store     *memSequenceStore
+	sequencer *Sequencer
+	aggregate uuid.UUID
+	applied   []string
+}
+
+func newSequencerHarness() *sequencerHarness {
+	mem := newMemSequenceStore()
+	return &sequencerHarness{
+		store:     mem,
+		sequencer: &Sequencer{queries: mem, MaxWait: time.Minute, SweepInterval: time.Second},
+		aggregate: uuid.New(),
+	}
+}
+
+func (h *sequencerHarness) handler(_ context.Context, _ string, _ uuid.UUID, payload []byte) error {
+	h.applied = append(h.applied, string(payload))
+	return nil
+}
+
+func (h *sequencerHarness) deliver(t *testing.T, seq int64, payload string) {
+	t.Helper()
+	envelope := &ledgerv1.EventEnvelope{AggregateId: h.aggregate.String(), AggregateSeq: seq}
+	wrapped := h.sequencer.Wrap("ledger.entry.v1", envelope, h.handler)
+	if err := wrapped(context.Background(), "EntryPosted", uuid.New(), []byte(payload)); err != nil {
+		t.Fatalf("deliver seq %d: %v", seq, err)
+	}
+}
+
+func (h *sequencerHarness) sweep(t *testing.T) {
+	t.Helper()
+	handlers := func(string) (EventHandler, error) { return h.handler, nil }
+	if err := h.sequencer.sweep(context.Background(), handlers); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+}
+
+func (h *sequencerHarness) expect(t *testing.T, applied []string, lastSeq int64, parked int64) {
+	t.Helper()
+	if !reflect.DeepEqual(h.applied, applied) {
+		t.Errorf("applied = %v, want %v", h.applied, applied)
+	}
+	if got := h.store.lastSeq[h.aggregate]; got != lastSeq {
+		t.Errorf("last seq = %d, want %d", got, lastSeq)
+	}
+	if got, _ := h.store.CountParkedEvents(context.Background()); got != parked {
+		t.Errorf("parked events = %d, want %d", got, parked)
+	}
+}
+
+func TestSequencer_AppliesInOrderEvents(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 1, "e1")
+	h.deliver(t, 2, "e2")
+	h.expect(t, []string{"e1", "e2"}, 2, 0)
+}
+
+func TestSequencer_ParksOutOfOrderEvents(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 1, "e1")
+	h.deliver(t, 3, "e3")
+	h.deliver(t, 4, "e4")
+	h.expect(t, []string{"e1"}, 1, 2)
+
+	// The sweep leaves a gap alone until MaxWait passes
+	h.sweep(t)
+	h.expect(t, []string{"e1"}, 1, 2)
+}
+
+func TestSequencer_ReleasesParkedEventsWhenGapFills(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 3, "e3")
+	h.deliver(t, 2, "e2")
+	h.deliver(t, 5, "e5")
+	h.expect(t, nil, 0, 3)
+
+	h.deliver(t, 1, "e1")
+	h.expect(t, []string{"e1", "e2", "e3"}, 3, 1)
+
+	h.deliver(t, 4, "e4")
+	h.expect(t, []string{"e1", "e2", "e3", "e4", "e5"}, 5, 0)
+}
+
+func TestSequencer_GivesUpOnGapAfterMaxWait(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 1, "e1")
+	h.deliver(t, 3, "e3")
+	h.deliver(t, 4, "e4")
+	h.deliver(t, 6, "e6")
+
+	h.store.age(2 * h.sequencer.MaxWait)
+	h.sweep(t)
+	h.expect(t, []string{"e1", "e3", "e4"}, 4, 1)
+
+	// The next gap is waited out too
+	h.sweep(t)
+	h.expect(t, []string{"e1", "e3", "e4", "e6"}, 6, 0)
+}
+
+func TestSequencer_AppliesLateArrivalAfterGivingUp(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 2, "e2")
+	h.deliver(t, 3, "e3")
+	h.store.age(2 * h.sequencer.MaxWait)
+	h.sweep(t)
+	h.expect(t, []string{"e2", "e3"}, 3, 0)
+
+	// The missing event is applied late without moving the sequence back
+	h.deliver(t, 1, "e1")
+	h.expect(t, []string{"e2", "e3", "e1"}, 3, 0)
+
+	// A redelivery is handed to the projector, which skips events it applied
+	h.deliver(t, 3, "e3")
+	h.expect(t, []string{"e2", "e3", "e1", "e3"}, 3, 0)
+}
+
+func TestSequencer_PassesUnsequencedEventsThrough(t *testing.T) {
+	h := newSequencerHarness()
+	h.deliver(t, 0, "legacy")
+	h.expect(t, []string{"legacy"}, 0, 0)
+}
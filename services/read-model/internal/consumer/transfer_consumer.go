@@ -4,13 +4,13 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
 	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
-	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/projection"
 	"github.com/google/uuid"
 	"github.com/segmentio/kafka-go"
@@ -26,12 +26,13 @@ import (
 
 // TransferConsumer reads transfer events from Kafka and applies them to projections
 type TransferConsumer struct {
-	reader    *kafka.Reader
-	projector *projection.Projector
+	reader      *kafka.Reader
+	projector   *projection.Projector
+	deadLetters *DeadLetterQueue
 }
 
 // NewTransferConsumer creates a Kafka consumer for the ledger.transfer.v1 topic
-func NewTransferConsumer(brokers []string, projector *projection.Projector) *TransferConsumer {
+func NewTransferConsumer(brokers []string, projector *projection.Projector, deadLetters *DeadLetterQueue) *TransferConsumer {
 	config := kafka.ReaderConfig{
 		Brokers:        brokers,
 		Topic:          "ledger.transfer.v1",
@@ -78,10 +79,13 @@ func NewTransferConsumer(brokers []string, projector *projection.Projector) *Tra
 
 	reader := kafka.NewReader(config)
 
-	return &TransferConsumer{
-		reader:    reader,
-		projector: projector,
+	c := &TransferConsumer{
+		reader:      reader,
+		projector:   projector,
+		deadLetters: deadLetters,
 	}
+	deadLetters.Register(config.Topic, c.Handle)
+	return c
 }
 
 // Start begins consuming transfer messages and blocks until context is canceled
@@ -113,13 +117,14 @@ func (c *TransferConsumer) Start(ctx context.Context) error {
 		}
 		msgCtx := otel.GetTextMapPropagator().Extract(ctx, carrier)
 
-		// Extract event type from headers
-		eventType, err := extractEventType(msg.Headers)
+		// Decode the event envelope, skipping events this build cannot read
+		envelope, eventID, err := decodeEvent(msg, "")
 		if err != nil {
-			log.Printf("Error extracting event_type: %v, skipping message", err)
+			log.Printf("Skipping message at partition %d offset %d: %v", msg.Partition, msg.Offset, err)
 			c.reader.CommitMessages(ctx, msg)
 			continue
 		}
+		eventType := envelope.EventType
 
 		// Create span for message processing
 		tracer := otel.Tracer("kafka-transfer-consumer")
@@ -132,49 +137,21 @@ func (c *TransferConsumer) Start(ctx context.Context) error {
 			),
 		)
 		defer span.End()
-
-		// Extract event_id from headers
-		eventID, err := extractEventID(msg.Headers)
-		if err != nil {
-			span.RecordError(err)
-			log.Printf("Error extracting event_id: %v, skipping message", err)
-			c.reader.CommitMessages(ctx, msg)
-			continue
-		}
 		span.SetAttributes(attribute.String("event_id", eventID.String()))
 
-		// Process the event based on type
-		start := time.Now()
-		var processErr error
-
-		switch eventType {
-		case "TransferInitiated":
-			processErr = c.processTransferInitiated(msgCtx, eventID, msg.Value)
-		case "TransferCompleted":
-			processErr = c.processTransferCompleted(msgCtx, eventID, msg.Value)
-		case "TransferFailed":
-			processErr = c.processTransferFailed(msgCtx, eventID, msg.Value)
-		default:
-			log.Printf("Unknown event type: %s, skipping", eventType)
-			c.reader.CommitMessages(ctx, msg)
-			continue
-		}
-
-		duration := time.Since(start).Seconds()
-
-		if processErr != nil {
-			span.RecordError(processErr)
-			metrics.EventProcessingErrors.WithLabelValues(eventType, "processing_error").Inc()
-			metrics.EventProcessingDuration.WithLabelValues(eventType, "error").Observe(duration)
-			log.Printf("Error processing event %s (%s): %v", eventID, eventType, processErr)
-			time.Sleep(time.Second)
+		// Process the event, dead-lettering it once its retries are exhausted
+		if err := c.deadLetters.Process(msgCtx, msg, envelope, eventID, c.Handle); err != nil {
+			if errors.Is(err, ErrUnknownEventType) {
+				log.Printf("Unknown event type: %s, skipping", eventType)
+				c.reader.CommitMessages(ctx, msg)
+				continue
+			}
+			// Canceled before the event was applied or dead-lettered; leave it uncommitted
+			span.RecordError(err)
+			log.Printf("Stopped processing event %s (%s): %v", eventID, eventType, err)
 			continue
 		}
 
-		// Record successful processing
-		metrics.EventsProcessed.WithLabelValues(eventType).Inc()
-		metrics.EventProcessingDuration.WithLabelValues(eventType, "success").Observe(duration)
-
 		// Commit the message
 		if err := c.reader.CommitMessages(ctx, msg); err != nil {
 			span.RecordError(err)
@@ -183,6 +160,22 @@ func (c *TransferConsumer) Start(ctx context.Context) error {
 	}
 }
 
+// Handle applies one ledger.transfer.v1 event to the projections
+func (c *TransferConsumer) Handle(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error {
+	switch eventType {
+	case "TransferInitiated":
+		return c.processTransferInitiated(ctx, eventID, payload)
+	case "TransferCompleted":
+		return c.processTransferCompleted(ctx, eventID, payload)
+	case "TransferFailed":
+		return c.processTransferFailed(ctx, eventID, payload)
+	case "TransferRefunded":
+		return c.processTransferRefunded(ctx, eventID, payload)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+}
+
 func (c *TransferConsumer) processTransferInitiated(ctx context.Context, eventID uuid.UUID, payload []byte) error {
 	var event ledgerv1.TransferInitiated
 	if err := proto.Unmarshal(payload, &event); err != nil {
@@ -210,10 +203,19 @@ func (c *TransferConsumer) processTransferFailed(ctx context.Context, eventID uu
 	return c.projector.ProcessTransferFailed(ctx, eventID, &event)
 }
 
+func (c *TransferConsumer) processTransferRefunded(ctx context.Context, eventID uuid.UUID, payload []byte) error {
+	var event ledgerv1.TransferRefunded
+	if err := proto.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("unmarshal TransferRefunded: %w", err)
+	}
+
+	return c.projector.ProcessTransferRefunded(ctx, eventID, &event)
+}
+
 // extractEventType extracts the event_type from Kafka message headers
 func extractEventType(headers []kafka.Header) (string, error) {
 	for _, h := range headers {
-		if h.Key == "event_type" {
+		if h.Key == "event_type" || h.Key == "event_name" {
 			return string(h.Value), nil
 		}
 		// Also check in headers JSON for backward compatibility
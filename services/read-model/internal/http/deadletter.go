@@ -0,0 +1,265 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/read-model/internal/consumer"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DeadLetterHandler provides admin endpoints to list, inspect and re-drive dead-lettered events
+type DeadLetterHandler struct {
+	queries     *store.Queries
+	deadLetters *consumer.DeadLetterQueue
+}
+
+// NewDeadLetterHandler creates a new dead-letter admin handler
+func NewDeadLetterHandler(db *pgxpool.Pool, deadLetters *consumer.DeadLetterQueue) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		queries:     store.New(db),
+		deadLetters: deadLetters,
+	}
+}
+
+// DeadLetterPartitionResponse represents the retained range of one dead-letter topic partition
+type DeadLetterPartitionResponse struct {
+	Topic           string `json:"topic"`
+	DeadLetterTopic string `json:"dead_letter_topic"`
+	Partition       int    `json:"partition"`
+	FirstOffset     int64  `json:"first_offset"`
+	NextOffset      int64  `json:"next_offset"`
+	Messages        int64  `json:"messages"`
+}
+
+// DeadLetterPartitionsResponse represents the dead-letter topics of every consumed topic
+type DeadLetterPartitionsResponse struct {
+	Partitions []DeadLetterPartitionResponse `json:"partitions"`
+}
+
+// DeadLetterResponse represents one dead-lettered message
+type DeadLetterResponse struct {
+	Partition       int               `json:"partition"`
+	Offset          int64             `json:"offset"`
+	EventType       string            `json:"event_type"`
+	EventID         string            `json:"event_id"`
+	Error           string            `json:"error"`
+	Attempts        int               `json:"attempts"`
+	FailedAt        string            `json:"failed_at,omitempty"`
+	SourceTopic     string            `json:"source_topic"`
+	SourcePartition int               `json:"source_partition"`
+	SourceOffset    int64             `json:"source_offset"`
+	Processed       bool              `json:"processed"` // the event has since been applied, e.g. by a re-drive
+	Key             string            `json:"key,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"` // only when inspecting a single message
+	Payload         []byte            `json:"payload,omitempty"` // only when inspecting a single message; base64
+}
+
+// DeadLettersResponse represents a page of dead-lettered messages of one partition
+type DeadLettersResponse struct {
+	Messages   []DeadLetterResponse `json:"messages"`
+	NextOffset *int64               `json:"next_offset,omitempty"` // offset of the next page; absent at the end
+}
+
+// RedriveResponse represents the outcome of re-driving a dead-lettered message
+type RedriveResponse struct {
+	Message DeadLetterResponse `json:"message"`
+	Status  string             `json:"status"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// ListPartitions handles GET /v1/admin/dlq
+func (h *DeadLetterHandler) ListPartitions(w http.ResponseWriter, r *http.Request) {
+	partitions, err := h.deadLetters.Partitions(r.Context())
+	if err != nil {
+		log.Printf("Error reading dead-letter partitions: %v", err)
+		http.Error(w, `{"error":"failed to read dead-letter topics"}`, http.StatusBadGateway)
+		return
+	}
+
+	resp := DeadLetterPartitionsResponse{Partitions: make([]DeadLetterPartitionResponse, len(partitions))}
+	for i, p := range partitions {
+		resp.Partitions[i] = DeadLetterPartitionResponse{
+			Topic:           p.Topic,
+			DeadLetterTopic: p.Topic + consumer.DeadLetterSuffix,
+			Partition:       p.Partition,
+			FirstOffset:     p.FirstOffset,
+			NextOffset:      p.NextOffset,
+			Messages:        p.NextOffset - p.FirstOffset,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ListMessages handles GET /v1/admin/dlq/:topic/messages?partition=&offset=&limit=
+func (h *DeadLetterHandler) ListMessages(w http.ResponseWriter, r *http.Request) {
+	topic := chi.URLParam(r, "topic")
+
+	partition := 0
+	if partitionStr := r.URL.Query().Get("partition"); partitionStr != "" {
+		p, err := strconv.Atoi(partitionStr)
+		if err != nil || p < 0 {
+			http.Error(w, `{"error":"invalid partition"}`, http.StatusBadRequest)
+			return
+		}
+		partition = p
+	}
+
+	var offset int64
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		o, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil || o < 0 {
+			http.Error(w, `{"error":"invalid offset"}`, http.StatusBadRequest)
+			return
+		}
+		offset = o
+	}
+
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > 500 {
+			http.Error(w, `{"error":"invalid limit (1-500)"}`, http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	letters, next, err := h.deadLetters.List(r.Context(), topic, partition, offset, limit)
+	if err != nil {
+		h.respondDeadLetterError(w, err)
+		return
+	}
+
+	resp := DeadLettersResponse{Messages: make([]DeadLetterResponse, len(letters))}
+	for i, letter := range letters {
+		resp.Messages[i] = h.toDeadLetterResponse(r, letter, false)
+	}
+	if next >= 0 {
+		resp.NextOffset = &next
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetMessage handles GET /v1/admin/dlq/:topic/messages/:partition/:offset
+func (h *DeadLetterHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
+	topic, partition, offset, ok := parseDeadLetterPath(w, r)
+	if !ok {
+		return
+	}
+
+	letter, err := h.deadLetters.Get(r.Context(), topic, partition, offset)
+	if err != nil {
+		h.respondDeadLetterError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.toDeadLetterResponse(r, letter, true))
+}
+
+// RedriveMessage handles POST /v1/admin/dlq/:topic/messages/:partition/:offset/redrive
+func (h *DeadLetterHandler) RedriveMessage(w http.ResponseWriter, r *http.Request) {
+	topic, partition, offset, ok := parseDeadLetterPath(w, r)
+	if !ok {
+		return
+	}
+
+	letter, err := h.deadLetters.Redrive(r.Context(), topic, partition, offset)
+	if err != nil {
+		if !errors.Is(err, consumer.ErrRedriveFailed) {
+			h.respondDeadLetterError(w, err)
+			return
+		}
+		log.Printf("Re-drive of %s partition %d offset %d failed: %v", topic+consumer.DeadLetterSuffix, partition, offset, err)
+		writeJSON(w, http.StatusUnprocessableEntity, RedriveResponse{
+			Message: h.toDeadLetterResponse(r, letter, false),
+			Status:  "failed",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RedriveResponse{
+		Message: h.toDeadLetterResponse(r, letter, false),
+		Status:  "applied",
+	})
+}
+
+// parseDeadLetterPath reads the topic, partition and offset path parameters,
+// responding with 400 when they are invalid
+func parseDeadLetterPath(w http.ResponseWriter, r *http.Request) (string, int, int64, bool) {
+	partition, err := strconv.Atoi(chi.URLParam(r, "partition"))
+	if err != nil || partition < 0 {
+		http.Error(w, `{"error":"invalid partition"}`, http.StatusBadRequest)
+		return "", 0, 0, false
+	}
+	offset, err := strconv.ParseInt(chi.URLParam(r, "offset"), 10, 64)
+	if err != nil || offset < 0 {
+		http.Error(w, `{"error":"invalid offset"}`, http.StatusBadRequest)
+		return "", 0, 0, false
+	}
+	return chi.URLParam(r, "topic"), partition, offset, true
+}
+
+func (h *DeadLetterHandler) respondDeadLetterError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, consumer.ErrUnknownTopic):
+		http.Error(w, `{"error":"unknown topic"}`, http.StatusNotFound)
+	case errors.Is(err, consumer.ErrDeadLetterNotFound):
+		http.Error(w, `{"error":"dead letter not found"}`, http.StatusNotFound)
+	default:
+		log.Printf("Error reading dead letters: %v", err)
+		http.Error(w, `{"error":"failed to read dead-letter topic"}`, http.StatusBadGateway)
+	}
+}
+
+func (h *DeadLetterHandler) toDeadLetterResponse(r *http.Request, letter consumer.DeadLetter, detail bool) DeadLetterResponse {
+	resp := DeadLetterResponse{
+		Partition:       letter.Partition,
+		Offset:          letter.Offset,
+		EventType:       letter.EventType,
+		EventID:         letter.EventID,
+		Error:           letter.Error,
+		Attempts:        letter.Attempts,
+		SourceTopic:     letter.SourceTopic,
+		SourcePartition: letter.SourcePartition,
+		SourceOffset:    letter.SourceOffset,
+		Key:             string(letter.Key),
+	}
+	if !letter.FailedAt.IsZero() {
+		resp.FailedAt = letter.FailedAt.Format(time.RFC3339)
+	}
+
+	if eventID, err := uuid.Parse(letter.EventID); err == nil {
+		processed, err := h.queries.IsEventProcessed(r.Context(), pgtype.UUID{Bytes: eventID, Valid: true})
+		if err != nil {
+			log.Printf("Error checking whether event %s was processed: %v", eventID, err)
+		}
+		resp.Processed = processed
+	}
+
+	if detail {
+		resp.Headers = make(map[string]string, len(letter.Headers))
+		for _, h := range letter.Headers {
+			resp.Headers[h.Key] = string(h.Value)
+		}
+		resp.Payload = letter.Payload
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
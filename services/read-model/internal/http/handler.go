@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/amirhf/credit-ledger/services/common/pagination"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
 	"github.com/go-chi/chi/v5"
@@ -16,7 +18,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// Handler provides HTTP endpoints for querying balances and statements
+// Handler provides HTTP endpoints for querying balances, statements and credit lots
 type Handler struct {
 	db      *pgxpool.Pool
 	queries *store.Queries
@@ -32,46 +34,77 @@ func NewHandler(db *pgxpool.Pool) *Handler {
 
 // BalanceResponse represents the account balance response
 type BalanceResponse struct {
+	AccountID      string `json:"account_id"`
+	Currency       string `json:"currency"`
+	BalanceMinor   int64  `json:"balance_minor"`
+	HeldMinor      int64  `json:"held_minor"`      // reserved by pending holds
+	AvailableMinor int64  `json:"available_minor"` // balance minus pending holds
+	UpdatedAt      string `json:"updated_at"`
+}
+
+// BalanceAsOfResponse represents the account balance at a point in time
+type BalanceAsOfResponse struct {
 	AccountID    string `json:"account_id"`
-	Currency     string `json:"currency"`
+	Currency     string `json:"currency,omitempty"` // omitted when the account has no balance record
 	BalanceMinor int64  `json:"balance_minor"`
-	UpdatedAt    string `json:"updated_at"`
+	AsOf         string `json:"as_of"`
 }
 
 // StatementEntry represents a single statement line
 type StatementEntry struct {
-	ID          int64  `json:"id"`
-	AccountID   string `json:"account_id"`
-	EntryID     string `json:"entry_id"`
-	AmountMinor int64  `json:"amount_minor"`
-	Side        string `json:"side"`
-	Timestamp   string `json:"timestamp"`
+	ID                int64  `json:"id"`
+	AccountID         string `json:"account_id"`
+	EntryID           string `json:"entry_id"`
+	AmountMinor       int64  `json:"amount_minor"`
+	Side              string `json:"side"`
+	Timestamp         string `json:"timestamp"`           // when the entry was booked
+	EffectiveAt       string `json:"effective_at"`        // value date the line is ordered and balanced by
+	VoidedBy          string `json:"voided_by,omitempty"` // void entry that reversed this line
+	BalanceAfterMinor int64  `json:"balance_after_minor"` // account balance after this line
 }
 
 // StatementsResponse represents the list of statement entries
 type StatementsResponse struct {
 	Statements []StatementEntry `json:"statements"`
+	// Balances at the start and end of the from/to window; only set for windowed queries
+	OpeningBalanceMinor *int64 `json:"opening_balance_minor,omitempty"`
+	ClosingBalanceMinor *int64 `json:"closing_balance_minor,omitempty"`
+	// Opaque cursors for the adjacent pages; empty when there is none
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 // TransferResponse represents a single transfer
 type TransferResponse struct {
-	ID             string `json:"id"`
-	FromAccountID  string `json:"from_account_id"`
-	ToAccountID    string `json:"to_account_id"`
-	AmountMinor    int64  `json:"amount_minor"`
-	Currency       string `json:"currency"`
-	Status         string `json:"status"`
-	IdempotencyKey string `json:"idempotency_key"`
-	CreatedAt      string `json:"created_at"`
-	UpdatedAt      string `json:"updated_at"`
+	ID             string                `json:"id"`
+	FromAccountID  string                `json:"from_account_id"`
+	ToAccountID    string                `json:"to_account_id"`
+	AmountMinor    int64                 `json:"amount_minor"`
+	Currency       string                `json:"currency"`
+	Status         string                `json:"status"`
+	IdempotencyKey string                `json:"idempotency_key"`
+	CreatedAt      string                `json:"created_at"`
+	UpdatedAt      string                `json:"updated_at"`
+	Legs           []TransferLegResponse `json:"legs,omitempty"`      // every debit and credit of the transfer
+	RefundOf       string                `json:"refund_of,omitempty"` // original transfer when this transfer is a refund
+	RefundedMinor  int64                 `json:"refunded_minor"`
+}
+
+// TransferLegResponse represents one debit or credit of a transfer
+type TransferLegResponse struct {
+	AccountID   string `json:"account_id"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	Side        string `json:"side"`
 }
 
 // TransfersListResponse represents the list of transfers
 type TransfersListResponse struct {
-	Transfers []TransferResponse `json:"transfers"`
-	Total     int64              `json:"total"`
-	Limit     int32              `json:"limit"`
-	Offset    int32              `json:"offset"`
+	Transfers  []TransferResponse `json:"transfers"`
+	Total      int64              `json:"total"`
+	Limit      int32              `json:"limit"`
+	NextCursor string             `json:"next_cursor,omitempty"` // opaque cursor for the next (older) page
+	PrevCursor string             `json:"prev_cursor,omitempty"` // opaque cursor for the previous (newer) page
 }
 
 // GetBalance handles GET /v1/accounts/:id/balance
@@ -91,6 +124,27 @@ func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A point-in-time balance is read from the statement lines' running balance
+	if asOfStr := r.URL.Query().Get("as_of"); asOfStr != "" {
+		asOf, err := time.Parse(time.RFC3339, asOfStr)
+		if err != nil {
+			metrics.BalanceQueriesTotal.WithLabelValues("invalid_input").Inc()
+			http.Error(w, `{"error":"invalid as_of timestamp (use RFC3339)"}`, http.StatusBadRequest)
+			return
+		}
+		h.getBalanceAsOf(w, r, accountID, pgAccountID, asOf, start)
+		return
+	}
+
+	heldMinor, err := h.queries.SumPendingHolds(r.Context(), pgAccountID)
+	if err != nil {
+		log.Printf("Error summing pending holds: %v", err)
+		metrics.BalanceQueriesTotal.WithLabelValues("error").Inc()
+		metrics.QueryDuration.WithLabelValues("balance", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
 	balance, err := h.queries.GetBalance(r.Context(), pgAccountID)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -104,10 +158,12 @@ func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 			metrics.QueryDuration.WithLabelValues("balance", "zero_balance").Observe(time.Since(start).Seconds())
 			
 			resp := BalanceResponse{
-				AccountID:    accountID.String(),
-				Currency:     currency,
-				BalanceMinor: 0,
-				UpdatedAt:    time.Now().Format(time.RFC3339),
+				AccountID:      accountID.String(),
+				Currency:       currency,
+				BalanceMinor:   0,
+				HeldMinor:      heldMinor,
+				AvailableMinor: -heldMinor,
+				UpdatedAt:      time.Now().Format(time.RFC3339),
 			}
 			
 			w.Header().Set("Content-Type", "application/json")
@@ -126,10 +182,12 @@ func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	copy(accountUUID[:], balance.AccountID.Bytes[:])
 
 	resp := BalanceResponse{
-		AccountID:    accountUUID.String(),
-		Currency:     balance.Currency,
-		BalanceMinor: balance.BalanceMinor,
-		UpdatedAt:    balance.UpdatedAt.Time.Format(time.RFC3339),
+		AccountID:      accountUUID.String(),
+		Currency:       balance.Currency,
+		BalanceMinor:   balance.BalanceMinor,
+		HeldMinor:      heldMinor,
+		AvailableMinor: balance.BalanceMinor - heldMinor,
+		UpdatedAt:      balance.UpdatedAt.Time.Format(time.RFC3339),
 	}
 
 	metrics.BalanceQueriesTotal.WithLabelValues("success").Inc()
@@ -140,7 +198,58 @@ func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// getBalanceAsOf responds with the account balance after the last statement line effective at or before asOf
+func (h *Handler) getBalanceAsOf(w http.ResponseWriter, r *http.Request, accountID uuid.UUID, pgAccountID pgtype.UUID, asOf time.Time, start time.Time) {
+	var pgAsOf pgtype.Timestamptz
+	if err := pgAsOf.Scan(asOf); err != nil {
+		http.Error(w, `{"error":"invalid as_of timestamp"}`, http.StatusBadRequest)
+		return
+	}
+
+	balanceMinor, err := h.queries.GetBalanceAsOf(r.Context(), store.GetBalanceAsOfParams{
+		AccountID:   pgAccountID,
+		EffectiveAt: pgAsOf,
+	})
+	if err != nil && err != pgx.ErrNoRows {
+		log.Printf("Error getting balance as of %s: %v", asOf.Format(time.RFC3339), err)
+		metrics.BalanceQueriesTotal.WithLabelValues("error").Inc()
+		metrics.QueryDuration.WithLabelValues("balance_as_of", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	// No lines at or before as_of means the account had a zero balance then
+
+	// Currency comes from the current balance record; an account without one
+	// only reports the currency the caller asked for
+	currency := r.URL.Query().Get("currency")
+	balance, err := h.queries.GetBalance(r.Context(), pgAccountID)
+	if err == nil {
+		currency = balance.Currency
+	} else if err != pgx.ErrNoRows {
+		log.Printf("Error getting balance: %v", err)
+		metrics.BalanceQueriesTotal.WithLabelValues("error").Inc()
+		metrics.QueryDuration.WithLabelValues("balance_as_of", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	resp := BalanceAsOfResponse{
+		AccountID:    accountID.String(),
+		Currency:     currency,
+		BalanceMinor: balanceMinor,
+		AsOf:         asOf.Format(time.RFC3339),
+	}
+
+	metrics.BalanceQueriesTotal.WithLabelValues("success").Inc()
+	metrics.QueryDuration.WithLabelValues("balance_as_of", "success").Observe(time.Since(start).Seconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
 // GetStatements handles GET /v1/accounts/:id/statements
+// Lines are ordered and filtered by effective date, newest first
 func (h *Handler) GetStatements(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	accountIDStr := chi.URLParam(r, "id")
@@ -156,6 +265,7 @@ func (h *Handler) GetStatements(w http.ResponseWriter, r *http.Request) {
 	toStr := r.URL.Query().Get("to")
 
 	var statements []store.Statement
+	var windowOpening, windowClosing *int64
 	var queryErr error
 
 	var pgAccountID pgtype.UUID
@@ -164,48 +274,87 @@ func (h *Handler) GetStatements(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if fromStr != "" && toStr != "" {
-		// Time-bounded query
-		from, err := time.Parse(time.RFC3339, fromStr)
+	var from, to time.Time
+	var pgFrom, pgTo pgtype.Timestamptz
+	if fromStr != "" {
+		from, err = time.Parse(time.RFC3339, fromStr)
 		if err != nil {
 			http.Error(w, `{"error":"invalid from timestamp (use RFC3339)"}`, http.StatusBadRequest)
 			return
 		}
-		to, err := time.Parse(time.RFC3339, toStr)
-		if err != nil {
-			http.Error(w, `{"error":"invalid to timestamp (use RFC3339)"}`, http.StatusBadRequest)
-			return
-		}
-
-		var pgFrom, pgTo pgtype.Timestamptz
 		if err := pgFrom.Scan(from); err != nil {
 			http.Error(w, `{"error":"invalid from timestamp"}`, http.StatusBadRequest)
 			return
 		}
+	}
+	if toStr != "" {
+		to, err = time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			http.Error(w, `{"error":"invalid to timestamp (use RFC3339)"}`, http.StatusBadRequest)
+			return
+		}
 		if err := pgTo.Scan(to); err != nil {
 			http.Error(w, `{"error":"invalid to timestamp"}`, http.StatusBadRequest)
 			return
 		}
+	}
+
+	// Parse pagination: pages are ordered newest first and continue from an opaque cursor
+	limit := pagination.ParseLimit(r, 100, 1000)
+	var cursor *pagination.Cursor
+	var cursorTs pgtype.Timestamptz
+	var cursorID int64
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := pagination.DecodeCursor(token)
+		if err == nil {
+			cursorID, err = strconv.ParseInt(c.ID, 10, 64)
+		}
+		if err == nil {
+			err = cursorTs.Scan(c.Ts)
+		}
+		if err != nil {
+			metrics.StatementQueriesTotal.WithLabelValues("invalid_input").Inc()
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
+		}
+		cursor = &c
+	}
 
-		statements, queryErr = h.queries.GetStatements(r.Context(), store.GetStatementsParams{
+	// One extra row tells whether another page follows
+	if cursor != nil && cursor.Prev {
+		statements, queryErr = h.queries.ListStatementsPrev(r.Context(), store.ListStatementsPrevParams{
 			AccountID: pgAccountID,
-			Ts:        pgFrom,
-			Ts_2:      pgTo,
+			FromTs:    pgFrom,
+			ToTs:      pgTo,
+			BeforeTs:  cursorTs,
+			BeforeID:  cursorID,
+			Limit:     limit + 1,
 		})
 	} else {
-		// Default: get last 100 statements
-		limit := int32(100)
-		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-			var parsedLimit int
-			if _, err := fmt.Sscanf(limitStr, "%d", &parsedLimit); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
-				limit = int32(parsedLimit)
-			}
+		params := store.ListStatementsParams{
+			AccountID: pgAccountID,
+			FromTs:    pgFrom,
+			ToTs:      pgTo,
+			Limit:     limit + 1,
+		}
+		if cursor != nil {
+			params.AfterTs = cursorTs
+			params.AfterID = pgtype.Int8{Int64: cursorID, Valid: true}
 		}
+		statements, queryErr = h.queries.ListStatements(r.Context(), params)
+	}
+	statements, nextCursor, prevCursor := pagination.KeysetPage(statements, limit, cursor, func(stmt store.Statement) pagination.Cursor {
+		return pagination.Cursor{Ts: stmt.EffectiveAt.Time, ID: strconv.FormatInt(stmt.ID, 10)}
+	})
 
-		statements, queryErr = h.queries.GetStatementsByAccount(r.Context(), store.GetStatementsByAccountParams{
-			AccountID: pgAccountID,
-			Limit:     limit,
-		})
+	// Opening balance is the running balance just before the window, closing at its end
+	if queryErr == nil && fromStr != "" && toStr != "" {
+		opening, closing, err := h.windowBalances(r, pgAccountID, from, to)
+		if err != nil {
+			queryErr = err
+		} else {
+			windowOpening, windowClosing = &opening, &closing
+		}
 	}
 
 	if queryErr != nil {
@@ -224,17 +373,28 @@ func (h *Handler) GetStatements(w http.ResponseWriter, r *http.Request) {
 		copy(stmtEntryID[:], stmt.EntryID.Bytes[:])
 
 		entries[i] = StatementEntry{
-			ID:          stmt.ID,
-			AccountID:   stmtAccountID.String(),
-			EntryID:     stmtEntryID.String(),
-			AmountMinor: stmt.AmountMinor,
-			Side:        stmt.Side,
-			Timestamp:   stmt.Ts.Time.Format(time.RFC3339),
+			ID:                stmt.ID,
+			AccountID:         stmtAccountID.String(),
+			EntryID:           stmtEntryID.String(),
+			AmountMinor:       stmt.AmountMinor,
+			Side:              stmt.Side,
+			Timestamp:         stmt.Ts.Time.Format(time.RFC3339),
+			EffectiveAt:       stmt.EffectiveAt.Time.Format(time.RFC3339),
+			BalanceAfterMinor: stmt.BalanceAfterMinor,
+		}
+		if stmt.VoidedBy.Valid {
+			var voidedBy uuid.UUID
+			copy(voidedBy[:], stmt.VoidedBy.Bytes[:])
+			entries[i].VoidedBy = voidedBy.String()
 		}
 	}
 
 	resp := StatementsResponse{
-		Statements: entries,
+		Statements:          entries,
+		OpeningBalanceMinor: windowOpening,
+		ClosingBalanceMinor: windowClosing,
+		NextCursor:          nextCursor,
+		PrevCursor:          prevCursor,
 	}
 
 	metrics.StatementQueriesTotal.WithLabelValues("success").Inc()
@@ -245,6 +405,36 @@ func (h *Handler) GetStatements(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// windowBalances returns the account's running balance before from and at to.
+// An account with no lines before a bound had a zero balance at that time.
+func (h *Handler) windowBalances(r *http.Request, pgAccountID pgtype.UUID, from, to time.Time) (int64, int64, error) {
+	balanceAt := func(t time.Time) (int64, error) {
+		var pgTs pgtype.Timestamptz
+		if err := pgTs.Scan(t); err != nil {
+			return 0, err
+		}
+		balance, err := h.queries.GetBalanceAsOf(r.Context(), store.GetBalanceAsOfParams{
+			AccountID:   pgAccountID,
+			EffectiveAt: pgTs,
+		})
+		if err == pgx.ErrNoRows {
+			return 0, nil
+		}
+		return balance, err
+	}
+
+	// Lines at exactly from belong to the window, so the opening balance is taken just before it
+	opening, err := balanceAt(from.Add(-time.Microsecond))
+	if err != nil {
+		return 0, 0, fmt.Errorf("opening balance: %w", err)
+	}
+	closing, err := balanceAt(to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("closing balance: %w", err)
+	}
+	return opening, closing, nil
+}
+
 // ListTransfers handles GET /v1/transfers
 func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
@@ -255,22 +445,24 @@ func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 	status := r.URL.Query().Get("status")
 	currency := r.URL.Query().Get("currency")
 	
-	// Parse pagination
-	limit := int32(20)
-	offset := int32(0)
-	
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		var parsedLimit int
-		if _, err := fmt.Sscanf(limitStr, "%d", &parsedLimit); err == nil && parsedLimit > 0 && parsedLimit <= 100 {
-			limit = int32(parsedLimit)
+	// Parse pagination: pages are ordered newest first and continue from an opaque cursor
+	limit := pagination.ParseLimit(r, 20, 100)
+	var cursor *pagination.Cursor
+	var cursorCreatedAt pgtype.Timestamptz
+	var cursorID pgtype.UUID
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		c, err := pagination.DecodeCursor(token)
+		if err == nil {
+			err = cursorID.Scan(c.ID)
 		}
-	}
-	
-	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
-		var parsedOffset int
-		if _, err := fmt.Sscanf(offsetStr, "%d", &parsedOffset); err == nil && parsedOffset >= 0 {
-			offset = int32(parsedOffset)
+		if err == nil {
+			err = cursorCreatedAt.Scan(c.Ts)
+		}
+		if err != nil {
+			http.Error(w, `{"error":"invalid cursor"}`, http.StatusBadRequest)
+			return
 		}
+		cursor = &c
 	}
 	
 	// Parse UUIDs
@@ -309,15 +501,33 @@ func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 		currencyText = pgtype.Text{String: currency, Valid: true}
 	}
 	
-	// Query transfers
-	transfers, err := h.queries.ListTransfers(r.Context(), store.ListTransfersParams{
-		FromAccountID: fromAccountID,
-		ToAccountID:   toAccountID,
-		Status:        statusText,
-		Currency:      currencyText,
-		Limit:         limit,
-		Offset:        offset,
-	})
+	// Query transfers, with one extra row to tell whether another page follows
+	var transfers []store.Transfer
+	var err error
+	if cursor != nil && cursor.Prev {
+		transfers, err = h.queries.ListTransfersPrev(r.Context(), store.ListTransfersPrevParams{
+			FromAccountID:   fromAccountID,
+			ToAccountID:     toAccountID,
+			Status:          statusText,
+			Currency:        currencyText,
+			BeforeCreatedAt: cursorCreatedAt,
+			BeforeID:        cursorID,
+			Limit:           limit + 1,
+		})
+	} else {
+		params := store.ListTransfersParams{
+			FromAccountID: fromAccountID,
+			ToAccountID:   toAccountID,
+			Status:        statusText,
+			Currency:      currencyText,
+			Limit:         limit + 1,
+		}
+		if cursor != nil {
+			params.AfterCreatedAt = cursorCreatedAt
+			params.AfterID = cursorID
+		}
+		transfers, err = h.queries.ListTransfers(r.Context(), params)
+	}
 	
 	if err != nil {
 		log.Printf("Error listing transfers: %v", err)
@@ -326,6 +536,12 @@ func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	transfers, nextCursor, prevCursor := pagination.KeysetPage(transfers, limit, cursor, func(t store.Transfer) pagination.Cursor {
+		var id uuid.UUID
+		copy(id[:], t.ID.Bytes[:])
+		return pagination.Cursor{Ts: t.CreatedAt.Time, ID: id.String()}
+	})
+	
 	// Get total count
 	total, err := h.queries.CountTransfers(r.Context(), store.CountTransfersParams{
 		FromAccountID: fromAccountID,
@@ -339,6 +555,30 @@ func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 		total = 0
 	}
 	
+	// Load the legs of every transfer on the page
+	transferIDs := make([]pgtype.UUID, len(transfers))
+	for i, t := range transfers {
+		transferIDs[i] = t.ID
+	}
+	legs, err := h.queries.ListTransferLegs(r.Context(), transferIDs)
+	if err != nil {
+		log.Printf("Error listing transfer legs: %v", err)
+		metrics.QueryDuration.WithLabelValues("transfers", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	legsByTransfer := make(map[[16]byte][]TransferLegResponse)
+	for _, leg := range legs {
+		var legAccountID uuid.UUID
+		copy(legAccountID[:], leg.AccountID.Bytes[:])
+		legsByTransfer[leg.TransferID.Bytes] = append(legsByTransfer[leg.TransferID.Bytes], TransferLegResponse{
+			AccountID:   legAccountID.String(),
+			AmountMinor: leg.AmountMinor,
+			Currency:    leg.Currency,
+			Side:        leg.Side,
+		})
+	}
+
 	// Convert to response format
 	transferResponses := make([]TransferResponse, len(transfers))
 	for i, t := range transfers {
@@ -357,14 +597,22 @@ func (h *Handler) ListTransfers(w http.ResponseWriter, r *http.Request) {
 			IdempotencyKey: t.IdempotencyKey,
 			CreatedAt:      t.CreatedAt.Time.Format(time.RFC3339),
 			UpdatedAt:      t.UpdatedAt.Time.Format(time.RFC3339),
+			Legs:           legsByTransfer[t.ID.Bytes],
+			RefundedMinor:  t.RefundedMinor,
+		}
+		if t.RefundOf.Valid {
+			var refundOf uuid.UUID
+			copy(refundOf[:], t.RefundOf.Bytes[:])
+			transferResponses[i].RefundOf = refundOf.String()
 		}
 	}
 	
 	resp := TransfersListResponse{
-		Transfers: transferResponses,
-		Total:     total,
-		Limit:     limit,
-		Offset:    offset,
+		Transfers:  transferResponses,
+		Total:      total,
+		Limit:      limit,
+		NextCursor: nextCursor,
+		PrevCursor: prevCursor,
 	}
 	
 	metrics.QueryDuration.WithLabelValues("transfers", "success").Observe(time.Since(start).Seconds())
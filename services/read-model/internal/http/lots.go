@@ -0,0 +1,174 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/amirhf/credit-ledger/services/read-model/internal/metrics"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// defaultExpiryWindow is how far ahead upcoming expirations are listed when no window is given
+const defaultExpiryWindow = 30 * 24 * time.Hour
+
+// LotResponse represents a credit lot with a remainder
+type LotResponse struct {
+	LotID          string `json:"lot_id"`
+	Currency       string `json:"currency"`
+	Source         string `json:"source,omitempty"`
+	GrantedMinor   int64  `json:"granted_minor"`
+	RemainingMinor int64  `json:"remaining_minor"`
+	ExpiredMinor   int64  `json:"expired_minor"`
+	GrantedAt      string `json:"granted_at,omitempty"`
+	ExpiresAt      string `json:"expires_at,omitempty"` // omitted when the lot never expires
+}
+
+// LotsResponse represents the remaining credit lots of an account
+type LotsResponse struct {
+	AccountID string        `json:"account_id"`
+	Lots      []LotResponse `json:"lots"`
+}
+
+// ExpiringTotal represents how much of an account's credit lapses in one currency
+type ExpiringTotal struct {
+	Currency    string `json:"currency"`
+	AmountMinor int64  `json:"amount_minor"`
+}
+
+// ExpiringLotsResponse represents the credit lots of an account that lapse by Until
+type ExpiringLotsResponse struct {
+	AccountID string          `json:"account_id"`
+	Until     string          `json:"until"`
+	Lots      []LotResponse   `json:"lots"`   // soonest expiry first
+	Totals    []ExpiringTotal `json:"totals"` // remainder lapsing per currency
+}
+
+// GetLots handles GET /v1/accounts/:id/lots
+// Lots are listed soonest expiry first, with never-expiring lots last.
+func (h *Handler) GetLots(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	accountID, pgAccountID, ok := parseLotAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	lots, err := h.queries.ListAccountLots(r.Context(), pgAccountID)
+	if err != nil {
+		log.Printf("Error listing credit lots: %v", err)
+		metrics.QueryDuration.WithLabelValues("lots", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	metrics.QueryDuration.WithLabelValues("lots", "success").Observe(time.Since(start).Seconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(LotsResponse{
+		AccountID: accountID.String(),
+		Lots:      toLotResponses(lots),
+	})
+}
+
+// GetExpiringLots handles GET /v1/accounts/:id/lots/expiring
+// Query parameters:
+//   - within: Go duration ahead of now to look (default 720h)
+//
+// Lots already past expiry that the ledger has not expired yet are included.
+func (h *Handler) GetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	accountID, pgAccountID, ok := parseLotAccountID(w, r)
+	if !ok {
+		return
+	}
+
+	within := defaultExpiryWindow
+	if withinStr := r.URL.Query().Get("within"); withinStr != "" {
+		parsed, err := time.ParseDuration(withinStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, `{"error":"invalid within duration (e.g. 168h)"}`, http.StatusBadRequest)
+			return
+		}
+		within = parsed
+	}
+
+	until := start.Add(within)
+	var pgUntil pgtype.Timestamptz
+	if err := pgUntil.Scan(until); err != nil {
+		http.Error(w, `{"error":"invalid within duration (e.g. 168h)"}`, http.StatusBadRequest)
+		return
+	}
+
+	lots, err := h.queries.ListUpcomingExpirations(r.Context(), store.ListUpcomingExpirationsParams{
+		AccountID: pgAccountID,
+		Until:     pgUntil,
+	})
+	if err != nil {
+		log.Printf("Error listing expiring credit lots: %v", err)
+		metrics.QueryDuration.WithLabelValues("expiring_lots", "error").Observe(time.Since(start).Seconds())
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	byCurrency := make(map[string]int64)
+	for _, lot := range lots {
+		byCurrency[lot.Currency] += lot.RemainingMinor
+	}
+	totals := make([]ExpiringTotal, 0, len(byCurrency))
+	for currency, amount := range byCurrency {
+		totals = append(totals, ExpiringTotal{Currency: currency, AmountMinor: amount})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+
+	metrics.QueryDuration.WithLabelValues("expiring_lots", "success").Observe(time.Since(start).Seconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ExpiringLotsResponse{
+		AccountID: accountID.String(),
+		Until:     until.Format(time.RFC3339),
+		Lots:      toLotResponses(lots),
+		Totals:    totals,
+	})
+}
+
+// parseLotAccountID reads the account ID path parameter, answering 400 when it is invalid
+func parseLotAccountID(w http.ResponseWriter, r *http.Request) (uuid.UUID, pgtype.UUID, bool) {
+	var pgAccountID pgtype.UUID
+	accountID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err == nil {
+		err = pgAccountID.Scan(accountID.String())
+	}
+	if err != nil {
+		http.Error(w, `{"error":"invalid account_id"}`, http.StatusBadRequest)
+		return uuid.Nil, pgAccountID, false
+	}
+	return accountID, pgAccountID, true
+}
+
+func toLotResponses(lots []store.CreditLot) []LotResponse {
+	out := make([]LotResponse, len(lots))
+	for i, lot := range lots {
+		out[i] = LotResponse{
+			LotID:          uuid.UUID(lot.LotID.Bytes).String(),
+			Currency:       lot.Currency,
+			Source:         lot.Source,
+			GrantedMinor:   lot.GrantedMinor,
+			RemainingMinor: lot.RemainingMinor,
+			ExpiredMinor:   lot.ExpiredMinor,
+		}
+		if lot.GrantedAt.Valid {
+			out[i].GrantedAt = lot.GrantedAt.Time.Format(time.RFC3339)
+		}
+		if lot.ExpiresAt.Valid {
+			out[i].ExpiresAt = lot.ExpiresAt.Time.Format(time.RFC3339)
+		}
+	}
+	return out
+}
@@ -34,6 +34,69 @@ var (
 		[]string{"event_type", "error_type"},
 	)
 
+	// EventRetries tracks retries of events whose processing failed
+	EventRetries = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_event_retries_total",
+			Help: "Total number of event processing retries",
+		},
+		[]string{"event_type"},
+	)
+
+	// DeadLetteredEvents tracks events sent to a dead-letter topic after exhausting their retries
+	DeadLetteredEvents = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_dead_lettered_events_total",
+			Help: "Total number of events sent to a dead-letter topic",
+		},
+		[]string{"topic", "event_type"},
+	)
+
+	// DeadLetterRedrives tracks re-drives of dead-lettered events into the projections
+	DeadLetterRedrives = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_dead_letter_redrives_total",
+			Help: "Total number of dead-lettered events re-driven into the projections",
+		},
+		[]string{"topic", "result"},
+	)
+
+	// EventsSkipped tracks events skipped because they could not be decoded or
+	// their type or schema version is unknown to this build
+	EventsSkipped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_events_skipped_total",
+			Help: "Total number of events skipped as undecodable or of an unknown type or schema version",
+		},
+		[]string{"event_type", "reason"},
+	)
+
+	// EventsParked tracks events parked because an earlier event of their aggregate had not arrived
+	EventsParked = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_events_parked_total",
+			Help: "Total number of events parked to wait for an earlier event of their aggregate",
+		},
+		[]string{"event_type"},
+	)
+
+	// ParkedEventsReleased tracks parked events applied, once in order or after giving up on a gap
+	ParkedEventsReleased = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "readmodel_parked_events_released_total",
+			Help: "Total number of parked events applied to the projections",
+		},
+		[]string{"event_type", "reason"},
+	)
+
+	// ParkedEvents tracks events currently waiting in parked_events
+	ParkedEvents = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "readmodel_parked_events",
+			Help: "Number of events waiting for an earlier event of their aggregate",
+		},
+	)
+
 	// ProjectionLag tracks the lag between event timestamp and processing time
 	ProjectionLag = promauto.NewHistogram(
 		prometheus.HistogramOpts{
@@ -0,0 +1,152 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ProcessAccountCreated records an account's type and normal balance side.
+// Entries projected before the account's AccountCreated were signed as
+// credit-normal; if the account turns out to be debit-normal its balance and
+// running balances are negated to match.
+func (p *Projector) ProcessAccountCreated(ctx context.Context, eventID uuid.UUID, event *ledgerv1.AccountCreated) error {
+	// Check if event already processed (idempotency)
+	var pgEventID pgtype.UUID
+	if err := pgEventID.Scan(eventID.String()); err != nil {
+		return fmt.Errorf("convert event_id to pgtype: %w", err)
+	}
+
+	processed, err := p.queries.IsEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("check event processed: %w", err)
+	}
+	if processed {
+		log.Printf("Account event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	accountID, err := uuid.Parse(event.AccountId)
+	if err != nil {
+		return fmt.Errorf("parse account_id: %w", err)
+	}
+	pgAccountID := pgtype.UUID{Bytes: accountID, Valid: true}
+
+	accountType, normal := accountTypeOf(event)
+
+	createdAt := time.Unix(0, event.TsUnixMs*int64(time.Millisecond))
+	var pgCreatedAt pgtype.Timestamptz
+	if err := pgCreatedAt.Scan(createdAt); err != nil {
+		return fmt.Errorf("convert created_at: %w", err)
+	}
+
+	// Begin transaction
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.queries.WithTx(tx)
+
+	claimed, err := qtx.ClaimEvent(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("claim event: %w", err)
+	}
+	if claimed == 0 {
+		log.Printf("Account event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Lock the account row so no entry is projected with the old sign meanwhile
+	if err := qtx.EnsureAccount(ctx, pgAccountID); err != nil {
+		return fmt.Errorf("ensure account %s: %w", accountID, err)
+	}
+	account, err := qtx.GetAccountForUpdate(ctx, pgAccountID)
+	if err != nil {
+		return fmt.Errorf("get account %s: %w", accountID, err)
+	}
+
+	if account.AccountType.Valid {
+		log.Printf("Account %s already has type %s, skipping", accountID, account.AccountType.String)
+	} else {
+		err = qtx.SetAccountType(ctx, store.SetAccountTypeParams{
+			AccountID:   pgAccountID,
+			AccountType: pgtype.Text{String: accountType, Valid: true},
+			NormalSide:  normal,
+			Currency:    pgtype.Text{String: event.Currency, Valid: event.Currency != ""},
+			CreatedAt:   pgCreatedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("set type of account %s: %w", accountID, err)
+		}
+
+		if account.NormalSide != normal {
+			if err := qtx.NegateBalance(ctx, pgAccountID); err != nil {
+				return fmt.Errorf("negate balance of account %s: %w", accountID, err)
+			}
+			if err := qtx.NegateRunningBalances(ctx, pgAccountID); err != nil {
+				return fmt.Errorf("negate running balances of account %s: %w", accountID, err)
+			}
+			log.Printf("Re-signed balances of account %s as %s-normal", accountID, normal)
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	log.Printf("Processed AccountCreated event %s: %s account %s", eventID, accountType, accountID)
+	return nil
+}
+
+// accountTypeOf returns the account type and normal side carried by an
+// AccountCreated event. Events from before account types carry neither and
+// describe credit-normal LIABILITY accounts.
+func accountTypeOf(event *ledgerv1.AccountCreated) (string, string) {
+	accountType := ledgerv1.AccountType_ACCOUNT_TYPE_LIABILITY
+	if event.AccountType != ledgerv1.AccountType_ACCOUNT_TYPE_UNSPECIFIED {
+		accountType = event.AccountType
+	}
+
+	// Stored without the enum prefix, as the accounts service names types
+	typeName := strings.TrimPrefix(accountType.String(), "ACCOUNT_TYPE_")
+
+	switch event.NormalSide {
+	case ledgerv1.Side_DEBIT:
+		return typeName, "DEBIT"
+	case ledgerv1.Side_CREDIT:
+		return typeName, "CREDIT"
+	}
+	if accountType == ledgerv1.AccountType_ACCOUNT_TYPE_ASSET || accountType == ledgerv1.AccountType_ACCOUNT_TYPE_EXPENSE {
+		return typeName, "DEBIT"
+	}
+	return typeName, "CREDIT"
+}
+
+// normalSide returns the side on which an account's balance increases, CREDIT
+// until its AccountCreated is projected. The account row is share-locked, so a
+// concurrent AccountCreated re-signing the balance waits for the caller to commit.
+func normalSide(ctx context.Context, qtx *store.Queries, accountID pgtype.UUID) (string, error) {
+	if err := qtx.EnsureAccount(ctx, accountID); err != nil {
+		return "", err
+	}
+	return qtx.GetAccountNormalSide(ctx, accountID)
+}
+
+// signedAmount returns the balance change of a line of amountMinor on side for
+// an account whose balance increases on normalSide
+func signedAmount(side, normalSide string, amountMinor int64) int64 {
+	if side == normalSide {
+		return amountMinor
+	}
+	return -amountMinor
+}
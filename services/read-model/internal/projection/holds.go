@@ -0,0 +1,195 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ProcessHoldPlaced records a pending hold, which reduces the account's available balance
+func (p *Projector) ProcessHoldPlaced(ctx context.Context, eventID uuid.UUID, event *ledgerv1.HoldPlaced) error {
+	// Check if event already processed (idempotency)
+	var pgEventID pgtype.UUID
+	if err := pgEventID.Scan(eventID.String()); err != nil {
+		return fmt.Errorf("convert event_id to pgtype: %w", err)
+	}
+
+	processed, err := p.queries.IsEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("check event processed: %w", err)
+	}
+	if processed {
+		log.Printf("Hold event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Parse UUIDs
+	holdID, err := uuid.Parse(event.HoldId)
+	if err != nil {
+		return fmt.Errorf("parse hold_id: %w", err)
+	}
+
+	accountID, err := uuid.Parse(event.AccountId)
+	if err != nil {
+		return fmt.Errorf("parse account_id: %w", err)
+	}
+
+	// Begin transaction
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.queries.WithTx(tx)
+
+	// Convert to pgtype
+	var pgHoldID, pgAccountID pgtype.UUID
+	if err := pgHoldID.Scan(holdID.String()); err != nil {
+		return fmt.Errorf("convert hold_id: %w", err)
+	}
+	if err := pgAccountID.Scan(accountID.String()); err != nil {
+		return fmt.Errorf("convert account_id: %w", err)
+	}
+
+	var pgExpiresAt, pgCreatedAt pgtype.Timestamptz
+	if err := pgExpiresAt.Scan(time.UnixMilli(event.ExpiresAtUnixMs)); err != nil {
+		return fmt.Errorf("convert expires_at: %w", err)
+	}
+	if err := pgCreatedAt.Scan(time.UnixMilli(event.TsUnixMs)); err != nil {
+		return fmt.Errorf("convert created_at: %w", err)
+	}
+
+	// Create hold record
+	err = qtx.CreateHold(ctx, store.CreateHoldParams{
+		ID:          pgHoldID,
+		AccountID:   pgAccountID,
+		Currency:    event.Amount.Currency,
+		AmountMinor: event.Amount.Units,
+		ExpiresAt:   pgExpiresAt,
+		CreatedAt:   pgCreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("create hold: %w", err)
+	}
+
+	// Mark event as processed
+	err = qtx.MarkEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("mark event processed: %w", err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	log.Printf("Processed HoldPlaced event %s for hold %s", eventID, holdID)
+	return nil
+}
+
+// ProcessHoldCaptured applies a capture; the hold stays pending while anything remains reserved
+func (p *Projector) ProcessHoldCaptured(ctx context.Context, eventID uuid.UUID, event *ledgerv1.HoldCaptured) error {
+	status := "PENDING"
+	if event.RemainingMinor == 0 {
+		status = "CAPTURED"
+	}
+
+	return p.updateHold(ctx, eventID, "HoldCaptured", event.HoldId, func(qtx *store.Queries, pgHoldID pgtype.UUID) (int64, error) {
+		return qtx.UpdateHoldCaptured(ctx, store.UpdateHoldCapturedParams{
+			RemainingMinor: event.RemainingMinor,
+			Status:         status,
+			ID:             pgHoldID,
+		})
+	})
+}
+
+// ProcessHoldReleased frees whatever the hold still reserved (released by request or expired)
+func (p *Projector) ProcessHoldReleased(ctx context.Context, eventID uuid.UUID, event *ledgerv1.HoldReleased) error {
+	status := "RELEASED"
+	if event.Reason == "expired" {
+		status = "EXPIRED"
+	}
+
+	return p.updateHold(ctx, eventID, "HoldReleased", event.HoldId, func(qtx *store.Queries, pgHoldID pgtype.UUID) (int64, error) {
+		return qtx.UpdateHoldReleased(ctx, store.UpdateHoldReleasedParams{
+			ID:     pgHoldID,
+			Status: status,
+		})
+	})
+}
+
+// updateHold applies a hold state change with event deduplication. Returns an error
+// if the hold has not been projected yet, so the event is retried after HoldPlaced.
+func (p *Projector) updateHold(ctx context.Context, eventID uuid.UUID, eventType, holdIDStr string,
+	update func(qtx *store.Queries, pgHoldID pgtype.UUID) (int64, error)) error {
+	// Check if event already processed (idempotency)
+	var pgEventID pgtype.UUID
+	if err := pgEventID.Scan(eventID.String()); err != nil {
+		return fmt.Errorf("convert event_id to pgtype: %w", err)
+	}
+
+	processed, err := p.queries.IsEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("check event processed: %w", err)
+	}
+	if processed {
+		log.Printf("Hold event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Parse hold ID
+	holdID, err := uuid.Parse(holdIDStr)
+	if err != nil {
+		return fmt.Errorf("parse hold_id: %w", err)
+	}
+
+	var pgHoldID pgtype.UUID
+	if err := pgHoldID.Scan(holdID.String()); err != nil {
+		return fmt.Errorf("convert hold_id: %w", err)
+	}
+
+	// Begin transaction
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.queries.WithTx(tx)
+
+	updated, err := update(qtx, pgHoldID)
+	if err != nil {
+		return fmt.Errorf("update hold: %w", err)
+	}
+	if updated == 0 {
+		exists, err := qtx.HoldExists(ctx, pgHoldID)
+		if err != nil {
+			return fmt.Errorf("check hold exists: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("hold %s not projected yet", holdID)
+		}
+		log.Printf("Hold %s is no longer pending, ignoring %s event %s", holdID, eventType, eventID)
+	}
+
+	// Mark event as processed
+	err = qtx.MarkEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("mark event processed: %w", err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	log.Printf("Processed %s event %s for hold %s", eventType, eventID, holdID)
+	return nil
+}
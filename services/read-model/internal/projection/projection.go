@@ -9,12 +9,13 @@ import (
 	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
 	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"google.golang.org/protobuf/proto"
 )
 
-// Projector applies EntryPosted events to balances and statements tables
+// Projector applies ledger and transfer events to the read model tables
 type Projector struct {
 	db      *pgxpool.Pool
 	queries *store.Queries
@@ -61,6 +62,17 @@ func (p *Projector) ProcessEntryPosted(ctx context.Context, eventID uuid.UUID, p
 
 	qtx := p.queries.WithTx(tx)
 
+	// Claim the event inside the transaction, so an event applied by a concurrent
+	// writer (e.g. a rebuild swapping in its tables) is not applied twice
+	claimed, err := qtx.ClaimEvent(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("claim event: %w", err)
+	}
+	if claimed == 0 {
+		log.Printf("Event %s already processed, skipping", eventID)
+		return nil
+	}
+
 	// Process each line in the entry
 	entryID, err := uuid.Parse(event.EntryId)
 	if err != nil {
@@ -68,6 +80,7 @@ func (p *Projector) ProcessEntryPosted(ctx context.Context, eventID uuid.UUID, p
 	}
 
 	ts := time.Unix(0, event.TsUnixMs*int64(time.Millisecond))
+	effectiveAt := effectiveTime(event.EffectiveAtUnixMs, ts)
 
 	for _, line := range event.Lines {
 		accountID, err := uuid.Parse(line.AccountId)
@@ -88,19 +101,18 @@ func (p *Projector) ProcessEntryPosted(ctx context.Context, eventID uuid.UUID, p
 		currency := line.Amount.Currency
 		amountMinor := line.Amount.Units
 
-		// Determine balance delta based on side
-		// In traditional accounting, for asset accounts:
-		// - DEBIT increases the asset (but in transfers, FROM account is debited = money out)
-		// - CREDIT decreases the asset (but in transfers, TO account is credited = money in)
-		// However, the orchestrator uses: FROM=DEBIT (out), TO=CREDIT (in)
-		// So we need to invert: DEBIT = decrease balance, CREDIT = increase balance
-		var balanceDelta int64
-		if line.Side == ledgerv1.Side_DEBIT {
-			balanceDelta = -amountMinor // Money leaving (FROM account)
-		} else {
-			balanceDelta = amountMinor // Money arriving (TO account)
+		sideStr := "DEBIT"
+		if line.Side == ledgerv1.Side_CREDIT {
+			sideStr = "CREDIT"
 		}
 
+		// A line on the account's normal side increases its balance, the other side decreases it
+		normal, err := normalSide(ctx, qtx, pgAccountID)
+		if err != nil {
+			return fmt.Errorf("get normal side of account %s: %w", accountID, err)
+		}
+		balanceDelta := signedAmount(sideStr, normal, amountMinor)
+
 		// Update balance (UPSERT with delta)
 		err = qtx.UpsertBalance(ctx, store.UpsertBalanceParams{
 			AccountID:    pgAccountID,
@@ -112,32 +124,29 @@ func (p *Projector) ProcessEntryPosted(ctx context.Context, eventID uuid.UUID, p
 		}
 
 		// Append to statements
-		sideStr := "DEBIT"
-		if line.Side == ledgerv1.Side_CREDIT {
-			sideStr = "CREDIT"
-		}
-
-		var pgTs pgtype.Timestamptz
+		var pgTs, pgEffectiveAt pgtype.Timestamptz
 		if err := pgTs.Scan(ts); err != nil {
 			return fmt.Errorf("convert timestamp to pgtype: %w", err)
 		}
+		if err := pgEffectiveAt.Scan(effectiveAt); err != nil {
+			return fmt.Errorf("convert effective_at to pgtype: %w", err)
+		}
 
-		err = qtx.CreateStatement(ctx, store.CreateStatementParams{
+		err = appendStatement(ctx, qtx, store.CreateStatementParams{
 			AccountID:   pgAccountID,
 			EntryID:     pgEntryID,
 			AmountMinor: amountMinor,
 			Side:        sideStr,
 			Ts:          pgTs,
-		})
+			EffectiveAt: pgEffectiveAt,
+		}, balanceDelta)
 		if err != nil {
 			return fmt.Errorf("create statement for account %s: %w", accountID, err)
 		}
 	}
 
-	// Mark event as processed
-	err = qtx.MarkEventProcessed(ctx, pgEventID)
-	if err != nil {
-		return fmt.Errorf("mark event processed: %w", err)
+	if err := applyLotMovements(ctx, qtx, event.LotMovements, effectiveAt, ts); err != nil {
+		return fmt.Errorf("apply lot movements of entry %s: %w", entryID, err)
 	}
 
 	// Commit transaction
@@ -149,6 +158,195 @@ func (p *Projector) ProcessEntryPosted(ctx context.Context, eventID uuid.UUID, p
 	return nil
 }
 
+// ProcessEntryVoided applies an EntryVoided event to the read model.
+// The original entry's statement lines are marked voided and linked to the void
+// entry, reversed lines are appended under the void entry, and balances are
+// restored. Lines already voided are skipped, so replays have no further effect.
+func (p *Projector) ProcessEntryVoided(ctx context.Context, eventID uuid.UUID, payload []byte) error {
+	// Check if event already processed (idempotency)
+	var pgEventID pgtype.UUID
+	if err := pgEventID.Scan(eventID.String()); err != nil {
+		return fmt.Errorf("convert event_id to pgtype: %w", err)
+	}
+
+	processed, err := p.queries.IsEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("check event processed: %w", err)
+	}
+	if processed {
+		log.Printf("Event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Deserialize event
+	var event ledgerv1.EntryVoided
+	if err := proto.Unmarshal(payload, &event); err != nil {
+		return fmt.Errorf("unmarshal EntryVoided: %w", err)
+	}
+
+	originalEntryID, err := uuid.Parse(event.OriginalEntryId)
+	if err != nil {
+		return fmt.Errorf("parse original_entry_id: %w", err)
+	}
+
+	voidEntryID, err := uuid.Parse(event.VoidEntryId)
+	if err != nil {
+		return fmt.Errorf("parse void_entry_id: %w", err)
+	}
+
+	var pgOriginalEntryID, pgVoidEntryID pgtype.UUID
+	if err := pgOriginalEntryID.Scan(originalEntryID.String()); err != nil {
+		return fmt.Errorf("convert original_entry_id to pgtype: %w", err)
+	}
+	if err := pgVoidEntryID.Scan(voidEntryID.String()); err != nil {
+		return fmt.Errorf("convert void_entry_id to pgtype: %w", err)
+	}
+
+	ts := time.Unix(0, event.TsUnixMs*int64(time.Millisecond))
+	var pgTs, pgEffectiveAt pgtype.Timestamptz
+	if err := pgTs.Scan(ts); err != nil {
+		return fmt.Errorf("convert timestamp to pgtype: %w", err)
+	}
+	if err := pgEffectiveAt.Scan(effectiveTime(event.EffectiveAtUnixMs, ts)); err != nil {
+		return fmt.Errorf("convert effective_at to pgtype: %w", err)
+	}
+
+	// Begin transaction for atomic projection update
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.queries.WithTx(tx)
+
+	// Claim the event inside the transaction, so an event applied by a concurrent
+	// writer (e.g. a rebuild swapping in its tables) is not applied twice
+	claimed, err := qtx.ClaimEvent(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("claim event: %w", err)
+	}
+	if claimed == 0 {
+		log.Printf("Event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Mark original lines voided; only lines not voided before are returned
+	voidedLines, err := qtx.VoidStatementsByEntry(ctx, store.VoidStatementsByEntryParams{
+		EntryID:  pgOriginalEntryID,
+		VoidedBy: pgVoidEntryID,
+		VoidedAt: pgTs,
+	})
+	if err != nil {
+		return fmt.Errorf("void statements for entry %s: %w", originalEntryID, err)
+	}
+
+	if len(voidedLines) == 0 {
+		count, err := qtx.CountStatementsByEntry(ctx, pgOriginalEntryID)
+		if err != nil {
+			return fmt.Errorf("count statements for entry %s: %w", originalEntryID, err)
+		}
+		if count == 0 {
+			// EntryPosted not projected yet; fail so the event is retried
+			return fmt.Errorf("original entry %s not projected yet", originalEntryID)
+		}
+		log.Printf("Entry %s already voided in read model, skipping reversal", originalEntryID)
+	}
+
+	for _, line := range voidedLines {
+		var accountID uuid.UUID
+		copy(accountID[:], line.AccountID.Bytes[:])
+
+		// Reverse the original effect by posting the amount on the opposite side
+		reversedSide := "DEBIT"
+		if line.Side == "DEBIT" {
+			reversedSide = "CREDIT"
+		}
+		normal, err := normalSide(ctx, qtx, line.AccountID)
+		if err != nil {
+			return fmt.Errorf("get normal side of account %s: %w", accountID, err)
+		}
+		balanceDelta := signedAmount(reversedSide, normal, line.AmountMinor)
+
+		balance, err := qtx.GetBalance(ctx, line.AccountID)
+		if err != nil {
+			return fmt.Errorf("get balance for account %s: %w", accountID, err)
+		}
+
+		err = qtx.UpsertBalance(ctx, store.UpsertBalanceParams{
+			AccountID:    line.AccountID,
+			Currency:     balance.Currency,
+			BalanceMinor: balanceDelta,
+		})
+		if err != nil {
+			return fmt.Errorf("upsert balance for account %s: %w", accountID, err)
+		}
+
+		err = appendStatement(ctx, qtx, store.CreateStatementParams{
+			AccountID:   line.AccountID,
+			EntryID:     pgVoidEntryID,
+			AmountMinor: line.AmountMinor,
+			Side:        reversedSide,
+			Ts:          pgTs,
+			EffectiveAt: pgEffectiveAt,
+		}, balanceDelta)
+		if err != nil {
+			return fmt.Errorf("create reversal statement for account %s: %w", accountID, err)
+		}
+	}
+
+	// Lot movements go with the reversal, so a void already reversed does not move lots again
+	if len(voidedLines) > 0 {
+		if err := applyLotMovements(ctx, qtx, event.LotMovements, effectiveTime(event.EffectiveAtUnixMs, ts), ts); err != nil {
+			return fmt.Errorf("apply lot movements of void entry %s: %w", voidEntryID, err)
+		}
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	log.Printf("Processed EntryVoided event %s: reversed %d lines of entry %s", eventID, len(voidedLines), originalEntryID)
+	return nil
+}
+
+// appendStatement appends a statement line with the account's running balance after it.
+// Lines are ordered by effective date; a line effective before lines already projected
+// for the account (a value-dated entry, or events from another partition arriving
+// late) is slotted in at its effective date, and the running balance of every later
+// line is shifted by balanceDelta.
+func appendStatement(ctx context.Context, qtx *store.Queries, arg store.CreateStatementParams, balanceDelta int64) error {
+	balanceBefore, err := qtx.GetBalanceAsOf(ctx, store.GetBalanceAsOfParams{
+		AccountID:   arg.AccountID,
+		EffectiveAt: arg.EffectiveAt,
+	})
+	if err != nil && err != pgx.ErrNoRows {
+		return fmt.Errorf("get running balance: %w", err)
+	}
+
+	err = qtx.ShiftRunningBalances(ctx, store.ShiftRunningBalancesParams{
+		Delta:       balanceDelta,
+		AccountID:   arg.AccountID,
+		EffectiveAt: arg.EffectiveAt,
+	})
+	if err != nil {
+		return fmt.Errorf("shift running balances: %w", err)
+	}
+
+	arg.BalanceAfterMinor = balanceBefore + balanceDelta
+	return qtx.CreateStatement(ctx, arg)
+}
+
+// effectiveTime returns an event's effective date; events from producers that
+// predate value dating carry none and take effect at their booking time
+func effectiveTime(effectiveAtUnixMs int64, ts time.Time) time.Time {
+	if effectiveAtUnixMs == 0 {
+		return ts
+	}
+	return time.UnixMilli(effectiveAtUnixMs)
+}
+
 // ProcessTransferInitiated creates a transfer record in the read model
 func (p *Projector) ProcessTransferInitiated(ctx context.Context, eventID uuid.UUID, event *ledgerv1.TransferInitiated) error {
 	// Check if event already processed (idempotency)
@@ -224,6 +422,31 @@ func (p *Projector) ProcessTransferInitiated(ctx context.Context, eventID uuid.U
 		return fmt.Errorf("create transfer: %w", err)
 	}
 
+	// Record every leg (simple transfers carry two, multi-leg postings any number)
+	for i, leg := range event.Legs {
+		var pgLegAccountID pgtype.UUID
+		if err := pgLegAccountID.Scan(leg.AccountId); err != nil {
+			return fmt.Errorf("convert legs[%d].account_id: %w", i, err)
+		}
+
+		side := "DEBIT"
+		if leg.Side == ledgerv1.Side_CREDIT {
+			side = "CREDIT"
+		}
+
+		err = qtx.CreateTransferLeg(ctx, store.CreateTransferLegParams{
+			TransferID:  pgTransferID,
+			LegIndex:    int32(i),
+			AccountID:   pgLegAccountID,
+			AmountMinor: leg.Amount.Units,
+			Currency:    leg.Amount.Currency,
+			Side:        side,
+		})
+		if err != nil {
+			return fmt.Errorf("create transfer leg: %w", err)
+		}
+	}
+
 	// Mark event as processed
 	err = qtx.MarkEventProcessed(ctx, pgEventID)
 	if err != nil {
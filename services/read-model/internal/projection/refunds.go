@@ -0,0 +1,97 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ProcessTransferRefunded links a completed refund to its original transfer and
+// records the original's refunded total. Returns an error if either transfer has
+// not been projected yet, so the event is retried after their TransferInitiated.
+func (p *Projector) ProcessTransferRefunded(ctx context.Context, eventID uuid.UUID, event *ledgerv1.TransferRefunded) error {
+	// Check if event already processed (idempotency)
+	var pgEventID pgtype.UUID
+	if err := pgEventID.Scan(eventID.String()); err != nil {
+		return fmt.Errorf("convert event_id to pgtype: %w", err)
+	}
+
+	processed, err := p.queries.IsEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("check event processed: %w", err)
+	}
+	if processed {
+		log.Printf("Transfer event %s already processed, skipping", eventID)
+		return nil
+	}
+
+	// Parse UUIDs
+	transferID, err := uuid.Parse(event.TransferId)
+	if err != nil {
+		return fmt.Errorf("parse transfer_id: %w", err)
+	}
+
+	refundID, err := uuid.Parse(event.RefundTransferId)
+	if err != nil {
+		return fmt.Errorf("parse refund_transfer_id: %w", err)
+	}
+
+	var pgTransferID, pgRefundID pgtype.UUID
+	if err := pgTransferID.Scan(transferID.String()); err != nil {
+		return fmt.Errorf("convert transfer_id: %w", err)
+	}
+	if err := pgRefundID.Scan(refundID.String()); err != nil {
+		return fmt.Errorf("convert refund_transfer_id: %w", err)
+	}
+
+	// Begin transaction
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := p.queries.WithTx(tx)
+
+	// Refund events may arrive out of order; keep the largest total seen
+	updated, err := qtx.UpdateTransferRefunded(ctx, store.UpdateTransferRefundedParams{
+		RefundedMinor: event.RefundedTotalMinor,
+		ID:            pgTransferID,
+	})
+	if err != nil {
+		return fmt.Errorf("update refunded total: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("transfer %s not projected yet", transferID)
+	}
+
+	updated, err = qtx.SetTransferRefundOf(ctx, store.SetTransferRefundOfParams{
+		ID:       pgRefundID,
+		RefundOf: pgTransferID,
+	})
+	if err != nil {
+		return fmt.Errorf("link refund: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("refund transfer %s not projected yet", refundID)
+	}
+
+	// Mark event as processed
+	err = qtx.MarkEventProcessed(ctx, pgEventID)
+	if err != nil {
+		return fmt.Errorf("mark event processed: %w", err)
+	}
+
+	// Commit transaction
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	log.Printf("Processed TransferRefunded event %s: refund %s of transfer %s", eventID, refundID, transferID)
+	return nil
+}
@@ -0,0 +1,141 @@
+package rebuild
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/amirhf/credit-ledger/services/common/events"
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaSource replays ledger.entry.v1 from offset 0 of every partition.
+// It reads partitions directly, outside any consumer group, so the live
+// consumer's committed offsets are untouched.
+type KafkaSource struct {
+	brokers []string
+	topic   string
+	dialer  *kafka.Dialer
+	next    map[int]int64 // next offset to replay per partition
+}
+
+// NewKafkaSource creates a source over the ledger entry topic
+func NewKafkaSource(brokers []string, dialer *kafka.Dialer) *KafkaSource {
+	return &KafkaSource{
+		brokers: brokers,
+		topic:   "ledger.entry.v1",
+		dialer:  dialer,
+		next:    make(map[int]int64),
+	}
+}
+
+// Replay applies every message between the last replayed offset and the
+// current end of each partition
+func (s *KafkaSource) Replay(ctx context.Context, apply ApplyFunc) (int, error) {
+	conn, err := s.dialer.DialContext(ctx, "tcp", s.brokers[0])
+	if err != nil {
+		return 0, fmt.Errorf("dial kafka: %w", err)
+	}
+	partitions, err := conn.ReadPartitions(s.topic)
+	conn.Close()
+	if err != nil {
+		return 0, fmt.Errorf("read partitions of %s: %w", s.topic, err)
+	}
+
+	var replayed int
+	for _, partition := range partitions {
+		first, last, err := s.readOffsets(ctx, partition.ID)
+		if err != nil {
+			return replayed, err
+		}
+
+		start, ok := s.next[partition.ID]
+		if !ok {
+			// A rebuild needs the whole log; refuse to build from a truncated one
+			if first > 0 {
+				return replayed, fmt.Errorf("partition %d of %s starts at offset %d: retention dropped earlier events, rebuild from the ledger instead",
+					partition.ID, s.topic, first)
+			}
+			start = first
+		}
+		if start >= last {
+			continue
+		}
+
+		n, err := s.replayPartition(ctx, partition.ID, start, last, apply)
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+		s.next[partition.ID] = last
+	}
+	return replayed, nil
+}
+
+// readOffsets returns the first and next-to-be-written offsets of a partition
+func (s *KafkaSource) readOffsets(ctx context.Context, partition int) (int64, int64, error) {
+	conn, err := s.dialer.DialLeader(ctx, "tcp", s.brokers[0], s.topic, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dial leader of partition %d: %w", partition, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read offsets of partition %d: %w", partition, err)
+	}
+	return first, last, nil
+}
+
+// replayPartition applies the messages in [start, end) of one partition
+func (s *KafkaSource) replayPartition(ctx context.Context, partition int, start, end int64, apply ApplyFunc) (int, error) {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   s.brokers,
+		Topic:     s.topic,
+		Partition: partition,
+		Dialer:    s.dialer,
+		MinBytes:  1,
+		MaxBytes:  10e6, // 10MB
+	})
+	defer reader.Close()
+
+	if err := reader.SetOffset(start); err != nil {
+		return 0, fmt.Errorf("seek partition %d: %w", partition, err)
+	}
+
+	var replayed int
+	for offset := start; offset < end; {
+		msg, err := reader.ReadMessage(ctx)
+		if err != nil {
+			return replayed, fmt.Errorf("read partition %d at offset %d: %w", partition, offset, err)
+		}
+		offset = msg.Offset + 1
+
+		envelope, err := events.Decode(msg.Value, msg.Headers)
+		if err != nil {
+			log.Printf("Skipping undecodable message at partition %d offset %d: %v", partition, msg.Offset, err)
+			continue
+		}
+		// Messages published before EntryVoided was routed here carry no type and are always EntryPosted
+		if envelope.EventType == "" {
+			envelope.EventType = "EntryPosted"
+		}
+		if err := events.Check(envelope); err != nil {
+			log.Printf("Skipping message at partition %d offset %d: %v", partition, msg.Offset, err)
+			continue
+		}
+		eventType := envelope.EventType
+		eventID, err := uuid.Parse(envelope.EventId)
+		if err != nil {
+			log.Printf("Skipping message at partition %d offset %d without a valid event_id", partition, msg.Offset)
+			continue
+		}
+
+		if err := apply(ctx, eventType, eventID, envelope.Payload.Value); err != nil {
+			return replayed, fmt.Errorf("apply %s event %s (partition %d offset %d): %w", eventType, eventID, partition, msg.Offset, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
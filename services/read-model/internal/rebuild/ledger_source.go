@@ -0,0 +1,330 @@
+package rebuild
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/protobuf/proto"
+)
+
+// The ledger database belongs to another service, so it is read with plain SQL
+// against its published schema rather than sqlc.
+
+// Journal entries in (ts, entry_id) order, each with the outbox event that
+// announced it when that row has not been cleaned up yet
+const ledgerEntries = `
+SELECT je.entry_id, je.batch_id, je.kind, je.ts, je.effective_at,
+       orig.entry_id AS original_entry_id, orig.void_reason,
+       o.id AS event_id, o.payload
+FROM journal_entries je
+LEFT JOIN journal_entries orig ON je.kind = 'VOID' AND orig.voided_by = je.entry_id
+LEFT JOIN outbox o ON o.aggregate_id = COALESCE(orig.entry_id, je.entry_id)
+  AND o.event_type = CASE WHEN je.kind = 'VOID' THEN 'EntryVoided' ELSE 'EntryPosted' END
+WHERE (je.ts, je.entry_id) > ($1, $2)
+ORDER BY je.ts, je.entry_id
+LIMIT $3
+`
+
+const ledgerEntryLines = `
+SELECT account_id, amount_minor, currency, side FROM journal_lines
+WHERE entry_id = $1
+ORDER BY id
+`
+
+// Lots granted by an entry; for a voided entry only the lots its void revoked
+const ledgerLotGrants = `
+SELECT id, account_id, currency, granted_minor, revoked_minor, expires_at, source FROM credit_lots
+WHERE grant_entry_id = $1 AND ($2::boolean = false OR revoked_at IS NOT NULL)
+ORDER BY id
+`
+
+// Amounts an entry took from lots, or for a void entry the amounts it gave back
+const ledgerLotConsumptions = `
+SELECT c.lot_id, l.account_id, l.currency, c.amount_minor, c.reason
+FROM lot_consumptions c
+JOIN credit_lots l ON l.id = c.lot_id
+WHERE CASE WHEN $2::boolean THEN c.restored_by = $1 ELSE c.entry_id = $1 END
+ORDER BY c.id
+`
+
+// ledgerEventNamespace derives stable event IDs for entries whose outbox row is gone
+var ledgerEventNamespace = uuid.MustParse("5b0e7f3c-2d4a-4f7e-9a51-3c8d2e6f1a90")
+
+// LedgerSource replays entry events from the ledger database. Events still in
+// the ledger outbox are replayed verbatim under their published event IDs;
+// older entries are re-derived from journal_entries and journal_lines.
+type LedgerSource struct {
+	db        *pgxpool.Pool
+	afterTs   time.Time
+	started   bool
+	recent    map[uuid.UUID]time.Time // entries applied within the overlap window
+	overlap   time.Duration
+	batchSize int32
+}
+
+// NewLedgerSource creates a source over the ledger database
+func NewLedgerSource(db *pgxpool.Pool) *LedgerSource {
+	return &LedgerSource{
+		db:        db,
+		recent:    make(map[uuid.UUID]time.Time),
+		overlap:   time.Minute, // Entry ts is set before commit, so later commits can land behind the cursor
+		batchSize: 500,
+	}
+}
+
+type ledgerEntry struct {
+	entryID         pgtype.UUID
+	batchID         pgtype.UUID
+	kind            string
+	ts              pgtype.Timestamptz
+	effectiveAt     pgtype.Timestamptz
+	originalEntryID pgtype.UUID
+	voidReason      pgtype.Text
+	eventID         pgtype.UUID
+	payload         []byte
+}
+
+// Replay applies every entry after the last replayed position. Catch-up passes
+// rescan an overlap window and skip the entries they already applied.
+func (s *LedgerSource) Replay(ctx context.Context, apply ApplyFunc) (int, error) {
+	afterTs, afterID := time.Unix(0, 0), uuid.Nil
+	if s.started {
+		afterTs = s.afterTs.Add(-s.overlap)
+	}
+
+	var replayed int
+	for {
+		entries, err := s.listEntries(ctx, afterTs, afterID)
+		if err != nil {
+			return replayed, err
+		}
+		if len(entries) == 0 {
+			s.started = true
+			return replayed, nil
+		}
+
+		for _, entry := range entries {
+			entryID := uuid.UUID(entry.entryID.Bytes)
+			if _, ok := s.recent[entryID]; ok {
+				continue
+			}
+			applied, err := s.applyEntry(ctx, entry, apply)
+			if err != nil {
+				return replayed, err
+			}
+			if applied {
+				replayed++
+			}
+			s.recent[entryID] = entry.ts.Time
+		}
+
+		last := entries[len(entries)-1]
+		afterTs, afterID = last.ts.Time, uuid.UUID(last.entryID.Bytes)
+		if afterTs.After(s.afterTs) {
+			s.afterTs = afterTs
+		}
+		for entryID, ts := range s.recent {
+			if ts.Before(s.afterTs.Add(-s.overlap)) {
+				delete(s.recent, entryID)
+			}
+		}
+	}
+}
+
+func (s *LedgerSource) listEntries(ctx context.Context, afterTs time.Time, afterID uuid.UUID) ([]ledgerEntry, error) {
+	rows, err := s.db.Query(ctx, ledgerEntries, afterTs, afterID.String(), s.batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("list journal entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []ledgerEntry
+	for rows.Next() {
+		var e ledgerEntry
+		if err := rows.Scan(&e.entryID, &e.batchID, &e.kind, &e.ts, &e.effectiveAt, &e.originalEntryID, &e.voidReason, &e.eventID, &e.payload); err != nil {
+			return nil, fmt.Errorf("scan journal entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// applyEntry applies the event for one journal entry; returns false for entries with no event
+func (s *LedgerSource) applyEntry(ctx context.Context, entry ledgerEntry, apply ApplyFunc) (bool, error) {
+	entryID := uuid.UUID(entry.entryID.Bytes)
+
+	eventType := "EntryPosted"
+	if entry.kind == "VOID" {
+		eventType = "EntryVoided"
+		if !entry.originalEntryID.Valid {
+			log.Printf("Skipping void entry %s: no entry references it as voided_by", entryID)
+			return false, nil
+		}
+	}
+
+	eventID := uuid.UUID(entry.eventID.Bytes)
+	payload := entry.payload
+	if !entry.eventID.Valid {
+		var err error
+		payload, err = s.deriveEvent(ctx, entry)
+		if err != nil {
+			return false, err
+		}
+		eventID = uuid.NewSHA1(ledgerEventNamespace, []byte(eventType+":"+entryID.String()))
+	}
+
+	if err := apply(ctx, eventType, eventID, payload); err != nil {
+		return false, fmt.Errorf("apply %s for entry %s: %w", eventType, entryID, err)
+	}
+	return true, nil
+}
+
+// deriveEvent rebuilds the event payload of an entry from the journal
+func (s *LedgerSource) deriveEvent(ctx context.Context, entry ledgerEntry) ([]byte, error) {
+	entryID := uuid.UUID(entry.entryID.Bytes)
+	tsUnixMs := entry.ts.Time.UnixMilli()
+	effectiveAtUnixMs := entry.effectiveAt.Time.UnixMilli()
+
+	if entry.kind == "VOID" {
+		lotMovements, err := s.deriveLotMovements(ctx, uuid.UUID(entry.originalEntryID.Bytes), entryID)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(&ledgerv1.EntryVoided{
+			OriginalEntryId:   uuid.UUID(entry.originalEntryID.Bytes).String(),
+			VoidEntryId:       entryID.String(),
+			VoidReason:        entry.voidReason.String,
+			TsUnixMs:          tsUnixMs,
+			EffectiveAtUnixMs: effectiveAtUnixMs,
+			LotMovements:      lotMovements,
+		})
+	}
+
+	rows, err := s.db.Query(ctx, ledgerEntryLines, entryID.String())
+	if err != nil {
+		return nil, fmt.Errorf("list lines of entry %s: %w", entryID, err)
+	}
+	defer rows.Close()
+
+	event := &ledgerv1.EntryPosted{
+		EntryId:           entryID.String(),
+		BatchId:           uuid.UUID(entry.batchID.Bytes).String(),
+		TsUnixMs:          tsUnixMs,
+		EffectiveAtUnixMs: effectiveAtUnixMs,
+	}
+	for rows.Next() {
+		var accountID pgtype.UUID
+		var amountMinor int64
+		var currency, side string
+		if err := rows.Scan(&accountID, &amountMinor, &currency, &side); err != nil {
+			return nil, fmt.Errorf("scan line of entry %s: %w", entryID, err)
+		}
+
+		protoSide := ledgerv1.Side_DEBIT
+		if side == "CREDIT" {
+			protoSide = ledgerv1.Side_CREDIT
+		}
+		event.Lines = append(event.Lines, &ledgerv1.EntryLine{
+			AccountId: uuid.UUID(accountID.Bytes).String(),
+			Amount: &ledgerv1.Money{
+				Units:    amountMinor,
+				Currency: currency,
+			},
+			Side: protoSide,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	event.LotMovements, err = s.deriveLotMovements(ctx, entryID, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(event)
+}
+
+// deriveLotMovements rebuilds the credit lot movements of an entry from the
+// ledger's lot tables. For a posting, voidEntryID is uuid.Nil and the movements
+// are its grants, consumptions and expiries; for the void of entryID they are
+// the lots it revoked and the consumptions it gave back.
+func (s *LedgerSource) deriveLotMovements(ctx context.Context, entryID, voidEntryID uuid.UUID) ([]*ledgerv1.LotMovement, error) {
+	isVoid := voidEntryID != uuid.Nil
+
+	rows, err := s.db.Query(ctx, ledgerLotGrants, entryID.String(), isVoid)
+	if err != nil {
+		return nil, fmt.Errorf("list lots granted by entry %s: %w", entryID, err)
+	}
+	defer rows.Close()
+
+	var movements []*ledgerv1.LotMovement
+	for rows.Next() {
+		var lotID, accountID pgtype.UUID
+		var currency, source string
+		var grantedMinor, revokedMinor int64
+		var expiresAt pgtype.Timestamptz
+		if err := rows.Scan(&lotID, &accountID, &currency, &grantedMinor, &revokedMinor, &expiresAt, &source); err != nil {
+			return nil, fmt.Errorf("scan lot of entry %s: %w", entryID, err)
+		}
+
+		movement := &ledgerv1.LotMovement{
+			LotId:     uuid.UUID(lotID.Bytes).String(),
+			AccountId: uuid.UUID(accountID.Bytes).String(),
+			Amount:    &ledgerv1.Money{Units: grantedMinor, Currency: currency},
+			Kind:      ledgerv1.LotMovementKind_LOT_GRANTED,
+			Source:    source,
+		}
+		if isVoid {
+			movement.Amount.Units = revokedMinor
+			movement.Kind = ledgerv1.LotMovementKind_LOT_REVOKED
+			movement.Source = ""
+		} else if expiresAt.Valid {
+			movement.ExpiresAtUnixMs = expiresAt.Time.UnixMilli()
+		}
+		movements = append(movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	consumedBy := entryID
+	if isVoid {
+		consumedBy = voidEntryID
+	}
+	rows, err = s.db.Query(ctx, ledgerLotConsumptions, consumedBy.String(), isVoid)
+	if err != nil {
+		return nil, fmt.Errorf("list lot consumptions of entry %s: %w", consumedBy, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var lotID, accountID pgtype.UUID
+		var currency, reason string
+		var amountMinor int64
+		if err := rows.Scan(&lotID, &accountID, &currency, &amountMinor, &reason); err != nil {
+			return nil, fmt.Errorf("scan lot consumption of entry %s: %w", consumedBy, err)
+		}
+
+		kind := ledgerv1.LotMovementKind_LOT_CONSUMED
+		switch {
+		case isVoid:
+			kind = ledgerv1.LotMovementKind_LOT_RESTORED
+		case reason == "EXPIRY":
+			kind = ledgerv1.LotMovementKind_LOT_EXPIRED
+		}
+		movements = append(movements, &ledgerv1.LotMovement{
+			LotId:     uuid.UUID(lotID.Bytes).String(),
+			AccountId: uuid.UUID(accountID.Bytes).String(),
+			Amount:    &ledgerv1.Money{Units: amountMinor, Currency: currency},
+			Kind:      kind,
+		})
+	}
+	return movements, rows.Err()
+}
@@ -0,0 +1,55 @@
+package rebuild
+
+// swappedTables are the projections of ledger entry events, in lock order
+var swappedTables = []string{"balances", "statements", "credit_lots"}
+
+// Pairs each index of the shadow table ($1) with the live index ($2) on the same
+// columns, operator classes and predicate
+const matchingIndexes = `
+SELECT n.relname AS shadow_name, o.relname AS live_name
+FROM pg_index ni
+JOIN pg_class n ON n.oid = ni.indexrelid
+JOIN pg_index oi ON oi.indrelid = $2::regclass
+  AND oi.indkey::text = ni.indkey::text
+  AND oi.indclass::text = ni.indclass::text
+  AND oi.indoption::text = ni.indoption::text
+  AND oi.indisunique = ni.indisunique
+  AND oi.indisprimary = ni.indisprimary
+  AND COALESCE(pg_get_expr(oi.indpred, oi.indrelid), '') = COALESCE(pg_get_expr(ni.indpred, ni.indrelid), '')
+JOIN pg_class o ON o.oid = oi.indexrelid
+WHERE ni.indrelid = $1::regclass
+  AND n.relname <> o.relname
+`
+
+// Counts accounts, and accounts whose balance differs from the sum of their
+// statement lines, signed by the account's normal side, or from the running
+// balance of their last line
+const inconsistentBalances = `
+SELECT count(*),
+       count(*) FILTER (WHERE b.balance_minor IS DISTINCT FROM s.statement_minor
+                           OR b.balance_minor IS DISTINCT FROM s.last_after_minor)
+FROM balances b
+FULL OUTER JOIN (
+  SELECT account_id,
+         SUM(CASE WHEN side = COALESCE(a.normal_side, 'CREDIT') THEN amount_minor ELSE -amount_minor END) AS statement_minor,
+         (array_agg(balance_after_minor ORDER BY effective_at DESC, id DESC))[1] AS last_after_minor
+  FROM statements
+  LEFT JOIN accounts a USING (account_id)
+  GROUP BY account_id
+) s ON s.account_id = b.account_id
+`
+
+// Counts accounts whose rebuilt balance differs from the live one
+const driftedBalances = `
+SELECT count(*)
+FROM ` + ShadowSchema + `.balances r
+FULL OUTER JOIN public.balances l ON l.account_id = r.account_id
+WHERE r.balance_minor IS DISTINCT FROM l.balance_minor
+`
+
+// Counts debit-normal accounts. Accounts only ever change from the default
+// credit-normal to debit-normal, when their AccountCreated is projected, so an
+// unchanged count means no account was re-signed.
+const debitNormalAccounts = `
+SELECT count(*) FROM public.accounts WHERE normal_side = 'DEBIT'
+`
@@ -0,0 +1,337 @@
+// Package rebuild rebuilds the balances, statements and credit lot projections
+// from the ledger's event history into shadow tables, verifies them and swaps
+// them in while the service keeps serving reads and projecting live events.
+package rebuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/amirhf/credit-ledger/services/read-model/internal/projection"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// ShadowSchema holds the tables being rebuilt
+	ShadowSchema = "readmodel_rebuild"
+	// RetiredSchema keeps the replaced tables until the next rebuild
+	RetiredSchema = "readmodel_retired"
+)
+
+// ErrAccountsResigned is returned when a live AccountCreated turned an account
+// debit-normal during the rebuild. Shadow rows projected before it keep the old
+// sign, so the rebuild is refused; running it again replays with the final sign.
+var ErrAccountsResigned = errors.New("accounts turned debit-normal during the rebuild")
+
+// ApplyFunc applies one entry event to the shadow tables
+type ApplyFunc func(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error
+
+// Source replays the ledger entry event history
+type Source interface {
+	// Replay applies every event after the last one replayed by a previous call,
+	// up to the end of the history as of this call, and returns how many it applied
+	Replay(ctx context.Context, apply ApplyFunc) (int, error)
+}
+
+// Rebuilder rebuilds the read-model projections of ledger entry events
+type Rebuilder struct {
+	db     *pgxpool.Pool // read-model database, public schema
+	dbURL  string
+	source Source
+	logger *log.Logger
+
+	// Catch-up passes run until one applies fewer events than this, so the
+	// final pass under the swap lock is short
+	catchUpThreshold int
+	maxCatchUpPasses int
+}
+
+// NewRebuilder creates a rebuilder; dbURL is used to open the shadow schema connection pool
+func NewRebuilder(db *pgxpool.Pool, dbURL string, source Source, logger *log.Logger) *Rebuilder {
+	return &Rebuilder{
+		db:               db,
+		dbURL:            dbURL,
+		source:           source,
+		logger:           logger,
+		catchUpThreshold: 100,
+		maxCatchUpPasses: 10,
+	}
+}
+
+// Run replays the history into fresh shadow tables and verifies them. When swap
+// is true the shadow tables then replace the live ones; otherwise they are left
+// in ShadowSchema for inspection.
+func (r *Rebuilder) Run(ctx context.Context, swap bool) error {
+	// Only one rebuild may own the shadow schema at a time
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", ShadowSchema).Scan(&locked); err != nil {
+		return fmt.Errorf("acquire rebuild lock: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("another rebuild is in progress")
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", ShadowSchema)
+
+	if err := r.createShadowTables(ctx); err != nil {
+		return fmt.Errorf("create shadow tables: %w", err)
+	}
+	r.logger.Printf("Created shadow tables in schema %s", ShadowSchema)
+
+	shadow, err := r.openShadowPool(ctx)
+	if err != nil {
+		return err
+	}
+	defer shadow.Close()
+
+	// The shadow projector signs entries by the live accounts table
+	debitAccounts, err := countDebitAccounts(ctx, r.db)
+	if err != nil {
+		return err
+	}
+
+	projector := projection.NewProjector(shadow)
+	apply := func(ctx context.Context, eventType string, eventID uuid.UUID, payload []byte) error {
+		switch eventType {
+		case "EntryPosted":
+			return projector.ProcessEntryPosted(ctx, eventID, payload)
+		case "EntryVoided":
+			return projector.ProcessEntryVoided(ctx, eventID, payload)
+		default:
+			return nil
+		}
+	}
+
+	replayed, err := r.source.Replay(ctx, apply)
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	r.logger.Printf("Replayed %d events", replayed)
+
+	// Catch up with events published while replaying
+	for pass := 1; pass <= r.maxCatchUpPasses && replayed >= r.catchUpThreshold; pass++ {
+		replayed, err = r.source.Replay(ctx, apply)
+		if err != nil {
+			return fmt.Errorf("catch up: %w", err)
+		}
+		r.logger.Printf("Catch-up pass %d replayed %d events", pass, replayed)
+	}
+
+	if err := r.verify(ctx, shadow, debitAccounts); err != nil {
+		return err
+	}
+
+	if !swap {
+		r.logger.Printf("Shadow tables verified and left in schema %s", ShadowSchema)
+		return nil
+	}
+
+	if err := r.swap(ctx, apply, debitAccounts); err != nil {
+		return fmt.Errorf("swap: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, "DROP SCHEMA IF EXISTS "+ShadowSchema+" CASCADE"); err != nil {
+		r.logger.Printf("Failed to drop schema %s: %v", ShadowSchema, err)
+	}
+	r.logger.Printf("Swapped in rebuilt tables; previous tables kept in schema %s", RetiredSchema)
+	return nil
+}
+
+// createShadowTables recreates ShadowSchema with empty copies of the projection
+// tables. The copies get their own statement ID sequence and the index names of
+// the live tables, so they are indistinguishable once swapped in.
+func (r *Rebuilder) createShadowTables(ctx context.Context) error {
+	statements := []string{
+		"DROP SCHEMA IF EXISTS " + ShadowSchema + " CASCADE",
+		"CREATE SCHEMA " + ShadowSchema,
+		"CREATE TABLE " + ShadowSchema + ".balances (LIKE public.balances INCLUDING ALL)",
+		"CREATE TABLE " + ShadowSchema + ".statements (LIKE public.statements INCLUDING ALL)",
+		"CREATE TABLE " + ShadowSchema + ".credit_lots (LIKE public.credit_lots INCLUDING ALL)",
+		"CREATE TABLE " + ShadowSchema + ".event_dedup (LIKE public.event_dedup INCLUDING ALL)",
+		"CREATE SEQUENCE " + ShadowSchema + ".statements_id_seq OWNED BY " + ShadowSchema + ".statements.id",
+		"ALTER TABLE " + ShadowSchema + ".statements ALTER COLUMN id SET DEFAULT nextval('" + ShadowSchema + ".statements_id_seq')",
+	}
+	for _, stmt := range statements {
+		if _, err := r.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+
+	for _, table := range swappedTables {
+		if err := r.copyIndexNames(ctx, table); err != nil {
+			return fmt.Errorf("name indexes of %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+type indexRename struct {
+	Shadow string
+	Live   string
+}
+
+// copyIndexNames renames the shadow table's indexes to the names of the matching live indexes
+func (r *Rebuilder) copyIndexNames(ctx context.Context, table string) error {
+	rows, err := r.db.Query(ctx, matchingIndexes, ShadowSchema+"."+table, "public."+table)
+	if err != nil {
+		return err
+	}
+	renames, err := pgx.CollectRows(rows, pgx.RowToStructByPos[indexRename])
+	if err != nil {
+		return err
+	}
+
+	// Rename through temporary names so a target name never collides with a pending one
+	for i, rename := range renames {
+		stmt := fmt.Sprintf("ALTER INDEX %s.%s RENAME TO rebuild_index_%d", ShadowSchema, pgx.Identifier{rename.Shadow}.Sanitize(), i)
+		if _, err := r.db.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	for i, rename := range renames {
+		stmt := fmt.Sprintf("ALTER INDEX %s.rebuild_index_%d RENAME TO %s", ShadowSchema, i, pgx.Identifier{rename.Live}.Sanitize())
+		if _, err := r.db.Exec(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openShadowPool opens a pool whose unqualified table names resolve to ShadowSchema,
+// so the projector's queries write to the shadow tables. Tables not rebuilt, such
+// as accounts, fall through to public.
+func (r *Rebuilder) openShadowPool(ctx context.Context) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(r.dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse database URL: %w", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = ShadowSchema + ",public"
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("connect shadow pool: %w", err)
+	}
+	return pool, nil
+}
+
+// verify checks that every shadow balance agrees with its statement lines and
+// that no account was re-signed since the replay started, and reports how far
+// the live tables had drifted
+func (r *Rebuilder) verify(ctx context.Context, shadow *pgxpool.Pool, debitAccounts int64) error {
+	if err := checkAccounts(ctx, r.db, debitAccounts); err != nil {
+		return fmt.Errorf("verify shadow tables: %w", err)
+	}
+
+	var accounts, inconsistent int64
+	if err := shadow.QueryRow(ctx, inconsistentBalances).Scan(&accounts, &inconsistent); err != nil {
+		return fmt.Errorf("verify shadow tables: %w", err)
+	}
+	if inconsistent > 0 {
+		return fmt.Errorf("verify shadow tables: %d of %d accounts have a balance that disagrees with their statements", inconsistent, accounts)
+	}
+
+	var drifted int64
+	if err := r.db.QueryRow(ctx, driftedBalances).Scan(&drifted); err != nil {
+		return fmt.Errorf("compare with live tables: %w", err)
+	}
+	r.logger.Printf("Verified %d rebuilt balances; %d differ from the live tables", accounts, drifted)
+	return nil
+}
+
+// swap replaces the live tables with the shadow tables in one transaction.
+// Live projection writes are blocked while a final catch-up runs, reads only
+// for the instant of the swap itself. The shadow event_dedup rows are merged
+// into the live table so the live consumer skips events the rebuild applied.
+// The swap is refused if an account was re-signed since the replay started.
+func (r *Rebuilder) swap(ctx context.Context, apply ApplyFunc, debitAccounts int64) error {
+	for _, stmt := range []string{
+		"DROP SCHEMA IF EXISTS " + RetiredSchema + " CASCADE",
+		"CREATE SCHEMA " + RetiredSchema,
+	} {
+		if _, err := r.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '30s'"); err != nil {
+		return err
+	}
+	// EXCLUSIVE blocks projection writes but not reads; same table order as the projector
+	if _, err := tx.Exec(ctx, "LOCK TABLE public.event_dedup, public.balances, public.statements, public.credit_lots IN EXCLUSIVE MODE"); err != nil {
+		return fmt.Errorf("lock live tables: %w", err)
+	}
+
+	replayed, err := r.source.Replay(ctx, apply)
+	if err != nil {
+		return fmt.Errorf("final catch-up: %w", err)
+	}
+	r.logger.Printf("Final catch-up replayed %d events", replayed)
+
+	// A live AccountCreated claims its event in event_dedup before re-signing,
+	// so the lock above holds further changes back until the swap commits
+	if err := checkAccounts(ctx, tx, debitAccounts); err != nil {
+		return err
+	}
+
+	statements := []string{
+		"INSERT INTO public.event_dedup SELECT * FROM " + ShadowSchema + ".event_dedup ON CONFLICT (event_id) DO NOTHING",
+	}
+	for _, table := range swappedTables {
+		statements = append(statements, "ALTER TABLE public."+table+" SET SCHEMA "+RetiredSchema)
+	}
+	for _, table := range swappedTables {
+		statements = append(statements, "ALTER TABLE "+ShadowSchema+"."+table+" SET SCHEMA public")
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", stmt, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// rowQuerier is satisfied by pools and transactions
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// countDebitAccounts returns how many live accounts are debit-normal
+func countDebitAccounts(ctx context.Context, q rowQuerier) (int64, error) {
+	var count int64
+	if err := q.QueryRow(ctx, debitNormalAccounts).Scan(&count); err != nil {
+		return 0, fmt.Errorf("count debit-normal accounts: %w", err)
+	}
+	return count, nil
+}
+
+// checkAccounts returns ErrAccountsResigned if the number of debit-normal
+// accounts is no longer debitAccounts
+func checkAccounts(ctx context.Context, q rowQuerier, debitAccounts int64) error {
+	count, err := countDebitAccounts(ctx, q)
+	if err != nil {
+		return err
+	}
+	if count != debitAccounts {
+		return fmt.Errorf("%w: %d debit-normal accounts when the replay started, %d now", ErrAccountsResigned, debitAccounts, count)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package rebuild
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	ledgerv1 "github.com/amirhf/credit-ledger/proto/gen/go/ledger/v1"
+	"github.com/amirhf/credit-ledger/services/common/migrate"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/projection"
+	"github.com/amirhf/credit-ledger/services/read-model/internal/store"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/lib/pq"
+	"google.golang.org/protobuf/proto"
+)
+
+type countRow struct {
+	count int64
+}
+
+func (r countRow) Scan(dest ...any) error {
+	*dest[0].(*int64) = r.count
+	return nil
+}
+
+type countQuerier struct {
+	count int64
+}
+
+func (q countQuerier) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return countRow{count: q.count}
+}
+
+func TestCheckAccounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  int64
+		now     int64
+		wantErr bool
+	}{
+		{name: "unchanged", before: 2, now: 2},
+		{name: "account turned debit-normal", before: 2, now: 3, wantErr: true},
+		{name: "first debit-normal account", before: 0, now: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAccounts(context.Background(), countQuerier{count: tt.now}, tt.before)
+			if got := errors.Is(err, ErrAccountsResigned); got != tt.wantErr {
+				t.Fatalf("checkAccounts error = %v, want ErrAccountsResigned: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testDatabase returns a pool on a freshly migrated scratch database. The
+// database tests are skipped unless READMODEL_TEST_DATABASE_URL is set; every
+// table in it is dropped.
+func testDatabase(t *testing.T) (*pgxpool.Pool, string) {
+	t.Helper()
+	dbURL := os.Getenv("READMODEL_TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("READMODEL_TEST_DATABASE_URL not set, skipping database test")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		"DROP SCHEMA IF EXISTS " + ShadowSchema + " CASCADE",
+		"DROP SCHEMA IF EXISTS " + RetiredSchema + " CASCADE",
+		"DROP SCHEMA public CASCADE",
+		"CREATE SCHEMA public",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if err := migrate.RunMigrations(db, store.MigrationsFS, "readmodel"); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := pgxpool.New(context.Background(), dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool, dbURL
+}
+
+type testEvent struct {
+	id      uuid.UUID
+	payload []byte
+}
+
+// testSource replays its events once; afterFirst runs after the first replay
+type testSource struct {
+	events     []testEvent
+	next       int
+	afterFirst func()
+}
+
+func (s *testSource) Replay(ctx context.Context, apply ApplyFunc) (int, error) {
+	first := s.next == 0
+	replayed := 0
+	for ; s.next < len(s.events); s.next++ {
+		event := s.events[s.next]
+		if err := apply(ctx, "EntryPosted", event.id, event.payload); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	if first && s.afterFirst != nil {
+		s.afterFirst()
+	}
+	return replayed, nil
+}
+
+// entryPosted returns an EntryPosted event moving amountMinor USD from debit to credit
+func entryPosted(t *testing.T, debit, credit uuid.UUID, amountMinor int64) testEvent {
+	t.Helper()
+	payload, err := proto.Marshal(&ledgerv1.EntryPosted{
+		EntryId: uuid.NewString(),
+		BatchId: uuid.NewString(),
+		Lines: []*ledgerv1.EntryLine{
+			{AccountId: debit.String(), Amount: &ledgerv1.Money{Units: amountMinor, Currency: "USD"}, Side: ledgerv1.Side_DEBIT},
+			{AccountId: credit.String(), Amount: &ledgerv1.Money{Units: amountMinor, Currency: "USD"}, Side: ledgerv1.Side_CREDIT},
+		},
+		TsUnixMs: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("marshal EntryPosted: %v", err)
+	}
+	return testEvent{id: uuid.New(), payload: payload}
+}
+
+func balanceOf(t *testing.T, pool *pgxpool.Pool, accountID uuid.UUID) int64 {
+	t.Helper()
+	var balance int64
+	if err := pool.QueryRow(context.Background(), "SELECT balance_minor FROM public.balances WHERE account_id = $1", accountID).Scan(&balance); err != nil {
+		t.Fatalf("get balance of %s: %v", accountID, err)
+	}
+	return balance
+}
+
+func TestRun_SwapsVerifiedTables(t *testing.T) {
+	pool, dbURL := testDatabase(t)
+	ctx := context.Background()
+
+	customer, merchant := uuid.New(), uuid.New()
+	source := &testSource{events: []testEvent{
+		entryPosted(t, customer, merchant, 500),
+		entryPosted(t, customer, merchant, 200),
+	}}
+
+	// The live tables only saw the first entry
+	if err := projection.NewProjector(pool).ProcessEntryPosted(ctx, source.events[0].id, source.events[0].payload); err != nil {
+		t.Fatalf("project live entry: %v", err)
+	}
+
+	if err := NewRebuilder(pool, dbURL, source, log.Default()).Run(ctx, true); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if got := balanceOf(t, pool, merchant); got != 700 {
+		t.Errorf("merchant balance = %d, want 700", got)
+	}
+	if got := balanceOf(t, pool, customer); got != -700 {
+		t.Errorf("customer balance = %d, want -700", got)
+	}
+
+	var retired bool
+	if err := pool.QueryRow(ctx, "SELECT to_regclass($1) IS NOT NULL", RetiredSchema+".balances").Scan(&retired); err != nil {
+		t.Fatalf("check retired tables: %v", err)
+	}
+	if !retired {
+		t.Error("expected the replaced tables to be kept in the retired schema")
+	}
+}
+
+func TestRun_RefusesSwapAfterAccountResigned(t *testing.T) {
+	pool, dbURL := testDatabase(t)
+	ctx := context.Background()
+
+	wallet, revenue := uuid.New(), uuid.New()
+	event := entryPosted(t, wallet, revenue, 500)
+	live := projection.NewProjector(pool)
+	if err := live.ProcessEntryPosted(ctx, event.id, event.payload); err != nil {
+		t.Fatalf("project live entry: %v", err)
+	}
+
+	// The wallet's AccountCreated arrives after the rebuild replayed its entry
+	// with the default credit-normal sign
+	source := &testSource{
+		events: []testEvent{event},
+		afterFirst: func() {
+			err := live.ProcessAccountCreated(ctx, uuid.New(), &ledgerv1.AccountCreated{
+				AccountId:   wallet.String(),
+				Currency:    "USD",
+				AccountType: ledgerv1.AccountType_ACCOUNT_TYPE_ASSET,
+				TsUnixMs:    time.Now().UnixMilli(),
+			})
+			if err != nil {
+				t.Errorf("project AccountCreated: %v", err)
+			}
+		},
+	}
+
+	err := NewRebuilder(pool, dbURL, source, log.Default()).Run(ctx, true)
+	if !errors.Is(err, ErrAccountsResigned) {
+		t.Fatalf("Run error = %v, want ErrAccountsResigned", err)
+	}
+
+	// The live tables keep the re-signed balance
+	if got := balanceOf(t, pool, wallet); got != 500 {
+		t.Errorf("wallet balance = %d, want 500", got)
+	}
+}
@@ -0,0 +1,5 @@
+-- Rollback statement void tracking
+DROP INDEX IF EXISTS idx_statements_voided_by;
+ALTER TABLE statements
+  DROP COLUMN IF EXISTS voided_at,
+  DROP COLUMN IF EXISTS voided_by;
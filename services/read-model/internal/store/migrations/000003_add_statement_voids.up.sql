@@ -0,0 +1,12 @@
+-- Track voided statement lines
+-- Populated by EntryVoided events: the original entry's lines are marked voided
+-- and linked to the compensating void entry, whose reversed lines are appended
+
+ALTER TABLE statements
+  ADD COLUMN voided_by UUID,
+  ADD COLUMN voided_at TIMESTAMPTZ;
+
+CREATE INDEX idx_statements_voided_by ON statements(voided_by) WHERE voided_by IS NOT NULL;
+
+COMMENT ON COLUMN statements.voided_by IS 'Entry ID of the void entry that reversed this line';
+COMMENT ON COLUMN statements.voided_at IS 'Timestamp of the void entry';
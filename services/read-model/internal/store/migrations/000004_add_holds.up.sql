@@ -0,0 +1,18 @@
+-- Add holds table to read-model for computing available balances
+-- Populated by HoldPlaced, HoldCaptured and HoldReleased events from ledger.hold.v1
+
+CREATE TABLE holds (
+    id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    currency TEXT NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    captured_minor BIGINT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_holds_account_pending ON holds(account_id) WHERE status = 'PENDING';
+
+COMMENT ON COLUMN holds.captured_minor IS 'Amount captured so far; amount_minor - captured_minor is still reserved while PENDING';
@@ -0,0 +1,14 @@
+-- Add transfer legs to read-model: every debit and credit of a transfer or multi-leg posting
+-- Populated from the legs carried by TransferInitiated events
+
+CREATE TABLE transfer_legs (
+    transfer_id UUID NOT NULL,
+    leg_index INT NOT NULL,
+    account_id UUID NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency TEXT NOT NULL,
+    side TEXT NOT NULL CHECK (side IN ('DEBIT', 'CREDIT')),
+    PRIMARY KEY (transfer_id, leg_index)
+);
+
+CREATE INDEX idx_transfer_legs_account ON transfer_legs(account_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transfers_refund_of;
+ALTER TABLE transfers DROP COLUMN IF EXISTS refunded_minor;
+ALTER TABLE transfers DROP COLUMN IF EXISTS refund_of;
@@ -0,0 +1,7 @@
+-- Add refunds to read-model: a refund is a transfer linked to the original it reverses
+-- Populated from TransferRefunded events
+
+ALTER TABLE transfers ADD COLUMN refund_of UUID;
+ALTER TABLE transfers ADD COLUMN refunded_minor BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_transfers_refund_of ON transfers(refund_of) WHERE refund_of IS NOT NULL;
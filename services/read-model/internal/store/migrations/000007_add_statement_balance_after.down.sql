@@ -0,0 +1 @@
+ALTER TABLE statements DROP COLUMN IF EXISTS balance_after_minor;
@@ -0,0 +1,19 @@
+-- Running balance on statement lines: the account balance after each line, in (ts, id) order
+-- Answers point-in-time balance queries from the last line at or before a given time
+
+ALTER TABLE statements ADD COLUMN balance_after_minor BIGINT;
+
+-- Backfill existing lines: CREDIT increases the balance, DEBIT decreases it
+UPDATE statements s
+SET balance_after_minor = r.running_minor
+FROM (
+    SELECT id,
+           SUM(CASE WHEN side = 'CREDIT' THEN amount_minor ELSE -amount_minor END)
+               OVER (PARTITION BY account_id ORDER BY ts, id) AS running_minor
+    FROM statements
+) r
+WHERE s.id = r.id;
+
+ALTER TABLE statements ALTER COLUMN balance_after_minor SET NOT NULL;
+
+COMMENT ON COLUMN statements.balance_after_minor IS 'Account balance after this line, ordered by ts then id';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS parked_events;
+DROP TABLE IF EXISTS aggregate_sequences;
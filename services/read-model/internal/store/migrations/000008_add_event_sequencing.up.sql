@@ -0,0 +1,21 @@
+-- Per-aggregate event ordering: the last aggregate_seq applied for each
+-- aggregate, and events that arrived ahead of a missing earlier one
+
+CREATE TABLE IF NOT EXISTS aggregate_sequences (
+    aggregate_id UUID PRIMARY KEY,
+    last_seq BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS parked_events (
+    aggregate_id UUID NOT NULL,
+    seq BIGINT NOT NULL,
+    topic TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    event_id UUID NOT NULL,
+    payload BYTEA NOT NULL,
+    parked_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (aggregate_id, seq)
+);
+
+CREATE INDEX IF NOT EXISTS idx_parked_events_parked_at ON parked_events(parked_at);
@@ -0,0 +1,13 @@
+-- Chart of accounts: each account's type and the side on which its balance
+-- increases, so balances are signed per account rather than by one convention
+
+CREATE TABLE IF NOT EXISTS accounts (
+    account_id UUID PRIMARY KEY,
+    account_type TEXT CHECK (account_type IN ('ASSET', 'LIABILITY', 'EQUITY', 'REVENUE', 'EXPENSE')),
+    normal_side TEXT NOT NULL DEFAULT 'CREDIT' CHECK (normal_side IN ('DEBIT', 'CREDIT')),
+    currency TEXT,
+    created_at TIMESTAMPTZ
+);
+
+COMMENT ON COLUMN accounts.account_type IS 'NULL until the account''s AccountCreated is projected';
+COMMENT ON COLUMN accounts.normal_side IS 'Side on which the balance increases: DEBIT for ASSET and EXPENSE, CREDIT otherwise';
@@ -0,0 +1,17 @@
+-- Remove statement effective dates; running balances revert to (ts, id) order
+DROP INDEX IF EXISTS idx_statements_account_effective_at;
+CREATE INDEX IF NOT EXISTS idx_statements_account_ts ON statements(account_id, ts DESC);
+ALTER TABLE statements DROP COLUMN IF EXISTS effective_at;
+
+UPDATE statements s
+SET balance_after_minor = r.running_minor
+FROM (
+    SELECT st.id,
+           SUM(CASE WHEN st.side = COALESCE(a.normal_side, 'CREDIT') THEN st.amount_minor ELSE -st.amount_minor END)
+               OVER (PARTITION BY st.account_id ORDER BY st.ts, st.id) AS running_minor
+    FROM statements st
+    LEFT JOIN accounts a USING (account_id)
+) r
+WHERE s.id = r.id;
+
+COMMENT ON COLUMN statements.balance_after_minor IS 'Account balance after this line, ordered by ts then id';
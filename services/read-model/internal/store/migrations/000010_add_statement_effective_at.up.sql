@@ -0,0 +1,17 @@
+-- Effective (value) date on statement lines, from the entry's effective_at.
+-- Statements and running balances are ordered by effective date, so a late-
+-- arriving entry is slotted in at the date it took effect; ts stays the booking time.
+
+ALTER TABLE statements ADD COLUMN IF NOT EXISTS effective_at TIMESTAMPTZ;
+
+-- Lines projected before value dating took effect when they were booked, so the
+-- running balances ordered by (ts, id) are already in effective order
+UPDATE statements SET effective_at = ts WHERE effective_at IS NULL;
+
+ALTER TABLE statements ALTER COLUMN effective_at SET NOT NULL;
+
+DROP INDEX IF EXISTS idx_statements_account_ts;
+CREATE INDEX IF NOT EXISTS idx_statements_account_effective_at ON statements(account_id, effective_at DESC, id DESC);
+
+COMMENT ON COLUMN statements.effective_at IS 'Value date of the entry; statements and running balances are ordered by effective_at then id';
+COMMENT ON COLUMN statements.balance_after_minor IS 'Account balance after this line, ordered by effective_at then id';
@@ -0,0 +1,26 @@
+-- Credit lots: grants of credit that debits draw down and that may expire.
+-- Populated by the lot movements on EntryPosted and EntryVoided events. Movements
+-- are applied as deltas, so a lot drawn down before its grant is projected
+-- (events of different entries arrive in any order) comes out right.
+
+CREATE TABLE IF NOT EXISTS credit_lots (
+    lot_id UUID PRIMARY KEY,
+    account_id UUID NOT NULL,
+    currency TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    granted_minor BIGINT NOT NULL DEFAULT 0,
+    remaining_minor BIGINT NOT NULL DEFAULT 0,
+    expired_minor BIGINT NOT NULL DEFAULT 0,
+    granted_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_credit_lots_account ON credit_lots(account_id);
+CREATE INDEX IF NOT EXISTS idx_credit_lots_expiring ON credit_lots(account_id, expires_at) WHERE remaining_minor > 0;
+
+COMMENT ON COLUMN credit_lots.remaining_minor IS 'Granted amount not yet consumed, expired or revoked';
+COMMENT ON COLUMN credit_lots.granted_at IS 'Effective date of the granting entry; NULL until the grant is projected';
+COMMENT ON COLUMN credit_lots.expires_at IS 'When the remainder lapses to breakage; NULL never expires';
+COMMENT ON COLUMN credit_lots.revoked_at IS 'Set when the granting entry was voided';
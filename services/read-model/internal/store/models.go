@@ -8,6 +8,22 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type Account struct {
+	AccountID pgtype.UUID
+	// NULL until the account's AccountCreated is projected
+	AccountType pgtype.Text
+	// Side on which the balance increases: DEBIT for ASSET and EXPENSE, CREDIT otherwise
+	NormalSide string
+	Currency   pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+}
+
+type AggregateSequence struct {
+	AggregateID pgtype.UUID
+	LastSeq     int64
+	UpdatedAt   pgtype.Timestamptz
+}
+
 type Balance struct {
 	AccountID    pgtype.UUID
 	Currency     string
@@ -15,11 +31,52 @@ type Balance struct {
 	UpdatedAt    pgtype.Timestamptz
 }
 
+type CreditLot struct {
+	LotID        pgtype.UUID
+	AccountID    pgtype.UUID
+	Currency     string
+	Source       string
+	GrantedMinor int64
+	// Granted amount not yet consumed, expired or revoked
+	RemainingMinor int64
+	ExpiredMinor   int64
+	// Effective date of the granting entry; NULL until the grant is projected
+	GrantedAt pgtype.Timestamptz
+	// When the remainder lapses to breakage; NULL never expires
+	ExpiresAt pgtype.Timestamptz
+	// Set when the granting entry was voided
+	RevokedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
 type EventDedup struct {
 	EventID     pgtype.UUID
 	ProcessedAt pgtype.Timestamptz
 }
 
+type Hold struct {
+	ID          pgtype.UUID
+	AccountID   pgtype.UUID
+	Currency    string
+	AmountMinor int64
+	// Amount captured so far; amount_minor - captured_minor is still reserved while PENDING
+	CapturedMinor int64
+	Status        string
+	ExpiresAt     pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+	UpdatedAt     pgtype.Timestamptz
+}
+
+type ParkedEvent struct {
+	AggregateID pgtype.UUID
+	Seq         int64
+	Topic       string
+	EventType   string
+	EventID     pgtype.UUID
+	Payload     []byte
+	ParkedAt    pgtype.Timestamptz
+}
+
 type Statement struct {
 	ID          int64
 	AccountID   pgtype.UUID
@@ -27,6 +84,14 @@ type Statement struct {
 	AmountMinor int64
 	Side        string
 	Ts          pgtype.Timestamptz
+	// Entry ID of the void entry that reversed this line
+	VoidedBy pgtype.UUID
+	// Timestamp of the void entry
+	VoidedAt pgtype.Timestamptz
+	// Account balance after this line, ordered by effective_at then id
+	BalanceAfterMinor int64
+	// Value date of the entry; statements and running balances are ordered by effective_at then id
+	EffectiveAt pgtype.Timestamptz
 }
 
 type Transfer struct {
@@ -39,4 +104,15 @@ type Transfer struct {
 	IdempotencyKey string
 	CreatedAt      pgtype.Timestamptz
 	UpdatedAt      pgtype.Timestamptz
+	RefundOf       pgtype.UUID
+	RefundedMinor  int64
+}
+
+type TransferLeg struct {
+	TransferID  pgtype.UUID
+	LegIndex    int32
+	AccountID   pgtype.UUID
+	AmountMinor int64
+	Currency    string
+	Side        string
 }
@@ -22,23 +22,58 @@ SET balance_minor = EXCLUDED.balance_minor,
 -- Statement Queries
 
 -- name: CreateStatement :exec
-INSERT INTO statements (account_id, entry_id, amount_minor, side, ts)
-VALUES ($1, $2, $3, $4, $5);
+INSERT INTO statements (account_id, entry_id, amount_minor, side, ts, balance_after_minor, effective_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
 
--- name: GetStatements :many
-SELECT id, account_id, entry_id, amount_minor, side, ts
+-- name: GetBalanceAsOf :one
+-- Balance after the last line effective at or before the given time
+SELECT balance_after_minor
 FROM statements
 WHERE account_id = $1
-  AND ts >= $2
-  AND ts <= $3
-ORDER BY ts DESC;
+  AND effective_at <= $2
+ORDER BY effective_at DESC, id DESC
+LIMIT 1;
 
--- name: GetStatementsByAccount :many
-SELECT id, account_id, entry_id, amount_minor, side, ts
+-- name: ShiftRunningBalances :exec
+-- Adds a line projected out of order to the running balance of every line effective after it
+UPDATE statements
+SET balance_after_minor = balance_after_minor + sqlc.arg('delta')::bigint
+WHERE account_id = sqlc.arg('account_id')
+  AND effective_at > sqlc.arg('effective_at');
+
+-- name: ListStatements :many
+-- Newest effective date first; after_ts/after_id continue below the last line of the previous page
+SELECT id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at
 FROM statements
-WHERE account_id = $1
-ORDER BY ts DESC
-LIMIT $2;
+WHERE account_id = sqlc.arg('account_id')
+  AND (sqlc.narg('from_ts')::timestamptz IS NULL OR effective_at >= sqlc.narg('from_ts'))
+  AND (sqlc.narg('to_ts')::timestamptz IS NULL OR effective_at <= sqlc.narg('to_ts'))
+  AND (sqlc.narg('after_ts')::timestamptz IS NULL OR (effective_at, id) < (sqlc.narg('after_ts'), sqlc.narg('after_id')::bigint))
+ORDER BY effective_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListStatementsPrev :many
+-- Oldest effective date first from just above before_ts/before_id; callers reverse the page
+SELECT id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at
+FROM statements
+WHERE account_id = sqlc.arg('account_id')
+  AND (sqlc.narg('from_ts')::timestamptz IS NULL OR effective_at >= sqlc.narg('from_ts'))
+  AND (sqlc.narg('to_ts')::timestamptz IS NULL OR effective_at <= sqlc.narg('to_ts'))
+  AND (effective_at, id) > (sqlc.arg('before_ts')::timestamptz, sqlc.arg('before_id')::bigint)
+ORDER BY effective_at ASC, id ASC
+LIMIT sqlc.arg('limit');
+
+-- name: CountStatementsByEntry :one
+SELECT COUNT(*) FROM statements
+WHERE entry_id = $1;
+
+-- name: VoidStatementsByEntry :many
+-- Only returns lines that were not voided yet, so replays reverse nothing
+UPDATE statements
+SET voided_by = $2, voided_at = $3
+WHERE entry_id = $1
+  AND voided_by IS NULL
+RETURNING id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at;
 
 -- Event Deduplication Queries
 
@@ -50,6 +85,12 @@ INSERT INTO event_dedup (event_id, processed_at)
 VALUES ($1, now())
 ON CONFLICT (event_id) DO NOTHING;
 
+-- name: ClaimEvent :execrows
+-- Returns 0 when the event was already processed; blocks while another transaction holds the claim
+INSERT INTO event_dedup (event_id, processed_at)
+VALUES ($1, now())
+ON CONFLICT (event_id) DO NOTHING;
+
 -- name: CleanupOldEvents :exec
 DELETE FROM event_dedup
 WHERE processed_at < $1;
@@ -61,26 +102,64 @@ INSERT INTO transfers (id, from_account_id, to_account_id, amount_minor, currenc
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now())
 ON CONFLICT (id) DO NOTHING;
 
+-- name: CreateTransferLeg :exec
+INSERT INTO transfer_legs (transfer_id, leg_index, account_id, amount_minor, currency, side)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (transfer_id, leg_index) DO NOTHING;
+
+-- name: ListTransferLegs :many
+SELECT transfer_id, leg_index, account_id, amount_minor, currency, side
+FROM transfer_legs
+WHERE transfer_id = ANY($1::uuid[])
+ORDER BY transfer_id, leg_index;
+
 -- name: UpdateTransferStatus :exec
 UPDATE transfers
 SET status = $2, updated_at = now()
 WHERE id = $1;
 
+-- name: UpdateTransferRefunded :execrows
+-- Returns 0 when the original transfer has not been projected yet
+UPDATE transfers
+SET refunded_minor = GREATEST(refunded_minor, sqlc.arg('refunded_minor')::bigint), updated_at = now()
+WHERE id = sqlc.arg('id');
+
+-- name: SetTransferRefundOf :execrows
+-- Returns 0 when the refund transfer has not been projected yet
+UPDATE transfers
+SET refund_of = $2, updated_at = now()
+WHERE id = $1;
+
 -- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
 FROM transfers
 WHERE id = $1;
 
 -- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at
+-- Newest first; after_created_at/after_id continue below the last transfer of the previous page
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
+FROM transfers
+WHERE 
+  (sqlc.narg('from_account_id')::uuid IS NULL OR from_account_id = sqlc.narg('from_account_id')) AND
+  (sqlc.narg('to_account_id')::uuid IS NULL OR to_account_id = sqlc.narg('to_account_id')) AND
+  (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')) AND
+  (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency')) AND
+  (sqlc.narg('after_created_at')::timestamptz IS NULL OR (created_at, id) < (sqlc.narg('after_created_at'), sqlc.narg('after_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransfersPrev :many
+-- Oldest first from just above before_created_at/before_id; callers reverse the page
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
 FROM transfers
 WHERE 
   (sqlc.narg('from_account_id')::uuid IS NULL OR from_account_id = sqlc.narg('from_account_id')) AND
   (sqlc.narg('to_account_id')::uuid IS NULL OR to_account_id = sqlc.narg('to_account_id')) AND
   (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')) AND
-  (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'))
-ORDER BY created_at DESC
-LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+  (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency')) AND
+  (created_at, id) > (sqlc.arg('before_created_at')::timestamptz, sqlc.arg('before_id')::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('limit');
 
 -- name: CountTransfers :one
 SELECT COUNT(*) FROM transfers
@@ -89,3 +168,152 @@ WHERE
   (sqlc.narg('to_account_id')::uuid IS NULL OR to_account_id = sqlc.narg('to_account_id')) AND
   (sqlc.narg('status')::text IS NULL OR status = sqlc.narg('status')) AND
   (sqlc.narg('currency')::text IS NULL OR currency = sqlc.narg('currency'));
+
+-- Hold Queries
+
+-- name: CreateHold :exec
+INSERT INTO holds (id, account_id, currency, amount_minor, status, expires_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 'PENDING', $5, $6, now())
+ON CONFLICT (id) DO NOTHING;
+
+-- name: UpdateHoldCaptured :execrows
+-- Returns 0 when the hold has not been projected yet or is no longer pending
+UPDATE holds
+SET captured_minor = amount_minor - sqlc.arg(remaining_minor)::bigint,
+    status = sqlc.arg(status),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'PENDING';
+
+-- name: UpdateHoldReleased :execrows
+-- Returns 0 when the hold has not been projected yet or is no longer pending
+UPDATE holds
+SET status = $2, updated_at = now()
+WHERE id = $1
+  AND status = 'PENDING';
+
+-- name: HoldExists :one
+SELECT EXISTS(SELECT 1 FROM holds WHERE id = $1);
+
+-- name: SumPendingHolds :one
+SELECT COALESCE(SUM(amount_minor - captured_minor), 0)::bigint AS held_minor
+FROM holds
+WHERE account_id = $1
+  AND status = 'PENDING'
+  AND expires_at > now();
+
+-- Event Sequencing Queries
+
+-- name: GetAggregateSeq :one
+SELECT last_seq FROM aggregate_sequences WHERE aggregate_id = $1;
+
+-- name: AdvanceAggregateSeq :exec
+INSERT INTO aggregate_sequences (aggregate_id, last_seq, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (aggregate_id) DO UPDATE
+SET last_seq = GREATEST(aggregate_sequences.last_seq, EXCLUDED.last_seq),
+    updated_at = now();
+
+-- name: ParkEvent :exec
+INSERT INTO parked_events (aggregate_id, seq, topic, event_type, event_id, payload, parked_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (aggregate_id, seq) DO NOTHING;
+
+-- name: GetParkedEvent :one
+SELECT aggregate_id, seq, topic, event_type, event_id, payload, parked_at
+FROM parked_events
+WHERE aggregate_id = $1 AND seq = $2;
+
+-- name: DeleteParkedEvent :exec
+DELETE FROM parked_events
+WHERE aggregate_id = $1 AND seq = $2;
+
+-- name: ListStalledAggregates :many
+-- Aggregates whose first parked event is next in sequence, or was parked before the cutoff
+SELECT p.aggregate_id, min(p.seq)::bigint AS first_seq, COALESCE(max(s.last_seq), 0)::bigint AS last_seq
+FROM parked_events p
+LEFT JOIN aggregate_sequences s ON s.aggregate_id = p.aggregate_id
+GROUP BY p.aggregate_id
+HAVING min(p.seq) <= COALESCE(max(s.last_seq), 0) + 1
+    OR min(p.parked_at) < sqlc.arg(parked_before)::timestamptz
+ORDER BY min(p.parked_at)
+LIMIT sqlc.arg(max_aggregates);
+
+-- name: CountParkedEvents :one
+SELECT count(*) FROM parked_events;
+
+-- Account Queries
+
+-- name: EnsureAccount :exec
+-- Accounts seen in entries before their AccountCreated get a credit-normal placeholder
+INSERT INTO accounts (account_id)
+VALUES ($1)
+ON CONFLICT (account_id) DO NOTHING;
+
+-- name: GetAccountNormalSide :one
+-- Locks the account so its normal side cannot change until the caller commits
+SELECT normal_side FROM accounts WHERE account_id = $1 FOR SHARE;
+
+-- name: GetAccountForUpdate :one
+SELECT account_id, account_type, normal_side, currency, created_at
+FROM accounts
+WHERE account_id = $1
+FOR UPDATE;
+
+-- name: SetAccountType :exec
+UPDATE accounts
+SET account_type = $2, normal_side = $3, currency = $4, created_at = $5
+WHERE account_id = $1;
+
+-- name: NegateBalance :exec
+UPDATE balances
+SET balance_minor = -balance_minor, updated_at = now()
+WHERE account_id = $1;
+
+-- name: NegateRunningBalances :exec
+UPDATE statements
+SET balance_after_minor = -balance_after_minor
+WHERE account_id = $1;
+
+-- Credit Lot Queries
+
+-- name: UpsertLotGrant :exec
+-- Movements projected before the grant have already adjusted remaining_minor
+INSERT INTO credit_lots (lot_id, account_id, currency, source, granted_minor, remaining_minor, granted_at, expires_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $5, $6, $7, now())
+ON CONFLICT (lot_id) DO UPDATE
+SET source = EXCLUDED.source,
+    granted_minor = EXCLUDED.granted_minor,
+    remaining_minor = credit_lots.remaining_minor + EXCLUDED.granted_minor,
+    granted_at = EXCLUDED.granted_at,
+    expires_at = EXCLUDED.expires_at,
+    updated_at = now();
+
+-- name: AdjustLot :exec
+-- Applies a consumption, expiry, restore or revoke as deltas, creating the lot if its grant is not projected yet
+INSERT INTO credit_lots (lot_id, account_id, currency, remaining_minor, expired_minor, revoked_at, updated_at)
+VALUES (sqlc.arg(lot_id), sqlc.arg(account_id), sqlc.arg(currency), sqlc.arg(remaining_delta), sqlc.arg(expired_delta), sqlc.narg(revoked_at), now())
+ON CONFLICT (lot_id) DO UPDATE
+SET remaining_minor = credit_lots.remaining_minor + EXCLUDED.remaining_minor,
+    expired_minor = credit_lots.expired_minor + EXCLUDED.expired_minor,
+    revoked_at = COALESCE(EXCLUDED.revoked_at, credit_lots.revoked_at),
+    updated_at = now();
+
+-- name: ListAccountLots :many
+-- Lots with a remainder, soonest expiry first and never-expiring lots last
+SELECT lot_id, account_id, currency, source, granted_minor, remaining_minor, expired_minor, granted_at, expires_at, revoked_at, updated_at
+FROM credit_lots
+WHERE account_id = $1
+  AND remaining_minor > 0
+  AND revoked_at IS NULL
+ORDER BY expires_at ASC NULLS LAST, granted_at ASC, lot_id ASC;
+
+-- name: ListUpcomingExpirations :many
+-- Lots with a remainder that lapses by until, soonest first
+SELECT lot_id, account_id, currency, source, granted_minor, remaining_minor, expired_minor, granted_at, expires_at, revoked_at, updated_at
+FROM credit_lots
+WHERE account_id = sqlc.arg(account_id)
+  AND remaining_minor > 0
+  AND revoked_at IS NULL
+  AND expires_at <= sqlc.arg(until)::timestamptz
+ORDER BY expires_at ASC, lot_id ASC;
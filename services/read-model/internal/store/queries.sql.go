@@ -11,6 +11,71 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const adjustLot = `-- name: AdjustLot :exec
+INSERT INTO credit_lots (lot_id, account_id, currency, remaining_minor, expired_minor, revoked_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (lot_id) DO UPDATE
+SET remaining_minor = credit_lots.remaining_minor + EXCLUDED.remaining_minor,
+    expired_minor = credit_lots.expired_minor + EXCLUDED.expired_minor,
+    revoked_at = COALESCE(EXCLUDED.revoked_at, credit_lots.revoked_at),
+    updated_at = now()
+`
+
+type AdjustLotParams struct {
+	LotID          pgtype.UUID
+	AccountID      pgtype.UUID
+	Currency       string
+	RemainingDelta int64
+	ExpiredDelta   int64
+	RevokedAt      pgtype.Timestamptz
+}
+
+// Applies a consumption, expiry, restore or revoke as deltas, creating the lot if its grant is not projected yet
+func (q *Queries) AdjustLot(ctx context.Context, arg AdjustLotParams) error {
+	_, err := q.db.Exec(ctx, adjustLot,
+		arg.LotID,
+		arg.AccountID,
+		arg.Currency,
+		arg.RemainingDelta,
+		arg.ExpiredDelta,
+		arg.RevokedAt,
+	)
+	return err
+}
+
+const advanceAggregateSeq = `-- name: AdvanceAggregateSeq :exec
+INSERT INTO aggregate_sequences (aggregate_id, last_seq, updated_at)
+VALUES ($1, $2, now())
+ON CONFLICT (aggregate_id) DO UPDATE
+SET last_seq = GREATEST(aggregate_sequences.last_seq, EXCLUDED.last_seq),
+    updated_at = now()
+`
+
+type AdvanceAggregateSeqParams struct {
+	AggregateID pgtype.UUID
+	LastSeq     int64
+}
+
+func (q *Queries) AdvanceAggregateSeq(ctx context.Context, arg AdvanceAggregateSeqParams) error {
+	_, err := q.db.Exec(ctx, advanceAggregateSeq, arg.AggregateID, arg.LastSeq)
+	return err
+}
+
+const claimEvent = `-- name: ClaimEvent :execrows
+INSERT INTO event_dedup (event_id, processed_at)
+VALUES ($1, now())
+ON CONFLICT (event_id) DO NOTHING
+`
+
+// Returns 0 when the event was already processed; blocks while another transaction holds the claim
+func (q *Queries) ClaimEvent(ctx context.Context, eventID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, claimEvent, eventID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const cleanupOldEvents = `-- name: CleanupOldEvents :exec
 DELETE FROM event_dedup
 WHERE processed_at < $1
@@ -21,6 +86,29 @@ func (q *Queries) CleanupOldEvents(ctx context.Context, processedAt pgtype.Times
 	return err
 }
 
+const countParkedEvents = `-- name: CountParkedEvents :one
+SELECT count(*) FROM parked_events
+`
+
+func (q *Queries) CountParkedEvents(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countParkedEvents)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countStatementsByEntry = `-- name: CountStatementsByEntry :one
+SELECT COUNT(*) FROM statements
+WHERE entry_id = $1
+`
+
+func (q *Queries) CountStatementsByEntry(ctx context.Context, entryID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countStatementsByEntry, entryID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countTransfers = `-- name: CountTransfers :one
 SELECT COUNT(*) FROM transfers
 WHERE 
@@ -49,18 +137,49 @@ func (q *Queries) CountTransfers(ctx context.Context, arg CountTransfersParams)
 	return count, err
 }
 
-const createStatement = `-- name: CreateStatement :exec
+const createHold = `-- name: CreateHold :exec
 
-INSERT INTO statements (account_id, entry_id, amount_minor, side, ts)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO holds (id, account_id, currency, amount_minor, status, expires_at, created_at, updated_at)
+VALUES ($1, $2, $3, $4, 'PENDING', $5, $6, now())
+ON CONFLICT (id) DO NOTHING
 `
 
-type CreateStatementParams struct {
+type CreateHoldParams struct {
+	ID          pgtype.UUID
 	AccountID   pgtype.UUID
-	EntryID     pgtype.UUID
+	Currency    string
 	AmountMinor int64
-	Side        string
-	Ts          pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+}
+
+// Hold Queries
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) error {
+	_, err := q.db.Exec(ctx, createHold,
+		arg.ID,
+		arg.AccountID,
+		arg.Currency,
+		arg.AmountMinor,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	return err
+}
+
+const createStatement = `-- name: CreateStatement :exec
+
+INSERT INTO statements (account_id, entry_id, amount_minor, side, ts, balance_after_minor, effective_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateStatementParams struct {
+	AccountID         pgtype.UUID
+	EntryID           pgtype.UUID
+	AmountMinor       int64
+	Side              string
+	Ts                pgtype.Timestamptz
+	BalanceAfterMinor int64
+	EffectiveAt       pgtype.Timestamptz
 }
 
 // Statement Queries
@@ -71,6 +190,8 @@ func (q *Queries) CreateStatement(ctx context.Context, arg CreateStatementParams
 		arg.AmountMinor,
 		arg.Side,
 		arg.Ts,
+		arg.BalanceAfterMinor,
+		arg.EffectiveAt,
 	)
 	return err
 }
@@ -108,6 +229,107 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 	return err
 }
 
+const createTransferLeg = `-- name: CreateTransferLeg :exec
+INSERT INTO transfer_legs (transfer_id, leg_index, account_id, amount_minor, currency, side)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (transfer_id, leg_index) DO NOTHING
+`
+
+type CreateTransferLegParams struct {
+	TransferID  pgtype.UUID
+	LegIndex    int32
+	AccountID   pgtype.UUID
+	AmountMinor int64
+	Currency    string
+	Side        string
+}
+
+func (q *Queries) CreateTransferLeg(ctx context.Context, arg CreateTransferLegParams) error {
+	_, err := q.db.Exec(ctx, createTransferLeg,
+		arg.TransferID,
+		arg.LegIndex,
+		arg.AccountID,
+		arg.AmountMinor,
+		arg.Currency,
+		arg.Side,
+	)
+	return err
+}
+
+const deleteParkedEvent = `-- name: DeleteParkedEvent :exec
+DELETE FROM parked_events
+WHERE aggregate_id = $1 AND seq = $2
+`
+
+type DeleteParkedEventParams struct {
+	AggregateID pgtype.UUID
+	Seq         int64
+}
+
+func (q *Queries) DeleteParkedEvent(ctx context.Context, arg DeleteParkedEventParams) error {
+	_, err := q.db.Exec(ctx, deleteParkedEvent, arg.AggregateID, arg.Seq)
+	return err
+}
+
+const ensureAccount = `-- name: EnsureAccount :exec
+
+INSERT INTO accounts (account_id)
+VALUES ($1)
+ON CONFLICT (account_id) DO NOTHING
+`
+
+// Account Queries
+// Accounts seen in entries before their AccountCreated get a credit-normal placeholder
+func (q *Queries) EnsureAccount(ctx context.Context, accountID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, ensureAccount, accountID)
+	return err
+}
+
+const getAccountForUpdate = `-- name: GetAccountForUpdate :one
+SELECT account_id, account_type, normal_side, currency, created_at
+FROM accounts
+WHERE account_id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetAccountForUpdate(ctx context.Context, accountID pgtype.UUID) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountForUpdate, accountID)
+	var i Account
+	err := row.Scan(
+		&i.AccountID,
+		&i.AccountType,
+		&i.NormalSide,
+		&i.Currency,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountNormalSide = `-- name: GetAccountNormalSide :one
+SELECT normal_side FROM accounts WHERE account_id = $1 FOR SHARE
+`
+
+// Locks the account so its normal side cannot change until the caller commits
+func (q *Queries) GetAccountNormalSide(ctx context.Context, accountID pgtype.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getAccountNormalSide, accountID)
+	var normal_side string
+	err := row.Scan(&normal_side)
+	return normal_side, err
+}
+
+const getAggregateSeq = `-- name: GetAggregateSeq :one
+
+SELECT last_seq FROM aggregate_sequences WHERE aggregate_id = $1
+`
+
+// Event Sequencing Queries
+func (q *Queries) GetAggregateSeq(ctx context.Context, aggregateID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getAggregateSeq, aggregateID)
+	var last_seq int64
+	err := row.Scan(&last_seq)
+	return last_seq, err
+}
+
 const getBalance = `-- name: GetBalance :one
 
 SELECT account_id, currency, balance_minor, updated_at
@@ -128,23 +350,218 @@ func (q *Queries) GetBalance(ctx context.Context, accountID pgtype.UUID) (Balanc
 	return i, err
 }
 
-const getStatements = `-- name: GetStatements :many
-SELECT id, account_id, entry_id, amount_minor, side, ts
+const getBalanceAsOf = `-- name: GetBalanceAsOf :one
+SELECT balance_after_minor
 FROM statements
 WHERE account_id = $1
-  AND ts >= $2
-  AND ts <= $3
-ORDER BY ts DESC
+  AND effective_at <= $2
+ORDER BY effective_at DESC, id DESC
+LIMIT 1
 `
 
-type GetStatementsParams struct {
+type GetBalanceAsOfParams struct {
+	AccountID   pgtype.UUID
+	EffectiveAt pgtype.Timestamptz
+}
+
+// Balance after the last line effective at or before the given time
+func (q *Queries) GetBalanceAsOf(ctx context.Context, arg GetBalanceAsOfParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getBalanceAsOf, arg.AccountID, arg.EffectiveAt)
+	var balance_after_minor int64
+	err := row.Scan(&balance_after_minor)
+	return balance_after_minor, err
+}
+
+const getParkedEvent = `-- name: GetParkedEvent :one
+SELECT aggregate_id, seq, topic, event_type, event_id, payload, parked_at
+FROM parked_events
+WHERE aggregate_id = $1 AND seq = $2
+`
+
+type GetParkedEventParams struct {
+	AggregateID pgtype.UUID
+	Seq         int64
+}
+
+func (q *Queries) GetParkedEvent(ctx context.Context, arg GetParkedEventParams) (ParkedEvent, error) {
+	row := q.db.QueryRow(ctx, getParkedEvent, arg.AggregateID, arg.Seq)
+	var i ParkedEvent
+	err := row.Scan(
+		&i.AggregateID,
+		&i.Seq,
+		&i.Topic,
+		&i.EventType,
+		&i.EventID,
+		&i.Payload,
+		&i.ParkedAt,
+	)
+	return i, err
+}
+
+const getTransfer = `-- name: GetTransfer :one
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
+FROM transfers
+WHERE id = $1
+`
+
+func (q *Queries) GetTransfer(ctx context.Context, id pgtype.UUID) (Transfer, error) {
+	row := q.db.QueryRow(ctx, getTransfer, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.AmountMinor,
+		&i.Currency,
+		&i.Status,
+		&i.IdempotencyKey,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.RefundOf,
+		&i.RefundedMinor,
+	)
+	return i, err
+}
+
+const holdExists = `-- name: HoldExists :one
+SELECT EXISTS(SELECT 1 FROM holds WHERE id = $1)
+`
+
+func (q *Queries) HoldExists(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, holdExists, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isEventProcessed = `-- name: IsEventProcessed :one
+
+SELECT EXISTS(SELECT 1 FROM event_dedup WHERE event_id = $1)
+`
+
+// Event Deduplication Queries
+func (q *Queries) IsEventProcessed(ctx context.Context, eventID pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isEventProcessed, eventID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listAccountLots = `-- name: ListAccountLots :many
+SELECT lot_id, account_id, currency, source, granted_minor, remaining_minor, expired_minor, granted_at, expires_at, revoked_at, updated_at
+FROM credit_lots
+WHERE account_id = $1
+  AND remaining_minor > 0
+  AND revoked_at IS NULL
+ORDER BY expires_at ASC NULLS LAST, granted_at ASC, lot_id ASC
+`
+
+// Lots with a remainder, soonest expiry first and never-expiring lots last
+func (q *Queries) ListAccountLots(ctx context.Context, accountID pgtype.UUID) ([]CreditLot, error) {
+	rows, err := q.db.Query(ctx, listAccountLots, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLot
+	for rows.Next() {
+		var i CreditLot
+		if err := rows.Scan(
+			&i.LotID,
+			&i.AccountID,
+			&i.Currency,
+			&i.Source,
+			&i.GrantedMinor,
+			&i.RemainingMinor,
+			&i.ExpiredMinor,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStalledAggregates = `-- name: ListStalledAggregates :many
+SELECT p.aggregate_id, min(p.seq)::bigint AS first_seq, COALESCE(max(s.last_seq), 0)::bigint AS last_seq
+FROM parked_events p
+LEFT JOIN aggregate_sequences s ON s.aggregate_id = p.aggregate_id
+GROUP BY p.aggregate_id
+HAVING min(p.seq) <= COALESCE(max(s.last_seq), 0) + 1
+    OR min(p.parked_at) < $1::timestamptz
+ORDER BY min(p.parked_at)
+LIMIT $2
+`
+
+type ListStalledAggregatesParams struct {
+	ParkedBefore  pgtype.Timestamptz
+	MaxAggregates int32
+}
+
+type ListStalledAggregatesRow struct {
+	AggregateID pgtype.UUID
+	FirstSeq    int64
+	LastSeq     int64
+}
+
+// Aggregates whose first parked event is next in sequence, or was parked before the cutoff
+func (q *Queries) ListStalledAggregates(ctx context.Context, arg ListStalledAggregatesParams) ([]ListStalledAggregatesRow, error) {
+	rows, err := q.db.Query(ctx, listStalledAggregates, arg.ParkedBefore, arg.MaxAggregates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListStalledAggregatesRow
+	for rows.Next() {
+		var i ListStalledAggregatesRow
+		if err := rows.Scan(&i.AggregateID, &i.FirstSeq, &i.LastSeq); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStatements = `-- name: ListStatements :many
+SELECT id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at
+FROM statements
+WHERE account_id = $1
+  AND ($2::timestamptz IS NULL OR effective_at >= $2)
+  AND ($3::timestamptz IS NULL OR effective_at <= $3)
+  AND ($4::timestamptz IS NULL OR (effective_at, id) < ($4, $5::bigint))
+ORDER BY effective_at DESC, id DESC
+LIMIT $6
+`
+
+type ListStatementsParams struct {
 	AccountID pgtype.UUID
-	Ts        pgtype.Timestamptz
-	Ts_2      pgtype.Timestamptz
+	FromTs    pgtype.Timestamptz
+	ToTs      pgtype.Timestamptz
+	AfterTs   pgtype.Timestamptz
+	AfterID   pgtype.Int8
+	Limit     int32
 }
 
-func (q *Queries) GetStatements(ctx context.Context, arg GetStatementsParams) ([]Statement, error) {
-	rows, err := q.db.Query(ctx, getStatements, arg.AccountID, arg.Ts, arg.Ts_2)
+// Newest effective date first; after_ts/after_id continue below the last line of the previous page
+func (q *Queries) ListStatements(ctx context.Context, arg ListStatementsParams) ([]Statement, error) {
+	rows, err := q.db.Query(ctx, listStatements,
+		arg.AccountID,
+		arg.FromTs,
+		arg.ToTs,
+		arg.AfterTs,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -159,6 +576,10 @@ func (q *Queries) GetStatements(ctx context.Context, arg GetStatementsParams) ([
 			&i.AmountMinor,
 			&i.Side,
 			&i.Ts,
+			&i.VoidedBy,
+			&i.VoidedAt,
+			&i.BalanceAfterMinor,
+			&i.EffectiveAt,
 		); err != nil {
 			return nil, err
 		}
@@ -170,21 +591,36 @@ func (q *Queries) GetStatements(ctx context.Context, arg GetStatementsParams) ([
 	return items, nil
 }
 
-const getStatementsByAccount = `-- name: GetStatementsByAccount :many
-SELECT id, account_id, entry_id, amount_minor, side, ts
+const listStatementsPrev = `-- name: ListStatementsPrev :many
+SELECT id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at
 FROM statements
 WHERE account_id = $1
-ORDER BY ts DESC
-LIMIT $2
+  AND ($2::timestamptz IS NULL OR effective_at >= $2)
+  AND ($3::timestamptz IS NULL OR effective_at <= $3)
+  AND (effective_at, id) > ($4::timestamptz, $5::bigint)
+ORDER BY effective_at ASC, id ASC
+LIMIT $6
 `
 
-type GetStatementsByAccountParams struct {
+type ListStatementsPrevParams struct {
 	AccountID pgtype.UUID
+	FromTs    pgtype.Timestamptz
+	ToTs      pgtype.Timestamptz
+	BeforeTs  pgtype.Timestamptz
+	BeforeID  int64
 	Limit     int32
 }
 
-func (q *Queries) GetStatementsByAccount(ctx context.Context, arg GetStatementsByAccountParams) ([]Statement, error) {
-	rows, err := q.db.Query(ctx, getStatementsByAccount, arg.AccountID, arg.Limit)
+// Oldest effective date first from just above before_ts/before_id; callers reverse the page
+func (q *Queries) ListStatementsPrev(ctx context.Context, arg ListStatementsPrevParams) ([]Statement, error) {
+	rows, err := q.db.Query(ctx, listStatementsPrev,
+		arg.AccountID,
+		arg.FromTs,
+		arg.ToTs,
+		arg.BeforeTs,
+		arg.BeforeID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -199,6 +635,10 @@ func (q *Queries) GetStatementsByAccount(ctx context.Context, arg GetStatementsB
 			&i.AmountMinor,
 			&i.Side,
 			&i.Ts,
+			&i.VoidedBy,
+			&i.VoidedAt,
+			&i.BalanceAfterMinor,
+			&i.EffectiveAt,
 		); err != nil {
 			return nil, err
 		}
@@ -210,70 +650,136 @@ func (q *Queries) GetStatementsByAccount(ctx context.Context, arg GetStatementsB
 	return items, nil
 }
 
-const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at
-FROM transfers
-WHERE id = $1
+const listTransferLegs = `-- name: ListTransferLegs :many
+SELECT transfer_id, leg_index, account_id, amount_minor, currency, side
+FROM transfer_legs
+WHERE transfer_id = ANY($1::uuid[])
+ORDER BY transfer_id, leg_index
 `
 
-func (q *Queries) GetTransfer(ctx context.Context, id pgtype.UUID) (Transfer, error) {
-	row := q.db.QueryRow(ctx, getTransfer, id)
-	var i Transfer
-	err := row.Scan(
-		&i.ID,
-		&i.FromAccountID,
-		&i.ToAccountID,
-		&i.AmountMinor,
-		&i.Currency,
-		&i.Status,
-		&i.IdempotencyKey,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
-}
-
-const isEventProcessed = `-- name: IsEventProcessed :one
-
-SELECT EXISTS(SELECT 1 FROM event_dedup WHERE event_id = $1)
-`
-
-// Event Deduplication Queries
-func (q *Queries) IsEventProcessed(ctx context.Context, eventID pgtype.UUID) (bool, error) {
-	row := q.db.QueryRow(ctx, isEventProcessed, eventID)
-	var exists bool
-	err := row.Scan(&exists)
-	return exists, err
+func (q *Queries) ListTransferLegs(ctx context.Context, dollar_1 []pgtype.UUID) ([]TransferLeg, error) {
+	rows, err := q.db.Query(ctx, listTransferLegs, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TransferLeg
+	for rows.Next() {
+		var i TransferLeg
+		if err := rows.Scan(
+			&i.TransferID,
+			&i.LegIndex,
+			&i.AccountID,
+			&i.AmountMinor,
+			&i.Currency,
+			&i.Side,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
 FROM transfers
 WHERE 
   ($1::uuid IS NULL OR from_account_id = $1) AND
   ($2::uuid IS NULL OR to_account_id = $2) AND
   ($3::text IS NULL OR status = $3) AND
-  ($4::text IS NULL OR currency = $4)
-ORDER BY created_at DESC
-LIMIT $6 OFFSET $5
+  ($4::text IS NULL OR currency = $4) AND
+  ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $7
 `
 
 type ListTransfersParams struct {
-	FromAccountID pgtype.UUID
-	ToAccountID   pgtype.UUID
-	Status        pgtype.Text
-	Currency      pgtype.Text
-	Offset        int32
-	Limit         int32
+	FromAccountID  pgtype.UUID
+	ToAccountID    pgtype.UUID
+	Status         pgtype.Text
+	Currency       pgtype.Text
+	AfterCreatedAt pgtype.Timestamptz
+	AfterID        pgtype.UUID
+	Limit          int32
 }
 
+// Newest first; after_created_at/after_id continue below the last transfer of the previous page
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
 	rows, err := q.db.Query(ctx, listTransfers,
 		arg.FromAccountID,
 		arg.ToAccountID,
 		arg.Status,
 		arg.Currency,
-		arg.Offset,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transfer
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.AmountMinor,
+			&i.Currency,
+			&i.Status,
+			&i.IdempotencyKey,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.RefundOf,
+			&i.RefundedMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersPrev = `-- name: ListTransfersPrev :many
+SELECT id, from_account_id, to_account_id, amount_minor, currency, status, idempotency_key, created_at, updated_at, refund_of, refunded_minor
+FROM transfers
+WHERE 
+  ($1::uuid IS NULL OR from_account_id = $1) AND
+  ($2::uuid IS NULL OR to_account_id = $2) AND
+  ($3::text IS NULL OR status = $3) AND
+  ($4::text IS NULL OR currency = $4) AND
+  (created_at, id) > ($5::timestamptz, $6::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $7
+`
+
+type ListTransfersPrevParams struct {
+	FromAccountID   pgtype.UUID
+	ToAccountID     pgtype.UUID
+	Status          pgtype.Text
+	Currency        pgtype.Text
+	BeforeCreatedAt pgtype.Timestamptz
+	BeforeID        pgtype.UUID
+	Limit           int32
+}
+
+// Oldest first from just above before_created_at/before_id; callers reverse the page
+func (q *Queries) ListTransfersPrev(ctx context.Context, arg ListTransfersPrevParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listTransfersPrev,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Status,
+		arg.Currency,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
 		arg.Limit,
 	)
 	if err != nil {
@@ -293,6 +799,56 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.IdempotencyKey,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.RefundOf,
+			&i.RefundedMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUpcomingExpirations = `-- name: ListUpcomingExpirations :many
+SELECT lot_id, account_id, currency, source, granted_minor, remaining_minor, expired_minor, granted_at, expires_at, revoked_at, updated_at
+FROM credit_lots
+WHERE account_id = $1
+  AND remaining_minor > 0
+  AND revoked_at IS NULL
+  AND expires_at <= $2::timestamptz
+ORDER BY expires_at ASC, lot_id ASC
+`
+
+type ListUpcomingExpirationsParams struct {
+	AccountID pgtype.UUID
+	Until     pgtype.Timestamptz
+}
+
+// Lots with a remainder that lapses by until, soonest first
+func (q *Queries) ListUpcomingExpirations(ctx context.Context, arg ListUpcomingExpirationsParams) ([]CreditLot, error) {
+	rows, err := q.db.Query(ctx, listUpcomingExpirations, arg.AccountID, arg.Until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreditLot
+	for rows.Next() {
+		var i CreditLot
+		if err := rows.Scan(
+			&i.LotID,
+			&i.AccountID,
+			&i.Currency,
+			&i.Source,
+			&i.GrantedMinor,
+			&i.RemainingMinor,
+			&i.ExpiredMinor,
+			&i.GrantedAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+			&i.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -315,6 +871,80 @@ func (q *Queries) MarkEventProcessed(ctx context.Context, eventID pgtype.UUID) e
 	return err
 }
 
+const negateBalance = `-- name: NegateBalance :exec
+UPDATE balances
+SET balance_minor = -balance_minor, updated_at = now()
+WHERE account_id = $1
+`
+
+func (q *Queries) NegateBalance(ctx context.Context, accountID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, negateBalance, accountID)
+	return err
+}
+
+const negateRunningBalances = `-- name: NegateRunningBalances :exec
+UPDATE statements
+SET balance_after_minor = -balance_after_minor
+WHERE account_id = $1
+`
+
+func (q *Queries) NegateRunningBalances(ctx context.Context, accountID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, negateRunningBalances, accountID)
+	return err
+}
+
+const parkEvent = `-- name: ParkEvent :exec
+INSERT INTO parked_events (aggregate_id, seq, topic, event_type, event_id, payload, parked_at)
+VALUES ($1, $2, $3, $4, $5, $6, now())
+ON CONFLICT (aggregate_id, seq) DO NOTHING
+`
+
+type ParkEventParams struct {
+	AggregateID pgtype.UUID
+	Seq         int64
+	Topic       string
+	EventType   string
+	EventID     pgtype.UUID
+	Payload     []byte
+}
+
+func (q *Queries) ParkEvent(ctx context.Context, arg ParkEventParams) error {
+	_, err := q.db.Exec(ctx, parkEvent,
+		arg.AggregateID,
+		arg.Seq,
+		arg.Topic,
+		arg.EventType,
+		arg.EventID,
+		arg.Payload,
+	)
+	return err
+}
+
+const setAccountType = `-- name: SetAccountType :exec
+UPDATE accounts
+SET account_type = $2, normal_side = $3, currency = $4, created_at = $5
+WHERE account_id = $1
+`
+
+type SetAccountTypeParams struct {
+	AccountID   pgtype.UUID
+	AccountType pgtype.Text
+	NormalSide  string
+	Currency    pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) SetAccountType(ctx context.Context, arg SetAccountTypeParams) error {
+	_, err := q.db.Exec(ctx, setAccountType,
+		arg.AccountID,
+		arg.AccountType,
+		arg.NormalSide,
+		arg.Currency,
+		arg.CreatedAt,
+	)
+	return err
+}
+
 const setBalance = `-- name: SetBalance :exec
 INSERT INTO balances (account_id, currency, balance_minor, updated_at)
 VALUES ($1, $2, $3, now())
@@ -334,6 +964,125 @@ func (q *Queries) SetBalance(ctx context.Context, arg SetBalanceParams) error {
 	return err
 }
 
+const setTransferRefundOf = `-- name: SetTransferRefundOf :execrows
+UPDATE transfers
+SET refund_of = $2, updated_at = now()
+WHERE id = $1
+`
+
+type SetTransferRefundOfParams struct {
+	ID       pgtype.UUID
+	RefundOf pgtype.UUID
+}
+
+// Returns 0 when the refund transfer has not been projected yet
+func (q *Queries) SetTransferRefundOf(ctx context.Context, arg SetTransferRefundOfParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setTransferRefundOf, arg.ID, arg.RefundOf)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const shiftRunningBalances = `-- name: ShiftRunningBalances :exec
+UPDATE statements
+SET balance_after_minor = balance_after_minor + $1::bigint
+WHERE account_id = $2
+  AND effective_at > $3
+`
+
+type ShiftRunningBalancesParams struct {
+	Delta       int64
+	AccountID   pgtype.UUID
+	EffectiveAt pgtype.Timestamptz
+}
+
+// Adds a line projected out of order to the running balance of every line effective after it
+func (q *Queries) ShiftRunningBalances(ctx context.Context, arg ShiftRunningBalancesParams) error {
+	_, err := q.db.Exec(ctx, shiftRunningBalances, arg.Delta, arg.AccountID, arg.EffectiveAt)
+	return err
+}
+
+const sumPendingHolds = `-- name: SumPendingHolds :one
+SELECT COALESCE(SUM(amount_minor - captured_minor), 0)::bigint AS held_minor
+FROM holds
+WHERE account_id = $1
+  AND status = 'PENDING'
+  AND expires_at > now()
+`
+
+func (q *Queries) SumPendingHolds(ctx context.Context, accountID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, sumPendingHolds, accountID)
+	var held_minor int64
+	err := row.Scan(&held_minor)
+	return held_minor, err
+}
+
+const updateHoldCaptured = `-- name: UpdateHoldCaptured :execrows
+UPDATE holds
+SET captured_minor = amount_minor - $1::bigint,
+    status = $2,
+    updated_at = now()
+WHERE id = $3
+  AND status = 'PENDING'
+`
+
+type UpdateHoldCapturedParams struct {
+	RemainingMinor int64
+	Status         string
+	ID             pgtype.UUID
+}
+
+// Returns 0 when the hold has not been projected yet or is no longer pending
+func (q *Queries) UpdateHoldCaptured(ctx context.Context, arg UpdateHoldCapturedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateHoldCaptured, arg.RemainingMinor, arg.Status, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateHoldReleased = `-- name: UpdateHoldReleased :execrows
+UPDATE holds
+SET status = $2, updated_at = now()
+WHERE id = $1
+  AND status = 'PENDING'
+`
+
+type UpdateHoldReleasedParams struct {
+	ID     pgtype.UUID
+	Status string
+}
+
+// Returns 0 when the hold has not been projected yet or is no longer pending
+func (q *Queries) UpdateHoldReleased(ctx context.Context, arg UpdateHoldReleasedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateHoldReleased, arg.ID, arg.Status)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateTransferRefunded = `-- name: UpdateTransferRefunded :execrows
+UPDATE transfers
+SET refunded_minor = GREATEST(refunded_minor, $1::bigint), updated_at = now()
+WHERE id = $2
+`
+
+type UpdateTransferRefundedParams struct {
+	RefundedMinor int64
+	ID            pgtype.UUID
+}
+
+// Returns 0 when the original transfer has not been projected yet
+func (q *Queries) UpdateTransferRefunded(ctx context.Context, arg UpdateTransferRefundedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateTransferRefunded, arg.RefundedMinor, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateTransferStatus = `-- name: UpdateTransferStatus :exec
 UPDATE transfers
 SET status = $2, updated_at = now()
@@ -368,3 +1117,87 @@ func (q *Queries) UpsertBalance(ctx context.Context, arg UpsertBalanceParams) er
 	_, err := q.db.Exec(ctx, upsertBalance, arg.AccountID, arg.Currency, arg.BalanceMinor)
 	return err
 }
+
+const upsertLotGrant = `-- name: UpsertLotGrant :exec
+
+INSERT INTO credit_lots (lot_id, account_id, currency, source, granted_minor, remaining_minor, granted_at, expires_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $5, $6, $7, now())
+ON CONFLICT (lot_id) DO UPDATE
+SET source = EXCLUDED.source,
+    granted_minor = EXCLUDED.granted_minor,
+    remaining_minor = credit_lots.remaining_minor + EXCLUDED.granted_minor,
+    granted_at = EXCLUDED.granted_at,
+    expires_at = EXCLUDED.expires_at,
+    updated_at = now()
+`
+
+type UpsertLotGrantParams struct {
+	LotID        pgtype.UUID
+	AccountID    pgtype.UUID
+	Currency     string
+	Source       string
+	GrantedMinor int64
+	GrantedAt    pgtype.Timestamptz
+	ExpiresAt    pgtype.Timestamptz
+}
+
+// Credit Lot Queries
+// Movements projected before the grant have already adjusted remaining_minor
+func (q *Queries) UpsertLotGrant(ctx context.Context, arg UpsertLotGrantParams) error {
+	_, err := q.db.Exec(ctx, upsertLotGrant,
+		arg.LotID,
+		arg.AccountID,
+		arg.Currency,
+		arg.Source,
+		arg.GrantedMinor,
+		arg.GrantedAt,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const voidStatementsByEntry = `-- name: VoidStatementsByEntry :many
+UPDATE statements
+SET voided_by = $2, voided_at = $3
+WHERE entry_id = $1
+  AND voided_by IS NULL
+RETURNING id, account_id, entry_id, amount_minor, side, ts, voided_by, voided_at, balance_after_minor, effective_at
+`
+
+type VoidStatementsByEntryParams struct {
+	EntryID  pgtype.UUID
+	VoidedBy pgtype.UUID
+	VoidedAt pgtype.Timestamptz
+}
+
+// Only returns lines that were not voided yet, so replays reverse nothing
+func (q *Queries) VoidStatementsByEntry(ctx context.Context, arg VoidStatementsByEntryParams) ([]Statement, error) {
+	rows, err := q.db.Query(ctx, voidStatementsByEntry, arg.EntryID, arg.VoidedBy, arg.VoidedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Statement
+	for rows.Next() {
+		var i Statement
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.EntryID,
+			&i.AmountMinor,
+			&i.Side,
+			&i.Ts,
+			&i.VoidedBy,
+			&i.VoidedAt,
+			&i.BalanceAfterMinor,
+			&i.EffectiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}